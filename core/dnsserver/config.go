@@ -1,12 +1,15 @@
 package dnsserver
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/proxyproto"
+	"github.com/coredns/coredns/request"
 )
 
 // Config configuration for a single server.
@@ -40,6 +43,21 @@ type Config struct {
 	// TLSConfig when listening for encrypted connections (gRPC, DNS-over-TLS).
 	TLSConfig *tls.Config
 
+	// ProxyProtocol, when set, makes the listeners accept PROXY protocol headers from the proxies in it,
+	// so plugins see the address of the client instead of the proxy.
+	ProxyProtocol *proxyproto.Config
+
+	// TsigSecret maps TSIG key names to their (base64 encoded) secrets. Requests signed with one of
+	// these keys are verified by the server, plugins can check the outcome with TsigStatus.
+	TsigSecret map[string]string
+
+	// FilterFuncs further filter the requests this config handles. When several configs serve the
+	// same zone, a request is handled by the first config for which all filter funcs return true.
+	FilterFuncs []FilterFunc
+
+	// ViewName is the name of the view, the set of filters, of this config.
+	ViewName string
+
 	// Plugin stack.
 	Plugin []plugin.Plugin
 
@@ -51,11 +69,18 @@ type Config struct {
 	// Handler's Name method.
 	registry map[string]plugin.Handler
 
+	// metaCollector is the plugin that collects metadata, so it can be used by the filter funcs
+	// before the request is sent down the plugin chain.
+	metaCollector MetadataCollector
+
 	// firstConfigInBlock is used to reference the first config in a server block, for the
 	// purpose of sharing single instance of each plugin among all zones in a server block.
 	firstConfigInBlock *Config
 }
 
+// FilterFunc returns true if the config should handle the request.
+type FilterFunc func(context.Context, *request.Request) bool
+
 // keyForConfig builds a key for identifying the configs during setup time
 func keyForConfig(blocIndex int, blocKeyIndex int) string {
 	return fmt.Sprintf("%d:%d", blocIndex, blocKeyIndex)
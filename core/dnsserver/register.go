@@ -139,13 +139,6 @@ func (h *dnsContext) InspectServerBlocks(sourceFile string, serverBlocks []caddy
 // MakeServers uses the newly-created siteConfigs to create and return a list of server instances.
 func (h *dnsContext) MakeServers() ([]caddy.Server, error) {
 
-	// Now that all Keys and Directives are parsed and initialized
-	// lets verify that there is no overlap on the zones and addresses to listen for
-	errValid := h.validateZonesAndListeningAddresses()
-	if errValid != nil {
-		return nil, errValid
-	}
-
 	// Copy the Plugin, ListenHosts and Debug from first config in the block
 	// to all other config in the same block . Doing this results in zones
 	// sharing the same plugin instances and settings as other zones in
@@ -155,6 +148,8 @@ func (h *dnsContext) MakeServers() ([]caddy.Server, error) {
 		c.ListenHosts = c.firstConfigInBlock.ListenHosts
 		c.Debug = c.firstConfigInBlock.Debug
 		c.TLSConfig = c.firstConfigInBlock.TLSConfig
+		c.TsigSecret = c.firstConfigInBlock.TsigSecret
+		c.ProxyProtocol = c.firstConfigInBlock.ProxyProtocol
 	}
 
 	// we must map (group) each config to a bind address
@@ -198,6 +193,27 @@ func (h *dnsContext) MakeServers() ([]caddy.Server, error) {
 
 	}
 
+	// Add the filter of the Viewer plugin in a server block, if there is one, to its configs. The
+	// plugins are registered when the servers above compile the plugin chains.
+	for _, c := range h.configs {
+		for _, d := range Directives {
+			if v, ok := c.Handler(d).(Viewer); ok {
+				if c.ViewName != "" {
+					return nil, fmt.Errorf("multiple views defined in server block for %s", c.Zone)
+				}
+				c.ViewName = v.ViewName()
+				c.FilterFuncs = append(c.FilterFuncs, v.Filter)
+			}
+		}
+	}
+
+	// Now that all Keys and Directives are parsed and initialized
+	// lets verify that there is no overlap on the zones and addresses to listen for
+	errValid := h.validateZonesAndListeningAddresses()
+	if errValid != nil {
+		return nil, errValid
+	}
+
 	return servers, nil
 }
 
@@ -249,10 +265,23 @@ func (c *Config) Handlers() []plugin.Handler {
 func (h *dnsContext) validateZonesAndListeningAddresses() error {
 	//Validate Zone and addresses
 	checker := newOverlapZone()
+	unfiltered := map[zoneAddr]bool{}
 	for _, conf := range h.configs {
+		// Configs with filters may serve the same zone as other configs, the first config whose
+		// filters pass handles a request. So a config with filters after one without them is never used.
+		if len(conf.FilterFuncs) > 0 {
+			for _, h := range conf.ListenHosts {
+				akey := zoneAddr{Transport: conf.Transport, Zone: conf.Zone, Address: h, Port: conf.Port}
+				if unfiltered[akey] {
+					return fmt.Errorf("cannot serve %s in view %q - it is already defined without a view before it", akey.String(), conf.ViewName)
+				}
+			}
+			continue
+		}
 		for _, h := range conf.ListenHosts {
 			// Validate the overlapping of ZoneAddr
 			akey := zoneAddr{Transport: conf.Transport, Zone: conf.Zone, Address: h, Port: conf.Port}
+			unfiltered[akey] = true
 			existZone, overlapZone := checker.registerAndCheck(akey)
 			if existZone != nil {
 				return fmt.Errorf("cannot serve %s - it is already defined", akey.String())
@@ -15,6 +15,7 @@ import (
 	"github.com/coredns/coredns/plugin/metrics/vars"
 	"github.com/coredns/coredns/plugin/pkg/edns"
 	"github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/pkg/proxyproto"
 	"github.com/coredns/coredns/plugin/pkg/rcode"
 	"github.com/coredns/coredns/plugin/pkg/reuseport"
 	"github.com/coredns/coredns/plugin/pkg/trace"
@@ -36,12 +37,14 @@ type Server struct {
 	server [2]*dns.Server // 0 is a net.Listener, 1 is a net.PacketConn (a *UDPConn) in our case.
 	m      sync.Mutex     // protects the servers
 
-	zones        map[string]*Config // zones keyed by their address
-	dnsWg        sync.WaitGroup     // used to wait on outstanding connections
-	graceTimeout time.Duration      // the maximum duration of a graceful shutdown
-	trace        trace.Trace        // the trace plugin for the server
-	debug        bool               // disable recover()
-	classChaos   bool               // allow non-INET class queries
+	zones        map[string][]*Config // configs keyed by their zone, several when views are used
+	dnsWg        sync.WaitGroup       // used to wait on outstanding connections
+	graceTimeout time.Duration        // the maximum duration of a graceful shutdown
+	trace        trace.Trace          // the trace plugin for the server
+	debug        bool                 // disable recover()
+	classChaos   bool                 // allow non-INET class queries
+	tsigSecret   map[string]string    // TSIG secrets of all zones
+	proxyProto   *proxyproto.Config   // PROXY protocol settings of the listeners, if enabled
 }
 
 // NewServer returns a new CoreDNS server and compiles all plugins in to it. By default CH class
@@ -50,7 +53,7 @@ func NewServer(addr string, group []*Config) (*Server, error) {
 
 	s := &Server{
 		Addr:         addr,
-		zones:        make(map[string]*Config),
+		zones:        make(map[string][]*Config),
 		graceTimeout: 5 * time.Second,
 	}
 
@@ -62,13 +65,30 @@ func NewServer(addr string, group []*Config) (*Server, error) {
 	// In a way, this kind of acts as a safety barrier.
 	s.dnsWg.Add(1)
 
-	for _, site := range group {
+	for i, site := range group {
 		if site.Debug {
 			s.debug = true
 			log.D.Set()
 		}
-		// set the config per zone
-		s.zones[site.Zone] = site
+		// set the config per zone, in the order of the server blocks
+		s.zones[site.Zone] = append(s.zones[site.Zone], site)
+
+		// The PROXY protocol is handled by the listeners, so all server blocks on them must agree.
+		if i == 0 {
+			s.proxyProto = site.ProxyProtocol
+		} else if !s.proxyProto.Equal(site.ProxyProtocol) {
+			return nil, fmt.Errorf("server blocks for %s have different proxyproto settings", addr)
+		}
+
+		for name, secret := range site.TsigSecret {
+			if s.tsigSecret == nil {
+				s.tsigSecret = make(map[string]string)
+			}
+			if old, ok := s.tsigSecret[name]; ok && old != secret {
+				return nil, fmt.Errorf("TSIG key %q is defined with different secrets for %s", name, addr)
+			}
+			s.tsigSecret[name] = secret
+		}
 
 		// compile custom plugin for everything
 		var stack plugin.Handler
@@ -89,6 +109,10 @@ func NewServer(addr string, group []*Config) (*Server, error) {
 			if _, ok := EnableChaos[stack.Name()]; ok {
 				s.classChaos = true
 			}
+			// The metadata collector is kept, so metadata can be collected before the filter funcs run.
+			if mdc, ok := stack.(MetadataCollector); ok {
+				site.metaCollector = mdc
+			}
 		}
 		site.pluginChain = stack
 	}
@@ -107,8 +131,9 @@ var _ caddy.GracefulServer = &Server{}
 // Serve starts the server with an existing listener. It blocks until the server stops.
 // This implements caddy.TCPServer interface.
 func (s *Server) Serve(l net.Listener) error {
+	l = s.proxyListener(l)
 	s.m.Lock()
-	s.server[tcp] = &dns.Server{Listener: l, Net: "tcp", Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+	s.server[tcp] = &dns.Server{Listener: l, Net: "tcp", TsigSecret: s.tsigSecret, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
 		ctx := context.WithValue(context.Background(), Key{}, s)
 		ctx = context.WithValue(ctx, LoopKey{}, 0)
 		s.ServeDNS(ctx, w, r)
@@ -119,10 +144,19 @@ func (s *Server) Serve(l net.Listener) error {
 }
 
 // ServePacket starts the server with an existing packetconn. It blocks until the server stops.
-// This implements caddy.UDPServer interface.
+// This implements caddy.UDPServer interface. With the PROXY protocol p is wrapped, so it's no longer a
+// *net.UDPConn and the DNS server reads and writes all datagrams with ReadFrom and WriteTo. It then doesn't
+// use the control messages that make replies come from the address the query was sent to, which matters
+// on a host with multiple addresses on a listener that binds to all of them.
 func (s *Server) ServePacket(p net.PacketConn) error {
+	if s.proxyProto != nil {
+		p = proxyproto.NewPacketConn(p, s.proxyProto)
+	}
 	s.m.Lock()
-	s.server[udp] = &dns.Server{PacketConn: p, Net: "udp", Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+	s.server[udp] = &dns.Server{PacketConn: p, Net: "udp", TsigSecret: s.tsigSecret, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		if s.proxyProto != nil {
+			w = proxyproto.ResponseWriter{ResponseWriter: w}
+		}
 		ctx := context.WithValue(context.Background(), Key{}, s)
 		ctx = context.WithValue(ctx, LoopKey{}, 0)
 		s.ServeDNS(ctx, w, r)
@@ -141,6 +175,15 @@ func (s *Server) Listen() (net.Listener, error) {
 	return l, nil
 }
 
+// proxyListener wraps l so it accepts PROXY protocol headers, if that is enabled. This is done when
+// serving, and not in Listen, because listeners are reused when reloading.
+func (s *Server) proxyListener(l net.Listener) net.Listener {
+	if s.proxyProto == nil {
+		return l
+	}
+	return proxyproto.NewListener(l, s.proxyProto)
+}
+
 // WrapListener Listen implements caddy.GracefulServer interface.
 func (s *Server) WrapListener(ln net.Listener) net.Listener {
 	return ln
@@ -238,27 +281,31 @@ func (s *Server) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 		off       int
 		end       bool
 		dshandler *Config
+		dsctx     context.Context
 	)
 
 	for {
-		if h, ok := s.zones[q[off:]]; ok {
-			if h.pluginChain == nil { // zone defined, but has not got any plugins
-				errorAndMetricsFunc(s.Addr, w, r, dns.RcodeRefused)
-				return
-			}
-			if r.Question[0].Qtype != dns.TypeDS {
-				rcode, _ := h.pluginChain.ServeDNS(ctx, w, r)
-				if !plugin.ClientWrite(rcode) {
-					errorFunc(s.Addr, w, r, rcode)
+		if z, ok := s.zones[q[off:]]; ok {
+			h, hctx := s.handler(ctx, z, w, r)
+			if h != nil {
+				if h.pluginChain == nil { // zone defined, but has not got any plugins
+					errorAndMetricsFunc(s.Addr, w, r, dns.RcodeRefused)
+					return
 				}
-				return
+				if r.Question[0].Qtype != dns.TypeDS {
+					rcode, _ := h.pluginChain.ServeDNS(hctx, w, r)
+					if !plugin.ClientWrite(rcode) {
+						errorFunc(s.Addr, w, r, rcode)
+					}
+					return
+				}
+				// The type is DS, keep the handler, but keep on searching as maybe we are serving
+				// the parent as well and the DS should be routed to it - this will probably *misroute* DS
+				// queries to a possibly grand parent, but there is no way for us to know at this point
+				// if there is an actual delegation from grandparent -> parent -> zone.
+				// In all fairness: direct DS queries should not be needed.
+				dshandler, dsctx = h, hctx
 			}
-			// The type is DS, keep the handler, but keep on searching as maybe we are serving
-			// the parent as well and the DS should be routed to it - this will probably *misroute* DS
-			// queries to a possibly grand parent, but there is no way for us to know at this point
-			// if there is an actual delegation from grandparent -> parent -> zone.
-			// In all fairness: direct DS queries should not be needed.
-			dshandler = h
 		}
 		off, end = dns.NextLabel(q, off)
 		if end {
@@ -268,7 +315,7 @@ func (s *Server) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 
 	if r.Question[0].Qtype == dns.TypeDS && dshandler != nil && dshandler.pluginChain != nil {
 		// DS request, and we found a zone, use the handler for the query.
-		rcode, _ := dshandler.pluginChain.ServeDNS(ctx, w, r)
+		rcode, _ := dshandler.pluginChain.ServeDNS(dsctx, w, r)
 		if !plugin.ClientWrite(rcode) {
 			errorFunc(s.Addr, w, r, rcode)
 		}
@@ -276,18 +323,40 @@ func (s *Server) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 	}
 
 	// Wildcard match, if we have found nothing try the root zone as a last resort.
-	if h, ok := s.zones["."]; ok && h.pluginChain != nil {
-		rcode, _ := h.pluginChain.ServeDNS(ctx, w, r)
-		if !plugin.ClientWrite(rcode) {
-			errorFunc(s.Addr, w, r, rcode)
+	if z, ok := s.zones["."]; ok {
+		if h, hctx := s.handler(ctx, z, w, r); h != nil && h.pluginChain != nil {
+			rcode, _ := h.pluginChain.ServeDNS(hctx, w, r)
+			if !plugin.ClientWrite(rcode) {
+				errorFunc(s.Addr, w, r, rcode)
+			}
+			return
 		}
-		return
 	}
 
 	// Still here? Error out with REFUSED.
 	errorAndMetricsFunc(s.Addr, w, r, dns.RcodeRefused)
 }
 
+// handler returns the first of the configs for a zone whose filter funcs all pass for the request, and
+// the context to use for it. It returns nil if there is none.
+func (s *Server) handler(ctx context.Context, configs []*Config, w dns.ResponseWriter, r *dns.Msg) (*Config, context.Context) {
+	for _, h := range configs {
+		if len(h.FilterFuncs) == 0 {
+			return h, ctx
+		}
+		hctx := ctx
+		state := &request.Request{W: w, Req: r}
+		if h.metaCollector != nil {
+			// Collect the metadata now, so the filter funcs can use it.
+			hctx = h.metaCollector.Collect(hctx, *state)
+		}
+		if passAllFilterFuncs(hctx, h.FilterFuncs, state) {
+			return h, context.WithValue(hctx, ViewKey{}, h.ViewName)
+		}
+	}
+	return nil, ctx
+}
+
 // OnStartupComplete lists the sites served by this server
 // and any relevant information, assuming Quiet is false.
 func (s *Server) OnStartupComplete() {
@@ -344,6 +413,9 @@ type (
 
 	// LoopKey is the context key to detect server wide loops.
 	LoopKey struct{}
+
+	// ViewKey is the context key for the name of the view that handles the request.
+	ViewKey struct{}
 )
 
 // EnableChaos is a map with plugin names for which we should open CH class queries as we block these by default.
@@ -2,11 +2,15 @@ package dnsserver
 
 import (
 	"context"
+	"net"
 	"testing"
 
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	"github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/pkg/proxyproto"
 	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
 )
@@ -49,6 +53,42 @@ func TestNewServer(t *testing.T) {
 	}
 }
 
+func TestNewServerTsigSecrets(t *testing.T) {
+	c1, c2 := testConfig("dns", testPlugin{}), testConfig("dns", testPlugin{})
+	c2.Zone = "example.org."
+	c1.TsigSecret = map[string]string{"xfr.example.com.": "c2VjcmV0"}
+	c2.TsigSecret = map[string]string{"xfr.example.com.": "c2VjcmV0"}
+	if _, err := NewServer("127.0.0.1:53", []*Config{c1, c2}); err != nil {
+		t.Errorf("Expected no error for the same secret, got %s", err)
+	}
+
+	c2.TsigSecret = map[string]string{"xfr.example.com.": "b3RoZXI="}
+	if _, err := NewServer("127.0.0.1:53", []*Config{c1, c2}); err == nil {
+		t.Errorf("Expected error for different secrets of the same key")
+	}
+}
+
+func TestNewServerProxyProtocol(t *testing.T) {
+	_, n1, _ := net.ParseCIDR("10.0.0.0/24")
+	_, n2, _ := net.ParseCIDR("10.0.1.0/24")
+	c1, c2 := testConfig("dns", testPlugin{}), testConfig("dns", testPlugin{})
+	c2.Zone = "example.org."
+	c1.ProxyProtocol = &proxyproto.Config{Allow: []*net.IPNet{n1}, Timeout: proxyproto.DefaultTimeout}
+	c2.ProxyProtocol = &proxyproto.Config{Allow: []*net.IPNet{n1}, Timeout: proxyproto.DefaultTimeout}
+	if _, err := NewServer("127.0.0.1:53", []*Config{c1, c2}); err != nil {
+		t.Errorf("Expected no error for the same settings, got %s", err)
+	}
+
+	c2.ProxyProtocol = &proxyproto.Config{Allow: []*net.IPNet{n2}, Timeout: proxyproto.DefaultTimeout}
+	if _, err := NewServer("127.0.0.1:53", []*Config{c1, c2}); err == nil {
+		t.Errorf("Expected error for different proxyproto settings")
+	}
+	c2.ProxyProtocol = nil
+	if _, err := NewServer("127.0.0.1:53", []*Config{c1, c2}); err == nil {
+		t.Errorf("Expected error for a server block without proxyproto")
+	}
+}
+
 func TestDebug(t *testing.T) {
 	configNoDebug, configDebug := testConfig("dns", testPlugin{}), testConfig("dns", testPlugin{})
 	configDebug.Debug = true
@@ -76,6 +116,50 @@ func TestDebug(t *testing.T) {
 	}
 }
 
+// rcodePlugin answers all requests with its rcode.
+type rcodePlugin int
+
+func (rp rcodePlugin) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	m := new(dns.Msg)
+	m.SetRcode(r, int(rp))
+	w.WriteMsg(m)
+	return int(rp), nil
+}
+
+func (rp rcodePlugin) Name() string { return "rcodeplugin" }
+
+func TestServeDNSView(t *testing.T) {
+	tcp := testConfig("dns", rcodePlugin(dns.RcodeNameError))
+	tcp.FilterFuncs = []FilterFunc{func(_ context.Context, req *request.Request) bool { return req.Proto() == "tcp" }}
+	tcp.ViewName = "tcp"
+	txt := testConfig("dns", rcodePlugin(dns.RcodeRefused))
+	txt.FilterFuncs = []FilterFunc{func(_ context.Context, req *request.Request) bool { return req.QType() == dns.TypeTXT }}
+	txt.ViewName = "txt"
+	all := testConfig("dns", rcodePlugin(dns.RcodeSuccess))
+
+	s, err := NewServer("127.0.0.1:53", []*Config{tcp, txt, all})
+	if err != nil {
+		t.Fatalf("Expected no error for NewServer, got %s", err)
+	}
+
+	tests := []struct {
+		qtype    uint16
+		expected int
+	}{
+		{dns.TypeTXT, dns.RcodeRefused},
+		{dns.TypeA, dns.RcodeSuccess},
+	}
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion("www.example.com.", tc.qtype)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		s.ServeDNS(context.TODO(), rec, m)
+		if rec.Msg.Rcode != tc.expected {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.expected], dns.RcodeToString[rec.Msg.Rcode])
+		}
+	}
+}
+
 func BenchmarkCoreServeDNS(b *testing.B) {
 	s, err := NewServer("127.0.0.1:53", []*Config{testConfig("dns", testPlugin{})})
 	if err != nil {
@@ -0,0 +1,36 @@
+package dnsserver
+
+import (
+	"context"
+
+	"github.com/coredns/coredns/request"
+)
+
+// Viewer is implemented by plugins that select the requests a server block handles. When the server
+// starts, the Filter of the Viewer in a server block is added to the FilterFuncs of its configs. This
+// makes it possible to have several server blocks for the same zone and port, each serving a different
+// view of the zone (split-horizon DNS).
+type Viewer interface {
+	// Filter returns true if the request should be handled by the server block of the plugin.
+	Filter(ctx context.Context, req *request.Request) bool
+
+	// ViewName returns the name of the view.
+	ViewName() string
+}
+
+// MetadataCollector is implemented by the plugin that collects metadata. The server uses it to collect
+// the metadata before the filter funcs are called, so they can use it.
+type MetadataCollector interface {
+	// Collect returns a context with the metadata for the request.
+	Collect(ctx context.Context, req request.Request) context.Context
+}
+
+// passAllFilterFuncs returns true if all filter funcs return true for the request.
+func passAllFilterFuncs(ctx context.Context, filterFuncs []FilterFunc, req *request.Request) bool {
+	for _, ff := range filterFuncs {
+		if !ff(ctx, req) {
+			return false
+		}
+	}
+	return true
+}
@@ -16,6 +16,7 @@ var Directives = []string{
 	"geoip",
 	"cancel",
 	"tls",
+	"proxyproto",
 	"reload",
 	"nsid",
 	"bufsize",
@@ -33,6 +34,7 @@ var Directives = []string{
 	"local",
 	"dns64",
 	"acl",
+	"rrl",
 	"any",
 	"chaos",
 	"loadbalance",
@@ -40,6 +42,7 @@ var Directives = []string{
 	"rewrite",
 	"header",
 	"dnssec",
+	"validate",
 	"autopath",
 	"minimal",
 	"template",
@@ -53,6 +56,7 @@ var Directives = []string{
 	"kubernetes",
 	"file",
 	"auto",
+	"sql",
 	"secondary",
 	"etcd",
 	"loop",
@@ -62,4 +66,5 @@ var Directives = []string{
 	"whoami",
 	"on",
 	"sign",
+	"view",
 }
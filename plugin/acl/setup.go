@@ -7,6 +7,7 @@ import (
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cidr"
 
 	"github.com/infobloxopen/go-trees/iptree"
 	"github.com/miekg/dns"
@@ -105,10 +106,9 @@ func parse(c *caddy.Controller) (ACL, error) {
 							p.filter = newDefaultFilter()
 							break
 						}
-						token = normalize(token)
-						_, source, err := net.ParseCIDR(token)
+						source, err := cidr.ParseNetwork(token)
 						if err != nil {
-							return a, c.Errf("illegal CIDR notation %q", token)
+							return a, c.Err(err.Error())
 						}
 						p.filter.InplaceInsertNet(source, struct{}{})
 					}
@@ -138,15 +138,3 @@ func isPreservedIdentifier(token string) bool {
 	identifier := strings.ToLower(token)
 	return identifier == "type" || identifier == "net"
 }
-
-// normalize appends '/32' for any single IPv4 address and '/128' for IPv6.
-func normalize(rawNet string) string {
-	if idx := strings.IndexAny(rawNet, "/"); idx >= 0 {
-		return rawNet
-	}
-
-	if idx := strings.IndexAny(rawNet, ":"); idx >= 0 {
-		return rawNet + "/128"
-	}
-	return rawNet + "/32"
-}
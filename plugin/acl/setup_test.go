@@ -223,37 +223,3 @@ func TestSetup(t *testing.T) {
 		})
 	}
 }
-
-func TestNormalize(t *testing.T) {
-	type args struct {
-		rawNet string
-	}
-	tests := []struct {
-		name string
-		args args
-		want string
-	}{
-		{
-			"Network range 1",
-			args{"10.218.10.8/24"},
-			"10.218.10.8/24",
-		},
-		{
-			"IP address 1",
-			args{"10.218.10.8"},
-			"10.218.10.8/32",
-		},
-		{
-			"IPv6 address 1",
-			args{"2001:0db8:85a3:0000:0000:8a2e:0370:7334"},
-			"2001:0db8:85a3:0000:0000:8a2e:0370:7334/128",
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := normalize(tt.args.rawNet); got != tt.want {
-				t.Errorf("Error: normalize() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
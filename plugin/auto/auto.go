@@ -4,6 +4,7 @@ package auto
 import (
 	"context"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
@@ -24,6 +25,7 @@ type (
 
 		metrics  *metrics.Metrics
 		transfer *transfer.Transfer
+		catalog  *catalogZone // catalog zone listing the zones, nil if there is none
 		loader
 	}
 
@@ -31,12 +33,27 @@ type (
 		directory string
 		template  string
 		re        *regexp.Regexp
+		zones     map[string]zoneConfig // settings of specific zones, keyed by origin
 
 		ReloadInterval time.Duration
 		upstream       *upstream.Upstream // Upstream for looking up names during the resolution process.
 	}
+
+	// zoneConfig holds the settings of a single zone.
+	zoneConfig struct {
+		reload time.Duration // overrides ReloadInterval if not negative
+		group  string        // group property of the zone in the catalog zone
+	}
 )
 
+// reloadInterval returns the reload interval of zone origin.
+func (l loader) reloadInterval(origin string) time.Duration {
+	if zc, ok := l.zones[strings.ToLower(origin)]; ok && zc.reload >= 0 {
+		return zc.reload
+	}
+	return l.ReloadInterval
+}
+
 // ServeDNS implements the plugin.Handler interface.
 func (a Auto) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 	state := request.Request{W: w, Req: r}
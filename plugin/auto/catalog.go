@@ -0,0 +1,84 @@
+package auto
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/catalog"
+)
+
+// catalogZone is a catalog zone (RFC 9432) that lists the zones that are served, so that secondaries can
+// pick these up automatically.
+type catalogZone struct {
+	name    string
+	members []string // zones in the catalog zone, sorted
+	serial  uint32
+}
+
+// updateCatalog generates the catalog zone again when the zones that are served have changed, and sends
+// notifies for it.
+func (a Auto) updateCatalog() {
+	c := a.catalog
+	if c == nil {
+		return
+	}
+
+	// Zones that are loaded, but not in the zones of the server block aren't served.
+	var names []string
+	for _, n := range a.Zones.Names() {
+		if n == c.name || plugin.Zones(a.Zones.Origins()).Matches(n) == "" {
+			continue
+		}
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	if c.serial != 0 && equal(names, c.members) {
+		return
+	}
+
+	members := make([]catalog.Member, len(names))
+	for i, n := range names {
+		members[i] = catalog.Member{Zone: n, Group: a.loader.zones[strings.ToLower(n)].group}
+	}
+	serial := uint32(time.Now().Unix())
+	if serial <= c.serial {
+		serial = c.serial + 1
+	}
+
+	zo := file.NewZone(c.name, "")
+	for _, rr := range catalog.Records(c.name, serial, members) {
+		zo.Insert(rr)
+	}
+
+	a.Zones.Lock()
+	if a.Zones.Z == nil {
+		a.Zones.Z = make(map[string]*file.Zone)
+	}
+	if _, ok := a.Zones.Z[c.name]; !ok {
+		a.Zones.names = append(a.Zones.names, c.name)
+	}
+	a.Zones.Z[c.name] = zo
+	a.Zones.Unlock()
+
+	c.members, c.serial = names, serial
+	log.Infof("Catalog zone `%s' lists %d zones, with %d SOA serial", c.name, len(names), serial)
+
+	if err := a.transfer.Notify(c.name); err != nil {
+		log.Warningf("Failed sending notifies: %s", err)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
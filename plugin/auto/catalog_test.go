@@ -0,0 +1,88 @@
+package auto
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/catalog"
+
+	"github.com/miekg/dns"
+)
+
+func TestCatalog(t *testing.T) {
+	tempdir, err := createFiles()
+	if err != nil {
+		if tempdir != "" {
+			os.RemoveAll(tempdir)
+		}
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	a := Auto{
+		loader: loader{
+			directory: tempdir,
+			re:        regexp.MustCompile(`db\.(.*)`),
+			template:  `${1}`,
+			zones:     map[string]zoneConfig{"example.org.": {reload: -1, group: "internal"}},
+		},
+		// example.net isn't served and isn't in the catalog zone.
+		Zones:   &Zones{origins: []string{"example.org.", "example.com.", "catalog.invalid."}},
+		catalog: &catalogZone{name: "catalog.invalid."},
+	}
+	if err := os.WriteFile(filepath.Join(tempdir, "db.example.net"), []byte(zoneContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A zone file for the catalog zone is ignored.
+	if err := os.WriteFile(filepath.Join(tempdir, "db.catalog.invalid"), []byte(zoneContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a.Walk()
+	members, serial := catalogMembers(t, a)
+	if len(members) != 2 || members[0].Zone != "example.com." || members[1].Zone != "example.org." {
+		t.Fatalf("Expected example.com. and example.org. in the catalog zone, got %v", members)
+	}
+	if members[1].Group != "internal" {
+		t.Errorf("Expected the group of example.org. to be set, got %q", members[1].Group)
+	}
+
+	// Nothing changed, the catalog zone stays the same.
+	a.Walk()
+	if _, serial1 := catalogMembers(t, a); serial1 != serial {
+		t.Errorf("Expected serial %d to stay the same, got %d", serial, serial1)
+	}
+
+	if err := os.Remove(filepath.Join(tempdir, "db.example.com")); err != nil {
+		t.Fatal(err)
+	}
+	a.Walk()
+	members, serial1 := catalogMembers(t, a)
+	if len(members) != 1 || members[0].Zone != "example.org." {
+		t.Errorf("Expected only example.org. in the catalog zone, got %v", members)
+	}
+	if serial1 <= serial {
+		t.Errorf("Expected serial to increase from %d, got %d", serial, serial1)
+	}
+	if _, ok := a.Zones.Z["catalog.invalid."]; !ok {
+		t.Errorf("Expected the catalog zone to still be there")
+	}
+}
+
+func catalogMembers(t *testing.T, a Auto) ([]catalog.Member, uint32) {
+	ch, err := a.Transfer("catalog.invalid.", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rrs []dns.RR
+	for r := range ch {
+		rrs = append(rrs, r...)
+	}
+	members, err := catalog.Parse("catalog.invalid.", rrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return members, rrs[0].(*dns.SOA).Serial
+}
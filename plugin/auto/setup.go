@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/coredns/caddy"
@@ -13,6 +14,8 @@ import (
 	clog "github.com/coredns/coredns/plugin/pkg/log"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 	"github.com/coredns/coredns/plugin/transfer"
+
+	"github.com/miekg/dns"
 )
 
 var log = clog.NewWithPlugin("auto")
@@ -137,6 +140,44 @@ func autoParse(c *caddy.Controller) (Auto, error) {
 				}
 				a.loader.ReloadInterval = d
 
+			case "zone": // zone ORIGIN [reload DURATION] [group GROUP]
+				args := c.RemainingArgs()
+				if len(args)%2 != 1 {
+					return a, c.ArgErr()
+				}
+				zc := zoneConfig{reload: nilInterval}
+				for i := 1; i < len(args); i += 2 {
+					switch args[i] {
+					case "reload":
+						d, err := time.ParseDuration(args[i+1])
+						if err != nil || d < 0 {
+							return a, c.Errf("invalid reload duration '%s'", args[i+1])
+						}
+						zc.reload = d
+					case "group":
+						zc.group = args[i+1]
+					default:
+						return a, c.Errf("unknown zone property '%s'", args[i])
+					}
+				}
+				if a.loader.zones == nil {
+					a.loader.zones = make(map[string]zoneConfig)
+				}
+				a.loader.zones[dns.Fqdn(strings.ToLower(args[0]))] = zc
+
+			case "catalog": // catalog ZONE
+				if !c.NextArg() {
+					return a, c.ArgErr()
+				}
+				name := dns.Fqdn(strings.ToLower(c.Val()))
+				if plugin.Zones(a.Zones.origins).Matches(name) == "" {
+					return a, c.Errf("catalog zone '%s' is not in the zones of auto", name)
+				}
+				a.catalog = &catalogZone{name: name}
+				if c.NextArg() {
+					return a, c.ArgErr()
+				}
+
 			case "upstream":
 				// remove soon
 				c.RemainingArgs() // eat remaining args
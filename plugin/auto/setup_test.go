@@ -1,6 +1,7 @@
 package auto
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -126,3 +127,94 @@ func TestAutoParse(t *testing.T) {
 		}
 	}
 }
+
+func TestAutoParseZones(t *testing.T) {
+	tests := []struct {
+		input           string
+		shouldErr       bool
+		expectedZones   map[string]zoneConfig
+		expectedCatalog string
+	}{
+		{
+			`auto example.org catalog.invalid {
+				directory /tmp
+				zone www.example.org reload 10s
+				zone Dev.Example.org group internal reload 0
+				catalog catalog.invalid
+			}`,
+			false,
+			map[string]zoneConfig{
+				"www.example.org.": {reload: 10 * time.Second},
+				"dev.example.org.": {reload: 0, group: "internal"},
+			},
+			"catalog.invalid.",
+		},
+		{
+			`auto example.org {
+				directory /tmp
+				zone www.example.org group
+			}`,
+			true, nil, "",
+		},
+		{
+			`auto example.org {
+				directory /tmp
+				zone www.example.org reload -1s
+			}`,
+			true, nil, "",
+		},
+		{
+			`auto example.org {
+				directory /tmp
+				zone www.example.org file db.www
+			}`,
+			true, nil, "",
+		},
+		{
+			`auto example.org {
+				directory /tmp
+				zone
+			}`,
+			true, nil, "",
+		},
+		// catalog zone not served.
+		{
+			`auto example.org {
+				directory /tmp
+				catalog catalog.invalid
+			}`,
+			true, nil, "",
+		},
+		{
+			`auto example.org {
+				directory /tmp
+				catalog
+			}`,
+			true, nil, "",
+		},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		a, err := autoParse(c)
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d expected errors, but got no error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d expected no errors, but got '%v'", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(a.loader.zones, tc.expectedZones) {
+			t.Errorf("Test %d expected zones %v, got %v", i, tc.expectedZones, a.loader.zones)
+		}
+		if a.catalog == nil || a.catalog.name != tc.expectedCatalog {
+			t.Errorf("Test %d expected catalog zone %q, got %v", i, tc.expectedCatalog, a.catalog)
+		}
+		if a.loader.reloadInterval("WWW.example.org.") != 10*time.Second || a.loader.reloadInterval("example.org.") != 60*time.Second {
+			t.Errorf("Test %d expected the reload interval of the zone to be overridden", i)
+		}
+	}
+}
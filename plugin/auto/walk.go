@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/coredns/coredns/plugin/file"
 
@@ -17,6 +18,9 @@ func (a Auto) Walk() error {
 
 	toDelete := make(map[string]bool)
 	for _, n := range a.Zones.Names() {
+		if a.catalog != nil && n == a.catalog.name {
+			continue
+		}
 		toDelete[n] = true
 	}
 
@@ -29,6 +33,10 @@ func (a Auto) Walk() error {
 		if !match {
 			return nil
 		}
+		if a.catalog != nil && strings.ToLower(origin) == a.catalog.name {
+			log.Warningf("Not loading zone `%s' from %s, it is the catalog zone", origin, path)
+			return nil
+		}
 
 		if z, ok := a.Zones.Z[origin]; ok {
 			// we already have this zone
@@ -51,7 +59,7 @@ func (a Auto) Walk() error {
 			return nil
 		}
 
-		zo.ReloadInterval = a.loader.ReloadInterval
+		zo.ReloadInterval = a.loader.reloadInterval(origin)
 		zo.Upstream = a.loader.upstream
 
 		a.Zones.Add(zo, origin, a.transfer)
@@ -83,6 +91,8 @@ func (a Auto) Walk() error {
 		log.Infof("Deleting zone `%s'", origin)
 	}
 
+	a.updateCatalog()
+
 	return nil
 }
 
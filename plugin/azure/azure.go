@@ -2,19 +2,23 @@ package azure
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"sync"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/backoff"
 	"github.com/coredns/coredns/plugin/pkg/fall"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 	"github.com/coredns/coredns/request"
 
 	publicdns "github.com/Azure/azure-sdk-for-go/profiles/latest/dns/mgmt/dns"
 	privatedns "github.com/Azure/azure-sdk-for-go/profiles/latest/privatedns/mgmt/privatedns"
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/miekg/dns"
 )
 
@@ -78,34 +82,53 @@ func New(ctx context.Context, publicClient publicdns.RecordSetsClient, privateCl
 	}, nil
 }
 
+const refresh = 1 * time.Minute
+
 // Run updates the zone from azure.
 func (h *Azure) Run(ctx context.Context) error {
 	if err := h.updateZones(ctx); err != nil {
 		return err
 	}
 	go func() {
+		wait := refresh
 		for {
 			select {
 			case <-ctx.Done():
 				log.Debugf("Breaking out of Azure update loop for %v: %v", h.zoneNames, ctx.Err())
 				return
-			case <-time.After(1 * time.Minute):
-				if err := h.updateZones(ctx); err != nil && ctx.Err() == nil {
+			case <-time.After(wait):
+				err := h.updateZones(ctx)
+				if err != nil && ctx.Err() == nil {
 					log.Errorf("Failed to update zones %v: %v", h.zoneNames, err)
 				}
+				wait = backoff.Next(wait, refresh, errors.Is(err, backoff.ErrThrottled))
 			}
 		}
 	}()
 	return nil
 }
 
+// isThrottled returns true if err is azure refusing a request because there were too many.
+func isThrottled(err error) bool {
+	var derr autorest.DetailedError
+	if !errors.As(err, &derr) {
+		return false
+	}
+	if code, ok := derr.StatusCode.(int); ok && code == http.StatusTooManyRequests {
+		return true
+	}
+	return derr.Response != nil && derr.Response.StatusCode == http.StatusTooManyRequests
+}
+
 func (h *Azure) updateZones(ctx context.Context) error {
 	var err error
 	var publicSet publicdns.RecordSetListResultPage
 	var privateSet privatedns.RecordSetListResultPage
 	errs := make([]string, 0)
+	throttled := false
 	for zName, z := range h.zones {
 		for i, hostedZone := range z {
+			start := time.Now()
 			newZ := file.NewZone(zName, "")
 			if hostedZone.private {
 				for privateSet, err = h.privateClient.List(ctx, hostedZone.id, hostedZone.zone, nil, ""); privateSet.NotDone(); err = privateSet.NextWithContext(ctx) {
@@ -118,14 +141,24 @@ func (h *Azure) updateZones(ctx context.Context) error {
 			}
 			if err != nil {
 				errs = append(errs, fmt.Sprintf("failed to list resource records for %v from azure: %v", hostedZone.zone, err))
+				throttled = throttled || isThrottled(err)
 			}
 			newZ.Upstream = h.upstream
 			h.zMu.Lock()
 			(*z[i]).z = newZ
 			h.zMu.Unlock()
+
+			if err == nil {
+				label := hostedZone.id + ":" + hostedZone.zone
+				syncDuration.WithLabelValues(label).Observe(time.Since(start).Seconds())
+				lastSync.WithLabelValues(label).Set(float64(time.Now().Unix()))
+			}
 		}
 	}
 
+	if throttled {
+		return fmt.Errorf("errors updating zones: %v: %w", errs, backoff.ErrThrottled)
+	}
 	if len(errs) != 0 {
 		return fmt.Errorf("errors updating zones: %v", errs)
 	}
@@ -2,6 +2,8 @@ package azure
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"reflect"
 	"testing"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/coredns/coredns/plugin/test"
 	"github.com/coredns/coredns/request"
 
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/miekg/dns"
 )
 
@@ -178,3 +181,20 @@ func TestAzure(t *testing.T) {
 		}
 	}
 }
+
+func TestIsThrottled(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{autorest.NewErrorWithError(errors.New("too many requests"), "dns.RecordSetsClient", "ListByDNSZone", &http.Response{StatusCode: http.StatusTooManyRequests}, "Failure sending request"), true},
+		{autorest.DetailedError{StatusCode: http.StatusTooManyRequests}, true},
+		{autorest.DetailedError{StatusCode: http.StatusNotFound}, false},
+		{errors.New("connection refused"), false},
+	}
+	for i, tc := range tests {
+		if got := isThrottled(tc.err); got != tc.expected {
+			t.Errorf("Test %d: expected %t for %v, got %t", i, tc.expected, tc.err, got)
+		}
+	}
+}
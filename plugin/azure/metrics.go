@@ -0,0 +1,26 @@
+package azure
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// syncDuration is the time it took to sync a zone.
+	syncDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "azure",
+		Name:      "sync_duration_seconds",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12), // from 50ms to ~100s
+		Help:      "Histogram of the time it took to sync a zone.",
+	}, []string{"zone"})
+	// lastSync is the time of the last successful sync of a zone, to tell how stale it is.
+	lastSync = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "azure",
+		Name:      "last_sync_timestamp_seconds",
+		Help:      "The time of the last successful sync of a zone, in seconds since the Unix epoch.",
+	}, []string{"zone"})
+)
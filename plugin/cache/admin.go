@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cache"
+	"github.com/coredns/coredns/plugin/pkg/reuseport"
+
+	"github.com/miekg/dns"
+)
+
+// admin is an HTTP endpoint to inspect and purge caches. All caches configured with the same address share
+// a single admin endpoint.
+type admin struct {
+	ln     net.Listener
+	caches []*Cache
+}
+
+var admins = struct {
+	sync.Mutex
+	m map[string]*admin
+}{m: make(map[string]*admin)}
+
+// Entry is a single cache entry as returned by the admin endpoint.
+type Entry struct {
+	Name  string   `json:"name"`
+	Type  string   `json:"type"`
+	Class string   `json:"class"` // Success or Denial
+	Rcode string   `json:"rcode"`
+	TTL   int      `json:"ttl"` // remaining TTL in seconds, negative for stale entries
+	Hits  int      `json:"hits"`
+	Scope uint8    `json:"scope,omitempty"`
+	Zones []string `json:"zones"`
+}
+
+// registerAdmin adds c to the admin endpoint on addr, starting a listener if there is none yet. When
+// reloading the new caches register before the old ones unregister, so the listener is kept.
+func registerAdmin(addr string, c *Cache) error {
+	admins.Lock()
+	defer admins.Unlock()
+
+	if a, ok := admins.m[addr]; ok {
+		a.caches = append(a.caches, c)
+		return nil
+	}
+
+	ln, err := reuseport.Listen("tcp", addr)
+	if err != nil {
+		log.Errorf("Failed to start cache admin handler: %s", err)
+		return err
+	}
+	a := &admin{ln: ln, caches: []*Cache{c}}
+	admins.m[addr] = a
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(entriesPath, a.entries)
+	mux.HandleFunc(purgePath, a.purge)
+	go func() { http.Serve(ln, mux) }()
+	return nil
+}
+
+// unregisterAdmin removes c from the admin endpoint on addr, and stops it when no caches are left.
+func unregisterAdmin(addr string, c *Cache) error {
+	admins.Lock()
+	defer admins.Unlock()
+
+	a, ok := admins.m[addr]
+	if !ok {
+		return nil
+	}
+	for i := range a.caches {
+		if a.caches[i] == c {
+			a.caches = append(a.caches[:i], a.caches[i+1:]...)
+			break
+		}
+	}
+	if len(a.caches) > 0 {
+		return nil
+	}
+	delete(admins.m, addr)
+	return a.ln.Close()
+}
+
+func (a *admin) list() []*Cache {
+	admins.Lock()
+	defer admins.Unlock()
+	return append([]*Cache(nil), a.caches...)
+}
+
+// entries lists the entries of all caches. The name and suffix query parameters select entries with that
+// exact name or that are a subdomain of suffix.
+func (a *admin) entries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	match := matcher(r)
+
+	entries := []Entry{}
+	for _, c := range a.list() {
+		now := c.now()
+		for _, x := range []struct {
+			class string
+			cache *cache.Cache
+		}{{Success, c.pcache}, {Denial, c.ncache}} {
+			x.cache.Walk(func(m map[uint64]interface{}, k uint64) bool {
+				el, ok := m[k]
+				if !ok {
+					return true
+				}
+				i := el.(*item)
+				if !match(i.name) {
+					return true
+				}
+				entries = append(entries, Entry{
+					Name:  i.name,
+					Type:  dns.Type(i.qtype).String(),
+					Class: x.class,
+					Rcode: dns.RcodeToString[i.Rcode],
+					TTL:   i.ttl(now),
+					Hits:  i.Freq.Hits(),
+					Scope: i.scope,
+					Zones: c.Zones,
+				})
+				return true
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// purge removes entries from all caches. The name and suffix query parameters select entries with that
+// exact name or that are a subdomain of suffix; without them, all entries are removed.
+func (a *admin) purge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	match := matcher(r)
+
+	n := 0
+	for _, c := range a.list() {
+		for _, ca := range []*cache.Cache{c.pcache, c.ncache} {
+			ca.Walk(func(m map[uint64]interface{}, k uint64) bool {
+				if el, ok := m[k]; ok && match(el.(*item).name) {
+					delete(m, k)
+					n++
+				}
+				return true
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Purged int `json:"purged"`
+	}{n})
+}
+
+// matcher returns a function that matches names against the name or suffix query parameters of r. If
+// neither is given every name matches.
+func matcher(r *http.Request) func(string) bool {
+	if name := r.URL.Query().Get("name"); name != "" {
+		name = strings.ToLower(dns.Fqdn(name))
+		return func(s string) bool { return s == name }
+	}
+	if suffix := r.URL.Query().Get("suffix"); suffix != "" {
+		suffix = strings.ToLower(dns.Fqdn(suffix))
+		return func(s string) bool { return plugin.Name(suffix).Matches(s) }
+	}
+	return func(string) bool { return true }
+}
+
+const (
+	entriesPath = "/cache/entries"
+	purgePath   = "/cache/purge"
+)
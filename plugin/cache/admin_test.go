@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestAdmin(t *testing.T) {
+	c := New()
+	c.Next = BackendHandler()
+	for _, name := range []string{"a.example.org.", "b.example.org.", "example.net."} {
+		req := new(dns.Msg)
+		req.SetQuestion(name, dns.TypeA)
+		c.ServeDNS(context.TODO(), &test.ResponseWriter{}, req)
+		c.ServeDNS(context.TODO(), &test.ResponseWriter{}, req) // hit
+	}
+	a := &admin{caches: []*Cache{c}}
+
+	tests := []struct {
+		query   string
+		entries int
+	}{
+		{"", 3},
+		{"?name=a.example.org", 1},
+		{"?name=A.EXAMPLE.ORG.", 1},
+		{"?suffix=example.org", 2},
+		{"?suffix=example.com", 0},
+	}
+	for i, tc := range tests {
+		rec := httptest.NewRecorder()
+		a.entries(rec, httptest.NewRequest(http.MethodGet, entriesPath+tc.query, nil))
+		entries := []Entry{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("Test %d: failed to decode entries: %s", i, err)
+		}
+		if len(entries) != tc.entries {
+			t.Errorf("Test %d: expected %d entries, got %d", i, tc.entries, len(entries))
+		}
+		for _, e := range entries {
+			if e.Type != "A" || e.Class != Success || e.TTL != 303 || e.Hits != 1 {
+				t.Errorf("Test %d: unexpected entry %+v", i, e)
+			}
+		}
+	}
+
+	purges := []struct {
+		query  string
+		purged int
+		left   int
+	}{
+		{"?name=example.com", 0, 3},
+		{"?suffix=example.org", 2, 1},
+		{"", 1, 0},
+	}
+	for i, tc := range purges {
+		rec := httptest.NewRecorder()
+		a.purge(rec, httptest.NewRequest(http.MethodPost, purgePath+tc.query, nil))
+		res := struct{ Purged int }{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Test %d: failed to decode purge result: %s", i, err)
+		}
+		if res.Purged != tc.purged {
+			t.Errorf("Test %d: expected %d purged, got %d", i, tc.purged, res.Purged)
+		}
+		if l := c.pcache.Len(); l != tc.left {
+			t.Errorf("Test %d: expected %d entries left, got %d", i, tc.left, l)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	a.purge(rec, httptest.NewRequest(http.MethodGet, purgePath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected %d for GET on purge, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
+
+func TestAdminRegister(t *testing.T) {
+	addr := "localhost:0"
+	c1, c2 := New(), New()
+	if err := registerAdmin(addr, c1); err != nil {
+		t.Fatalf("Failed to register admin: %s", err)
+	}
+	if err := registerAdmin(addr, c2); err != nil {
+		t.Fatalf("Failed to register admin: %s", err)
+	}
+	if l := len(admins.m[addr].caches); l != 2 {
+		t.Errorf("Expected 2 caches, got %d", l)
+	}
+	unregisterAdmin(addr, c1)
+	if _, ok := admins.m[addr]; !ok {
+		t.Errorf("Expected admin endpoint to be running")
+	}
+	unregisterAdmin(addr, c2)
+	if _, ok := admins.m[addr]; ok {
+		t.Errorf("Expected admin endpoint to be stopped")
+	}
+}
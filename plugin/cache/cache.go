@@ -9,6 +9,7 @@ import (
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/cache"
 	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	"github.com/coredns/coredns/plugin/pkg/edns"
 	"github.com/coredns/coredns/plugin/pkg/response"
 	"github.com/coredns/coredns/request"
 
@@ -36,7 +37,19 @@ type Cache struct {
 	duration   time.Duration
 	percentage int
 
-	staleUpTo time.Duration
+	staleUpTo    time.Duration
+	staleMode    string
+	staleTimeout time.Duration
+
+	// Client Subnet variants.
+	subnets  *subnets
+	variants int
+
+	// Snapshots on disk.
+	persist *persist
+
+	// Address of the admin endpoint.
+	admin string
 
 	// Testing.
 	now func() time.Time
@@ -46,19 +59,23 @@ type Cache struct {
 // caller to set the Next handler.
 func New() *Cache {
 	return &Cache{
-		Zones:      []string{"."},
-		pcap:       defaultCap,
-		pcache:     cache.New(defaultCap),
-		pttl:       maxTTL,
-		minpttl:    minTTL,
-		ncap:       defaultCap,
-		ncache:     cache.New(defaultCap),
-		nttl:       maxNTTL,
-		minnttl:    minNTTL,
-		prefetch:   0,
-		duration:   1 * time.Minute,
-		percentage: 10,
-		now:        time.Now,
+		Zones:        []string{"."},
+		pcap:         defaultCap,
+		pcache:       cache.New(defaultCap),
+		pttl:         maxTTL,
+		minpttl:      minTTL,
+		ncap:         defaultCap,
+		ncache:       cache.New(defaultCap),
+		nttl:         maxNTTL,
+		minnttl:      minNTTL,
+		prefetch:     0,
+		duration:     1 * time.Minute,
+		percentage:   10,
+		staleMode:    staleImmediate,
+		staleTimeout: defaultStaleTimeout,
+		subnets:      newSubnets(2*defaultCap, defaultVariants),
+		variants:     defaultVariants,
+		now:          time.Now,
 	}
 }
 
@@ -78,6 +95,13 @@ func key(qname string, m *dns.Msg, t response.Type) (bool, uint64) {
 	return true, hash(qname, m.Question[0].Qtype)
 }
 
+// keys returns the keys to look up for state, most specific first. The last key is the one holding the
+// answer that is valid for all clients.
+func (c *Cache) keys(state request.Request) []uint64 {
+	k := hash(state.Name(), state.QType())
+	return append(c.subnets.keys(k, state), k)
+}
+
 func hash(qname string, qtype uint16) uint64 {
 	h := fnv.New64()
 	h.Write([]byte{byte(qtype >> 8)})
@@ -107,6 +131,9 @@ type ResponseWriter struct {
 	do         bool // When true the original request had the DO bit set.
 	prefetch   bool // When true write nothing back to the client.
 	remoteAddr net.Addr
+
+	verify chan *dns.Msg      // When not nil, send the reply here instead of writing it back to the client.
+	report *edns.SubnetReport // Client Subnet option of the upstream reply, if reported.
 }
 
 // newPrefetchResponseWriter returns a Cache ResponseWriter to be used in
@@ -159,9 +186,18 @@ func (w *ResponseWriter) WriteMsg(res *dns.Msg) error {
 		duration = computeTTL(msgTTL, w.minpttl, w.pttl)
 	}
 
+	var scope uint8
+	if hasKey && duration > 0 {
+		key, scope, hasKey = w.scopedKey(key, res, duration)
+	}
+	// When verifying a stale item, don't let an error mask it.
+	if w.verify != nil && mt == response.ServerError {
+		hasKey = false
+	}
+
 	if hasKey && duration > 0 {
 		if w.state.Match(res) {
-			w.set(res, key, mt, duration)
+			w.set(res, key, scope, mt, duration)
 			cacheSize.WithLabelValues(w.server, Success).Set(float64(w.pcache.Len()))
 			cacheSize.WithLabelValues(w.server, Denial).Set(float64(w.ncache.Len()))
 		} else {
@@ -185,25 +221,35 @@ func (w *ResponseWriter) WriteMsg(res *dns.Msg) error {
 		res.AuthenticatedData = false // unset AD bit if client is not OK with DNSSEC
 	}
 
+	if w.verify != nil {
+		select {
+		case w.verify <- res:
+		default:
+		}
+		return nil
+	}
+
 	return w.ResponseWriter.WriteMsg(res)
 }
 
-func (w *ResponseWriter) set(m *dns.Msg, key uint64, mt response.Type, duration time.Duration) {
+func (w *ResponseWriter) set(m *dns.Msg, key uint64, scope uint8, mt response.Type, duration time.Duration) {
 	// duration is expected > 0
 	// and key is valid
 	switch mt {
 	case response.NoError, response.Delegation:
 		i := newItem(m, w.now(), duration)
+		i.name, i.qtype, i.scope = w.state.Name(), w.state.QType(), scope
 		if w.pcache.Add(key, i) {
 			evictions.WithLabelValues(w.server, Success).Inc()
 		}
 		// when pre-fetching, remove the negative cache entry if it exists
-		if w.prefetch {
+		if w.prefetch || w.verify != nil {
 			w.ncache.Remove(key)
 		}
 
 	case response.NameError, response.NoData, response.ServerError:
 		i := newItem(m, w.now(), duration)
+		i.name, i.qtype, i.scope = w.state.Name(), w.state.QType(), scope
 		if w.ncache.Add(key, i) {
 			evictions.WithLabelValues(w.server, Denial).Inc()
 		}
@@ -231,7 +277,8 @@ const (
 	maxNTTL = dnsutil.MaximumDefaulTTL / 2
 	minNTTL = dnsutil.MinimalDefaultTTL
 
-	defaultCap = 10000 // default capacity of the cache.
+	defaultCap      = 10000 // default capacity of the cache.
+	defaultVariants = 16    // default maximum number of Client Subnet variants per name and type.
 
 	// Success is the class for caching positive caching.
 	Success = "success"
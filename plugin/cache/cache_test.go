@@ -201,7 +201,7 @@ func TestCache(t *testing.T) {
 		valid, k := key(state.Name(), m, mt)
 
 		if valid {
-			crr.set(m, k, mt, c.pttl)
+			crr.set(m, k, 0, mt, c.pttl)
 		}
 
 		i, _ := c.get(time.Now().UTC(), state, "dns://:53")
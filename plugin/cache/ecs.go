@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/cache"
+	"github.com/coredns/coredns/plugin/pkg/edns"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// subnets tracks, per name and type, the scope prefix lengths (RFC 7871) of the answers stored in the
+// cache. Answers with a non-zero scope are only valid for clients in that subnet and are stored as a
+// variant under a key that includes the (masked) subnet. The name and type key itself always holds
+// the answer that is valid for all clients.
+type subnets struct {
+	c        *cache.Cache
+	variants int // maximum number of variants per name and type
+}
+
+// scopes holds the scope prefix lengths seen for a single name and type.
+type scopes struct {
+	sync.Mutex
+	v4 []uint8 // sorted, longest first
+	v6 []uint8
+
+	variants map[uint64]time.Time // variant key -> time after which it can be forgotten
+}
+
+func newSubnets(size, variants int) *subnets {
+	return &subnets{c: cache.New(size), variants: variants}
+}
+
+// keys returns the keys of the variants that may hold an answer for state, most specific first.
+func (s *subnets) keys(k uint64, state request.Request) []uint64 {
+	i, ok := s.c.Get(k)
+	if !ok {
+		return nil
+	}
+	sc := i.(*scopes)
+
+	family, ip, source := clientSubnet(state)
+	sc.Lock()
+	lengths := sc.v4
+	if family == 2 {
+		lengths = sc.v6
+	}
+	var keys []uint64
+	for _, l := range lengths {
+		if l > source {
+			continue
+		}
+		keys = append(keys, subnetHash(k, family, ip, l))
+	}
+	sc.Unlock()
+	return keys
+}
+
+// add registers a variant for the name and type in k. It returns the key under which to store the
+// answer and its scope, or false if the maximum number of variants has been reached.
+func (s *subnets) add(k uint64, e *dns.EDNS0_SUBNET, now, expire time.Time) (uint64, uint8, bool) {
+	scope := e.SourceScope
+	if scope > e.SourceNetmask {
+		// We can't know the scope for a longer prefix than was sent, see RFC 7871, Section 7.3.1.
+		scope = e.SourceNetmask
+	}
+	vk := subnetHash(k, e.Family, e.Address, scope)
+
+	var sc *scopes
+	if i, ok := s.c.Get(k); ok {
+		sc = i.(*scopes)
+	} else {
+		sc = &scopes{variants: make(map[uint64]time.Time)}
+		s.c.Add(k, sc)
+	}
+
+	sc.Lock()
+	defer sc.Unlock()
+	if _, ok := sc.variants[vk]; !ok {
+		for v, t := range sc.variants {
+			if now.After(t) {
+				delete(sc.variants, v)
+			}
+		}
+		if len(sc.variants) >= s.variants {
+			return 0, 0, false
+		}
+	}
+	sc.variants[vk] = expire
+
+	if e.Family == 2 {
+		sc.v6 = addLength(sc.v6, scope)
+	} else {
+		sc.v4 = addLength(sc.v4, scope)
+	}
+	return vk, scope, true
+}
+
+// addLength adds l to the sorted lengths if not already present.
+func addLength(lengths []uint8, l uint8) []uint8 {
+	for _, x := range lengths {
+		if x == l {
+			return lengths
+		}
+	}
+	lengths = append(lengths, l)
+	sort.Slice(lengths, func(i, j int) bool { return lengths[i] > lengths[j] })
+	return lengths
+}
+
+// clientSubnet returns the family, address and source prefix length of the client in state. This is the
+// Client Subnet option in the query when present, otherwise the client's address.
+func clientSubnet(state request.Request) (uint16, net.IP, uint8) {
+	if e := edns.Subnet(state.Req); e != nil {
+		return e.Family, e.Address, e.SourceNetmask
+	}
+	ip := net.ParseIP(state.IP())
+	if ip4 := ip.To4(); ip4 != nil {
+		return 1, ip4, net.IPv4len * 8
+	}
+	return 2, ip, net.IPv6len * 8
+}
+
+// subnetHash returns the key of the variant of k for ip masked to scope bits.
+func subnetHash(k uint64, family uint16, ip net.IP, scope uint8) uint64 {
+	bits := net.IPv4len * 8
+	if family == 2 {
+		ip = ip.To16()
+		bits = net.IPv6len * 8
+	} else {
+		ip = ip.To4()
+	}
+
+	h := fnv.New64()
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, k)
+	h.Write(b)
+	h.Write([]byte{byte(family), scope})
+	h.Write(ip.Mask(net.CIDRMask(int(scope), bits)))
+	return h.Sum64()
+}
+
+// scopedKey returns the key under which to store res, taking the Client Subnet scope of the reply into
+// account, and that scope. It returns false if the answer should not be cached.
+func (w *ResponseWriter) scopedKey(k uint64, res *dns.Msg, duration time.Duration) (uint64, uint8, bool) {
+	var e *dns.EDNS0_SUBNET
+	if w.report != nil {
+		e = w.report.Subnet()
+	}
+	if e == nil {
+		e = edns.Subnet(res)
+	}
+	if e == nil || e.SourceScope == 0 || e.SourceNetmask == 0 {
+		return k, 0, true
+	}
+
+	now := w.now()
+	return w.subnets.add(k, e, now, now.Add(duration+w.staleUpTo))
+}
+
+// setSubnet adds the Client Subnet option of the query to m when the client sent one. Scope is the scope
+// prefix length of the answer.
+func setSubnet(m *dns.Msg, req *dns.Msg, scope uint8) {
+	e := edns.Subnet(req)
+	if e == nil {
+		return
+	}
+	o := replyOPT(m, req)
+	o.Option = append(o.Option, &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: e.Family,
+		SourceNetmask: e.SourceNetmask, SourceScope: scope, Address: e.Address})
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/pkg/edns"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// subnetBackend answers with the address of the client and reports a Client Subnet option with the given scope, just
+// as the forward plugin does when it added the option itself.
+func subnetBackend(scope uint8) plugin.Handler {
+	return plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		state := request.Request{W: w, Req: r}
+		e := edns.NewSubnet(net.ParseIP(state.IP()), 24, 56)
+		e.SourceScope = scope
+		edns.ReportSubnet(ctx, e)
+
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.Response, m.RecursionAvailable = true, true
+		m.Answer = []dns.RR{test.A("example.org. 60 IN A " + state.IP())}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	})
+}
+
+func TestCacheSubnetScope(t *testing.T) {
+	c := New()
+	c.Next = subnetBackend(24)
+
+	tests := []struct {
+		remote   string
+		ecs      string // when not empty, the query carries this client subnet (/24)
+		cached   bool
+		expected string
+	}{
+		{remote: "10.240.1.1", cached: false, expected: "10.240.1.1"},
+		{remote: "10.240.1.2", cached: true, expected: "10.240.1.1"},
+		{remote: "10.240.2.1", cached: false, expected: "10.240.2.1"},
+		{remote: "10.240.2.200", cached: true, expected: "10.240.2.1"},
+		{remote: "192.0.2.1", ecs: "10.240.1.0", cached: true, expected: "10.240.1.1"},
+		{remote: "10.240.1.3", ecs: "10.240.3.0", cached: false, expected: "10.240.1.3"},
+	}
+
+	for i, tc := range tests {
+		req := new(dns.Msg)
+		req.SetQuestion("example.org.", dns.TypeA)
+		if tc.ecs != "" {
+			req.SetEdns0(4096, false)
+			req.IsEdns0().Option = append(req.IsEdns0().Option, edns.NewSubnet(net.ParseIP(tc.ecs), 24, 56))
+		}
+
+		called := false
+		next := subnetBackend(24)
+		c.Next = plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+			called = true
+			return next.ServeDNS(ctx, w, r)
+		})
+
+		rec := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: tc.remote})
+		c.ServeDNS(context.TODO(), rec, req)
+
+		if called == tc.cached {
+			t.Errorf("Test %d: expected cached to be %t", i, tc.cached)
+		}
+		if a := rec.Msg.Answer[0].(*dns.A).A.String(); a != tc.expected {
+			t.Errorf("Test %d: expected answer %s, got %s", i, tc.expected, a)
+		}
+		if tc.ecs != "" && tc.cached {
+			e := edns.Subnet(rec.Msg)
+			if e == nil {
+				t.Fatalf("Test %d: expected Client Subnet option in reply", i)
+			}
+			if e.SourceScope != 24 || e.Address.String() != tc.ecs {
+				t.Errorf("Test %d: expected %s/24 scope 24 in reply, got %s/%d scope %d", i, tc.ecs, e.Address, e.SourceNetmask, e.SourceScope)
+			}
+		}
+	}
+}
+
+func TestCacheSubnetVariants(t *testing.T) {
+	c := New()
+	c.subnets = newSubnets(defaultCap, 2)
+
+	for i, remote := range []string{"10.240.1.1", "10.240.2.1", "10.240.3.1"} {
+		req := new(dns.Msg)
+		req.SetQuestion("example.org.", dns.TypeA)
+		c.Next = subnetBackend(24)
+		c.ServeDNS(context.TODO(), &test.ResponseWriter{RemoteIP: remote}, req)
+
+		expected := i + 1
+		if expected > 2 {
+			expected = 2
+		}
+		if l := c.pcache.Len(); l != expected {
+			t.Errorf("Test %d: expected %d items in cache, got %d", i, expected, l)
+		}
+	}
+}
+
+func TestCacheSubnetGlobal(t *testing.T) {
+	c := New()
+	c.Next = subnetBackend(0)
+
+	req := new(dns.Msg)
+	req.SetQuestion("example.org.", dns.TypeA)
+	c.ServeDNS(context.TODO(), &test.ResponseWriter{RemoteIP: "10.240.1.1"}, req)
+
+	// A scope of 0 means the answer is valid for everyone.
+	c.Next = plugin.HandlerFunc(func(context.Context, dns.ResponseWriter, *dns.Msg) (int, error) {
+		return 255, nil // Below, a 255 means we tried querying upstream.
+	})
+	if ret, _ := c.ServeDNS(context.TODO(), &test.ResponseWriter{RemoteIP: "192.0.2.1"}, req); ret == 255 {
+		t.Errorf("Expected answer from cache")
+	}
+}
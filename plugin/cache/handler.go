@@ -7,6 +7,7 @@ import (
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/metrics"
+	"github.com/coredns/coredns/plugin/pkg/edns"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
@@ -42,17 +43,16 @@ func (c *Cache) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 		crr := &ResponseWriter{ResponseWriter: w, Cache: c, state: state, server: server, do: do}
 		return c.doRefresh(ctx, state, crr)
 	}
+	i.Freq.Update(c.duration, now)
 	if ttl < 0 {
-		servedStale.WithLabelValues(server).Inc()
-		// Adjust the time to get a 0 TTL in the reply built from a stale item.
-		now = now.Add(time.Duration(ttl) * time.Second)
-		cw := newPrefetchResponseWriter(server, state, c)
-		go c.doPrefetch(ctx, state, cw, i, now)
-	} else if c.shouldPrefetch(i, now) {
+		return c.serveStale(ctx, w, r, state, server, i, now)
+	}
+	if c.shouldPrefetch(i, now) {
 		cw := newPrefetchResponseWriter(server, state, c)
 		go c.doPrefetch(ctx, state, cw, i, now)
 	}
 	resp := i.toMsg(r, now, do)
+	setSubnet(resp, r, i.scope)
 	w.WriteMsg(resp)
 
 	return dns.RcodeSuccess, nil
@@ -74,6 +74,7 @@ func (c *Cache) doRefresh(ctx context.Context, state request.Request, cw *Respon
 	if !state.Do() {
 		setDo(state.Req)
 	}
+	ctx, cw.report = edns.WithSubnetReport(ctx)
 	return plugin.NextOrFailure(c.Name(), c.Next, ctx, cw, state.Req)
 }
 
@@ -81,7 +82,6 @@ func (c *Cache) shouldPrefetch(i *item, now time.Time) bool {
 	if c.prefetch <= 0 {
 		return false
 	}
-	i.Freq.Update(c.duration, now)
 	threshold := int(math.Ceil(float64(c.percentage) / 100 * float64(i.origTTL)))
 	return i.Freq.Hits() >= c.prefetch && i.ttl(now) <= threshold
 }
@@ -90,17 +90,18 @@ func (c *Cache) shouldPrefetch(i *item, now time.Time) bool {
 func (c *Cache) Name() string { return "cache" }
 
 func (c *Cache) get(now time.Time, state request.Request, server string) (*item, bool) {
-	k := hash(state.Name(), state.QType())
 	cacheRequests.WithLabelValues(server).Inc()
 
-	if i, ok := c.ncache.Get(k); ok && i.(*item).ttl(now) > 0 {
-		cacheHits.WithLabelValues(server, Denial).Inc()
-		return i.(*item), true
-	}
+	for _, k := range c.keys(state) {
+		if i, ok := c.ncache.Get(k); ok && i.(*item).ttl(now) > 0 {
+			cacheHits.WithLabelValues(server, Denial).Inc()
+			return i.(*item), true
+		}
 
-	if i, ok := c.pcache.Get(k); ok && i.(*item).ttl(now) > 0 {
-		cacheHits.WithLabelValues(server, Success).Inc()
-		return i.(*item), true
+		if i, ok := c.pcache.Get(k); ok && i.(*item).ttl(now) > 0 {
+			cacheHits.WithLabelValues(server, Success).Inc()
+			return i.(*item), true
+		}
 	}
 	cacheMisses.WithLabelValues(server).Inc()
 	return nil, false
@@ -108,21 +109,22 @@ func (c *Cache) get(now time.Time, state request.Request, server string) (*item,
 
 // getIgnoreTTL unconditionally returns an item if it exists in the cache.
 func (c *Cache) getIgnoreTTL(now time.Time, state request.Request, server string) *item {
-	k := hash(state.Name(), state.QType())
 	cacheRequests.WithLabelValues(server).Inc()
 
-	if i, ok := c.ncache.Get(k); ok {
-		ttl := i.(*item).ttl(now)
-		if ttl > 0 || (c.staleUpTo > 0 && -ttl < int(c.staleUpTo.Seconds())) {
-			cacheHits.WithLabelValues(server, Denial).Inc()
-			return i.(*item)
+	for _, k := range c.keys(state) {
+		if i, ok := c.ncache.Get(k); ok {
+			ttl := i.(*item).ttl(now)
+			if ttl > 0 || (c.staleUpTo > 0 && -ttl < int(c.staleUpTo.Seconds())) {
+				cacheHits.WithLabelValues(server, Denial).Inc()
+				return i.(*item)
+			}
 		}
-	}
-	if i, ok := c.pcache.Get(k); ok {
-		ttl := i.(*item).ttl(now)
-		if ttl > 0 || (c.staleUpTo > 0 && -ttl < int(c.staleUpTo.Seconds())) {
-			cacheHits.WithLabelValues(server, Success).Inc()
-			return i.(*item)
+		if i, ok := c.pcache.Get(k); ok {
+			ttl := i.(*item).ttl(now)
+			if ttl > 0 || (c.staleUpTo > 0 && -ttl < int(c.staleUpTo.Seconds())) {
+				cacheHits.WithLabelValues(server, Success).Inc()
+				return i.(*item)
+			}
 		}
 	}
 	cacheMisses.WithLabelValues(server).Inc()
@@ -130,12 +132,13 @@ func (c *Cache) getIgnoreTTL(now time.Time, state request.Request, server string
 }
 
 func (c *Cache) exists(state request.Request) *item {
-	k := hash(state.Name(), state.QType())
-	if i, ok := c.ncache.Get(k); ok {
-		return i.(*item)
-	}
-	if i, ok := c.pcache.Get(k); ok {
-		return i.(*item)
+	for _, k := range c.keys(state) {
+		if i, ok := c.ncache.Get(k); ok {
+			return i.(*item)
+		}
+		if i, ok := c.pcache.Get(k); ok {
+			return i.(*item)
+		}
 	}
 	return nil
 }
@@ -9,6 +9,8 @@ import (
 )
 
 type item struct {
+	failed int64 // unix nano time of the last failed stale refresh, atomic and first in struct for proper alignment
+
 	Rcode              int
 	AuthenticatedData  bool
 	RecursionAvailable bool
@@ -18,6 +20,11 @@ type item struct {
 
 	origTTL uint32
 	stored  time.Time
+	scope   uint8 // Client Subnet scope prefix length, 0 when valid for all clients.
+
+	// Name and type of the query, only used for inspecting the cache.
+	name  string
+	qtype uint16
 
 	*freq.Freq
 }
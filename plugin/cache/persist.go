@@ -0,0 +1,224 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/coredns/coredns/plugin/cache/freq"
+	"github.com/coredns/coredns/plugin/pkg/cache"
+
+	"github.com/miekg/dns"
+)
+
+// snapshot is the on-disk representation of the cache.
+type snapshot struct {
+	Version int
+	Items   []snapshotItem
+}
+
+// snapshotItem is a single cached item. The resource records are stored in their text representation.
+type snapshotItem struct {
+	Key    uint64
+	Denial bool // true if the item lives in the denial cache
+	Name   string
+	Qtype  uint16
+
+	Rcode              int
+	AuthenticatedData  bool
+	RecursionAvailable bool
+	Answer             []string
+	Ns                 []string
+	Extra              []string
+
+	OrigTTL uint32
+	Stored  time.Time
+}
+
+const (
+	snapshotVersion        = 1
+	defaultPersistInterval = 10 * time.Minute
+)
+
+// persist holds the settings for writing the cache to disk.
+type persist struct {
+	file     string
+	interval time.Duration
+	stop     chan struct{}
+}
+
+// startPersist loads the cache from disk and starts saving it periodically.
+func (c *Cache) startPersist() {
+	if err := c.load(); err != nil {
+		// A missing or broken snapshot should not prevent us from starting.
+		log.Warningf("Failed to load cache from %q: %s", c.persist.file, err)
+	}
+	if c.persist.interval == 0 {
+		return
+	}
+
+	c.persist.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		tick := time.NewTicker(c.persist.interval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-tick.C:
+				if err := c.save(); err != nil {
+					log.Errorf("Failed to save cache to %q: %s", c.persist.file, err)
+				}
+			}
+		}
+	}(c.persist.stop)
+}
+
+// stopPersist stops saving the cache periodically.
+func (c *Cache) stopPersist() {
+	if c.persist.stop == nil {
+		return
+	}
+	close(c.persist.stop)
+	c.persist.stop = nil
+}
+
+// save writes the positive and negative cache to disk. Items that are only valid for a client subnet are
+// not saved.
+func (c *Cache) save() error {
+	s := snapshot{Version: snapshotVersion}
+	s.Items = appendSnapshot(s.Items, c.pcache, false)
+	s.Items = appendSnapshot(s.Items, c.ncache, true)
+
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so a crash doesn't leave a truncated snapshot behind.
+	tmp, err := ioutil.TempFile(filepath.Dir(c.persist.file), filepath.Base(c.persist.file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.persist.file); err != nil {
+		return err
+	}
+	log.Debugf("Saved %d items to %q", len(s.Items), c.persist.file)
+	return nil
+}
+
+func appendSnapshot(items []snapshotItem, ca *cache.Cache, denial bool) []snapshotItem {
+	ca.Walk(func(m map[uint64]interface{}, k uint64) bool {
+		el, ok := m[k]
+		if !ok {
+			return true
+		}
+		i := el.(*item)
+		if i.scope != 0 {
+			return true
+		}
+		items = append(items, snapshotItem{
+			Key:                k,
+			Denial:             denial,
+			Name:               i.name,
+			Qtype:              i.qtype,
+			Rcode:              i.Rcode,
+			AuthenticatedData:  i.AuthenticatedData,
+			RecursionAvailable: i.RecursionAvailable,
+			Answer:             rrStrings(i.Answer),
+			Ns:                 rrStrings(i.Ns),
+			Extra:              rrStrings(i.Extra),
+			OrigTTL:            i.origTTL,
+			Stored:             i.stored,
+		})
+		return true
+	})
+	return items
+}
+
+// load reads the cache from disk. Items are stored with their original storage time, so the time that
+// has elapsed since then is taken off their TTL. Items that are expired (and too old to be served stale)
+// are skipped.
+func (c *Cache) load() error {
+	buf, err := ioutil.ReadFile(c.persist.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s := snapshot{}
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return err
+	}
+	if s.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", s.Version)
+	}
+
+	now := c.now()
+	n := 0
+	for _, si := range s.Items {
+		i := &item{
+			Rcode:              si.Rcode,
+			AuthenticatedData:  si.AuthenticatedData,
+			RecursionAvailable: si.RecursionAvailable,
+			origTTL:            si.OrigTTL,
+			stored:             si.Stored.UTC(),
+			name:               si.Name,
+			qtype:              si.Qtype,
+			Freq:               new(freq.Freq),
+		}
+		if ttl := i.ttl(now); ttl <= 0 && -ttl >= int(c.staleUpTo.Seconds()) {
+			continue
+		}
+		if i.Answer, err = parseRRs(si.Answer); err != nil {
+			return err
+		}
+		if i.Ns, err = parseRRs(si.Ns); err != nil {
+			return err
+		}
+		if i.Extra, err = parseRRs(si.Extra); err != nil {
+			return err
+		}
+
+		if si.Denial {
+			c.ncache.Add(si.Key, i)
+		} else {
+			c.pcache.Add(si.Key, i)
+		}
+		n++
+	}
+	log.Infof("Loaded %d items from %q", n, c.persist.file)
+	return nil
+}
+
+func rrStrings(rrs []dns.RR) []string {
+	s := make([]string, len(rrs))
+	for i, r := range rrs {
+		s[i] = r.String()
+	}
+	return s
+}
+
+func parseRRs(s []string) ([]dns.RR, error) {
+	rrs := make([]dns.RR, len(s))
+	for i := range s {
+		r, err := dns.NewRR(s[i])
+		if err != nil {
+			return nil, err
+		}
+		rrs[i] = r
+	}
+	return rrs, nil
+}
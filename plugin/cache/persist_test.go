@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestPersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coredns-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "cache.json")
+
+	c := New()
+	c.persist = &persist{file: file}
+	c.Next = ttlBackend(60)
+	req := new(dns.Msg)
+	req.SetQuestion("example.org.", dns.TypeA)
+	c.ServeDNS(context.TODO(), &test.ResponseWriter{}, req)
+
+	c.Next = nxDomainBackend(60)
+	req.SetQuestion("nx.example.org.", dns.TypeA)
+	c.ServeDNS(context.TODO(), &test.ResponseWriter{}, req)
+
+	if err := c.OnFinalShutdown(); err != nil {
+		t.Fatalf("Failed to save cache: %s", err)
+	}
+
+	tests := []struct {
+		elapsed  time.Duration
+		items    int
+		ttl      uint32
+		expected int
+	}{
+		{0, 2, 60, 0},
+		{20 * time.Second, 2, 40, 0},
+		{2 * time.Minute, 0, 0, 255},
+	}
+	for i, tc := range tests {
+		c1 := New()
+		c1.persist = &persist{file: file}
+		c1.now = func() time.Time { return time.Now().Add(tc.elapsed) }
+		if err := c1.OnStartup(); err != nil {
+			t.Fatalf("Test %d: failed to load cache: %s", i, err)
+		}
+		if l := c1.pcache.Len() + c1.ncache.Len(); l != tc.items {
+			t.Errorf("Test %d: expected %d items, got %d", i, tc.items, l)
+		}
+
+		c1.Next = plugin.HandlerFunc(func(context.Context, dns.ResponseWriter, *dns.Msg) (int, error) {
+			return 255, nil // Below, a 255 means we tried querying upstream.
+		})
+		req.SetQuestion("example.org.", dns.TypeA)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		ret, _ := c1.ServeDNS(context.TODO(), rec, req)
+		if ret != tc.expected {
+			t.Errorf("Test %d: expected %d, got %d", i, tc.expected, ret)
+			continue
+		}
+		if tc.expected != 0 {
+			continue
+		}
+		// allow for a second of rounding
+		if ttl := rec.Msg.Answer[0].Header().Ttl; ttl > tc.ttl || ttl < tc.ttl-1 {
+			t.Errorf("Test %d: expected TTL %d, got %d", i, tc.ttl, ttl)
+		}
+	}
+}
+
+func TestPersistMissingFile(t *testing.T) {
+	c := New()
+	c.persist = &persist{file: "/does/not/exist/cache.json"}
+	if err := c.OnStartup(); err != nil {
+		t.Errorf("Expected no error for missing snapshot, got: %s", err)
+	}
+	if err := c.OnFinalShutdown(); err == nil {
+		t.Errorf("Expected error saving to non existent directory, got none")
+	}
+}
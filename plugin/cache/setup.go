@@ -3,6 +3,8 @@ package cache
 import (
 	"errors"
 	"fmt"
+	"net"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -27,6 +29,45 @@ func setup(c *caddy.Controller) error {
 		return ca
 	})
 
+	c.OnStartup(ca.OnStartup)
+	c.OnRestart(ca.OnFinalShutdown)
+	c.OnShutdown(ca.OnShutdown)
+	c.OnFinalShutdown(ca.OnFinalShutdown)
+
+	return nil
+}
+
+// OnStartup loads the cache from disk and starts the admin endpoint, if configured.
+func (c *Cache) OnStartup() error {
+	if c.persist != nil {
+		c.startPersist()
+	}
+	if c.admin != "" {
+		return registerAdmin(c.admin, c)
+	}
+	return nil
+}
+
+// OnShutdown stops saving the cache periodically and removes c from the admin endpoint.
+func (c *Cache) OnShutdown() error {
+	if c.persist != nil {
+		c.stopPersist()
+	}
+	if c.admin != "" {
+		return unregisterAdmin(c.admin, c)
+	}
+	return nil
+}
+
+// OnFinalShutdown saves the cache to disk. This is also called on restart, before the new cache starts
+// up and loads what we've saved.
+func (c *Cache) OnFinalShutdown() error {
+	if c.persist == nil {
+		return nil
+	}
+	if err := c.save(); err != nil {
+		return fmt.Errorf("failed to save cache to %q: %s", c.persist.file, err)
+	}
 	return nil
 }
 
@@ -165,11 +206,11 @@ func cacheParse(c *caddy.Controller) (*Cache, error) {
 
 			case "serve_stale":
 				args := c.RemainingArgs()
-				if len(args) > 1 {
+				if len(args) > 3 {
 					return nil, c.ArgErr()
 				}
 				ca.staleUpTo = 1 * time.Hour
-				if len(args) == 1 {
+				if len(args) > 0 {
 					d, err := time.ParseDuration(args[0])
 					if err != nil {
 						return nil, err
@@ -179,6 +220,68 @@ func cacheParse(c *caddy.Controller) (*Cache, error) {
 					}
 					ca.staleUpTo = d
 				}
+				if len(args) > 1 {
+					mode := args[1]
+					if mode != staleImmediate && mode != staleVerify {
+						return nil, fmt.Errorf("invalid value for serve_stale refresh mode: %s", mode)
+					}
+					ca.staleMode = mode
+				}
+				if len(args) > 2 {
+					if ca.staleMode != staleVerify {
+						return nil, fmt.Errorf("serve_stale timeout is only valid with %s", staleVerify)
+					}
+					d, err := time.ParseDuration(args[2])
+					if err != nil {
+						return nil, err
+					}
+					if d <= 0 {
+						return nil, errors.New("invalid non-positive timeout for serve_stale")
+					}
+					ca.staleTimeout = d
+				}
+			case "ecs":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, c.ArgErr()
+				}
+				variants, err := strconv.Atoi(args[0])
+				if err != nil {
+					return nil, err
+				}
+				if variants < 0 {
+					return nil, fmt.Errorf("ecs variants can not be negative: %d", variants)
+				}
+				ca.variants = variants
+			case "persist":
+				args := c.RemainingArgs()
+				if len(args) == 0 || len(args) > 2 {
+					return nil, c.ArgErr()
+				}
+				file := args[0]
+				if root := dnsserver.GetConfig(c).Root; !filepath.IsAbs(file) && root != "" {
+					file = filepath.Join(root, file)
+				}
+				ca.persist = &persist{file: file, interval: defaultPersistInterval}
+				if len(args) == 2 {
+					d, err := time.ParseDuration(args[1])
+					if err != nil {
+						return nil, err
+					}
+					if d < 0 {
+						return nil, errors.New("invalid negative duration for persist")
+					}
+					ca.persist.interval = d
+				}
+			case "admin":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, c.ArgErr()
+				}
+				if _, _, err := net.SplitHostPort(args[0]); err != nil {
+					return nil, err
+				}
+				ca.admin = args[0]
 			default:
 				return nil, c.ArgErr()
 			}
@@ -187,6 +290,7 @@ func cacheParse(c *caddy.Controller) (*Cache, error) {
 		ca.Zones = origins
 		ca.pcache = cache.New(ca.pcap)
 		ca.ncache = cache.New(ca.ncap)
+		ca.subnets = newSubnets(ca.pcap+ca.ncap, ca.variants)
 	}
 
 	return ca, nil
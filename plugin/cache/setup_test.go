@@ -117,20 +117,28 @@ func TestSetup(t *testing.T) {
 
 func TestServeStale(t *testing.T) {
 	tests := []struct {
-		input     string
-		shouldErr bool
-		staleUpTo time.Duration
+		input        string
+		shouldErr    bool
+		staleUpTo    time.Duration
+		staleMode    string
+		staleTimeout time.Duration
 	}{
-		{"serve_stale", false, 1 * time.Hour},
-		{"serve_stale 20m", false, 20 * time.Minute},
-		{"serve_stale 1h20m", false, 80 * time.Minute},
-		{"serve_stale 0m", false, 0},
-		{"serve_stale 0", false, 0},
+		{"serve_stale", false, 1 * time.Hour, staleImmediate, defaultStaleTimeout},
+		{"serve_stale 20m", false, 20 * time.Minute, staleImmediate, defaultStaleTimeout},
+		{"serve_stale 1h20m", false, 80 * time.Minute, staleImmediate, defaultStaleTimeout},
+		{"serve_stale 0m", false, 0, staleImmediate, defaultStaleTimeout},
+		{"serve_stale 0", false, 0, staleImmediate, defaultStaleTimeout},
+		{"serve_stale 1h immediate", false, 1 * time.Hour, staleImmediate, defaultStaleTimeout},
+		{"serve_stale 1h verify", false, 1 * time.Hour, staleVerify, defaultStaleTimeout},
+		{"serve_stale 1h verify 500ms", false, 1 * time.Hour, staleVerify, 500 * time.Millisecond},
 		// fails
-		{"serve_stale 20", true, 0},
-		{"serve_stale -20m", true, 0},
-		{"serve_stale aa", true, 0},
-		{"serve_stale 1m nono", true, 0},
+		{"serve_stale 20", true, 0, "", 0},
+		{"serve_stale -20m", true, 0, "", 0},
+		{"serve_stale aa", true, 0, "", 0},
+		{"serve_stale 1m nono", true, 0, "", 0},
+		{"serve_stale 1m immediate 500ms", true, 0, "", 0},
+		{"serve_stale 1m verify 0s", true, 0, "", 0},
+		{"serve_stale 1m verify 1s 2s", true, 0, "", 0},
 	}
 	for i, test := range tests {
 		c := caddy.NewTestController("dns", fmt.Sprintf("cache {\n%s\n}", test.input))
@@ -148,5 +156,112 @@ func TestServeStale(t *testing.T) {
 		if ca.staleUpTo != test.staleUpTo {
 			t.Errorf("Test %v: Expected stale %v but found: %v", i, test.staleUpTo, ca.staleUpTo)
 		}
+		if ca.staleMode != test.staleMode {
+			t.Errorf("Test %v: Expected stale mode %v but found: %v", i, test.staleMode, ca.staleMode)
+		}
+		if ca.staleTimeout != test.staleTimeout {
+			t.Errorf("Test %v: Expected stale timeout %v but found: %v", i, test.staleTimeout, ca.staleTimeout)
+		}
+	}
+}
+
+func TestSetupECS(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		variants  int
+	}{
+		{"", false, defaultVariants},
+		{"ecs 4", false, 4},
+		{"ecs 0", false, 0},
+		// fails
+		{"ecs", true, 0},
+		{"ecs -1", true, 0},
+		{"ecs aa", true, 0},
+		{"ecs 1 2", true, 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", fmt.Sprintf("cache {\n%s\n}", test.input))
+		ca, err := cacheParse(c)
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %v: Expected error but found nil", i)
+			continue
+		} else if !test.shouldErr && err != nil {
+			t.Errorf("Test %v: Expected no error but found error: %v", i, err)
+			continue
+		}
+		if test.shouldErr && err != nil {
+			continue
+		}
+		if ca.subnets.variants != test.variants {
+			t.Errorf("Test %v: Expected variants %v but found: %v", i, test.variants, ca.subnets.variants)
+		}
+	}
+}
+
+func TestSetupPersist(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		file      string
+		interval  time.Duration
+	}{
+		{"persist /tmp/cache.json", false, "/tmp/cache.json", defaultPersistInterval},
+		{"persist /tmp/cache.json 1m", false, "/tmp/cache.json", time.Minute},
+		{"persist /tmp/cache.json 0s", false, "/tmp/cache.json", 0},
+		// fails
+		{"persist", true, "", 0},
+		{"persist /tmp/cache.json -1m", true, "", 0},
+		{"persist /tmp/cache.json 1m 2m", true, "", 0},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", fmt.Sprintf("cache {\n%s\n}", test.input))
+		ca, err := cacheParse(c)
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %v: Expected error but found nil", i)
+			continue
+		} else if !test.shouldErr && err != nil {
+			t.Errorf("Test %v: Expected no error but found error: %v", i, err)
+			continue
+		}
+		if test.shouldErr && err != nil {
+			continue
+		}
+		if ca.persist.file != test.file || ca.persist.interval != test.interval {
+			t.Errorf("Test %v: Expected %s %v but found: %s %v", i, test.file, test.interval, ca.persist.file, ca.persist.interval)
+		}
+	}
+}
+
+func TestSetupAdmin(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		admin     string
+	}{
+		{"", false, ""},
+		{"admin localhost:9154", false, "localhost:9154"},
+		{"admin :9154", false, ":9154"},
+		// fails
+		{"admin", true, ""},
+		{"admin localhost", true, ""},
+		{"admin :9154 :9155", true, ""},
+	}
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", fmt.Sprintf("cache {\n%s\n}", test.input))
+		ca, err := cacheParse(c)
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %v: Expected error but found nil", i)
+			continue
+		} else if !test.shouldErr && err != nil {
+			t.Errorf("Test %v: Expected no error but found error: %v", i, err)
+			continue
+		}
+		if test.shouldErr && err != nil {
+			continue
+		}
+		if ca.admin != test.admin {
+			t.Errorf("Test %v: Expected admin %q but found: %q", i, test.admin, ca.admin)
+		}
 	}
 }
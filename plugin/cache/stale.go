@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// Refresh modes for serve_stale.
+const (
+	staleImmediate = "immediate" // serve stale, then refresh in the background
+	staleVerify    = "verify"    // refresh first, serve stale when that fails or takes too long
+)
+
+const (
+	// defaultStaleTimeout is the default client response timer, see RFC 8767, Section 5.
+	defaultStaleTimeout = 1800 * time.Millisecond
+	// staleRecheck is the failure recheck timer: after a failed refresh, stale answers are served
+	// immediately for this long, see RFC 8767, Section 5.
+	staleRecheck = 30 * time.Second
+)
+
+// serveStale answers the query in state with the expired item i. In verify mode the next plugin is asked
+// first and its answer is used, unless it fails or doesn't answer within c.staleTimeout.
+func (c *Cache) serveStale(ctx context.Context, w dns.ResponseWriter, r *dns.Msg, state request.Request, server string, i *item, now time.Time) (int, error) {
+	do := state.Do()
+	// Adjust the time to get a 0 TTL in the reply built from a stale item.
+	stale := now.Add(time.Duration(i.ttl(now)) * time.Second)
+
+	if c.staleMode != staleVerify {
+		servedStale.WithLabelValues(server).Inc()
+		cw := newPrefetchResponseWriter(server, state, c)
+		go c.doPrefetch(ctx, state, cw, i, stale)
+		c.writeStale(w, r, i, stale, do)
+		return dns.RcodeSuccess, nil
+	}
+	if i.recentlyFailed(now) {
+		// Don't bother the next plugin again so soon after it failed.
+		servedStale.WithLabelValues(server).Inc()
+		c.writeStale(w, r, i, stale, do)
+		return dns.RcodeSuccess, nil
+	}
+
+	cw := newPrefetchResponseWriter(server, state, c)
+	cw.prefetch, cw.do = false, do
+	cw.verify = make(chan *dns.Msg, 1)
+	go func() {
+		cachePrefetches.WithLabelValues(cw.server).Inc()
+		c.doRefresh(ctx, state, cw)
+		// If nothing was written, this signals the failure.
+		select {
+		case cw.verify <- nil:
+		default:
+		}
+	}()
+
+	timeout := time.NewTimer(c.staleTimeout)
+	defer timeout.Stop()
+	select {
+	case m := <-cw.verify:
+		if m != nil && m.Rcode != dns.RcodeServerFailure && m.Rcode != dns.RcodeRefused {
+			w.WriteMsg(m)
+			return dns.RcodeSuccess, nil
+		}
+	case <-timeout.C:
+	}
+
+	i.setFailed(now)
+	servedStale.WithLabelValues(server).Inc()
+	c.writeStale(w, r, i, stale, do)
+	return dns.RcodeSuccess, nil
+}
+
+// writeStale writes the reply built from the stale item i, with an extended DNS error (RFC 8914) telling
+// the client it is stale.
+func (c *Cache) writeStale(w dns.ResponseWriter, r *dns.Msg, i *item, now time.Time, do bool) {
+	resp := i.toMsg(r, now, do)
+	setSubnet(resp, r, i.scope)
+	code := dns.ExtendedErrorCodeStaleAnswer
+	if i.Rcode == dns.RcodeNameError {
+		code = dns.ExtendedErrorCodeStaleNXDOMAINAnswer
+	}
+	if o := replyOPT(resp, r); o != nil {
+		o.Option = append(o.Option, &dns.EDNS0_EDE{InfoCode: code})
+	}
+	w.WriteMsg(resp)
+}
+
+// replyOPT returns the OPT RR of m, adding one if the request r has one. It returns nil if r has no OPT RR,
+// as a client that doesn't support EDNS0 must not get one back.
+func replyOPT(m, r *dns.Msg) *dns.OPT {
+	ro := r.IsEdns0()
+	if ro == nil {
+		return nil
+	}
+	if o := m.IsEdns0(); o != nil {
+		return o
+	}
+	m.SetEdns0(ro.UDPSize(), ro.Do())
+	return m.IsEdns0()
+}
+
+// setFailed records that refreshing i failed at now.
+func (i *item) setFailed(now time.Time) { atomic.StoreInt64(&i.failed, now.UnixNano()) }
+
+// recentlyFailed returns true if refreshing i failed less than staleRecheck ago.
+func (i *item) recentlyFailed(now time.Time) bool {
+	failed := atomic.LoadInt64(&i.failed)
+	return failed != 0 && now.Sub(time.Unix(0, failed)) < staleRecheck
+}
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+// staleCache returns a cache with an item for example.org. that expired 10 minutes ago.
+func staleCache(mode string) *Cache {
+	c := New()
+	c.staleUpTo = time.Hour
+	c.staleMode = mode
+	c.staleTimeout = 100 * time.Millisecond
+	c.Next = ttlBackend(60)
+
+	req := new(dns.Msg)
+	req.SetQuestion("example.org.", dns.TypeA)
+	c.ServeDNS(context.TODO(), &test.ResponseWriter{}, req)
+
+	c.now = func() time.Time { return time.Now().Add(10 * time.Minute) }
+	return c
+}
+
+func servfailBackend() plugin.Handler {
+	return plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeServerFailure)
+		w.WriteMsg(m)
+		return dns.RcodeServerFailure, nil
+	})
+}
+
+func isStale(m *dns.Msg) bool {
+	o := m.IsEdns0()
+	if o == nil {
+		return false
+	}
+	for _, e := range o.Option {
+		if ede, ok := e.(*dns.EDNS0_EDE); ok && ede.InfoCode == dns.ExtendedErrorCodeStaleAnswer {
+			return true
+		}
+	}
+	return false
+}
+
+func TestServeStaleModes(t *testing.T) {
+	tests := []struct {
+		mode  string
+		next  plugin.Handler
+		stale bool
+	}{
+		{staleImmediate, ttlBackend(60), true},
+		{staleVerify, ttlBackend(60), false},
+		{staleVerify, servfailBackend(), true},
+		{staleVerify, plugin.HandlerFunc(func(context.Context, dns.ResponseWriter, *dns.Msg) (int, error) {
+			return dns.RcodeServerFailure, nil // nothing written, as forward does when no upstream is reachable
+		}), true},
+		{staleVerify, plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+			time.Sleep(300 * time.Millisecond)
+			return ttlBackend(60).ServeDNS(ctx, w, r)
+		}), true},
+	}
+
+	for i, tc := range tests {
+		c := staleCache(tc.mode)
+		c.Next = tc.next
+
+		req := new(dns.Msg)
+		req.SetQuestion("example.org.", dns.TypeA)
+		req.SetEdns0(4096, false)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		c.ServeDNS(context.TODO(), rec, req)
+
+		if rec.Msg.Rcode != dns.RcodeSuccess || len(rec.Msg.Answer) != 1 {
+			t.Fatalf("Test %d: expected an answer, got %s", i, rec.Msg)
+		}
+		if stale := isStale(rec.Msg); stale != tc.stale {
+			t.Errorf("Test %d: expected stale to be %t, got %t", i, tc.stale, stale)
+		}
+		ttl := rec.Msg.Answer[0].Header().Ttl
+		if tc.stale && ttl != 0 {
+			t.Errorf("Test %d: expected TTL 0 for a stale answer, got %d", i, ttl)
+		}
+		if !tc.stale && ttl == 0 {
+			t.Errorf("Test %d: expected non-zero TTL for a fresh answer", i)
+		}
+	}
+}
+
+func TestServeStaleNoEdns(t *testing.T) {
+	c := staleCache(staleImmediate)
+
+	req := new(dns.Msg)
+	req.SetQuestion("example.org.", dns.TypeA)
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	c.ServeDNS(context.TODO(), rec, req)
+	if rec.Msg.IsEdns0() != nil {
+		t.Errorf("Expected no OPT RR in the reply to a client that didn't send one")
+	}
+}
+
+func TestServeStaleRecheck(t *testing.T) {
+	c := staleCache(staleVerify)
+	c.Next = servfailBackend()
+
+	req := new(dns.Msg)
+	req.SetQuestion("example.org.", dns.TypeA)
+	c.ServeDNS(context.TODO(), &test.ResponseWriter{}, req)
+
+	// The SERVFAIL must not be cached, and the next plugin must not be asked again.
+	if c.ncache.Len() != 0 {
+		t.Errorf("Expected SERVFAIL not to be cached")
+	}
+	called := false
+	c.Next = plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		called = true
+		return ttlBackend(60).ServeDNS(ctx, w, r)
+	})
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	c.ServeDNS(context.TODO(), rec, req)
+	if called {
+		t.Errorf("Expected no refresh within the failure recheck time")
+	}
+	if ttl := rec.Msg.Answer[0].Header().Ttl; ttl != 0 {
+		t.Errorf("Expected stale answer, got TTL %d", ttl)
+	}
+
+	now := c.now()
+	c.now = func() time.Time { return now.Add(staleRecheck) }
+	c.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), req)
+	if !called {
+		t.Errorf("Expected refresh after the failure recheck time")
+	}
+}
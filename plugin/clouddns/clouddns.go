@@ -6,18 +6,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/backoff"
 	"github.com/coredns/coredns/plugin/pkg/fall"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
 	gcp "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
 )
 
 // CloudDNS is a plugin that returns RR from GCP Cloud DNS.
@@ -38,6 +41,7 @@ type zone struct {
 	zoneName    string
 	z           *file.Zone
 	dns         string
+	change      string // id of the latest change when z was loaded
 }
 
 type zones map[string][]*zone
@@ -75,6 +79,8 @@ func New(ctx context.Context, c gcpDNS, keys map[string][]string, up *upstream.U
 	}, nil
 }
 
+const refresh = 1 * time.Minute
+
 // Run executes first update, spins up an update forever-loop.
 // Returns error if first update fails.
 func (h *CloudDNS) Run(ctx context.Context) error {
@@ -82,21 +88,41 @@ func (h *CloudDNS) Run(ctx context.Context) error {
 		return err
 	}
 	go func() {
+		wait := refresh
 		for {
 			select {
 			case <-ctx.Done():
 				log.Debugf("Breaking out of CloudDNS update loop for %v: %v", h.zoneNames, ctx.Err())
 				return
-			case <-time.After(1 * time.Minute):
-				if err := h.updateZones(ctx); err != nil && ctx.Err() == nil /* Don't log error if ctx expired. */ {
+			case <-time.After(wait):
+				err := h.updateZones(ctx)
+				if err != nil && ctx.Err() == nil /* Don't log error if ctx expired. */ {
 					log.Errorf("Failed to update zones %v: %v", h.zoneNames, err)
 				}
+				wait = backoff.Next(wait, refresh, errors.Is(err, backoff.ErrThrottled))
 			}
 		}
 	}()
 	return nil
 }
 
+// isThrottled returns true if err is Cloud DNS refusing a request because a rate limit is exceeded.
+func isThrottled(err error) bool {
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return false
+	}
+	if gerr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	for _, e := range gerr.Errors {
+		if e.Reason == "rateLimitExceeded" || e.Reason == "userRateLimitExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
 // ServeDNS implements the plugin.Handler interface.
 func (h *CloudDNS) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 	state := request.Request{W: w, Req: r}
@@ -172,7 +198,8 @@ func updateZoneFromRRS(rrs *gcp.ResourceRecordSetsListResponse, z *file.Zone) er
 }
 
 // updateZones re-queries resource record sets for each zone and updates the
-// zone object.
+// zone object. A zone is only re-queried if there is a change to it since it
+// was last loaded.
 // Returns error if any zones error'ed out, but waits for other zones to
 // complete first.
 func (h *CloudDNS) updateZones(ctx context.Context) error {
@@ -187,18 +214,44 @@ func (h *CloudDNS) updateZones(ctx context.Context) error {
 			}()
 
 			for i, hostedZone := range z {
+				start := time.Now()
+				label := hostedZone.projectName + ":" + hostedZone.zoneName
+
+				// The latest change is looked up before the record sets, so a change in between is
+				// seen by the next update.
+				var change, status string
+				change, status, err = h.client.latestChange(ctx, hostedZone.projectName, hostedZone.zoneName)
+				if err != nil {
+					err = syncError(zName, hostedZone, err)
+					return
+				}
+				// The record sets may not reflect a pending change yet, don't record it so the
+				// zone is loaded again until the change is done.
+				if status != "done" {
+					change = ""
+				}
+				if change != "" && change == hostedZone.change {
+					syncSkipped.WithLabelValues(label).Inc()
+					lastSync.WithLabelValues(label).Set(float64(time.Now().Unix()))
+					continue
+				}
+
 				newZ := file.NewZone(zName, "")
 				newZ.Upstream = h.upstream
 				rrListResponse, err = h.client.listRRSets(ctx, hostedZone.projectName, hostedZone.zoneName)
 				if err != nil {
-					err = fmt.Errorf("failed to list resource records for %v:%v:%v from gcp: %v", zName, hostedZone.projectName, hostedZone.zoneName, err)
+					err = syncError(zName, hostedZone, err)
 					return
 				}
 				updateZoneFromRRS(rrListResponse, newZ)
 
 				h.zMu.Lock()
 				(*z[i]).z = newZ
+				(*z[i]).change = change
 				h.zMu.Unlock()
+
+				syncDuration.WithLabelValues(label).Observe(time.Since(start).Seconds())
+				lastSync.WithLabelValues(label).Set(float64(time.Now().Unix()))
 			}
 
 		}(zName, z)
@@ -206,17 +259,29 @@ func (h *CloudDNS) updateZones(ctx context.Context) error {
 	// Collect errors (if any). This will also sync on all zones updates
 	// completion.
 	var errs []string
+	throttled := false
 	for i := 0; i < len(h.zones); i++ {
 		err := <-errc
 		if err != nil {
 			errs = append(errs, err.Error())
+			throttled = throttled || errors.Is(err, backoff.ErrThrottled)
 		}
 	}
+	if throttled {
+		return fmt.Errorf("errors updating zones: %v: %w", errs, backoff.ErrThrottled)
+	}
 	if len(errs) != 0 {
 		return fmt.Errorf("errors updating zones: %v", errs)
 	}
 	return nil
 }
 
+func syncError(zName string, hostedZone *zone, err error) error {
+	if isThrottled(err) {
+		return fmt.Errorf("failed to list resource records for %v:%v:%v from gcp: %w: %v", zName, hostedZone.projectName, hostedZone.zoneName, backoff.ErrThrottled, err)
+	}
+	return fmt.Errorf("failed to list resource records for %v:%v:%v from gcp: %v", zName, hostedZone.projectName, hostedZone.zoneName, err)
+}
+
 // Name implements the Handler interface.
 func (h *CloudDNS) Name() string { return "clouddns" }
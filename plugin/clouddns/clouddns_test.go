@@ -3,9 +3,11 @@ package clouddns
 import (
 	"context"
 	"errors"
+	"net/http"
 	"reflect"
 	"testing"
 
+	"github.com/coredns/coredns/plugin/pkg/backoff"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	"github.com/coredns/coredns/plugin/pkg/fall"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/miekg/dns"
 	gcp "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
 )
 
 type fakeGCPClient struct {
@@ -120,6 +123,30 @@ func (c fakeGCPClient) listRRSets(ctx context.Context, projectName, hostedZoneNa
 	return &gcp.ResourceRecordSetsListResponse{Rrsets: rr}, nil
 }
 
+func (c fakeGCPClient) latestChange(ctx context.Context, projectName, hostedZoneName string) (string, string, error) {
+	if hostedZoneName == "throttled-zone" {
+		return "", "", &googleapi.Error{Code: http.StatusTooManyRequests, Message: "Rate Limit Exceeded"}
+	}
+	return "", "", nil
+}
+
+// changingGCPClient counts the record set listings, and reports change as the latest change.
+type changingGCPClient struct {
+	fakeGCPClient
+	change string
+	status string
+	lists  *int
+}
+
+func (c changingGCPClient) latestChange(ctx context.Context, projectName, hostedZoneName string) (string, string, error) {
+	return c.change, c.status, nil
+}
+
+func (c changingGCPClient) listRRSets(ctx context.Context, projectName, hostedZoneName string) (*gcp.ResourceRecordSetsListResponse, error) {
+	*c.lists++
+	return c.fakeGCPClient.listRRSets(ctx, projectName, hostedZoneName)
+}
+
 func TestCloudDNS(t *testing.T) {
 	ctx := context.Background()
 
@@ -308,3 +335,56 @@ func TestCloudDNS(t *testing.T) {
 		}
 	}
 }
+
+func TestCloudDNSUnchanged(t *testing.T) {
+	ctx := context.Background()
+
+	lists := 0
+	client := &changingGCPClient{change: "1", lists: &lists}
+	r, err := New(ctx, client, map[string][]string{"org.": {"sample-project-1:sample-zone-2"}}, &upstream.Upstream{})
+	if err != nil {
+		t.Fatalf("Failed to create Cloud DNS: %v", err)
+	}
+
+	tests := []struct {
+		change        string
+		status        string
+		expectedLists int
+	}{
+		// Pending on the first sync, listed until it's done.
+		{"1", "pending", 1},
+		{"1", "pending", 2},
+		{"1", "done", 3},
+		// No change, the record sets aren't listed again.
+		{"1", "done", 3},
+		{"2", "done", 4},
+		{"2", "done", 4},
+		{"3", "pending", 5},
+		{"3", "done", 6},
+		// No changes at all, always listed.
+		{"", "", 7},
+		{"", "", 8},
+	}
+	for i, tc := range tests {
+		client.change = tc.change
+		client.status = tc.status
+		if err := r.updateZones(ctx); err != nil {
+			t.Fatalf("Test %d: failed to update zones: %v", i, err)
+		}
+		if lists != tc.expectedLists {
+			t.Errorf("Test %d: expected %d listings, got %d", i, tc.expectedLists, lists)
+		}
+	}
+}
+
+func TestCloudDNSThrottled(t *testing.T) {
+	ctx := context.Background()
+
+	r, err := New(ctx, fakeGCPClient{}, map[string][]string{"org.": {"sample-project-1:throttled-zone"}}, &upstream.Upstream{})
+	if err != nil {
+		t.Fatalf("Failed to create Cloud DNS: %v", err)
+	}
+	if err := r.updateZones(ctx); !errors.Is(err, backoff.ErrThrottled) {
+		t.Errorf("Expected the update to be throttled, got %v", err)
+	}
+}
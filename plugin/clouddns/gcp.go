@@ -9,6 +9,7 @@ import (
 type gcpDNS interface {
 	zoneExists(projectName, hostedZoneName string) error
 	listRRSets(ctx context.Context, projectName, hostedZoneName string) (*gcp.ResourceRecordSetsListResponse, error)
+	latestChange(ctx context.Context, projectName, hostedZoneName string) (id, status string, err error)
 }
 
 type gcpClient struct {
@@ -40,3 +41,17 @@ func (c gcpClient) listRRSets(ctx context.Context, projectName, hostedZoneName s
 	}
 	return &gcp.ResourceRecordSetsListResponse{Rrsets: rs}, nil
 }
+
+// latestChange is a wrapper method around `gcp.Service.Changes.List`
+// it returns the id and the status ("pending" or "done") of the latest
+// change to a hosted zone, or empty strings if there are none.
+func (c gcpClient) latestChange(ctx context.Context, projectName, hostedZoneName string) (string, string, error) {
+	resp, err := c.Changes.List(projectName, hostedZoneName).SortBy("changeSequence").SortOrder("descending").MaxResults(1).Context(ctx).Do()
+	if err != nil {
+		return "", "", err
+	}
+	if len(resp.Changes) == 0 {
+		return "", "", nil
+	}
+	return resp.Changes[0].Id, resp.Changes[0].Status, nil
+}
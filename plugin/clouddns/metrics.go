@@ -0,0 +1,33 @@
+package clouddns
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// syncDuration is the time it took to sync a managed zone.
+	syncDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "clouddns",
+		Name:      "sync_duration_seconds",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12), // from 50ms to ~100s
+		Help:      "Histogram of the time it took to sync a managed zone.",
+	}, []string{"zone"})
+	// lastSync is the time of the last successful sync of a managed zone, to tell how stale it is.
+	lastSync = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "clouddns",
+		Name:      "last_sync_timestamp_seconds",
+		Help:      "The time of the last successful sync of a managed zone, in seconds since the Unix epoch.",
+	}, []string{"zone"})
+	// syncSkipped is the number of times the sync of a managed zone was skipped, because it didn't change.
+	syncSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "clouddns",
+		Name:      "sync_skipped_total",
+		Help:      "Counter of the syncs of a managed zone that were skipped because it didn't change.",
+	}, []string{"zone"})
+)
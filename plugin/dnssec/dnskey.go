@@ -64,8 +64,12 @@ func ParseKeyFile(pubFile, privFile string) (*DNSKEY, error) {
 
 // getDNSKEY returns the correct DNSKEY to the client. Signatures are added when do is true.
 func (d Dnssec) getDNSKEY(state request.Request, zone string, do bool, server string) *dns.Msg {
-	keys := make([]dns.RR, len(d.keys))
-	for i, k := range d.keys {
+	dnskeys := d.keys
+	if ks := d.managed.keys(zone); ks != nil {
+		dnskeys = ks.dnskey
+	}
+	keys := make([]dns.RR, len(dnskeys))
+	for i, k := range dnskeys {
 		keys[i] = dns.Copy(k.K)
 		keys[i].Header().Name = zone
 	}
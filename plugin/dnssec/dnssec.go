@@ -23,6 +23,7 @@ type Dnssec struct {
 	splitkeys bool
 	inflight  *singleflight.Group
 	cache     *cache.Cache
+	managed   *managed // zones with managed keys, these don't use keys
 }
 
 // New returns a new Dnssec.
@@ -93,6 +94,14 @@ func (d Dnssec) Sign(state request.Request, now time.Time, server string) *dns.M
 
 func (d Dnssec) sign(rrs []dns.RR, signerName string, ttl, incep, expir uint32, server string) ([]dns.RR, error) {
 	k := hash(rrs)
+	keys, splitkeys := d.keys, d.splitkeys
+	if ks := d.managed.keys(signerName); ks != nil {
+		keys, splitkeys = ks.zsk, false
+		if len(rrs) > 0 && rrs[0].Header().Rrtype == dns.TypeDNSKEY {
+			keys = ks.ksk
+		}
+		k ^= ks.gen
+	}
 	sgs, ok := d.get(k, server)
 	if ok {
 		return sgs, nil
@@ -100,8 +109,8 @@ func (d Dnssec) sign(rrs []dns.RR, signerName string, ttl, incep, expir uint32,
 
 	sigs, err := d.inflight.Do(k, func() (interface{}, error) {
 		var sigs []dns.RR
-		for _, k := range d.keys {
-			if d.splitkeys {
+		for _, k := range keys {
+			if splitkeys {
 				if len(rrs) > 0 && rrs[0].Header().Rrtype == dns.TypeDNSKEY {
 					// We are signing a DNSKEY RRSet. With split keys, we need to use a KSK here.
 					if !k.isKSK() {
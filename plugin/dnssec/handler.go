@@ -38,6 +38,17 @@ func (d Dnssec) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 		}
 	}
 
+	// The CDS and CDNSKEY records of zones with managed keys are ours as well.
+	if qtype == dns.TypeCDS || qtype == dns.TypeCDNSKEY {
+		if ks := d.managed.keys(qname); ks != nil && len(ks.cds) > 0 {
+			state.Zone = qname
+			resp := d.getCDS(state, ks, do, server)
+			resp.Authoritative = true
+			w.WriteMsg(resp)
+			return dns.RcodeSuccess, nil
+		}
+	}
+
 	if do {
 		drr := &ResponseWriter{w, d, server}
 		return plugin.NextOrFailure(d.Name(), d.Next, ctx, drr, r)
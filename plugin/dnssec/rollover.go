@@ -0,0 +1,119 @@
+package dnssec
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/rollover"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// managed holds the zones whose keys are generated and rolled over according to a policy.
+type managed struct {
+	managers []*rollover.Manager
+
+	sync.RWMutex
+	sets map[string]*keySet
+}
+
+// keySet holds the keys of a managed zone at a moment in time.
+type keySet struct {
+	dnskey []*DNSKEY // the DNSKEY RRset
+	ksk    []*DNSKEY // keys that sign the DNSKEY RRset
+	zsk    []*DNSKEY // keys that sign all other RRsets
+	cds    []dns.RR  // CDS and CDNSKEY records
+	gen    uint64    // identifies the signing keys, it is part of the signature cache key
+}
+
+// rollCheck is the maximum time between two checks for key rollovers.
+const rollCheck = time.Hour
+
+func newManaged(managers []*rollover.Manager) *managed {
+	return &managed{managers: managers, sets: make(map[string]*keySet)}
+}
+
+// keys returns the keys of zone, or nil if zone isn't managed.
+func (m *managed) keys(zone string) *keySet {
+	if m == nil {
+		return nil
+	}
+	m.RLock()
+	defer m.RUnlock()
+	return m.sets[zone]
+}
+
+// roll does the key rollovers that are due at now, and updates the key sets. It returns the time of the
+// next check.
+func (m *managed) roll(now time.Time) time.Time {
+	next := now.Add(rollCheck)
+	sets := make(map[string]*keySet, len(m.managers))
+	for _, mgr := range m.managers {
+		if _, err := mgr.Roll(now); err != nil {
+			log.Errorf("Failed to roll keys of zone %q: %s", mgr.Zone(), err)
+		}
+		sets[mgr.Zone()] = newKeySet(mgr.Zone(), mgr.Keys(now))
+		if n := mgr.Next(now); !n.IsZero() && n.Before(next) {
+			next = n
+		}
+	}
+	m.Lock()
+	m.sets = sets
+	m.Unlock()
+	return next
+}
+
+// run does the key rollovers until stop is closed.
+func (m *managed) run(stop <-chan struct{}) {
+	for {
+		next := m.roll(time.Now().UTC())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func newKeySet(zone string, ks rollover.Keys) *keySet {
+	convert := func(keys []*rollover.Key) []*DNSKEY {
+		dks := make([]*DNSKEY, len(keys))
+		for i, k := range keys {
+			dks[i] = &DNSKEY{K: k.Public, D: k.Public.ToDS(dns.SHA256), s: k.Private, tag: k.Tag}
+		}
+		return dks
+	}
+	set := &keySet{dnskey: convert(ks.DNSKEY), ksk: convert(ks.KSK), zsk: convert(ks.ZSK), cds: ks.CDS(zone, origTTL)}
+
+	h := fnv.New64()
+	for _, k := range append(ks.KSK, ks.ZSK...) {
+		h.Write([]byte{byte(k.Tag >> 8), byte(k.Tag)})
+	}
+	set.gen = h.Sum64()
+	return set
+}
+
+// getCDS returns the CDS or CDNSKEY records of a managed zone to the client. Signatures are added when do
+// is true.
+func (d Dnssec) getCDS(state request.Request, ks *keySet, do bool, server string) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(state.Req)
+	for _, rr := range ks.cds {
+		if rr.Header().Rrtype == state.QType() {
+			m.Answer = append(m.Answer, rr)
+		}
+	}
+	if !do || len(m.Answer) == 0 {
+		return m
+	}
+
+	incep, expir := incepExpir(time.Now().UTC())
+	if sigs, err := d.sign(m.Answer, state.Zone, origTTL, incep, expir, server); err == nil {
+		m.Answer = append(m.Answer, sigs...)
+	}
+	return m
+}
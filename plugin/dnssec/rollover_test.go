@@ -0,0 +1,59 @@
+package dnssec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/cache"
+	"github.com/coredns/coredns/plugin/pkg/rollover"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+func TestManagedKeys(t *testing.T) {
+	m, err := rollover.New("miek.nl.", t.TempDir(), rollover.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mg := newManaged([]*rollover.Manager{m})
+	mg.roll(time.Now().UTC())
+	ks := mg.keys("miek.nl.")
+	if ks == nil || len(ks.ksk) != 1 || len(ks.zsk) != 1 {
+		t.Fatalf("Expected a KSK and a ZSK to be generated, got %v", ks)
+	}
+
+	d := New([]string{"miek.nl."}, nil, false, nil, cache.New(defaultCap))
+	d.managed = mg
+
+	// The DNSKEY RRset is signed with the KSK.
+	r := new(dns.Msg)
+	r.SetQuestion("miek.nl.", dns.TypeDNSKEY)
+	state := request.Request{Req: r, Zone: "miek.nl.", W: &test.ResponseWriter{}}
+	resp := d.getDNSKEY(state, "miek.nl.", true, server)
+	if x := len(resp.Answer); x != 3 {
+		t.Fatalf("Expected %d records in the DNSKEY answer, got %d", 3, x)
+	}
+	if sig, ok := resp.Answer[2].(*dns.RRSIG); !ok || sig.KeyTag != ks.ksk[0].tag {
+		t.Errorf("Expected DNSKEY RRset to be signed by the KSK with key tag %d, got %v", ks.ksk[0].tag, resp.Answer[2])
+	}
+
+	// Other RRsets with the ZSK.
+	m1 := d.Sign(request.Request{Req: testMsg(), Zone: "miek.nl."}, time.Now().UTC(), server)
+	for _, rr := range m1.Answer {
+		if sig, ok := rr.(*dns.RRSIG); ok && sig.KeyTag != ks.zsk[0].tag {
+			t.Errorf("Expected answer to be signed by the ZSK with key tag %d, got %d", ks.zsk[0].tag, sig.KeyTag)
+		}
+	}
+
+	// The CDS record is for the KSK.
+	r.SetQuestion("miek.nl.", dns.TypeCDS)
+	resp = d.getCDS(state, ks, false, server)
+	if len(resp.Answer) != 1 {
+		t.Fatalf("Expected %d CDS record, got %d", 1, len(resp.Answer))
+	}
+	if x := resp.Answer[0].(*dns.CDS).KeyTag; x != ks.ksk[0].tag {
+		t.Errorf("Expected CDS for the KSK with key tag %d, got %d", ks.ksk[0].tag, x)
+	}
+}
@@ -5,12 +5,14 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/cache"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/pkg/rollover"
 )
 
 var log = clog.NewWithPlugin("dnssec")
@@ -18,11 +20,24 @@ var log = clog.NewWithPlugin("dnssec")
 func init() { plugin.Register("dnssec", setup) }
 
 func setup(c *caddy.Controller) error {
-	zones, keys, capacity, splitkeys, err := dnssecParse(c)
+	zones, keys, capacity, splitkeys, managers, err := dnssecParse(c)
 	if err != nil {
 		return plugin.Error("dnssec", err)
 	}
 
+	// Generate the keys of the zones with managed keys now, so we can sign right away.
+	var mg *managed
+	if len(managers) > 0 {
+		now := time.Now().UTC()
+		for _, m := range managers {
+			if _, err := m.Roll(now); err != nil {
+				return plugin.Error("dnssec", err)
+			}
+		}
+		mg = newManaged(managers)
+		mg.roll(now)
+	}
+
 	ca := cache.New(capacity)
 	stop := make(chan struct{})
 
@@ -32,25 +47,32 @@ func setup(c *caddy.Controller) error {
 	})
 	c.OnStartup(func() error {
 		go periodicClean(ca, stop)
+		if mg != nil {
+			go mg.run(stop)
+		}
 		return nil
 	})
 
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
-		return New(zones, keys, splitkeys, next, ca)
+		d := New(zones, keys, splitkeys, next, ca)
+		d.managed = mg
+		return d
 	})
 
 	return nil
 }
 
-func dnssecParse(c *caddy.Controller) ([]string, []*DNSKEY, int, bool, error) {
+func dnssecParse(c *caddy.Controller) ([]string, []*DNSKEY, int, bool, []*rollover.Manager, error) {
 	zones := []string{}
 	keys := []*DNSKEY{}
 	capacity := defaultCap
+	dir := ""
+	policy := rollover.DefaultPolicy()
 
 	i := 0
 	for c.Next() {
 		if i > 0 {
-			return nil, nil, 0, false, plugin.ErrOnce
+			return nil, nil, 0, false, nil, plugin.ErrOnce
 		}
 		i++
 
@@ -61,27 +83,54 @@ func dnssecParse(c *caddy.Controller) ([]string, []*DNSKEY, int, bool, error) {
 
 			switch x := c.Val(); x {
 			case "key":
-				k, e := keyParse(c)
+				k, d, e := keyParse(c)
 				if e != nil {
-					return nil, nil, 0, false, e
+					return nil, nil, 0, false, nil, e
 				}
 				keys = append(keys, k...)
+				if d != "" {
+					dir = d
+				}
 			case "cache_capacity":
 				if !c.NextArg() {
-					return nil, nil, 0, false, c.ArgErr()
+					return nil, nil, 0, false, nil, c.ArgErr()
 				}
 				value := c.Val()
 				cacheCap, err := strconv.Atoi(value)
 				if err != nil {
-					return nil, nil, 0, false, err
+					return nil, nil, 0, false, nil, err
 				}
 				capacity = cacheCap
 			default:
-				return nil, nil, 0, false, c.Errf("unknown property '%s'", x)
+				ok, err := policy.Parse(c)
+				if err != nil {
+					return nil, nil, 0, false, nil, err
+				}
+				if !ok {
+					return nil, nil, 0, false, nil, c.Errf("unknown property '%s'", x)
+				}
 			}
 
 		}
 	}
+	if dir != "" {
+		if len(keys) > 0 {
+			return nil, nil, 0, false, nil, fmt.Errorf("key file and key directory can not be used together")
+		}
+		if err := policy.Validate(); err != nil {
+			return nil, nil, 0, false, nil, err
+		}
+		managers := make([]*rollover.Manager, len(zones))
+		for i, zone := range zones {
+			m, err := rollover.New(zone, dir, policy)
+			if err != nil {
+				return nil, nil, 0, false, nil, err
+			}
+			managers[i] = m
+		}
+		return zones, keys, capacity, false, managers, nil
+	}
+
 	// Check if we have both KSKs and ZSKs.
 	zsk, ksk := 0, 0
 	for _, k := range keys {
@@ -104,25 +153,36 @@ func dnssecParse(c *caddy.Controller) ([]string, []*DNSKEY, int, bool, error) {
 			}
 		}
 		if !ok {
-			return zones, keys, capacity, splitkeys, fmt.Errorf("key %s (keyid: %d) can not sign any of the zones", string(kname), k.tag)
+			return zones, keys, capacity, splitkeys, nil, fmt.Errorf("key %s (keyid: %d) can not sign any of the zones", string(kname), k.tag)
 		}
 	}
 
-	return zones, keys, capacity, splitkeys, nil
+	return zones, keys, capacity, splitkeys, nil, nil
 }
 
-func keyParse(c *caddy.Controller) ([]*DNSKEY, error) {
+// keyParse parses the key property, it returns the keys read for key file, or the directory for key directory.
+func keyParse(c *caddy.Controller) ([]*DNSKEY, string, error) {
 	keys := []*DNSKEY{}
 	config := dnsserver.GetConfig(c)
 
 	if !c.NextArg() {
-		return nil, c.ArgErr()
+		return nil, "", c.ArgErr()
 	}
 	value := c.Val()
+	if value == "directory" {
+		if !c.NextArg() {
+			return nil, "", c.ArgErr()
+		}
+		dir := c.Val()
+		if !filepath.IsAbs(dir) && config.Root != "" {
+			dir = filepath.Join(config.Root, dir)
+		}
+		return nil, dir, nil
+	}
 	if value == "file" {
 		ks := c.RemainingArgs()
 		if len(ks) == 0 {
-			return nil, c.ArgErr()
+			return nil, "", c.ArgErr()
 		}
 
 		for _, k := range ks {
@@ -139,10 +199,10 @@ func keyParse(c *caddy.Controller) ([]*DNSKEY, error) {
 			}
 			k, err := ParseKeyFile(base+".key", base+".private")
 			if err != nil {
-				return nil, err
+				return nil, "", err
 			}
 			keys = append(keys, k)
 		}
 	}
-	return keys, nil
+	return keys, "", nil
 }
@@ -54,12 +54,38 @@ func TestSetupDnssec(t *testing.T) {
 				key file Kcluster.local
 			}`, false, []string{"example.org.", "cluster.local."}, nil, false, defaultCap, "",
 		},
+		{
+			`dnssec example.org {
+				key directory .
+				algorithm ed25519
+				zsk_lifetime 720h
+				ksk_lifetime 0s
+			}`, false, []string{"example.org."}, nil, false, defaultCap, "",
+		},
 		// fails
 		{
 			`dnssec example.org {
 				key file Kcluster.local
 			}`, true, []string{"example.org."}, nil, false, defaultCap, "can not sign any",
 		},
+		{
+			`dnssec cluster.local {
+				key file Kcluster.local
+				key directory .
+			}`, true, []string{"cluster.local."}, nil, false, defaultCap, "can not be used together",
+		},
+		{
+			`dnssec example.org {
+				key directory .
+				zsk_lifetime 1h
+			}`, true, []string{"example.org."}, nil, false, defaultCap, "must be more than",
+		},
+		{
+			`dnssec example.org {
+				key directory .
+				algorithm RSAMD5
+			}`, true, []string{"example.org."}, nil, false, defaultCap, "unsupported algorithm",
+		},
 		{
 			`dnssec example.org {
 				key
@@ -82,7 +108,7 @@ func TestSetupDnssec(t *testing.T) {
 
 	for i, test := range tests {
 		c := caddy.NewTestController("dns", test.input)
-		zones, keys, capacity, splitkeys, err := dnssecParse(c)
+		zones, keys, capacity, splitkeys, _, err := dnssecParse(c)
 
 		if test.shouldErr && err == nil {
 			t.Errorf("Test %d: Expected error but found %s for input %s", i, err, test.input)
@@ -37,7 +37,9 @@ type Etcd struct {
 	Upstream   *upstream.Upstream
 	Client     *etcdcv3.Client
 
-	endpoints []string // Stored here as well, to aid in testing.
+	endpoints []string  // Stored here as well, to aid in testing.
+	mirror    *mirror   // If set, the keys are looked up in a copy in memory.
+	registry  *registry // If set, services can be registered with an HTTP API.
 }
 
 // Services implements the ServiceBackend interface.
@@ -72,15 +74,24 @@ func (e *Etcd) Records(ctx context.Context, state request.Request, exact bool) (
 	name := state.Name()
 
 	path, star := msg.PathWithWildcard(name, e.PathPrefix)
-	r, err := e.get(ctx, path, !exact)
+	kvs, err := e.get(ctx, path, !exact)
 	if err != nil {
 		return nil, err
 	}
 	segments := strings.Split(msg.Path(name, e.PathPrefix), "/")
-	return e.loopNodes(r.Kvs, segments, star, state.QType())
+	return e.loopNodes(kvs, segments, star, state.QType())
 }
 
-func (e *Etcd) get(ctx context.Context, path string, recursive bool) (*etcdcv3.GetResponse, error) {
+func (e *Etcd) get(ctx context.Context, path string, recursive bool) ([]*mvccpb.KeyValue, error) {
+	if e.mirror != nil {
+		if kvs, ok := e.mirror.get(path, recursive); ok {
+			if len(kvs) == 0 {
+				return nil, errKeyNotFound
+			}
+			return kvs, nil
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
 	defer cancel()
 	if recursive {
@@ -101,7 +112,7 @@ func (e *Etcd) get(ctx context.Context, path string, recursive bool) (*etcdcv3.G
 				return nil, errKeyNotFound
 			}
 		}
-		return r, nil
+		return r.Kvs, nil
 	}
 
 	r, err := e.Client.Get(ctx, path)
@@ -111,7 +122,7 @@ func (e *Etcd) get(ctx context.Context, path string, recursive bool) (*etcdcv3.G
 	if r.Count == 0 {
 		return nil, errKeyNotFound
 	}
-	return r, nil
+	return r.Kvs, nil
 }
 
 func (e *Etcd) loopNodes(kv []*mvccpb.KeyValue, nameParts []string, star bool, qType uint16) (sx []msg.Service, err error) {
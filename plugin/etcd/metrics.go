@@ -0,0 +1,16 @@
+package etcd
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// mirrorRevision is the etcd revision of the keys mirrored in memory.
+var mirrorRevision = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: plugin.Namespace,
+	Subsystem: "etcd",
+	Name:      "mirror_revision",
+	Help:      "The etcd revision of the keys mirrored in memory.",
+}, []string{"path"})
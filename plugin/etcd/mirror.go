@@ -0,0 +1,236 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	etcdcv3 "go.etcd.io/etcd/client/v3"
+)
+
+var log = clog.NewWithPlugin("etcd")
+
+// mirror keeps a copy in memory of the keys under a path in etcd. All keys are loaded once, after that a
+// watch keeps the copy up to date. When the revision the watch needs is compacted away, all keys are loaded
+// again.
+type mirror struct {
+	client *etcdcv3.Client
+	path   string // with a trailing slash
+
+	mu   sync.RWMutex
+	root *node
+	rev  int64 // revision of the copy, 0 until all keys are loaded
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// node is a path segment of a key, the keys are stored in a tree of these.
+type node struct {
+	kv       *mvccpb.KeyValue
+	children map[string]*node
+}
+
+// resyncDelay is the time to wait after the watch failed.
+const resyncDelay = 2 * time.Second
+
+func newMirror(client *etcdcv3.Client, prefix string) *mirror {
+	// As in msg.Path, prefix may or may not start with a slash.
+	return &mirror{client: client, path: path.Join("/"+prefix+"/") + "/", root: &node{}}
+}
+
+// Start starts mirroring in the background. Until all keys are loaded, the mirror isn't used.
+func (m *mirror) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	go m.run(ctx)
+	return nil
+}
+
+// Stop stops mirroring.
+func (m *mirror) Stop() error {
+	if m.cancel == nil {
+		return nil
+	}
+	m.cancel()
+	<-m.done
+	return nil
+}
+
+func (m *mirror) run(ctx context.Context) {
+	defer close(m.done)
+
+	load := true
+	for {
+		var err error
+		load, err = m.sync(ctx, load)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warningf("Mirror of %s failed, retrying: %s", m.path, err)
+
+		select {
+		case <-time.After(resyncDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sync loads all keys if load is true, and then applies the changes seen by a watch until that fails. It
+// returns true if all keys must be loaded again.
+func (m *mirror) sync(ctx context.Context, load bool) (bool, error) {
+	if load {
+		r, err := m.client.Get(ctx, m.path, etcdcv3.WithPrefix())
+		if err != nil {
+			return true, err
+		}
+		m.load(r.Kvs, r.Header.Revision)
+	}
+
+	ctx, cancel := context.WithCancel(etcdcv3.WithRequireLeader(ctx))
+	defer cancel()
+
+	wch := m.client.Watch(ctx, m.path, etcdcv3.WithPrefix(), etcdcv3.WithRev(m.revision()+1), etcdcv3.WithProgressNotify())
+	for resp := range wch {
+		if resp.CompactRevision != 0 {
+			return true, rpctypes.ErrCompacted
+		}
+		if err := resp.Err(); err != nil {
+			return false, err
+		}
+		m.apply(resp.Events, resp.Header.Revision)
+	}
+	return false, errors.New("watch closed")
+}
+
+// load replaces the copy with kvs, at revision rev.
+func (m *mirror) load(kvs []*mvccpb.KeyValue, rev int64) {
+	root := &node{}
+	for _, kv := range kvs {
+		root.insert(kv)
+	}
+
+	m.mu.Lock()
+	m.root = root
+	m.rev = rev
+	m.mu.Unlock()
+
+	mirrorRevision.WithLabelValues(m.path).Set(float64(rev))
+}
+
+// apply applies the changes in events to the copy, and sets its revision to rev.
+func (m *mirror) apply(events []*etcdcv3.Event, rev int64) {
+	m.mu.Lock()
+	for _, ev := range events {
+		switch ev.Type {
+		case etcdcv3.EventTypePut:
+			m.root.insert(ev.Kv)
+		case etcdcv3.EventTypeDelete:
+			m.root.remove(segments(string(ev.Kv.Key)))
+		}
+	}
+	if rev > m.rev {
+		m.rev = rev
+	}
+	rev = m.rev
+	m.mu.Unlock()
+
+	mirrorRevision.WithLabelValues(m.path).Set(float64(rev))
+}
+
+func (m *mirror) revision() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.rev
+}
+
+// get returns the keys as Etcd.get does: if recursive, the keys under path, or path itself if there are
+// none. The keys are sorted. It returns false if the mirror can't be used, because it isn't loaded yet.
+func (m *mirror) get(path string, recursive bool) ([]*mvccpb.KeyValue, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.rev == 0 {
+		return nil, false
+	}
+
+	n := m.root.find(segments(strings.TrimSuffix(path, "/")))
+	if n == nil {
+		return nil, true
+	}
+	var kvs []*mvccpb.KeyValue
+	if recursive {
+		for _, c := range n.children {
+			kvs = c.collect(kvs)
+		}
+		sort.Slice(kvs, func(i, j int) bool { return string(kvs[i].Key) < string(kvs[j].Key) })
+	}
+	if len(kvs) == 0 && n.kv != nil {
+		kvs = []*mvccpb.KeyValue{n.kv}
+	}
+	return kvs, true
+}
+
+func segments(key string) []string { return strings.Split(key, "/") }
+
+func (n *node) insert(kv *mvccpb.KeyValue) {
+	for _, s := range segments(string(kv.Key)) {
+		c, ok := n.children[s]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[string]*node)
+			}
+			c = &node{}
+			n.children[s] = c
+		}
+		n = c
+	}
+	n.kv = kv
+}
+
+// remove removes the key with segs, and the nodes that are left empty. It returns true if n is empty.
+func (n *node) remove(segs []string) bool {
+	if len(segs) == 0 {
+		n.kv = nil
+		return len(n.children) == 0
+	}
+	c, ok := n.children[segs[0]]
+	if !ok {
+		return false
+	}
+	if c.remove(segs[1:]) {
+		delete(n.children, segs[0])
+	}
+	return n.kv == nil && len(n.children) == 0
+}
+
+func (n *node) find(segs []string) *node {
+	for _, s := range segs {
+		c, ok := n.children[s]
+		if !ok {
+			return nil
+		}
+		n = c
+	}
+	return n
+}
+
+// collect appends the keys of n and all nodes below it to kvs.
+func (n *node) collect(kvs []*mvccpb.KeyValue) []*mvccpb.KeyValue {
+	if n.kv != nil {
+		kvs = append(kvs, n.kv)
+	}
+	for _, c := range n.children {
+		kvs = c.collect(kvs)
+	}
+	return kvs
+}
@@ -0,0 +1,95 @@
+package etcd
+
+import (
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	etcdcv3 "go.etcd.io/etcd/client/v3"
+)
+
+func kv(key string) *mvccpb.KeyValue {
+	return &mvccpb.KeyValue{Key: []byte(key), Value: []byte(`{"host":"10.0.0.1"}`)}
+}
+
+func keys(kvs []*mvccpb.KeyValue) []string {
+	var ks []string
+	for _, kv := range kvs {
+		ks = append(ks, string(kv.Key))
+	}
+	return ks
+}
+
+func TestMirrorGet(t *testing.T) {
+	m := newMirror(nil, "skydns")
+	if _, ok := m.get("/skydns/test/a", false); ok {
+		t.Fatal("Expected the mirror not to be used before it is loaded")
+	}
+
+	m.load([]*mvccpb.KeyValue{
+		kv("/skydns/test/skydns/b"),
+		kv("/skydns/test/skydns/a/x"),
+		kv("/skydns/test/skydns/a"),
+		kv("/skydns/test/skydns/a/y"),
+		kv("/skydns/test/skydns-other/a"),
+	}, 10)
+
+	tests := []struct {
+		path      string
+		recursive bool
+		expected  []string
+	}{
+		{"/skydns/test/skydns", true, []string{"/skydns/test/skydns/a", "/skydns/test/skydns/a/x", "/skydns/test/skydns/a/y", "/skydns/test/skydns/b"}},
+		{"/skydns/test/skydns/a", true, []string{"/skydns/test/skydns/a/x", "/skydns/test/skydns/a/y"}},
+		{"/skydns/test/skydns/a", false, []string{"/skydns/test/skydns/a"}},
+		// No keys below it, the key itself.
+		{"/skydns/test/skydns/b", true, []string{"/skydns/test/skydns/b"}},
+		// Not a key.
+		{"/skydns/test", false, nil},
+		{"/skydns/test/skydns/c", true, nil},
+	}
+	for i, tc := range tests {
+		kvs, ok := m.get(tc.path, tc.recursive)
+		if !ok {
+			t.Fatalf("Test %d: expected the mirror to be used", i)
+		}
+		got := keys(kvs)
+		if len(got) != len(tc.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+			continue
+		}
+		for j := range got {
+			if got[j] != tc.expected[j] {
+				t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestMirrorApply(t *testing.T) {
+	m := newMirror(nil, "skydns")
+	m.load([]*mvccpb.KeyValue{kv("/skydns/test/skydns/a/x")}, 10)
+
+	m.apply([]*etcdcv3.Event{
+		{Type: etcdcv3.EventTypePut, Kv: kv("/skydns/test/skydns/b")},
+		{Type: etcdcv3.EventTypeDelete, Kv: &mvccpb.KeyValue{Key: []byte("/skydns/test/skydns/a/x")}},
+	}, 12)
+
+	if rev := m.revision(); rev != 12 {
+		t.Errorf("Expected revision 12, got %d", rev)
+	}
+	kvs, _ := m.get("/skydns/test/skydns", true)
+	if got := keys(kvs); len(got) != 1 || got[0] != "/skydns/test/skydns/b" {
+		t.Errorf("Expected only /skydns/test/skydns/b, got %v", got)
+	}
+	// The empty nodes of the deleted key are removed.
+	if n := m.root.find(segments("/skydns/test/skydns/a")); n != nil {
+		t.Errorf("Expected the node of the deleted key to be removed")
+	}
+
+	// A progress notification only moves the revision forward.
+	m.apply(nil, 11)
+	if rev := m.revision(); rev != 12 {
+		t.Errorf("Expected revision 12, got %d", rev)
+	}
+}
@@ -0,0 +1,290 @@
+package etcd
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/pkg/reuseport"
+
+	"github.com/miekg/dns"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	etcdcv3 "go.etcd.io/etcd/client/v3"
+)
+
+// registry serves an HTTP API to register services in etcd, so the tooling that does this doesn't need to
+// know how the names map to keys. It has these endpoints:
+//
+//	PUT    /v1/services/NAME[?lease=SECONDS]   registers the msg.Service in the body as NAME
+//	DELETE /v1/services/NAME                   deregisters NAME
+//	PUT    /v1/leases/ID                       keeps lease ID alive
+//
+// A service registered with a lease is removed when the lease isn't kept alive. Only the leases granted by
+// the API can be kept alive with it.
+type registry struct {
+	e         *Etcd
+	addr      string
+	token     string      // If set, requests must have it as bearer token.
+	tlsConfig *tls.Config // If set, the API is served with TLS and clients must have a certificate.
+
+	ln  net.Listener
+	mux *http.ServeMux
+
+	mu     sync.Mutex
+	leases map[etcdcv3.LeaseID]time.Time // The leases granted by the API, with the time they expire.
+}
+
+const (
+	servicesPath = "/v1/services/"
+	leasesPath   = "/v1/leases/"
+
+	maxBodySize = 64 * 1024
+)
+
+// Startup starts serving the API.
+func (r *registry) Startup() error {
+	// Reloading the plugin without changing the listening address results
+	// in an error unless we reuse the port because Startup is called for
+	// new handlers before Shutdown is called for the old ones.
+	ln, err := reuseport.Listen("tcp", r.addr)
+	if err != nil {
+		log.Errorf("Failed to start registration API: %s", err)
+		return err
+	}
+	if r.tlsConfig != nil {
+		ln = tls.NewListener(ln, r.tlsConfig)
+	}
+	r.ln = ln
+
+	r.mux = http.NewServeMux()
+	r.mux.HandleFunc(servicesPath, r.authorize(r.services))
+	r.mux.HandleFunc(leasesPath, r.authorize(r.keepAlive))
+
+	go func() { http.Serve(r.ln, r.mux) }()
+	return nil
+}
+
+// Shutdown stops serving the API.
+func (r *registry) Shutdown() error {
+	if r.ln != nil {
+		return r.ln.Close()
+	}
+	return nil
+}
+
+// authorize returns a handler that calls h if the request has the token, when one is set.
+func (r *registry) authorize(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if r.token != "" {
+			token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(r.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+		}
+		h(w, req)
+	}
+}
+
+// registration is the response to a registration.
+type registration struct {
+	Key   string `json:"key"`
+	Lease int64  `json:"lease,omitempty"`
+}
+
+func (r *registry) services(w http.ResponseWriter, req *http.Request) {
+	name := dns.Fqdn(strings.ToLower(strings.TrimPrefix(req.URL.Path, servicesPath)))
+	if err := r.validName(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key := msg.Path(name, r.e.PathPrefix)
+
+	ctx, cancel := context.WithTimeout(req.Context(), etcdTimeout)
+	defer cancel()
+
+	switch req.Method {
+	case http.MethodPut:
+		s := new(msg.Service)
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBodySize)).Decode(s); err != nil {
+			http.Error(w, fmt.Sprintf("invalid service: %s", err), http.StatusBadRequest)
+			return
+		}
+		if err := validService(name, s); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ttl := int64(0)
+		if l := req.URL.Query().Get("lease"); l != "" {
+			var err error
+			ttl, err = strconv.ParseInt(l, 10, 64)
+			if err != nil || ttl <= 0 {
+				http.Error(w, fmt.Sprintf("invalid lease %q", l), http.StatusBadRequest)
+				return
+			}
+		}
+
+		value, _ := json.Marshal(s)
+		reg := registration{Key: key}
+		opts := []etcdcv3.OpOption{}
+		if ttl > 0 {
+			lease, err := r.e.Client.Grant(ctx, ttl)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			reg.Lease = int64(lease.ID)
+			opts = append(opts, etcdcv3.WithLease(lease.ID))
+			r.granted(lease.ID, lease.TTL)
+		}
+		if _, err := r.e.Client.Put(ctx, key, string(value), opts...); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, reg)
+
+	case http.MethodDelete:
+		resp, err := r.e.Client.Delete(ctx, key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if resp.Deleted == 0 {
+			http.Error(w, errKeyNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "PUT, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *registry) keepAlive(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPut {
+		w.Header().Set("Allow", "PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	i, err := strconv.ParseInt(strings.TrimPrefix(req.URL.Path, leasesPath), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid lease", http.StatusBadRequest)
+		return
+	}
+	id := etcdcv3.LeaseID(i)
+	r.mu.Lock()
+	_, ok := r.leases[id]
+	r.mu.Unlock()
+	if !ok {
+		http.Error(w, rpctypes.ErrLeaseNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), etcdTimeout)
+	defer cancel()
+
+	resp, err := r.e.Client.KeepAliveOnce(ctx, id)
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		r.mu.Lock()
+		delete(r.leases, id)
+		r.mu.Unlock()
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	r.granted(id, resp.TTL)
+	writeJSON(w, struct {
+		TTL int64 `json:"ttl"`
+	}{resp.TTL})
+}
+
+// granted records that lease id was granted or kept alive for ttl seconds, and forgets the leases that expired.
+func (r *registry) granted(id etcdcv3.LeaseID, ttl int64) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for l, expire := range r.leases {
+		if now.After(expire) {
+			delete(r.leases, l)
+		}
+	}
+	r.leases[id] = now.Add(time.Duration(ttl) * time.Second)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// isLoopback returns true if the host of addr is localhost or a loopback address.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// validName checks if name can be registered: it must be a domain name in one of the zones, without
+// wildcards.
+func (r *registry) validName(name string) error {
+	if _, ok := dns.IsDomainName(name); !ok || name == "." {
+		return fmt.Errorf("invalid name %q", name)
+	}
+	for _, l := range dns.SplitDomainName(name) {
+		if l == "*" || l == "any" {
+			return fmt.Errorf("name %q has a wildcard", name)
+		}
+	}
+	if plugin.Zones(r.e.Zones).Matches(name) == "" {
+		return fmt.Errorf("name %q is not in the zones of the plugin", name)
+	}
+	return nil
+}
+
+// validService checks if s can be registered as name, so it results in valid records.
+func validService(name string, s *msg.Service) error {
+	if s.Host == "" && s.Text == "" {
+		return errors.New("host or text must be set")
+	}
+	if s.Host != "" && net.ParseIP(s.Host) == nil {
+		if _, ok := dns.IsDomainName(s.Host); !ok {
+			return fmt.Errorf("invalid host %q", s.Host)
+		}
+	}
+	if s.Mail && s.Host == "" {
+		return errors.New("a mail exchanger must have a host")
+	}
+	for _, v := range []struct {
+		field string
+		value int
+	}{{"port", s.Port}, {"priority", s.Priority}, {"weight", s.Weight}} {
+		if v.value < 0 || v.value > 65535 {
+			return fmt.Errorf("%s must be in range [0, 65535]: %d", v.field, v.value)
+		}
+	}
+	if s.TargetStrip < 0 || s.TargetStrip >= dns.CountLabel(name) {
+		return fmt.Errorf("targetstrip must be less than the number of labels of the name: %d", s.TargetStrip)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package etcd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/etcd/msg"
+
+	etcdcv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestValidService(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   msg.Service
+		shouldErr bool
+	}{
+		{"a.skydns.test.", msg.Service{Host: "10.0.0.1"}, false},
+		{"a.skydns.test.", msg.Service{Host: "2001:db8::1", Port: 53}, false},
+		{"a.skydns.test.", msg.Service{Host: "b.skydns.test", Mail: true, Priority: 10}, false},
+		{"a.skydns.test.", msg.Service{Text: "hello"}, false},
+		{"a.skydns.test.", msg.Service{Host: "10.0.0.1", TargetStrip: 2}, false},
+		// No records.
+		{"a.skydns.test.", msg.Service{}, true},
+		{"a.skydns.test.", msg.Service{Host: "a..skydns.test"}, true},
+		{"a.skydns.test.", msg.Service{Text: "hello", Mail: true}, true},
+		{"a.skydns.test.", msg.Service{Host: "10.0.0.1", Port: 65536}, true},
+		{"a.skydns.test.", msg.Service{Host: "10.0.0.1", Priority: -1}, true},
+		{"a.skydns.test.", msg.Service{Host: "10.0.0.1", Weight: 70000}, true},
+		{"a.skydns.test.", msg.Service{Host: "10.0.0.1", TargetStrip: 3}, true},
+	}
+
+	for i, tc := range tests {
+		err := validService(tc.name, &tc.service)
+		if tc.shouldErr && err == nil {
+			t.Errorf("Test %d: expected error for %+v", i, tc.service)
+		}
+		if !tc.shouldErr && err != nil {
+			t.Errorf("Test %d: expected no error for %+v, got %s", i, tc.service, err)
+		}
+	}
+}
+
+func TestRegistryBadRequest(t *testing.T) {
+	r := &registry{e: &Etcd{Zones: []string{"skydns.test."}, PathPrefix: "skydns"}, leases: make(map[etcdcv3.LeaseID]time.Time)}
+
+	tests := []struct {
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{http.MethodPut, "/v1/services/a.example.org", `{"host":"10.0.0.1"}`, http.StatusBadRequest},
+		{http.MethodPut, "/v1/services/*.skydns.test", `{"host":"10.0.0.1"}`, http.StatusBadRequest},
+		{http.MethodPut, "/v1/services/a.any.skydns.test", `{"host":"10.0.0.1"}`, http.StatusBadRequest},
+		{http.MethodPut, "/v1/services/", `{"host":"10.0.0.1"}`, http.StatusBadRequest},
+		{http.MethodPut, "/v1/services/a.skydns.test", `{"host":`, http.StatusBadRequest},
+		{http.MethodPut, "/v1/services/a.skydns.test", `{"port":80}`, http.StatusBadRequest},
+		{http.MethodPut, "/v1/services/a.skydns.test?lease=0", `{"host":"10.0.0.1"}`, http.StatusBadRequest},
+		{http.MethodPut, "/v1/services/a.skydns.test?lease=a", `{"host":"10.0.0.1"}`, http.StatusBadRequest},
+		{http.MethodPut, "/v1/services/a.skydns.test", strings.Repeat(" ", maxBodySize+1), http.StatusBadRequest},
+		{http.MethodGet, "/v1/services/a.skydns.test", "", http.StatusMethodNotAllowed},
+		{http.MethodPut, "/v1/leases/abc", "", http.StatusBadRequest},
+		{http.MethodDelete, "/v1/leases/1", "", http.StatusMethodNotAllowed},
+		// Not granted by the API.
+		{http.MethodPut, "/v1/leases/1", "", http.StatusNotFound},
+	}
+
+	for i, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		w := httptest.NewRecorder()
+		if strings.HasPrefix(tc.path, leasesPath) {
+			r.keepAlive(w, req)
+		} else {
+			r.services(w, req)
+		}
+		if w.Code != tc.expected {
+			t.Errorf("Test %d: expected status %d for %s %s, got %d", i, tc.expected, tc.method, tc.path, w.Code)
+		}
+	}
+}
+
+func TestRegistryAuthorize(t *testing.T) {
+	r := &registry{token: "secret"}
+	h := r.authorize(func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	tests := []struct {
+		authorization string
+		expected      int
+	}{
+		{"Bearer secret", http.StatusNoContent},
+		{"", http.StatusUnauthorized},
+		{"Bearer other", http.StatusUnauthorized},
+		{"Basic c2VjcmV0", http.StatusUnauthorized},
+	}
+
+	for i, tc := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/v1/leases/1", nil)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		w := httptest.NewRecorder()
+		h(w, req)
+		if w.Code != tc.expected {
+			t.Errorf("Test %d: expected status %d for %q, got %d", i, tc.expected, tc.authorization, w.Code)
+		}
+	}
+}
+
+func TestRegistryGranted(t *testing.T) {
+	r := &registry{leases: map[etcdcv3.LeaseID]time.Time{1: time.Now().Add(-time.Second), 2: time.Now().Add(time.Minute)}}
+	r.granted(3, 30)
+
+	for id, expected := range map[etcdcv3.LeaseID]bool{1: false, 2: true, 3: true} {
+		if _, ok := r.leases[id]; ok != expected {
+			t.Errorf("Expected lease %d to be known: %t, got %t", id, expected, ok)
+		}
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected bool
+	}{
+		{"localhost:8053", true},
+		{"127.0.0.1:8053", true},
+		{"[::1]:8053", true},
+		{":8053", false},
+		{"0.0.0.0:8053", false},
+		{"10.0.0.1:8053", false},
+		{"example.org:8053", false},
+		{"localhost", false},
+	}
+
+	for i, tc := range tests {
+		if got := isLoopback(tc.addr); got != tc.expected {
+			t.Errorf("Test %d: expected %t for %s, got %t", i, tc.expected, tc.addr, got)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package etcd
 
 import (
 	"crypto/tls"
+	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
@@ -20,6 +21,15 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("etcd", err)
 	}
 
+	if e.mirror != nil {
+		c.OnStartup(e.mirror.Start)
+		c.OnShutdown(e.mirror.Stop)
+	}
+	if e.registry != nil {
+		c.OnStartup(e.registry.Startup)
+		c.OnShutdown(e.registry.Shutdown)
+	}
+
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
 		e.Next = next
 		return e
@@ -36,6 +46,8 @@ func etcdParse(c *caddy.Controller) (*Etcd, error) {
 		endpoints = []string{defaultEndpoint}
 		username  string
 		password  string
+		mirrored  bool
+		register  *registry
 	)
 
 	etc.Upstream = upstream.New()
@@ -79,6 +91,16 @@ func etcdParse(c *caddy.Controller) (*Etcd, error) {
 					return &Etcd{}, c.Errf("credentials requires 2 arguments, username and password")
 				}
 				username, password = args[0], args[1]
+			case "mirror":
+				if len(c.RemainingArgs()) != 0 {
+					return &Etcd{}, c.ArgErr()
+				}
+				mirrored = true
+			case "register":
+				register, err = registerParse(c)
+				if err != nil {
+					return &Etcd{}, err
+				}
 			default:
 				if c.Val() != "}" {
 					return &Etcd{}, c.Errf("unknown property '%s'", c.Val())
@@ -91,12 +113,55 @@ func etcdParse(c *caddy.Controller) (*Etcd, error) {
 		}
 		etc.Client = client
 		etc.endpoints = endpoints
+		if mirrored {
+			etc.mirror = newMirror(client, etc.PathPrefix)
+		}
+		if register != nil {
+			register.e = &etc
+			etc.registry = register
+		}
 
 		return &etc, nil
 	}
 	return &Etcd{}, nil
 }
 
+// registerParse parses the arguments of register: ADDRESS [token TOKEN] [tls CERT KEY CACERT].
+func registerParse(c *caddy.Controller) (*registry, error) {
+	args := c.RemainingArgs()
+	if len(args) == 0 {
+		return nil, c.ArgErr()
+	}
+	r := &registry{addr: args[0], leases: make(map[etcdcv3.LeaseID]time.Time)}
+	for args = args[1:]; len(args) > 0; {
+		switch args[0] {
+		case "token":
+			if len(args) < 2 {
+				return nil, c.ArgErr()
+			}
+			r.token, args = args[1], args[2:]
+		case "tls":
+			if len(args) < 4 {
+				return nil, c.ArgErr()
+			}
+			tc, err := mwtls.NewTLSConfig(args[1], args[2], args[3])
+			if err != nil {
+				return nil, err
+			}
+			// NewTLSConfig only sets RootCAs, the clients are verified with the same CA.
+			tc.ClientAuth = tls.RequireAndVerifyClientCert
+			tc.ClientCAs = tc.RootCAs
+			r.tlsConfig, args = tc, args[4:]
+		default:
+			return nil, c.Errf("unknown register option '%s'", args[0])
+		}
+	}
+	if r.token == "" && r.tlsConfig == nil && !isLoopback(r.addr) {
+		return nil, c.Errf("register address '%s' is not a loopback address, it requires a token or tls", r.addr)
+	}
+	return r, nil
+}
+
 func newEtcdClient(endpoints []string, cc *tls.Config, username, password string) (*etcdcv3.Client, error) {
 	etcdCfg := etcdcv3.Config{
 		Endpoints: endpoints,
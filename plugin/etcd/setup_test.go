@@ -50,6 +50,68 @@ func TestSetupEtcd(t *testing.T) {
 		}
 			`, false, "skydns", []string{"http://localhost:2379"}, "", "username", "password",
 		},
+		// mirrored in memory
+		{
+			`etcd {
+			mirror
+		}
+			`, false, "skydns", []string{"http://localhost:2379"}, "", "", "",
+		},
+		{
+			`etcd {
+			mirror always
+		}
+			`, true, "skydns", []string{"http://localhost:2379"}, "Wrong argument count", "", "",
+		},
+		// registration API
+		{
+			`etcd {
+			register localhost:8080
+		}
+			`, false, "skydns", []string{"http://localhost:2379"}, "", "", "",
+		},
+		{
+			`etcd {
+			register :8080 token secret
+		}
+			`, false, "skydns", []string{"http://localhost:2379"}, "", "", "",
+		},
+		{
+			`etcd {
+			register :8080
+		}
+			`, true, "skydns", []string{"http://localhost:2379"}, "requires a token or tls", "", "",
+		},
+		{
+			`etcd {
+			register localhost:8080 token
+		}
+			`, true, "skydns", []string{"http://localhost:2379"}, "Wrong argument count", "", "",
+		},
+		{
+			`etcd {
+			register :8080 tls cert.pem key.pem
+		}
+			`, true, "skydns", []string{"http://localhost:2379"}, "Wrong argument count", "", "",
+		},
+		{
+			`etcd {
+			register :8080 tls cert.pem key.pem ca.pem
+		}
+			`, true, "skydns", []string{"http://localhost:2379"}, "could not load TLS cert", "", "",
+		},
+		{
+			`etcd {
+			register localhost:8080 password secret
+		}
+			`, true, "skydns", []string{"http://localhost:2379"}, "unknown register option", "", "",
+		},
+		{
+			`etcd {
+			register
+		}
+			`, true, "skydns", []string{"http://localhost:2379"}, "Wrong argument count", "", "",
+		},
 		// with credentials, missing password
 		{
 			`etcd {
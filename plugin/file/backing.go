@@ -0,0 +1,75 @@
+package file
+
+import (
+	"os"
+	"time"
+)
+
+// A secondary zone can be saved to a backing file after each transfer, so that it can be served after a
+// restart, even when the primaries can't be reached. The modification time of the backing file is the last
+// time the zone was refreshed, from which the SOA expire timer runs.
+
+// LoadBackingFile loads the zone from its backing file. Nothing is loaded if there is no backing file, or
+// when the zone in it has expired.
+func (z *Zone) LoadBackingFile() error {
+	if z.BackingFile == "" {
+		return nil
+	}
+	f, err := os.Open(z.BackingFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	zone, err := Parse(f, z.origin, z.BackingFile, -1)
+	if err != nil {
+		return err
+	}
+	expire := time.Second * time.Duration(zone.Apex.SOA.Expire)
+	if age := time.Since(fi.ModTime()); age >= expire {
+		log.Warningf("Not loading zone `%s' from %q, it expired %s ago", z.origin, z.BackingFile, (age - expire).Round(time.Second))
+		return nil
+	}
+
+	z.Lock()
+	z.Apex = zone.Apex
+	z.Tree = zone.Tree
+	z.transferred, z.refreshed = fi.ModTime(), fi.ModTime()
+	z.Unlock()
+	log.Infof("Loaded zone `%s' from %q with %d SOA serial", z.origin, z.BackingFile, zone.Apex.SOA.Serial)
+	return nil
+}
+
+// saveBackingFile writes the zone to its backing file, if it has one.
+func (z *Zone) saveBackingFile() error {
+	if z.BackingFile == "" {
+		return nil
+	}
+	apex, err := z.ApexIfDefined()
+	if err != nil {
+		return err
+	}
+	z.RLock()
+	tr := z.Tree
+	z.RUnlock()
+	return writeZone(z.BackingFile, apex, tr)
+}
+
+// touchBackingFile sets the modification time of the backing file to t, to record the zone was refreshed.
+func (z *Zone) touchBackingFile(t time.Time) error {
+	if z.BackingFile == "" {
+		return nil
+	}
+	err := os.Chtimes(z.BackingFile, t, t)
+	if os.IsNotExist(err) {
+		return z.saveBackingFile()
+	}
+	return err
+}
@@ -0,0 +1,96 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestBackingFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "db.example.org")
+
+	z := parseIxfrZone(t, "10", "a.example.org. 3600 IN A 127.0.0.1\n")
+	z.BackingFile = name
+	if err := z.saveBackingFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A zone refreshed a minute ago is loaded, its expire is 1209600s.
+	refreshed := time.Now().Add(-time.Minute).Truncate(time.Second)
+	if err := z.touchBackingFile(refreshed); err != nil {
+		t.Fatal(err)
+	}
+	z1 := NewZone("example.org.", "stdin")
+	z1.BackingFile = name
+	if err := z1.LoadBackingFile(); err != nil {
+		t.Fatal(err)
+	}
+	if z1.Apex.SOA == nil || z1.Apex.SOA.Serial != 10 {
+		t.Fatalf("Expected zone with serial %d to be loaded, got %v", 10, z1.Apex.SOA)
+	}
+	if len(z1.rrset("a.example.org.", dns.TypeA)) != 1 {
+		t.Errorf("Expected a.example.org. to be loaded")
+	}
+	if _, r := z1.Refreshed(); !r.Equal(refreshed) {
+		t.Errorf("Expected zone refreshed at %s, got %s", refreshed, r)
+	}
+
+	// An expired zone is not.
+	expired := time.Now().Add(-1209601 * time.Second)
+	if err := os.Chtimes(name, expired, expired); err != nil {
+		t.Fatal(err)
+	}
+	z2 := NewZone("example.org.", "stdin")
+	z2.BackingFile = name
+	if err := z2.LoadBackingFile(); err != nil {
+		t.Fatal(err)
+	}
+	if z2.Apex.SOA != nil {
+		t.Errorf("Expected expired zone not to be loaded")
+	}
+
+	// Nor is a zone without a backing file.
+	z3 := NewZone("example.org.", "stdin")
+	z3.BackingFile = filepath.Join(t.TempDir(), "db.example.org")
+	if err := z3.LoadBackingFile(); err != nil {
+		t.Fatal(err)
+	}
+	if z3.Apex.SOA != nil {
+		t.Errorf("Expected no zone to be loaded")
+	}
+}
+
+func TestBackingFileSerialZero(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "db.example.org")
+	db := "example.org. 3600 IN SOA ns.example.org. admin.example.org. 0 7200 3600 1209600 3600\n" +
+		"example.org. 3600 IN NS ns1.example.org.\n"
+	if err := os.WriteFile(name, []byte(db), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	z := NewZone("example.org.", "stdin")
+	z.BackingFile = name
+	if err := z.LoadBackingFile(); err != nil {
+		t.Fatal(err)
+	}
+	if z.Apex.SOA == nil || z.Apex.SOA.Serial != 0 {
+		t.Errorf("Expected zone with serial 0 to be loaded, got %v", z.Apex.SOA)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	z := NewZone("example.org.", "stdin")
+	z.refreshed = time.Now().Add(-time.Hour)
+
+	z.expire(2 * time.Hour)
+	if z.Expired {
+		t.Errorf("Expected zone refreshed an hour ago not to be expired")
+	}
+	z.expire(time.Hour)
+	if !z.Expired {
+		t.Errorf("Expected zone refreshed an hour ago to be expired")
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/coredns/coredns/plugin"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/pkg/tsig"
 	"github.com/coredns/coredns/plugin/transfer"
 	"github.com/coredns/coredns/request"
 
@@ -58,6 +59,7 @@ func (f File) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (i
 			m := new(dns.Msg)
 			m.SetReply(r)
 			m.Authoritative = true
+			tsig.Reply(m, r)
 			w.WriteMsg(m)
 
 			log.Infof("Notify from %s for %s: checking transfer", state.IP(), zone)
@@ -76,6 +78,31 @@ func (f File) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (i
 		return dns.RcodeSuccess, nil
 	}
 
+	if r.Opcode == dns.OpcodeUpdate {
+		m := new(dns.Msg)
+		signer, err := tsig.Status(w, r)
+		if err != nil {
+			log.Warningf("Refusing update of zone %q from %s: TSIG of key %q: %s", zone, state.IP(), signer, err)
+			m.SetRcode(r, dns.RcodeNotAuth)
+			w.WriteMsg(m)
+			return dns.RcodeSuccess, nil
+		}
+
+		rcode := z.DynamicUpdate(state, signer)
+		m.SetRcode(r, rcode)
+		tsig.Reply(m, r)
+		w.WriteMsg(m)
+
+		if rcode == dns.RcodeSuccess && f.transfer != nil {
+			go func() {
+				if err := f.transfer.Notify(zone); err != nil {
+					log.Warningf("Failed sending notifies: %s", err)
+				}
+			}()
+		}
+		return dns.RcodeSuccess, nil
+	}
+
 	z.RLock()
 	exp := z.Expired
 	z.RUnlock()
@@ -0,0 +1,175 @@
+package file
+
+import (
+	"github.com/coredns/coredns/plugin/file/tree"
+
+	"github.com/miekg/dns"
+)
+
+// maxHistory is the number of changes kept for incremental zone transfers.
+const maxHistory = 10
+
+// delta is the difference between two versions of a zone, in the format used by IXFR (RFC 1995).
+type delta struct {
+	from, to *dns.SOA
+	del, add []dns.RR
+}
+
+// diff returns the difference between the zones old and z for names. Both SOA records are taken from the
+// zones as is, if they are the same no new serial was set.
+func diff(old, z *Zone, names map[string]struct{}) *delta {
+	d := &delta{from: old.Apex.SOA, to: z.Apex.SOA}
+	for name := range names {
+		before, after := old.all(name), z.all(name)
+		for _, rr := range before {
+			if rr.Header().Rrtype != dns.TypeSOA && !contains(after, rr) {
+				d.del = append(d.del, rr)
+			}
+		}
+		for _, rr := range after {
+			if rr.Header().Rrtype != dns.TypeSOA && !contains(before, rr) {
+				d.add = append(d.add, rr)
+			}
+		}
+	}
+	return d
+}
+
+// zoneDiff returns the difference between the complete zones old and z, or nil if old has no SOA or the
+// serial didn't change.
+func zoneDiff(old, z *Zone) *delta {
+	if old.Apex.SOA == nil || z.Apex.SOA == nil || old.Apex.SOA.Serial == z.Apex.SOA.Serial {
+		return nil
+	}
+	names := map[string]struct{}{z.origin: {}}
+	for _, t := range []*tree.Tree{old.Tree, z.Tree} {
+		t.Walk(func(e *tree.Elem, _ map[uint16][]dns.RR) error {
+			names[e.Name()] = struct{}{}
+			return nil
+		})
+	}
+	return diff(old, z, names)
+}
+
+// contains returns true if rrs has a record equal to rr, including its TTL.
+func contains(rrs []dns.RR, rr dns.RR) bool {
+	for _, x := range rrs {
+		if dns.IsDuplicate(x, rr) && x.Header().Ttl == rr.Header().Ttl {
+			return true
+		}
+	}
+	return false
+}
+
+// addHistory adds the changes in deltas to the history of z, dropping the oldest changes when there are more
+// than maxHistory. The history is restarted when the changes don't follow on the last one. The caller must
+// hold the write lock on z.
+func (z *Zone) addHistory(deltas ...*delta) {
+	for _, d := range deltas {
+		if n := len(z.history); n > 0 && z.history[n-1].to.Serial != d.from.Serial {
+			z.history = nil
+		}
+		z.history = append(z.history, d)
+	}
+	if n := len(z.history); n > maxHistory {
+		z.history = append([]*delta(nil), z.history[n-maxHistory:]...)
+	}
+}
+
+// changesSince returns the changes that bring a zone with serial up to date with z, or nil if z doesn't
+// have those in its history.
+func (z *Zone) changesSince(serial uint32) []*delta {
+	z.RLock()
+	defer z.RUnlock()
+	if z.Apex.SOA == nil {
+		return nil
+	}
+	for i, d := range z.history {
+		if d.from.Serial == serial {
+			if z.history[len(z.history)-1].to.Serial != z.Apex.SOA.Serial {
+				return nil
+			}
+			return z.history[i:]
+		}
+	}
+	return nil
+}
+
+// applyDeltas returns a copy of z with the changes in deltas applied. It returns nil if the changes don't
+// apply to z.
+func (z *Zone) applyDeltas(deltas []*delta) *Zone {
+	z.RLock()
+	nz := (&Zone{origin: z.origin, Apex: z.Apex, Tree: z.Tree}).clone()
+	z.RUnlock()
+
+	for _, d := range deltas {
+		if nz.Apex.SOA == nil || d.from.Serial != nz.Apex.SOA.Serial {
+			return nil
+		}
+		for _, rr := range d.del {
+			nz.remove(rr)
+		}
+		for _, rr := range d.add {
+			nz.Insert(rr)
+		}
+		nz.Apex.SOA = d.to
+	}
+	return nz
+}
+
+// parseIXFR parses the records of an incremental zone transfer (RFC 1995, Section 4) in rrs. It returns
+// false if rrs doesn't hold an incremental transfer, but a complete zone.
+func parseIXFR(rrs []dns.RR) ([]*delta, bool) {
+	if len(rrs) < 3 {
+		return nil, false
+	}
+	last, ok := rrs[0].(*dns.SOA)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := rrs[1].(*dns.SOA); !ok {
+		return nil, false
+	}
+	if end, ok := rrs[len(rrs)-1].(*dns.SOA); !ok || end.Serial != last.Serial {
+		return nil, false
+	}
+
+	deltas, complete := splitDeltas(rrs[1 : len(rrs)-1])
+	if !complete || deltas[len(deltas)-1].to.Serial != last.Serial {
+		return nil, false
+	}
+	return deltas, true
+}
+
+// splitDeltas splits rrs, which must start with a SOA record, into changes. Each change is the old SOA, the
+// deleted records, the new SOA and the added records. It returns false if the last change is incomplete,
+// that change is not returned.
+func splitDeltas(rrs []dns.RR) ([]*delta, bool) {
+	var (
+		deltas []*delta
+		d      *delta
+	)
+	for _, rr := range rrs {
+		soa, isSOA := rr.(*dns.SOA)
+		switch {
+		case isSOA && (d == nil || d.to != nil):
+			d = &delta{from: soa}
+			deltas = append(deltas, d)
+		case isSOA:
+			d.to = soa
+		case d == nil:
+			return nil, false
+		case d.to == nil:
+			d.del = append(d.del, rr)
+		default:
+			d.add = append(d.add, rr)
+		}
+	}
+	if d == nil {
+		return nil, false
+	}
+	if d.to == nil {
+		return deltas[:len(deltas)-1], false
+	}
+	return deltas, true
+}
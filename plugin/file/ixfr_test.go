@@ -0,0 +1,178 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func parseIxfrZone(t *testing.T, serial string, body string) *Zone {
+	t.Helper()
+	db := "example.org. 3600 IN SOA ns.example.org. admin.example.org. " + serial + " 7200 3600 1209600 3600\n" +
+		"example.org. 3600 IN NS ns1.example.org.\n" + body
+	z, err := Parse(strings.NewReader(db), "example.org.", "stdin", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return z
+}
+
+func TestTransferIncremental(t *testing.T) {
+	z := parseIxfrZone(t, "10", "a.example.org. 3600 IN A 127.0.0.1\n")
+	z.replace(parseIxfrZone(t, "11", "a.example.org. 3600 IN A 127.0.0.2\n"))
+	z.replace(parseIxfrZone(t, "12", "a.example.org. 3600 IN A 127.0.0.2\nb.example.org. 3600 IN A 127.0.0.3\n"))
+
+	tests := []struct {
+		serial   uint32
+		expected []string
+	}{
+		{10, []string{"SOA 12", "SOA 10", "A 127.0.0.1", "SOA 11", "A 127.0.0.2", "SOA 11", "SOA 12", "A 127.0.0.3", "SOA 12"}},
+		{11, []string{"SOA 12", "SOA 11", "SOA 12", "A 127.0.0.3", "SOA 12"}},
+		{12, []string{"SOA 12"}},
+		{9, []string{"SOA 12", "NS ns1.example.org.", "A 127.0.0.2", "A 127.0.0.3", "SOA 12"}}, // not in the history
+	}
+
+	for i, tc := range tests {
+		ch, err := z.Transfer(tc.serial)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		var got []string
+		for rrs := range ch {
+			for _, rr := range rrs {
+				switch x := rr.(type) {
+				case *dns.SOA:
+					got = append(got, fmt.Sprintf("SOA %d", x.Serial))
+				case *dns.A:
+					got = append(got, "A "+x.A.String())
+				case *dns.NS:
+					got = append(got, "NS "+x.Ns)
+				}
+			}
+		}
+		if strings.Join(got, ", ") != strings.Join(tc.expected, ", ") {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+		}
+	}
+}
+
+func TestAddHistory(t *testing.T) {
+	soa := func(serial uint32) *dns.SOA {
+		return &dns.SOA{Hdr: dns.RR_Header{Name: "example.org.", Rrtype: dns.TypeSOA, Class: dns.ClassINET}, Serial: serial}
+	}
+
+	z := NewZone("example.org.", "stdin")
+	for i := uint32(1); i <= maxHistory+5; i++ {
+		z.addHistory(&delta{from: soa(i), to: soa(i + 1)})
+	}
+	if x := len(z.history); x != maxHistory {
+		t.Fatalf("Expected %d changes in the history, got %d", maxHistory, x)
+	}
+	if x := z.history[0].from.Serial; x != 6 {
+		t.Errorf("Expected oldest change from serial %d, got %d", 6, x)
+	}
+
+	// A change that doesn't follow on the last one restarts the history.
+	z.addHistory(&delta{from: soa(100), to: soa(101)})
+	if x := len(z.history); x != 1 {
+		t.Errorf("Expected %d change in the history, got %d", 1, x)
+	}
+}
+
+func TestParseIXFR(t *testing.T) {
+	tests := []struct {
+		rrs         []dns.RR
+		incremental bool
+		changes     int
+	}{
+		{
+			[]dns.RR{
+				test.SOA("example.org. 3600 IN SOA ns. admin. 12 7200 3600 1209600 3600"),
+				test.SOA("example.org. 3600 IN SOA ns. admin. 10 7200 3600 1209600 3600"),
+				test.A("a.example.org. 3600 IN A 127.0.0.1"),
+				test.SOA("example.org. 3600 IN SOA ns. admin. 11 7200 3600 1209600 3600"),
+				test.SOA("example.org. 3600 IN SOA ns. admin. 11 7200 3600 1209600 3600"),
+				test.SOA("example.org. 3600 IN SOA ns. admin. 12 7200 3600 1209600 3600"),
+				test.A("b.example.org. 3600 IN A 127.0.0.2"),
+				test.SOA("example.org. 3600 IN SOA ns. admin. 12 7200 3600 1209600 3600"),
+			},
+			true, 2,
+		},
+		{ // complete zone
+			[]dns.RR{
+				test.SOA("example.org. 3600 IN SOA ns. admin. 12 7200 3600 1209600 3600"),
+				test.NS("example.org. 3600 IN NS ns.example.org."),
+				test.A("a.example.org. 3600 IN A 127.0.0.1"),
+				test.SOA("example.org. 3600 IN SOA ns. admin. 12 7200 3600 1209600 3600"),
+			},
+			false, 0,
+		},
+		{ // last change doesn't end in the current serial
+			[]dns.RR{
+				test.SOA("example.org. 3600 IN SOA ns. admin. 12 7200 3600 1209600 3600"),
+				test.SOA("example.org. 3600 IN SOA ns. admin. 10 7200 3600 1209600 3600"),
+				test.SOA("example.org. 3600 IN SOA ns. admin. 11 7200 3600 1209600 3600"),
+				test.SOA("example.org. 3600 IN SOA ns. admin. 12 7200 3600 1209600 3600"),
+			},
+			false, 0,
+		},
+	}
+
+	for i, tc := range tests {
+		deltas, ok := parseIXFR(tc.rrs)
+		if ok != tc.incremental {
+			t.Errorf("Test %d: expected incremental %t, got %t", i, tc.incremental, ok)
+		}
+		if len(deltas) != tc.changes {
+			t.Errorf("Test %d: expected %d changes, got %d", i, tc.changes, len(deltas))
+		}
+	}
+}
+
+func TestTransferInIncremental(t *testing.T) {
+	primary := parseIxfrZone(t, "10", "a.example.org. 3600 IN A 127.0.0.1\n")
+	primary.replace(parseIxfrZone(t, "11", "a.example.org. 3600 IN A 127.0.0.2\n"))
+
+	s := dnstest.NewServer(func(w dns.ResponseWriter, r *dns.Msg) {
+		var serial uint32
+		if r.Question[0].Qtype == dns.TypeIXFR {
+			serial = r.Ns[0].(*dns.SOA).Serial
+		}
+		ch, err := primary.Transfer(serial)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		out := make(chan *dns.Envelope)
+		go func() {
+			for rrs := range ch {
+				out <- &dns.Envelope{RR: rrs}
+			}
+			close(out)
+		}()
+		new(dns.Transfer).Out(w, r, out)
+		w.Hijack()
+	})
+	defer s.Close()
+
+	z := parseIxfrZone(t, "10", "a.example.org. 3600 IN A 127.0.0.1\n")
+	z.TransferFrom = []string{s.Addr}
+	if err := z.TransferIn(); err != nil {
+		t.Fatalf("Unable to run TransferIn: %v", err)
+	}
+	if x := z.Apex.SOA.Serial; x != 11 {
+		t.Errorf("Expected serial %d, got %d", 11, x)
+	}
+	if x := len(z.history); x != 1 {
+		t.Errorf("Expected the transferred change in the history, got %d changes", x)
+	}
+	rrs := z.rrset("a.example.org.", dns.TypeA)
+	if len(rrs) != 1 || rrs[0].(*dns.A).A.String() != "127.0.0.2" {
+		t.Errorf("Expected the change to be applied, got %v", rrs)
+	}
+}
@@ -0,0 +1,209 @@
+package file
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/coredns/coredns/plugin/file/tree"
+
+	"github.com/miekg/dns"
+)
+
+// The journal holds the changes made by dynamic updates that are not yet written back to the zone file.
+// Each change is stored as in an IXFR (RFC 1995): the old SOA, the deleted records, the new SOA and the
+// added records, all in the presentation format.
+
+// record appends d to the journal.
+func (u *updater) record(d *delta) error {
+	f, err := os.OpenFile(u.journal, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, d.from.String())
+	for _, rr := range d.del {
+		fmt.Fprintln(w, rr.String())
+	}
+	fmt.Fprintln(w, d.to.String())
+	for _, rr := range d.add {
+		fmt.Fprintln(w, rr.String())
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readJournal reads the changes from the journal in r.
+func readJournal(r io.Reader, origin, file string) ([]*delta, error) {
+	var rrs []dns.RR
+	zp := dns.NewZoneParser(r, origin, file)
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		rrs = append(rrs, rr)
+	}
+	if err := zp.Err(); err != nil {
+		return nil, err
+	}
+	if len(rrs) == 0 {
+		return nil, nil
+	}
+	if _, ok := rrs[0].(*dns.SOA); !ok {
+		return nil, fmt.Errorf("journal %q does not start with a SOA record", file)
+	}
+	// A partially written last change is dropped, it was never acknowledged.
+	deltas, _ := splitDeltas(rrs)
+	return deltas, nil
+}
+
+// replay applies the changes in the journal that follow the zone's current SOA serial. Changes for an older
+// serial are skipped, these are already in the zone file.
+func (z *Zone) replay() error {
+	u := z.updater
+	f, err := os.Open(u.journal)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	deltas, err := readJournal(f, z.origin, u.journal)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	// Skip the changes that are already in the zone file.
+	serial := z.SOASerialIfDefined()
+	for len(deltas) > 0 && int64(deltas[0].from.Serial) != serial {
+		deltas = deltas[1:]
+	}
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	u.Lock()
+	defer u.Unlock()
+	nz := z.applyDeltas(deltas)
+	if nz == nil {
+		return fmt.Errorf("journal %q does not apply to zone %q", u.journal, z.origin)
+	}
+	u.dirty = true
+
+	z.Lock()
+	z.Apex = nz.Apex
+	z.Tree = nz.Tree
+	z.addHistory(deltas...)
+	z.Unlock()
+
+	log.Infof("Replayed %d changes from journal %q for zone %q, now at %d SOA serial", len(deltas), u.journal, z.origin, nz.Apex.SOA.Serial)
+	return nil
+}
+
+// writeBack writes the zone to its file when it has changed, and then removes the journal.
+func (z *Zone) writeBack() error {
+	u := z.updater
+	u.Lock()
+	defer u.Unlock()
+	if !u.dirty {
+		return nil
+	}
+
+	apex, err := z.ApexIfDefined()
+	if err != nil {
+		return err
+	}
+	z.RLock()
+	tr := z.Tree
+	file := z.file
+	z.RUnlock()
+
+	if err := writeZone(file, apex, tr); err != nil {
+		return err
+	}
+
+	u.dirty = false
+	if err := os.Remove(u.journal); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	log.Infof("Wrote zone %q with %d SOA serial back to %q", z.origin, apex[0].(*dns.SOA).Serial, file)
+	return nil
+}
+
+// writeZone writes the zone with the records in apex and tr to file. A temporary file is written first, so a
+// crash doesn't leave a truncated zone file behind.
+func writeZone(file string, apex []dns.RR, tr *tree.Tree) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if fi, err := os.Stat(file); err == nil {
+		tmp.Chmod(fi.Mode())
+	}
+
+	w := bufio.NewWriter(tmp)
+	for _, rr := range apex {
+		fmt.Fprintln(w, rr.String())
+	}
+	tr.Walk(func(e *tree.Elem, _ map[uint16][]dns.RR) error {
+		for _, rr := range e.All() {
+			fmt.Fprintln(w, rr.String())
+		}
+		return nil
+	})
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// startWriteback periodically writes the zone back to its file.
+func (z *Zone) startWriteback() {
+	u := z.updater
+	if u == nil || u.writeback == 0 {
+		return
+	}
+	u.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		tick := time.NewTicker(u.writeback)
+		defer tick.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-tick.C:
+				if err := z.writeBack(); err != nil {
+					log.Errorf("Failed to write back zone %q: %s", z.origin, err)
+				}
+			}
+		}
+	}(u.stop)
+}
+
+// stopWriteback stops writing the zone back to its file.
+func (z *Zone) stopWriteback() {
+	u := z.updater
+	if u == nil || u.stop == nil {
+		return
+	}
+	close(u.stop)
+	u.stop = nil
+}
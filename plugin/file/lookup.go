@@ -44,6 +44,11 @@ func (z *Zone) Lookup(ctx context.Context, state request.Request, qname string)
 		return nil, nil, nil, ServerFailure
 	}
 
+	var chain *nsec3Chain
+	if do {
+		chain = z.nsec3(tr)
+	}
+
 	if qname == z.origin {
 		switch qtype {
 		case dns.TypeSOA:
@@ -93,6 +98,9 @@ func (z *Zone) Lookup(ctx context.Context, state request.Request, qname string)
 		}
 
 		elem, found = tr.Search(parts)
+		if found && nsec3Only(elem) {
+			found = false
+		}
 		if !found {
 			// Apex will always be found, when we are here we can search for a wildcard
 			// and save the result of that search. So when nothing match, but we have a
@@ -163,6 +171,9 @@ func (z *Zone) Lookup(ctx context.Context, state request.Request, qname string)
 			if do {
 				dss := typeFromElem(elem, dns.TypeDS, do)
 				nsrrs = append(nsrrs, dss...)
+				if len(dss) == 0 && chain != nil {
+					nsrrs = append(nsrrs, chain.noData(elem.Name())...)
+				}
 			}
 
 			return nil, nsrrs, glue, Delegation
@@ -189,7 +200,9 @@ func (z *Zone) Lookup(ctx context.Context, state request.Request, qname string)
 		// NODATA
 		if len(rrs) == 0 {
 			ret := ap.soa(do)
-			if do {
+			if chain != nil {
+				ret = append(ret, chain.noData(qname)...)
+			} else if do {
 				nsec := typeFromElem(elem, dns.TypeNSEC, do)
 				ret = append(ret, nsec...)
 			}
@@ -226,7 +239,9 @@ func (z *Zone) Lookup(ctx context.Context, state request.Request, qname string)
 		// NODATA response.
 		if len(rrs) == 0 {
 			ret := ap.soa(do)
-			if do {
+			if chain != nil {
+				ret = append(ret, chain.wildcardNoData(qname, wildElem.Name())...)
+			} else if do {
 				nsec := typeFromElem(wildElem, dns.TypeNSEC, do)
 				ret = append(ret, nsec...)
 			}
@@ -235,7 +250,9 @@ func (z *Zone) Lookup(ctx context.Context, state request.Request, qname string)
 
 		if do {
 			// An NSEC is needed to say no longer name exists under this wildcard.
-			if deny, found := tr.Prev(qname); found {
+			if chain != nil {
+				auth = append(auth, chain.wildcardAnswer(qname, wildElem.Name())...)
+			} else if deny, found := tr.Prev(qname); found {
 				nsec := typeFromElem(deny, dns.TypeNSEC, do)
 				auth = append(auth, nsec...)
 			}
@@ -252,13 +269,21 @@ func (z *Zone) Lookup(ctx context.Context, state request.Request, qname string)
 
 	// Hacky way to get around empty-non-terminals. If a longer name does exist, but this qname, does not, it
 	// must be an empty-non-terminal. If so, we do the proper NXDOMAIN handling, but set the rcode to be success.
-	if x, found := tr.Next(qname); found {
+	if x, found := tr.Next(qname); found && !nsec3Only(x) {
 		if dns.IsSubDomain(qname, x.Name()) {
 			rcode = Success
 		}
 	}
 
 	ret := ap.soa(do)
+	if chain != nil {
+		if rcode == NameError {
+			ret = append(ret, chain.nameError(qname)...)
+		} else {
+			ret = append(ret, chain.noData(qname)...)
+		}
+		goto Out
+	}
 	if do {
 		deny, found := tr.Prev(qname)
 		if !found {
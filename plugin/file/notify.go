@@ -3,6 +3,7 @@ package file
 import (
 	"net"
 
+	"github.com/coredns/coredns/plugin/pkg/tsig"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
@@ -11,6 +12,7 @@ import (
 // isNotify checks if state is a notify message and if so, will *also* check if it
 // is from one of the configured masters. If not it will not be a valid notify
 // message. If the zone z is not a secondary zone the message will also be ignored.
+// When the zone has a TransferKey the notify must be signed with it.
 func (z *Zone) isNotify(state request.Request) bool {
 	if state.Req.Opcode != dns.OpcodeNotify {
 		return false
@@ -18,6 +20,13 @@ func (z *Zone) isNotify(state request.Request) bool {
 	if len(z.TransferFrom) == 0 {
 		return false
 	}
+	signer, err := tsig.Status(state.W, state.Req)
+	if err != nil {
+		return false
+	}
+	if z.TransferKey != nil && z.TransferKey.Name != signer {
+		return false
+	}
 	// If remote IP matches we accept.
 	remote := state.IP()
 	for _, f := range z.TransferFrom {
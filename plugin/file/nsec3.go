@@ -0,0 +1,204 @@
+package file
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/coredns/coredns/plugin/file/tree"
+
+	"github.com/miekg/dns"
+)
+
+// NSEC3 records (RFC 5155) are stored in the tree under their hashed owner names, like any other record.
+// To find the NSEC3 records that match or cover a name, the NSEC3 chain sorted on hash is built once for
+// each version of the tree. The chain used is the one of the NSEC3PARAM record at the apex.
+
+// nsec3Chain is the NSEC3 chain of a tree.
+type nsec3Chain struct {
+	tree   *tree.Tree
+	param  *dns.NSEC3PARAM // nil when the zone isn't signed with NSEC3
+	hashes []string        // hashes of the owner names, sorted, in upper case
+	elems  []*tree.Elem    // elements with the NSEC3 record for the hash at the same index
+}
+
+// nsec3 returns the NSEC3 chain of tr, or nil if the zone isn't signed with NSEC3.
+func (z *Zone) nsec3(tr *tree.Tree) *nsec3Chain {
+	z.chainLock.Lock()
+	defer z.chainLock.Unlock()
+	if z.chain == nil || z.chain.tree != tr {
+		z.chain = newNSEC3Chain(z.origin, tr)
+	}
+	if z.chain.param == nil {
+		return nil
+	}
+	return z.chain
+}
+
+func newNSEC3Chain(origin string, tr *tree.Tree) *nsec3Chain {
+	c := &nsec3Chain{tree: tr}
+	apex, found := tr.Search(origin)
+	if !found {
+		return c
+	}
+	for _, rr := range apex.Type(dns.TypeNSEC3PARAM) {
+		if p := rr.(*dns.NSEC3PARAM); p.Flags == 0 && p.Hash == dns.SHA1 {
+			c.param = p
+			break
+		}
+	}
+	if c.param == nil {
+		return c
+	}
+
+	tr.Walk(func(e *tree.Elem, _ map[uint16][]dns.RR) error {
+		for _, rr := range e.Type(dns.TypeNSEC3) {
+			n := rr.(*dns.NSEC3)
+			if n.Hash != c.param.Hash || n.Iterations != c.param.Iterations || !strings.EqualFold(n.Salt, c.param.Salt) {
+				continue
+			}
+			i, end := dns.NextLabel(e.Name(), 0)
+			if end || e.Name()[i:] != origin {
+				continue
+			}
+			c.hashes = append(c.hashes, strings.ToUpper(e.Name()[:i-1]))
+			c.elems = append(c.elems, e)
+			break
+		}
+		return nil
+	})
+	sort.Sort(byHash{c})
+	return c
+}
+
+type byHash struct{ *nsec3Chain }
+
+func (b byHash) Len() int           { return len(b.hashes) }
+func (b byHash) Less(i, j int) bool { return b.hashes[i] < b.hashes[j] }
+func (b byHash) Swap(i, j int) {
+	b.hashes[i], b.hashes[j] = b.hashes[j], b.hashes[i]
+	b.elems[i], b.elems[j] = b.elems[j], b.elems[i]
+}
+
+func (c *nsec3Chain) hash(name string) string {
+	return dns.HashName(name, c.param.Hash, c.param.Iterations, c.param.Salt)
+}
+
+// match returns the element with the NSEC3 record that matches name, or nil if there is none.
+func (c *nsec3Chain) match(name string) *tree.Elem {
+	h := c.hash(name)
+	i := sort.SearchStrings(c.hashes, h)
+	if i < len(c.hashes) && c.hashes[i] == h {
+		return c.elems[i]
+	}
+	return nil
+}
+
+// cover returns the element with the NSEC3 record that covers name, or nil if name has a matching record.
+func (c *nsec3Chain) cover(name string) *tree.Elem {
+	if len(c.hashes) == 0 {
+		return nil
+	}
+	h := c.hash(name)
+	i := sort.SearchStrings(c.hashes, h)
+	if i < len(c.hashes) && c.hashes[i] == h {
+		return nil
+	}
+	if i == 0 {
+		// Hash is before the first one, it is covered by the last record that wraps around.
+		i = len(c.hashes)
+	}
+	return c.elems[i-1]
+}
+
+// closestEncloser returns the closest provable encloser of qname and the next closer name, see RFC 5155,
+// Section 7.2.1.
+func (c *nsec3Chain) closestEncloser(qname string) (ce, nc string) {
+	for name := qname; ; {
+		if c.match(name) != nil {
+			return name, nc
+		}
+		i, end := dns.NextLabel(name, 0)
+		if end {
+			return "", ""
+		}
+		nc, name = name, name[i:]
+	}
+}
+
+// nextCloser returns the name one label longer than the closest encloser ce, that is a parent of qname.
+func nextCloser(qname, ce string) string {
+	i, _ := dns.PrevLabel(qname, dns.CountLabel(ce)+1)
+	return qname[i:]
+}
+
+// nameError returns the records that prove qname doesn't exist: the closest encloser proof and the record
+// that covers the wildcard at the closest encloser, see RFC 5155, Section 7.2.2.
+func (c *nsec3Chain) nameError(qname string) []dns.RR {
+	ce, nc := c.closestEncloser(qname)
+	if ce == "" {
+		return nil
+	}
+	return proof(c.match(ce), c.cover(nc), c.cover("*."+ce))
+}
+
+// noData returns the records that prove qname doesn't have the queried type, see RFC 5155, Section 7.2.3.
+// If there is no matching record for qname, it's an unsigned delegation in an opt-out span, and the closest
+// encloser proof is returned, see RFC 5155, Sections 7.2.4 and 7.2.7.
+func (c *nsec3Chain) noData(qname string) []dns.RR {
+	if e := c.match(qname); e != nil {
+		return proof(e)
+	}
+	ce, nc := c.closestEncloser(qname)
+	if ce == "" {
+		return nil
+	}
+	return proof(c.match(ce), c.cover(nc))
+}
+
+// wildcardNoData returns the records that prove that qname, synthesized from wildcard, doesn't have the
+// queried type, see RFC 5155, Section 7.2.5.
+func (c *nsec3Chain) wildcardNoData(qname, wildcard string) []dns.RR {
+	ce := wildcard[2:]
+	return proof(c.match(ce), c.cover(nextCloser(qname, ce)), c.match(wildcard))
+}
+
+// wildcardAnswer returns the record that proves qname doesn't exist, for an answer synthesized from
+// wildcard, see RFC 5155, Section 7.2.6.
+func (c *nsec3Chain) wildcardAnswer(qname, wildcard string) []dns.RR {
+	return proof(c.cover(nextCloser(qname, wildcard[2:])))
+}
+
+// proof returns the NSEC3 records and their signatures of elems, skipping nil and duplicate elements.
+func proof(elems ...*tree.Elem) []dns.RR {
+	var rrs []dns.RR
+	for i, e := range elems {
+		if e == nil || duplicate(elems[:i], e) {
+			continue
+		}
+		rrs = append(rrs, typeFromElem(e, dns.TypeNSEC3, true)...)
+	}
+	return rrs
+}
+
+func duplicate(elems []*tree.Elem, e *tree.Elem) bool {
+	for _, x := range elems {
+		if x == e {
+			return true
+		}
+	}
+	return false
+}
+
+// nsec3Only returns true if e only holds NSEC3 records and their signatures. Such a name doesn't exist
+// for queries, see RFC 5155, Section 7.2.8.
+func nsec3Only(e *tree.Elem) bool {
+	if len(e.Type(dns.TypeNSEC3)) == 0 {
+		return false
+	}
+	for _, t := range e.Types() {
+		if t != dns.TypeNSEC3 && t != dns.TypeRRSIG {
+			return false
+		}
+	}
+	return true
+}
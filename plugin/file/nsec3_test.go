@@ -1,21 +1,27 @@
 package file
 
 import (
+	"context"
 	"strings"
 	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
 )
 
 func TestParseNSEC3PARAM(t *testing.T) {
 	_, err := Parse(strings.NewReader(nsec3paramTest), "miek.nl", "stdin", 0)
-	if err == nil {
-		t.Fatalf("Expected error when reading zone, got nothing")
+	if err != nil {
+		t.Fatalf("Expected no error when reading zone, got %q", err)
 	}
 }
 
 func TestParseNSEC3(t *testing.T) {
-	_, err := Parse(strings.NewReader(nsec3Test), "miek.nl", "stdin", 0)
-	if err == nil {
-		t.Fatalf("Expected error when reading zone, got nothing")
+	_, err := Parse(strings.NewReader(nsec3Test), "example.org", "stdin", 0)
+	if err != nil {
+		t.Fatalf("Expected no error when reading zone, got %q", err)
 	}
 }
 
@@ -26,3 +32,125 @@ miek.nl.		0	IN	NSEC3PARAM 1 0 5 A3DEBC9CC4F695C7`
 const nsec3Test = `example.org.		1800	IN	SOA	sns.dns.icann.org. noc.dns.icann.org. 2016082508 7200 3600 1209600 3600
 aub8v9ce95ie18spjubsr058h41n7pa5.example.org. 284 IN NSEC3 1 1 5 D0CBEAAF0AC77314 AUB95P93VPKP55G6U5S4SGS7LS61ND85 NS SOA TXT RRSIG DNSKEY NSEC3PARAM
 aub8v9ce95ie18spjubsr058h41n7pa5.example.org. 284 IN RRSIG NSEC3 8 2 600 20160910232502 20160827231002 14028 example.org. XBNpA7KAIjorPbXvTinOHrc1f630aHic2U716GHLHA4QMx9cl9ss4QjR Wj2UpDM9zBW/jNYb1xb0yjQoez/Jv200w0taSWjRci5aUnRpOi9bmcrz STHb6wIUjUsbJ+NstQsUwVkj6679UviF1FqNwr4GlJnWG3ZrhYhE+NI6 s0k=`
+
+var (
+	nsec3Apex   = test.NSEC3("8um1kjcjmofvvmq7cb0op7jt39lg8r9j.example.org. 3600 IN NSEC3 1 1 0 - GQO7H7R357FJ31QJIUDOG4AMTM030PLU NS SOA RRSIG DNSKEY NSEC3PARAM")
+	nsec3A      = test.NSEC3("6hsudpcugovcsu6rib34sa6rm87tqm57.example.org. 3600 IN NSEC3 1 1 0 - 8UM1KJCJMOFVVMQ7CB0OP7JT39LG8R9J A RRSIG")
+	nsec3BC     = test.NSEC3("1s1pi9tjgnu6e58j6vburdor8b34boav.example.org. 3600 IN NSEC3 1 1 0 - 6HSUDPCUGOVCSU6RIB34SA6RM87TQM57 A RRSIG")
+	nsec3C      = test.NSEC3("gqo7h7r357fj31qjiudog4amtm030plu.example.org. 3600 IN NSEC3 1 1 0 - H0K0TC6LVJGBU028K6QCVDUJ3JT9URL5")
+	nsec3W      = test.NSEC3("jrfh8dk3oofi50c0ct4kau7h45dl0k8c.example.org. 3600 IN NSEC3 1 1 0 - L9QCRTNKG05MBACGV440V6VLRI1DUP6M")
+	nsec3WildW  = test.NSEC3("l9qcrtnkg05mbacgv440v6vlri1dup6m.example.org. 3600 IN NSEC3 1 1 0 - 1S1PI9TJGNU6E58J6VBURDOR8B34BOAV TXT RRSIG")
+	nsec3SOA    = test.SOA("example.org. 3600 IN SOA sns.dns.icann.org. noc.dns.icann.org. 2017042745 7200 3600 1209600 3600")
+	nsec3Secure = test.NS("secure.example.org. 3600 IN NS ns.example.net.")
+	nsec3SubNS  = test.NS("sub.example.org. 3600 IN NS ns.sub.example.org.")
+)
+
+var nsec3TestCases = []test.Case{
+	{
+		Qname: "a.example.org.", Qtype: dns.TypeMX, Do: true,
+		Ns: []dns.RR{nsec3A, nsec3SOA},
+	},
+	{
+		// Closest encloser a.example.org., x.a.example.org. and *.a.example.org. are covered.
+		Qname: "x.a.example.org.", Qtype: dns.TypeA, Do: true,
+		Rcode: dns.RcodeNameError,
+		Ns:    []dns.RR{nsec3A, nsec3Apex, nsec3SOA, nsec3WildW},
+	},
+	{
+		// Closest encloser is the apex, which also covers the wildcard.
+		Qname: "nope.example.org.", Qtype: dns.TypeA, Do: true,
+		Rcode: dns.RcodeNameError,
+		Ns:    []dns.RR{nsec3Apex, nsec3SOA, nsec3WildW},
+	},
+	{
+		Qname: "nope.example.org.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns:    []dns.RR{nsec3SOA},
+	},
+	{
+		// Empty non-terminal.
+		Qname: "c.example.org.", Qtype: dns.TypeA, Do: true,
+		Ns: []dns.RR{nsec3SOA, nsec3C},
+	},
+	{
+		Qname: "foo.w.example.org.", Qtype: dns.TypeTXT, Do: true,
+		Answer: []dns.RR{test.TXT(`foo.w.example.org. 3600 IN TXT "wildcard"`)},
+		Ns:     []dns.RR{nsec3BC, test.NS("example.org. 3600 IN NS a.iana-servers.net.")},
+	},
+	{
+		Qname: "foo.w.example.org.", Qtype: dns.TypeA, Do: true,
+		Ns: []dns.RR{nsec3BC, nsec3SOA, nsec3W, nsec3WildW},
+	},
+	{
+		// Unsigned delegation in an opt-out span, the closest encloser proof is the apex record.
+		Qname: "www.sub.example.org.", Qtype: dns.TypeA, Do: true,
+		Ns:    []dns.RR{nsec3Apex, nsec3SubNS},
+		Extra: []dns.RR{test.A("ns.sub.example.org. 3600 IN A 127.0.0.3")},
+	},
+	{
+		Qname: "sub.example.org.", Qtype: dns.TypeDS, Do: true,
+		Ns: []dns.RR{nsec3Apex, nsec3SOA},
+	},
+	{
+		Qname: "www.secure.example.org.", Qtype: dns.TypeA, Do: true,
+		Ns: []dns.RR{test.DS("secure.example.org. 3600 IN DS 57855 5 1 B6DCD485719ADCA18E5F3D48A2331627FDD3636B"), nsec3Secure},
+	},
+	{
+		// NSEC3 owner names don't exist.
+		Qname: "1s1pi9tjgnu6e58j6vburdor8b34boav.example.org.", Qtype: dns.TypeA, Do: true,
+		Rcode: dns.RcodeNameError,
+		Ns:    []dns.RR{nsec3Apex, nsec3SOA, nsec3WildW},
+	},
+	{
+		Qname: "1s1pi9tjgnu6e58j6vburdor8b34boav.example.org.", Qtype: dns.TypeNSEC3,
+		Rcode: dns.RcodeNameError,
+		Ns:    []dns.RR{nsec3SOA},
+	},
+}
+
+func TestLookupNSEC3(t *testing.T) {
+	zone, err := Parse(strings.NewReader(dbExampleOrgNSEC3), "example.org.", "stdin", 0)
+	if err != nil {
+		t.Fatalf("Expected no error when reading zone, got %q", err)
+	}
+
+	fm := File{Next: test.ErrorHandler(), Zones: Zones{Z: map[string]*Zone{"example.org.": zone}, Names: []string{"example.org."}}}
+	ctx := context.TODO()
+
+	for _, tc := range nsec3TestCases {
+		m := tc.Msg()
+
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := fm.ServeDNS(ctx, rec, m); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+			return
+		}
+
+		if err := test.SortAndCheck(rec.Msg, tc); err != nil {
+			t.Errorf("Test %s/%s: %s", tc.Qname, dns.TypeToString[tc.Qtype], err)
+		}
+	}
+}
+
+// dbExampleOrgNSEC3 is signed with NSEC3 without iterations and salt and with opt-out, the signatures have
+// been left out.
+const dbExampleOrgNSEC3 = `
+$ORIGIN example.org.
+@	3600	IN	SOA	sns.dns.icann.org. noc.dns.icann.org. 2017042745 7200 3600 1209600 3600
+	3600	IN	NS	a.iana-servers.net.
+	0	IN	NSEC3PARAM	1 0 0 -
+a	3600	IN	A	127.0.0.1
+b.c	3600	IN	A	127.0.0.2
+*.w	3600	IN	TXT	"wildcard"
+sub	3600	IN	NS	ns.sub
+ns.sub	3600	IN	A	127.0.0.3
+secure	3600	IN	NS	ns.example.net.
+secure	3600	IN	DS	57855 5 1 B6DCD485719ADCA18E5F3D48A2331627FDD3636B
+1s1pi9tjgnu6e58j6vburdor8b34boav	3600	IN	NSEC3	1 1 0 - 6HSUDPCUGOVCSU6RIB34SA6RM87TQM57 A RRSIG
+6hsudpcugovcsu6rib34sa6rm87tqm57	3600	IN	NSEC3	1 1 0 - 8UM1KJCJMOFVVMQ7CB0OP7JT39LG8R9J A RRSIG
+8um1kjcjmofvvmq7cb0op7jt39lg8r9j	3600	IN	NSEC3	1 1 0 - GQO7H7R357FJ31QJIUDOG4AMTM030PLU NS SOA RRSIG DNSKEY NSEC3PARAM
+gqo7h7r357fj31qjiudog4amtm030plu	3600	IN	NSEC3	1 1 0 - H0K0TC6LVJGBU028K6QCVDUJ3JT9URL5
+h0k0tc6lvjgbu028k6qcvduj3jt9url5	3600	IN	NSEC3	1 1 0 - JRFH8DK3OOFI50C0CT4KAU7H45DL0K8C NS DS RRSIG
+jrfh8dk3oofi50c0ct4kau7h45dl0k8c	3600	IN	NSEC3	1 1 0 - L9QCRTNKG05MBACGV440V6VLRI1DUP6M
+l9qcrtnkg05mbacgv440v6vlri1dup6m	3600	IN	NSEC3	1 1 0 - 1S1PI9TJGNU6E58J6VBURDOR8B34BOAV TXT RRSIG
+`
@@ -35,11 +35,13 @@ func (z *Zone) Reload(t *transfer.Transfer) error {
 					continue
 				}
 
-				// copy elements we need
-				z.Lock()
-				z.Apex = zone.Apex
-				z.Tree = zone.Tree
-				z.Unlock()
+				if z.updater != nil {
+					if !z.reloadUpdated(zone) {
+						continue
+					}
+				} else {
+					z.replace(zone)
+				}
 
 				log.Infof("Successfully reloaded zone %q in %q with %d SOA serial", z.origin, zFile, z.Apex.SOA.Serial)
 				if t != nil {
@@ -57,6 +59,43 @@ func (z *Zone) Reload(t *transfer.Transfer) error {
 	return nil
 }
 
+// reloadUpdated replaces z, which allows dynamic updates, with zone read from disk. This is only done when
+// its serial is higher than the current serial, otherwise it's an older version that doesn't have the latest
+// updates. When it is replaced, the journal is discarded as it no longer applies.
+func (z *Zone) reloadUpdated(zone *Zone) bool {
+	z.updater.Lock()
+	defer z.updater.Unlock()
+
+	if serial := z.SOASerialIfDefined(); serial >= 0 && !less(uint32(serial), zone.Apex.SOA.Serial) {
+		return false
+	}
+	z.replace(zone)
+
+	z.updater.dirty = false
+	if err := os.Remove(z.updater.journal); err != nil && !os.IsNotExist(err) {
+		log.Warningf("Failed to remove journal %q: %s", z.updater.journal, err)
+	}
+	return true
+}
+
+// replace replaces the records of z with those of zone, and adds the changes to the history.
+func (z *Zone) replace(zone *Zone) {
+	z.RLock()
+	old := &Zone{origin: z.origin, Apex: z.Apex, Tree: z.Tree}
+	z.RUnlock()
+	d := zoneDiff(old, zone)
+
+	z.Lock()
+	z.Apex = zone.Apex
+	z.Tree = zone.Tree
+	if d != nil {
+		z.addHistory(d)
+	} else {
+		z.history = nil
+	}
+	z.Unlock()
+}
+
 // SOASerialIfDefined returns the SOA's serial if the zone has a SOA record in the Apex, or -1 otherwise.
 func (z *Zone) SOASerialIfDefined() int64 {
 	z.RLock()
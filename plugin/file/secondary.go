@@ -1,59 +1,162 @@
 package file
 
 import (
+	"fmt"
 	"math/rand"
 	"time"
 
 	"github.com/miekg/dns"
 )
 
-// TransferIn retrieves the zone from the masters, parses it and sets it live.
+// TransferIn retrieves the zone from the masters, parses it and sets it live. When the zone was transferred
+// before an incremental transfer (IXFR) is requested, if that fails the entire zone is transferred (AXFR).
 func (z *Zone) TransferIn() error {
 	if len(z.TransferFrom) == 0 {
 		return nil
 	}
+	z.RLock()
+	soa := z.Apex.SOA
+	z.RUnlock()
+
+	var Err error
+	for _, tr := range z.TransferFrom {
+		if soa != nil {
+			if Err = z.ixfrIn(tr, soa); Err == nil {
+				z.transferDone()
+				return nil
+			}
+			log.Warningf("Failed incremental transfer `%s' from %q, trying full transfer: %v", z.origin, tr, Err)
+		}
+		if Err = z.axfrIn(tr); Err == nil {
+			z.transferDone()
+			return nil
+		}
+	}
+	return Err
+}
+
+// transferDone records a successful transfer, and saves the zone to its backing file.
+func (z *Zone) transferDone() {
+	now := time.Now()
+	z.Lock()
+	z.transferred, z.refreshed = now, now
+	z.Unlock()
+	if err := z.saveBackingFile(); err != nil {
+		log.Errorf("Failed to save zone `%s' to %q: %v", z.origin, z.BackingFile, err)
+	}
+	if z.OnTransfer != nil {
+		z.OnTransfer()
+	}
+}
+
+// Refresh checks the primaries for a newer serial of the zone, and transfers it when there is one.
+func (z *Zone) Refresh() error {
+	ok, err := z.shouldTransfer()
+	if err != nil {
+		return err
+	}
+	if ok {
+		return z.TransferIn()
+	}
+
+	now := time.Now()
+	z.Lock()
+	z.refreshed = now
+	z.Unlock()
+	if err := z.touchBackingFile(now); err != nil {
+		log.Warningf("Failed to update the modification time of %q: %v", z.BackingFile, err)
+	}
+	return nil
+}
+
+// Refreshed returns the time of the last successful transfer of the zone, and of the last time it was
+// known to be up to date. Both are zero if that never happened.
+func (z *Zone) Refreshed() (transferred, refreshed time.Time) {
+	z.RLock()
+	defer z.RUnlock()
+	return z.transferred, z.refreshed
+}
+
+// axfrIn transfers the entire zone from tr.
+func (z *Zone) axfrIn(tr string) error {
 	m := new(dns.Msg)
 	m.SetAxfr(z.origin)
+	rrs, err := z.xfrIn(m, tr)
+	if err != nil {
+		log.Errorf("Failed to transfer `%s' from %q: %v", z.origin, tr, err)
+		return err
+	}
+	return z.load(rrs, tr)
+}
 
-	z1 := z.CopyWithoutApex()
-	var (
-		Err error
-		tr  string
-	)
+// ixfrIn transfers the changes since soa from tr, see RFC 1995. The primary may send the entire zone instead.
+func (z *Zone) ixfrIn(tr string, soa *dns.SOA) error {
+	m := new(dns.Msg)
+	m.SetIxfr(z.origin, soa.Serial, soa.Ns, soa.Mbox)
+	rrs, err := z.xfrIn(m, tr)
+	if err != nil {
+		return err
+	}
+	if len(rrs) == 0 {
+		return fmt.Errorf("empty transfer")
+	}
+	if last, ok := rrs[0].(*dns.SOA); ok && !less(soa.Serial, last.Serial) {
+		return nil // up to date
+	}
 
-Transfer:
-	for _, tr = range z.TransferFrom {
-		t := new(dns.Transfer)
-		c, err := t.In(m, tr)
-		if err != nil {
-			log.Errorf("Failed to setup transfer `%s' with `%q': %v", z.origin, tr, err)
-			Err = err
-			continue Transfer
-		}
-		for env := range c {
-			if env.Error != nil {
-				log.Errorf("Failed to transfer `%s' from %q: %v", z.origin, tr, env.Error)
-				Err = env.Error
-				continue Transfer
-			}
-			for _, rr := range env.RR {
-				if err := z1.Insert(rr); err != nil {
-					log.Errorf("Failed to parse transfer `%s' from: %q: %v", z.origin, tr, err)
-					Err = err
-					continue Transfer
-				}
-			}
+	deltas, ok := parseIXFR(rrs)
+	if !ok {
+		return z.load(rrs, tr)
+	}
+	nz := z.applyDeltas(deltas)
+	if nz == nil {
+		return fmt.Errorf("changes don't apply to %d SOA serial", soa.Serial)
+	}
+
+	z.Lock()
+	z.Tree = nz.Tree
+	z.Apex = nz.Apex
+	z.addHistory(deltas...)
+	z.Expired = false
+	z.Unlock()
+	log.Infof("Transferred %d changes: %s from %s", len(deltas), z.origin, tr)
+	return nil
+}
+
+// xfrIn sends the transfer request m to tr and returns the received records.
+func (z *Zone) xfrIn(m *dns.Msg, tr string) ([]dns.RR, error) {
+	t := new(dns.Transfer)
+	if z.TransferKey != nil {
+		t.TsigSecret = z.TransferKey.Secrets()
+		z.TransferKey.Sign(m)
+	}
+	c, err := t.In(m, tr)
+	if err != nil {
+		log.Errorf("Failed to setup transfer `%s' with `%q': %v", z.origin, tr, err)
+		return nil, err
+	}
+	var rrs []dns.RR
+	for env := range c {
+		if env.Error != nil {
+			return nil, env.Error
 		}
-		Err = nil
-		break
+		rrs = append(rrs, env.RR...)
 	}
-	if Err != nil {
-		return Err
+	return rrs, nil
+}
+
+// load sets the zone in rrs, transferred from tr, live.
+func (z *Zone) load(rrs []dns.RR, tr string) error {
+	z1 := z.CopyWithoutApex()
+	for _, rr := range rrs {
+		if err := z1.Insert(rr); err != nil {
+			log.Errorf("Failed to parse transfer `%s' from: %q: %v", z.origin, tr, err)
+			return err
+		}
 	}
 
+	z.replace(z1)
 	z.Lock()
-	z.Tree = z1.Tree
-	z.Apex = z1.Apex
 	z.Expired = false
 	z.Unlock()
 	log.Infof("Transferred: %s from %s", z.origin, tr)
@@ -67,6 +170,10 @@ func (z *Zone) shouldTransfer() (bool, error) {
 	c.Net = "tcp" // do this query over TCP to minimize spoofing
 	m := new(dns.Msg)
 	m.SetQuestion(z.origin, dns.TypeSOA)
+	if z.TransferKey != nil {
+		c.TsigSecret = z.TransferKey.Secrets()
+		z.TransferKey.Sign(m)
+	}
 
 	var Err error
 	serial := -1
@@ -106,13 +213,19 @@ func less(a, b uint32) bool {
 // Update updates the secondary zone according to its SOA. It will run for the life time of the server
 // and uses the SOA parameters. Every refresh it will check for a new SOA number. If that fails (for all
 // server) it will retry every retry interval. If the zone failed to transfer before the expire, the zone
-// will be marked expired.
+// will be marked expired. The expire timer starts at the last successful refresh, which may be before
+// Update is called when the zone was loaded from its backing file. Update returns after StopUpdate is called.
 func (z *Zone) Update() error {
 	// If we don't have a SOA, we don't have a zone, wait for it to appear.
 	for z.Apex.SOA == nil {
-		time.Sleep(1 * time.Second)
+		select {
+		case <-z.updateShutdown:
+			return nil
+		case <-time.After(1 * time.Second):
+		}
 	}
-	retryActive := false
+	_, refreshed := z.Refreshed()
+	retryActive := time.Since(refreshed) >= time.Second*time.Duration(z.Apex.SOA.Refresh)
 
 Restart:
 	refresh := time.Second * time.Duration(z.Apex.SOA.Refresh)
@@ -125,11 +238,17 @@ Restart:
 
 	for {
 		select {
+		case <-z.updateShutdown:
+			refreshTicker.Stop()
+			retryTicker.Stop()
+			expireTicker.Stop()
+			return nil
+
 		case <-expireTicker.C:
 			if !retryActive {
 				break
 			}
-			z.Expired = true
+			z.expire(expire)
 
 		case <-retryTicker.C:
 			if !retryActive {
@@ -138,19 +257,12 @@ Restart:
 
 			time.Sleep(jitter(2000)) // 2s randomize
 
-			ok, err := z.shouldTransfer()
-			if err != nil {
+			if err := z.Refresh(); err != nil {
 				log.Warningf("Failed retry check %s", err)
+				z.expire(expire)
 				continue
 			}
 
-			if ok {
-				if err := z.TransferIn(); err != nil {
-					// transfer failed, leave retryActive true
-					break
-				}
-			}
-
 			// no errors, stop timers and restart
 			retryActive = false
 			refreshTicker.Stop()
@@ -162,21 +274,12 @@ Restart:
 
 			time.Sleep(jitter(5000)) // 5s randomize
 
-			ok, err := z.shouldTransfer()
-			if err != nil {
+			if err := z.Refresh(); err != nil {
 				log.Warningf("Failed refresh check %s", err)
 				retryActive = true
 				continue
 			}
 
-			if ok {
-				if err := z.TransferIn(); err != nil {
-					// transfer failed
-					retryActive = true
-					break
-				}
-			}
-
 			// no errors, stop timers and restart
 			retryActive = false
 			refreshTicker.Stop()
@@ -188,6 +291,23 @@ Restart:
 	}
 }
 
+// StopUpdate stops Update, when the zone is no longer served.
+func (z *Zone) StopUpdate() {
+	z.stopUpdate.Do(func() { close(z.updateShutdown) })
+}
+
+// UpdateStopped returns a channel that is closed when StopUpdate is called.
+func (z *Zone) UpdateStopped() <-chan struct{} { return z.updateShutdown }
+
+// expire marks the zone as expired if it wasn't refreshed in the last expire duration.
+func (z *Zone) expire(expire time.Duration) {
+	z.Lock()
+	defer z.Unlock()
+	if time.Since(z.refreshed) >= expire {
+		z.Expired = true
+	}
+}
+
 // jitter returns a random duration between [0,n) * time.Millisecond
 func jitter(n int) time.Duration {
 	r := rand.Intn(n)
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/pkg/tsig"
 	"github.com/coredns/coredns/plugin/test"
 	"github.com/coredns/coredns/request"
 
@@ -138,6 +139,35 @@ func TestIsNotify(t *testing.T) {
 	}
 }
 
+func TestIsNotifyTsig(t *testing.T) {
+	z := new(Zone)
+	z.origin = testZone
+	z.TransferFrom = []string{"10.240.0.1:53"}
+	z.TransferKey = &tsig.Key{Name: "xfr.miek.nl.", Algorithm: dns.HmacSHA256, Secret: "c2VjcmV0"}
+
+	tests := []struct {
+		key    string
+		status error
+		notify bool
+	}{
+		{"", nil, false},
+		{"xfr.miek.nl.", nil, true},
+		{"xfr.miek.nl.", dns.ErrSig, false},
+		{"other.miek.nl.", nil, false},
+	}
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetNotify(testZone)
+		if tc.key != "" {
+			m.SetTsig(tc.key, dns.HmacSHA256, 300, 0)
+		}
+		state := request.Request{W: &test.TsigResponseWriter{ResponseWriter: &test.ResponseWriter{}, Status: tc.status}, Req: m}
+		if x := z.isNotify(state); x != tc.notify {
+			t.Errorf("Test %d: expected notify to be %t, got %t", i, tc.notify, x)
+		}
+	}
+}
+
 func newRequest(zone string, qtype uint16) request.Request {
 	m := new(dns.Msg)
 	m.SetQuestion("example.com.", dns.TypeA)
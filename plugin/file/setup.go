@@ -1,15 +1,21 @@
 package file
 
 import (
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cidr"
+	"github.com/coredns/coredns/plugin/pkg/tsig"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 	"github.com/coredns/coredns/plugin/transfer"
+
+	"github.com/miekg/dns"
 )
 
 func init() { plugin.Register("file", setup) }
@@ -52,8 +58,12 @@ func setup(c *caddy.Controller) error {
 	for _, n := range zones.Names {
 		z := zones.Z[n]
 		c.OnShutdown(z.OnShutdown)
+		c.OnFinalShutdown(z.OnFinalShutdown)
 		c.OnStartup(func() error {
-			z.StartupOnce.Do(func() { z.Reload(f.transfer) })
+			z.StartupOnce.Do(func() {
+				z.Reload(f.transfer)
+				z.startWriteback()
+			})
 			return nil
 		})
 	}
@@ -92,7 +102,9 @@ func fileParse(c *caddy.Controller) (Zones, error) {
 			openErr = err
 		}
 
+		stanza := []string{}
 		for i := range origins {
+			stanza = append(stanza, origins[i])
 			z[origins[i]] = NewZone(origins[i], fileName)
 			if openErr == nil {
 				reader.Seek(0, 0)
@@ -105,8 +117,68 @@ func fileParse(c *caddy.Controller) (Zones, error) {
 			names = append(names, origins[i])
 		}
 
+		var u *updater
+		keys := map[string]bool{}
 		for c.NextBlock() {
 			switch c.Val() {
+			case "update":
+				args := c.RemainingArgs()
+				acl := updateACL{}
+				if len(args) > 2 && args[len(args)-2] == "key" {
+					acl.key = strings.ToLower(dns.Fqdn(args[len(args)-1]))
+					args = args[:len(args)-2]
+				}
+				if len(args) == 0 {
+					return Zones{}, c.ArgErr()
+				}
+				if u == nil {
+					u = &updater{journal: fileName + ".jnl", writeback: defaultWriteback}
+				}
+				for _, a := range args {
+					n, err := parseNetwork(a)
+					if err != nil {
+						return Zones{}, c.Err(err.Error())
+					}
+					acl.nets = append(acl.nets, n...)
+				}
+				u.acls = append(u.acls, acl)
+			case "key":
+				k, err := tsig.Parse(c.RemainingArgs())
+				if err != nil {
+					return Zones{}, c.Err(err.Error())
+				}
+				if err := k.Register(config); err != nil {
+					return Zones{}, c.Err(err.Error())
+				}
+				keys[k.Name] = true
+			case "journal":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return Zones{}, c.ArgErr()
+				}
+				if u == nil {
+					return Zones{}, c.Err("journal requires update")
+				}
+				u.journal = args[0]
+				if !filepath.IsAbs(u.journal) && config.Root != "" {
+					u.journal = filepath.Join(config.Root, u.journal)
+				}
+			case "writeback":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return Zones{}, c.ArgErr()
+				}
+				if u == nil {
+					return Zones{}, c.Err("writeback requires update")
+				}
+				d, err := time.ParseDuration(args[0])
+				if err != nil {
+					return Zones{}, plugin.Error("file", err)
+				}
+				if d < 0 {
+					return Zones{}, c.Errf("writeback can not be negative: %s", args[0])
+				}
+				u.writeback = d
 			case "reload":
 				d, err := time.ParseDuration(c.RemainingArgs()[0])
 				if err != nil {
@@ -121,6 +193,25 @@ func fileParse(c *caddy.Controller) (Zones, error) {
 				return Zones{}, c.Errf("unknown property '%s'", c.Val())
 			}
 		}
+
+		if u != nil {
+			for _, acl := range u.acls {
+				if acl.key != "" && !keys[acl.key] {
+					return Zones{}, c.Errf("key %q is not defined", acl.key)
+				}
+			}
+			// The zone is written back to the file, so it can't hold more than one zone.
+			if len(stanza) != 1 {
+				return Zones{}, c.Errf("update requires a single zone for %q", fileName)
+			}
+			zone := z[stanza[0]]
+			zone.updater = u
+			if openErr == nil {
+				if err := zone.replay(); err != nil {
+					return Zones{}, plugin.Error("file", err)
+				}
+			}
+		}
 	}
 
 	for origin := range z {
@@ -138,3 +229,17 @@ func fileParse(c *caddy.Controller) (Zones, error) {
 	}
 	return Zones{Z: z, Names: names}, nil
 }
+
+// parseNetwork parses s as an address, network or '*' (any address).
+func parseNetwork(s string) ([]*net.IPNet, error) {
+	if s == "*" {
+		_, all4, _ := net.ParseCIDR("0.0.0.0/0")
+		_, all6, _ := net.ParseCIDR("::/0")
+		return []*net.IPNet{all4, all6}, nil
+	}
+	n, err := cidr.ParseNetwork(s)
+	if err != nil {
+		return nil, err
+	}
+	return []*net.IPNet{n}, nil
+}
@@ -122,3 +122,87 @@ func TestParseReload(t *testing.T) {
 		}
 	}
 }
+
+func TestParseUpdate(t *testing.T) {
+	name, rm, err := test.TempFile(".", dbMiekNL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rm()
+
+	tests := []struct {
+		input     string
+		shouldErr bool
+		allow     int
+		journal   string
+		writeback time.Duration
+	}{
+		{`file ` + name + ` miek.nl.`, false, 0, "", 0},
+		{`file ` + name + ` miek.nl. {
+			update 10.0.0.0/8 192.0.2.1
+		}`, false, 2, name + ".jnl", defaultWriteback},
+		{`file ` + name + ` miek.nl. {
+			update *
+			journal /tmp/miek.nl.jnl
+			writeback 0
+		}`, false, 2, "/tmp/miek.nl.jnl", 0},
+		{`file ` + name + ` miek.nl. {
+			update 10.0.0.0/8 key update.miek.nl.
+			key update.miek.nl. hmac-sha256 c2VjcmV0
+		}`, false, 1, name + ".jnl", defaultWriteback},
+		// errors
+		{`file ` + name + ` miek.nl. {
+			update
+		}`, true, 0, "", 0},
+		{`file ` + name + ` miek.nl. {
+			update 10.0.0.0/33
+		}`, true, 0, "", 0},
+		{`file ` + name + ` miek.nl. {
+			journal /tmp/miek.nl.jnl
+		}`, true, 0, "", 0},
+		{`file ` + name + ` miek.nl. {
+			update 10.0.0.0/8 key update.miek.nl.
+		}`, true, 0, "", 0},
+		{`file ` + name + ` miek.nl. {
+			update *
+			writeback -1s
+		}`, true, 0, "", 0},
+		{`file ` + name + ` miek.nl. example.org. {
+			update *
+		}`, true, 0, "", 0},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		z, err := fileParse(c)
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: expected error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d: expected no error, got %s", i, err)
+		}
+		u := z.Z["miek.nl."].updater
+		if tc.allow == 0 {
+			if u != nil {
+				t.Errorf("Test %d: expected updates to be disabled", i)
+			}
+			continue
+		}
+		nets := 0
+		for _, acl := range u.acls {
+			nets += len(acl.nets)
+		}
+		if nets != tc.allow {
+			t.Errorf("Test %d: expected %d allowed networks, got %d", i, tc.allow, nets)
+		}
+		if u.journal != tc.journal {
+			t.Errorf("Test %d: expected journal %q, got %q", i, tc.journal, u.journal)
+		}
+		if u.writeback != tc.writeback {
+			t.Errorf("Test %d: expected writeback %s, got %s", i, tc.writeback, u.writeback)
+		}
+	}
+}
@@ -5,5 +5,15 @@ func (z *Zone) OnShutdown() error {
 	if 0 < z.ReloadInterval {
 		z.reloadShutdown <- true
 	}
+	z.stopWriteback()
 	return nil
 }
+
+// OnFinalShutdown writes the zone back to its file if it was changed by dynamic updates. On a reload this
+// isn't needed, as the new instance reads the changes from the journal.
+func (z *Zone) OnFinalShutdown() error {
+	if z.updater == nil {
+		return nil
+	}
+	return z.writeBack()
+}
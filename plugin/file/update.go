@@ -0,0 +1,426 @@
+package file
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin/file/tree"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// updater holds the dynamic update (RFC 2136) settings and state of a zone.
+type updater struct {
+	sync.Mutex // serializes updates, journal writes and write backs
+
+	acls      []updateACL
+	journal   string // path of the journal
+	writeback time.Duration
+	dirty     bool // true if the zone has changes that are not written back yet
+	stop      chan struct{}
+}
+
+// updateACL allows updates from networks, optionally only when signed with a TSIG key.
+type updateACL struct {
+	nets []*net.IPNet
+	key  string // name of the TSIG key
+}
+
+const defaultWriteback = 15 * time.Minute
+
+// allowed returns true if ip may send updates. Signer is the name of the TSIG key the update was signed
+// with, if any.
+func (u *updater) allowed(ip, signer string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, a := range u.acls {
+		if a.key != "" && a.key != signer {
+			continue
+		}
+		for _, n := range a.nets {
+			if n.Contains(addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DynamicUpdate applies the dynamic update in state to z and returns the rcode for the reply. Signer is the
+// name of the (verified) TSIG key the update was signed with, if any. The update is applied to a copy of the
+// zone, which replaces the zone after the change has been written to the journal.
+func (z *Zone) DynamicUpdate(state request.Request, signer string) int {
+	u := z.updater
+	if u == nil {
+		return dns.RcodeNotImplemented
+	}
+	if !u.allowed(state.IP(), signer) {
+		return dns.RcodeRefused
+	}
+	r := state.Req
+	if len(r.Question) != 1 || r.Question[0].Qtype != dns.TypeSOA {
+		return dns.RcodeFormatError
+	}
+	if strings.ToLower(r.Question[0].Name) != z.origin {
+		return dns.RcodeNotAuth
+	}
+	class := r.Question[0].Qclass
+
+	u.Lock()
+	defer u.Unlock()
+
+	z.RLock()
+	old := &Zone{origin: z.origin, Apex: z.Apex, Tree: z.Tree}
+	z.RUnlock()
+	if old.Apex.SOA == nil {
+		return dns.RcodeServerFailure
+	}
+
+	if rcode := old.prerequisites(r.Answer, class); rcode != dns.RcodeSuccess {
+		return rcode
+	}
+	if rcode := z.prescan(r.Ns, class); rcode != dns.RcodeSuccess {
+		return rcode
+	}
+
+	nz := old.clone()
+	names := nz.apply(r.Ns, class)
+	d := diff(old, nz, names)
+	if d.from == d.to {
+		// The update didn't set a higher serial.
+		if len(d.del) == 0 && len(d.add) == 0 {
+			return dns.RcodeSuccess
+		}
+		soa := dns.Copy(d.from).(*dns.SOA)
+		soa.Serial++
+		nz.Apex.SOA = soa
+		d.to = soa
+	}
+
+	if err := u.record(d); err != nil {
+		log.Errorf("Failed to write journal %q for zone %q: %s", u.journal, z.origin, err)
+		return dns.RcodeServerFailure
+	}
+	u.dirty = true
+
+	z.Lock()
+	z.Apex = nz.Apex
+	z.Tree = nz.Tree
+	z.addHistory(d)
+	z.Unlock()
+
+	log.Infof("Updated zone %q from %s to %d SOA serial", z.origin, state.IP(), d.to.Serial)
+	return dns.RcodeSuccess
+}
+
+// prerequisites checks the prerequisite section of an update against z, see RFC 2136, Section 3.2.
+func (z *Zone) prerequisites(rrs []dns.RR, class uint16) int {
+	var values []dns.RR
+	for _, rr := range rrs {
+		h := rr.Header()
+		name := strings.ToLower(h.Name)
+		if h.Ttl != 0 {
+			return dns.RcodeFormatError
+		}
+		if !dns.IsSubDomain(z.origin, name) {
+			return dns.RcodeNotZone
+		}
+
+		switch h.Class {
+		case dns.ClassANY:
+			if h.Rdlength != 0 {
+				return dns.RcodeFormatError
+			}
+			if h.Rrtype == dns.TypeANY {
+				if !z.inUse(name) {
+					return dns.RcodeNameError
+				}
+			} else if len(z.rrset(name, h.Rrtype)) == 0 {
+				return dns.RcodeNXRrset
+			}
+		case dns.ClassNONE:
+			if h.Rdlength != 0 {
+				return dns.RcodeFormatError
+			}
+			if h.Rrtype == dns.TypeANY {
+				if z.inUse(name) {
+					return dns.RcodeYXDomain
+				}
+			} else if len(z.rrset(name, h.Rrtype)) > 0 {
+				return dns.RcodeYXRrset
+			}
+		case class:
+			values = append(values, rr)
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+
+	// Value dependent prerequisites: the RRsets must be exactly the same, ignoring TTLs.
+	sets := map[string][]dns.RR{}
+	for _, rr := range values {
+		k := strings.ToLower(rr.Header().Name) + "/" + dns.Type(rr.Header().Rrtype).String()
+		sets[k] = append(sets[k], rr)
+	}
+	for _, set := range sets {
+		h := set[0].Header()
+		if !equalSet(set, z.rrset(strings.ToLower(h.Name), h.Rrtype)) {
+			return dns.RcodeNXRrset
+		}
+	}
+	return dns.RcodeSuccess
+}
+
+// prescan checks the update section of an update, see RFC 2136, Section 3.4.1.
+func (z *Zone) prescan(rrs []dns.RR, class uint16) int {
+	for _, rr := range rrs {
+		h := rr.Header()
+		if !dns.IsSubDomain(z.origin, strings.ToLower(h.Name)) {
+			return dns.RcodeNotZone
+		}
+		switch h.Class {
+		case class:
+			if isMeta(h.Rrtype) || h.Rrtype == dns.TypeANY {
+				return dns.RcodeFormatError
+			}
+		case dns.ClassANY:
+			if h.Ttl != 0 || h.Rdlength != 0 || isMeta(h.Rrtype) {
+				return dns.RcodeFormatError
+			}
+		case dns.ClassNONE:
+			if h.Ttl != 0 || isMeta(h.Rrtype) || h.Rrtype == dns.TypeANY {
+				return dns.RcodeFormatError
+			}
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+	return dns.RcodeSuccess
+}
+
+// apply applies the update section rrs to z, see RFC 2136, Section 3.4.2. It returns the names that
+// were touched by the update.
+func (z *Zone) apply(rrs []dns.RR, class uint16) map[string]struct{} {
+	names := map[string]struct{}{z.origin: {}}
+	for _, rr := range rrs {
+		h := rr.Header()
+		name := strings.ToLower(h.Name)
+		names[name] = struct{}{}
+
+		switch h.Class {
+		case class:
+			z.add(dns.Copy(rr), name)
+
+		case dns.ClassANY:
+			if h.Rrtype == dns.TypeANY {
+				// At the apex this leaves the SOA and NS records alone, as they are stored in the Apex.
+				if e, ok := z.Tree.Search(name); ok {
+					for _, t := range e.Types() {
+						z.Tree.Delete(&dns.ANY{Hdr: dns.RR_Header{Name: name, Rrtype: t}})
+					}
+				}
+				continue
+			}
+			if name == z.origin && (h.Rrtype == dns.TypeSOA || h.Rrtype == dns.TypeNS) {
+				continue
+			}
+			z.Tree.Delete(&dns.ANY{Hdr: dns.RR_Header{Name: name, Rrtype: h.Rrtype}})
+
+		case dns.ClassNONE:
+			if h.Rrtype == dns.TypeSOA {
+				continue
+			}
+			rr = dns.Copy(rr)
+			rr.Header().Name = name
+			rr.Header().Class = class
+			if name == z.origin && h.Rrtype == dns.TypeNS && len(z.Apex.NS) == 1 {
+				// The last NS record of the zone can not be removed.
+				continue
+			}
+			z.remove(rr)
+		}
+	}
+	return names
+}
+
+// add adds rr to z, unless it conflicts with a CNAME. An existing record with the same data is replaced.
+func (z *Zone) add(rr dns.RR, name string) {
+	rr.Header().Name = name
+	t := rr.Header().Rrtype
+	if t == dns.TypeSOA {
+		if name == z.origin && less(z.Apex.SOA.Serial, rr.(*dns.SOA).Serial) {
+			z.Insert(rr)
+		}
+		return
+	}
+
+	if e, ok := z.Tree.Search(name); ok {
+		for _, et := range e.Types() {
+			if dnssecType(et) || dnssecType(t) {
+				continue
+			}
+			if et == dns.TypeCNAME && t != dns.TypeCNAME {
+				return
+			}
+			if et != dns.TypeCNAME && t == dns.TypeCNAME {
+				return
+			}
+		}
+		if t == dns.TypeCNAME {
+			z.Tree.Delete(rr)
+		}
+	}
+
+	for _, x := range z.rrset(name, t) {
+		if dns.IsDuplicate(x, rr) {
+			z.remove(x)
+		}
+	}
+	if err := z.Insert(rr); err != nil {
+		log.Warningf("Dropping update: %s", err)
+	}
+}
+
+// remove removes the record rr from z, ignoring its TTL.
+func (z *Zone) remove(rr dns.RR) {
+	name, t := rr.Header().Name, rr.Header().Rrtype
+	keep := func(rrs []dns.RR) []dns.RR {
+		var k []dns.RR
+		for _, x := range rrs {
+			if !dns.IsDuplicate(x, rr) {
+				k = append(k, x)
+			}
+		}
+		return k
+	}
+
+	if name == z.origin {
+		switch t {
+		case dns.TypeSOA:
+			return
+		case dns.TypeNS:
+			z.Apex.NS = keep(z.Apex.NS)
+			return
+		case dns.TypeRRSIG:
+			z.Apex.SIGSOA = keep(z.Apex.SIGSOA)
+			z.Apex.SIGNS = keep(z.Apex.SIGNS)
+		}
+	}
+
+	e, ok := z.Tree.Search(name)
+	if !ok {
+		return
+	}
+	rrs := keep(e.Type(t))
+	z.Tree.Delete(rr)
+	for _, x := range rrs {
+		z.Tree.Insert(x)
+	}
+}
+
+// rrset returns the records with name and type t in z.
+func (z *Zone) rrset(name string, t uint16) []dns.RR {
+	if name == z.origin {
+		switch t {
+		case dns.TypeSOA:
+			return []dns.RR{z.Apex.SOA}
+		case dns.TypeNS:
+			return z.Apex.NS
+		}
+	}
+	e, ok := z.Tree.Search(name)
+	if !ok {
+		return nil
+	}
+	return e.Type(t)
+}
+
+// all returns all records with name in z.
+func (z *Zone) all(name string) []dns.RR {
+	var rrs []dns.RR
+	if name == z.origin {
+		rrs = append(rrs, z.Apex.SOA)
+		rrs = append(rrs, z.Apex.SIGSOA...)
+		rrs = append(rrs, z.Apex.NS...)
+		rrs = append(rrs, z.Apex.SIGNS...)
+	}
+	if e, ok := z.Tree.Search(name); ok {
+		rrs = append(rrs, e.All()...)
+	}
+	return rrs
+}
+
+// inUse returns true if name has any records in z.
+func (z *Zone) inUse(name string) bool {
+	if name == z.origin {
+		return true
+	}
+	e, ok := z.Tree.Search(name)
+	return ok && !e.Empty()
+}
+
+// clone returns a copy of z that can be modified without affecting z.
+func (z *Zone) clone() *Zone {
+	z1 := NewZone(z.origin, z.file)
+	z1.Apex = Apex{
+		SOA:    z.Apex.SOA,
+		NS:     append([]dns.RR(nil), z.Apex.NS...),
+		SIGSOA: append([]dns.RR(nil), z.Apex.SIGSOA...),
+		SIGNS:  append([]dns.RR(nil), z.Apex.SIGNS...),
+	}
+	z.Tree.Walk(func(e *tree.Elem, _ map[uint16][]dns.RR) error {
+		for _, rr := range e.All() {
+			z1.Tree.Insert(rr)
+		}
+		return nil
+	})
+	return z1
+}
+
+// equalSet returns true if a and b hold the same records, ignoring TTLs.
+func equalSet(a, b []dns.RR) bool {
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if dns.IsDuplicate(x, y) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, y := range b {
+		found := false
+		for _, x := range a {
+			if dns.IsDuplicate(x, y) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// isMeta returns true for the meta types that can't be used in an update.
+func isMeta(t uint16) bool {
+	switch t {
+	case dns.TypeAXFR, dns.TypeIXFR, dns.TypeMAILA, dns.TypeMAILB, dns.TypeOPT, dns.TypeTSIG:
+		return true
+	}
+	return false
+}
+
+// dnssecType returns true for the types that may coexist with a CNAME.
+func dnssecType(t uint16) bool {
+	return t == dns.TypeRRSIG || t == dns.TypeNSEC
+}
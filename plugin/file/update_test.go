@@ -0,0 +1,251 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+func newUpdateZone(t *testing.T, dir string) *Zone {
+	t.Helper()
+	name := filepath.Join(dir, "db.example.org")
+	if _, err := os.Stat(name); os.IsNotExist(err) {
+		if err := ioutil.WriteFile(name, []byte(dbUpdateTest), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	z, err := Parse(f, "example.org.", name, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := parseNetwork("10.240.0.0/16")
+	z.updater = &updater{acls: []updateACL{{nets: n}}, journal: name + ".jnl"}
+	return z
+}
+
+// wire packs and unpacks m, so the record headers are as received from a client.
+func wire(t *testing.T, m *dns.Msg) *dns.Msg {
+	t.Helper()
+	buf, err := m.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m1 := new(dns.Msg)
+	if err := m1.Unpack(buf); err != nil {
+		t.Fatal(err)
+	}
+	return m1
+}
+
+func TestDynamicUpdate(t *testing.T) {
+	tests := []struct {
+		update   func(m *dns.Msg)
+		rcode    int
+		name     string
+		qtype    uint16
+		expected int // number of records with name and qtype after the update
+		serial   uint32
+	}{
+		{ // add a record
+			func(m *dns.Msg) { m.Insert([]dns.RR{test.A("b.example.org. 300 IN A 127.0.0.3")}) },
+			dns.RcodeSuccess, "b.example.org.", dns.TypeA, 1, 11,
+		},
+		{ // add a record that exists
+			func(m *dns.Msg) { m.Insert([]dns.RR{test.A("a.example.org. 3600 IN A 127.0.0.1")}) },
+			dns.RcodeSuccess, "a.example.org.", dns.TypeA, 2, 10,
+		},
+		{ // add a record conflicting with a CNAME
+			func(m *dns.Msg) { m.Insert([]dns.RR{test.A("c.example.org. 300 IN A 127.0.0.3")}) },
+			dns.RcodeSuccess, "c.example.org.", dns.TypeA, 0, 10,
+		},
+		{ // delete an RRset
+			func(m *dns.Msg) { m.RemoveRRset([]dns.RR{test.A("a.example.org. 0 IN A 127.0.0.1")}) },
+			dns.RcodeSuccess, "a.example.org.", dns.TypeA, 0, 11,
+		},
+		{ // delete a single record
+			func(m *dns.Msg) { m.Remove([]dns.RR{test.A("a.example.org. 0 IN A 127.0.0.1")}) },
+			dns.RcodeSuccess, "a.example.org.", dns.TypeA, 1, 11,
+		},
+		{ // delete a name
+			func(m *dns.Msg) { m.RemoveName([]dns.RR{test.A("c.example.org. 0 IN A 127.0.0.1")}) },
+			dns.RcodeSuccess, "c.example.org.", dns.TypeCNAME, 0, 11,
+		},
+		{ // delete all NS records, the last one stays
+			func(m *dns.Msg) {
+				m.Remove([]dns.RR{test.NS("example.org. 0 IN NS ns1.example.org."), test.NS("example.org. 0 IN NS ns2.example.org.")})
+			},
+			dns.RcodeSuccess, "example.org.", dns.TypeNS, 1, 11,
+		},
+		{ // update the SOA
+			func(m *dns.Msg) {
+				m.Insert([]dns.RR{test.SOA("example.org. 3600 IN SOA ns.example.org. admin.example.org. 20 7200 3600 1209600 3600")})
+			},
+			dns.RcodeSuccess, "example.org.", dns.TypeSOA, 1, 20,
+		},
+		{ // name must exist
+			func(m *dns.Msg) {
+				m.NameUsed([]dns.RR{test.A("b.example.org. 0 IN A 127.0.0.1")})
+				m.Insert([]dns.RR{test.A("b.example.org. 300 IN A 127.0.0.3")})
+			},
+			dns.RcodeNameError, "b.example.org.", dns.TypeA, 0, 10,
+		},
+		{ // name must not exist
+			func(m *dns.Msg) {
+				m.NameNotUsed([]dns.RR{test.A("a.example.org. 0 IN A 127.0.0.1")})
+				m.Insert([]dns.RR{test.A("a.example.org. 300 IN A 127.0.0.3")})
+			},
+			dns.RcodeYXDomain, "a.example.org.", dns.TypeA, 2, 10,
+		},
+		{ // RRset must not exist
+			func(m *dns.Msg) {
+				m.RRsetNotUsed([]dns.RR{test.A("a.example.org. 0 IN A 127.0.0.1")})
+				m.Insert([]dns.RR{test.A("a.example.org. 300 IN A 127.0.0.3")})
+			},
+			dns.RcodeYXRrset, "a.example.org.", dns.TypeA, 2, 10,
+		},
+		{ // RRset must exist with these values
+			func(m *dns.Msg) {
+				m.Used([]dns.RR{test.A("a.example.org. 0 IN A 127.0.0.1")})
+				m.Insert([]dns.RR{test.A("a.example.org. 300 IN A 127.0.0.3")})
+			},
+			dns.RcodeNXRrset, "a.example.org.", dns.TypeA, 2, 10,
+		},
+		{ // RRset with all values
+			func(m *dns.Msg) {
+				m.Used([]dns.RR{test.A("a.example.org. 0 IN A 127.0.0.1"), test.A("a.example.org. 0 IN A 127.0.0.2")})
+				m.Insert([]dns.RR{test.A("a.example.org. 300 IN A 127.0.0.3")})
+			},
+			dns.RcodeSuccess, "a.example.org.", dns.TypeA, 3, 11,
+		},
+		{ // outside of the zone
+			func(m *dns.Msg) { m.Insert([]dns.RR{test.A("example.net. 300 IN A 127.0.0.3")}) },
+			dns.RcodeNotZone, "example.net.", dns.TypeA, 0, 10,
+		},
+	}
+
+	for i, tc := range tests {
+		z := newUpdateZone(t, t.TempDir())
+
+		m := new(dns.Msg)
+		m.SetUpdate("example.org.")
+		tc.update(m)
+		state := request.Request{W: &test.ResponseWriter{}, Req: wire(t, m)}
+
+		if rcode := z.DynamicUpdate(state, ""); rcode != tc.rcode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.rcode], dns.RcodeToString[rcode])
+		}
+		if x := len(z.rrset(tc.name, tc.qtype)); x != tc.expected {
+			t.Errorf("Test %d: expected %d records, got %d", i, tc.expected, x)
+		}
+		if x := z.Apex.SOA.Serial; x != tc.serial {
+			t.Errorf("Test %d: expected serial %d, got %d", i, tc.serial, x)
+		}
+	}
+}
+
+func TestDynamicUpdateRefused(t *testing.T) {
+	z := newUpdateZone(t, t.TempDir())
+
+	m := new(dns.Msg)
+	m.SetUpdate("example.org.")
+	m.Insert([]dns.RR{test.A("b.example.org. 300 IN A 127.0.0.3")})
+
+	state := request.Request{W: &test.ResponseWriter{RemoteIP: "192.0.2.1"}, Req: m}
+	if rcode := z.DynamicUpdate(state, ""); rcode != dns.RcodeRefused {
+		t.Errorf("Expected rcode %s, got %s", dns.RcodeToString[dns.RcodeRefused], dns.RcodeToString[rcode])
+	}
+
+	// Only signed updates from anywhere.
+	all, _ := parseNetwork("*")
+	z.updater.acls = []updateACL{{nets: all, key: "update.example.org."}}
+	if rcode := z.DynamicUpdate(state, ""); rcode != dns.RcodeRefused {
+		t.Errorf("Expected rcode %s for an unsigned update, got %s", dns.RcodeToString[dns.RcodeRefused], dns.RcodeToString[rcode])
+	}
+	if rcode := z.DynamicUpdate(state, "other.example.org."); rcode != dns.RcodeRefused {
+		t.Errorf("Expected rcode %s for an update signed with another key, got %s", dns.RcodeToString[dns.RcodeRefused], dns.RcodeToString[rcode])
+	}
+	if rcode := z.DynamicUpdate(state, "update.example.org."); rcode != dns.RcodeSuccess {
+		t.Errorf("Expected rcode %s for a signed update, got %s", dns.RcodeToString[dns.RcodeSuccess], dns.RcodeToString[rcode])
+	}
+
+	z.updater = nil
+	state = request.Request{W: &test.ResponseWriter{}, Req: m}
+	if rcode := z.DynamicUpdate(state, ""); rcode != dns.RcodeNotImplemented {
+		t.Errorf("Expected rcode %s, got %s", dns.RcodeToString[dns.RcodeNotImplemented], dns.RcodeToString[rcode])
+	}
+}
+
+func TestDynamicUpdateJournal(t *testing.T) {
+	dir := t.TempDir()
+	z := newUpdateZone(t, dir)
+
+	for _, rr := range []dns.RR{test.A("b.example.org. 300 IN A 127.0.0.3"), test.TXT("d.example.org. 300 IN TXT \"hello\"")} {
+		m := new(dns.Msg)
+		m.SetUpdate("example.org.")
+		m.Insert([]dns.RR{rr})
+		m.RemoveRRset([]dns.RR{test.CNAME("c.example.org. 0 IN CNAME a.example.org.")})
+		if rcode := z.DynamicUpdate(request.Request{W: &test.ResponseWriter{}, Req: wire(t, m)}, ""); rcode != dns.RcodeSuccess {
+			t.Fatalf("Expected success, got %s", dns.RcodeToString[rcode])
+		}
+	}
+
+	// A restart reads the old zone file and the journal.
+	z1 := newUpdateZone(t, dir)
+	if err := z1.replay(); err != nil {
+		t.Fatal(err)
+	}
+	if x := z1.Apex.SOA.Serial; x != 12 {
+		t.Errorf("Expected serial %d after replaying the journal, got %d", 12, x)
+	}
+	for _, name := range []string{"b.example.org.", "d.example.org."} {
+		if !z1.inUse(name) {
+			t.Errorf("Expected %s after replaying the journal", name)
+		}
+	}
+	if z1.inUse("c.example.org.") {
+		t.Errorf("Expected c.example.org. to be deleted after replaying the journal")
+	}
+
+	if err := z1.writeBack(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(z1.updater.journal); !os.IsNotExist(err) {
+		t.Errorf("Expected journal to be removed after writing back the zone")
+	}
+	buf, err := ioutil.ReadFile(z1.file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), "\"hello\"") {
+		t.Errorf("Expected the written back zone to contain the update, got:\n%s", buf)
+	}
+
+	z2 := newUpdateZone(t, dir)
+	if x := z2.Apex.SOA.Serial; x != 12 {
+		t.Errorf("Expected serial %d after writing back the zone, got %d", 12, x)
+	}
+	if !z2.inUse("d.example.org.") {
+		t.Errorf("Expected d.example.org. after writing back the zone")
+	}
+}
+
+const dbUpdateTest = `$ORIGIN example.org.
+@	3600 IN	SOA ns.example.org. admin.example.org. 10 7200 3600 1209600 3600
+	3600 IN NS ns1.example.org.
+	3600 IN NS ns2.example.org.
+a	3600 IN A 127.0.0.1
+	3600 IN A 127.0.0.2
+c	3600 IN CNAME a.example.org.
+`
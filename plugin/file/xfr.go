@@ -16,19 +16,41 @@ func (f File) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
 	return z.Transfer(serial)
 }
 
-// Transfer transfers a zone with serial in the returned channel and implements IXFR fallback, by just
-// sending a single SOA record.
+// Transfer transfers a zone with serial in the returned channel. If serial is not zero and the changes since
+// serial are in the history an incremental transfer is done, otherwise it implements IXFR fallback, by just
+// sending a single SOA record when serial is current, or the entire zone.
 func (z *Zone) Transfer(serial uint32) (<-chan []dns.RR, error) {
+	var deltas []*delta
+	if serial != 0 {
+		deltas = z.changesSince(serial)
+	}
 	// get soa and apex
 	apex, err := z.ApexIfDefined()
 	if err != nil {
 		return nil, err
 	}
+	soa := apex[0].(*dns.SOA)
+	if len(deltas) > 0 && deltas[len(deltas)-1].to.Serial != soa.Serial {
+		// The zone changed in the mean time, fallback to a full transfer.
+		deltas = nil
+	}
 
 	ch := make(chan []dns.RR)
 	go func() {
-		if serial != 0 && apex[0].(*dns.SOA).Serial == serial { // ixfr fallback, only send SOA
-			ch <- []dns.RR{apex[0]}
+		if serial != 0 && soa.Serial == serial { // ixfr fallback, only send SOA
+			ch <- []dns.RR{soa}
+
+			close(ch)
+			return
+		}
+
+		if len(deltas) > 0 { // incremental transfer, see RFC 1995, Section 4
+			ch <- []dns.RR{soa}
+			for _, d := range deltas {
+				ch <- append([]dns.RR{d.from}, d.del...)
+				ch <- append([]dns.RR{d.to}, d.add...)
+			}
+			ch <- []dns.RR{soa}
 
 			close(ch)
 			return
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/coredns/coredns/plugin/file/tree"
+	"github.com/coredns/coredns/plugin/pkg/tsig"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 
 	"github.com/miekg/dns"
@@ -26,10 +27,25 @@ type Zone struct {
 
 	StartupOnce  sync.Once
 	TransferFrom []string
+	TransferKey  *tsig.Key // TSIG key to sign transfers and SOA checks with, and that notifies must be signed with
+	BackingFile  string    // file a transferred zone is saved to, and loaded from on startup
+	OnTransfer   func()    // called after each successful transfer, if set
+
+	transferred time.Time // last successful transfer
+	refreshed   time.Time // last successful transfer or check that the zone is up to date
+
+	updateShutdown chan struct{}
+	stopUpdate     sync.Once
 
 	ReloadInterval time.Duration
 	reloadShutdown chan bool
 
+	updater *updater // set when dynamic updates are allowed
+	history []*delta // changes for incremental zone transfers, oldest first
+
+	chainLock sync.Mutex
+	chain     *nsec3Chain // NSEC3 chain of Tree, built on first use
+
 	Upstream *upstream.Upstream // Upstream for looking up external names during the resolution process.
 }
 
@@ -49,6 +65,7 @@ func NewZone(name, file string) *Zone {
 		file:           filepath.Clean(file),
 		Tree:           &tree.Tree{},
 		reloadShutdown: make(chan bool),
+		updateShutdown: make(chan struct{}),
 	}
 }
 
@@ -56,6 +73,8 @@ func NewZone(name, file string) *Zone {
 func (z *Zone) Copy() *Zone {
 	z1 := NewZone(z.origin, z.file)
 	z1.TransferFrom = z.TransferFrom
+	z1.TransferKey = z.TransferKey
+	z1.BackingFile = z.BackingFile
 	z1.Expired = z.Expired
 
 	z1.Apex = z.Apex
@@ -66,6 +85,8 @@ func (z *Zone) Copy() *Zone {
 func (z *Zone) CopyWithoutApex() *Zone {
 	z1 := NewZone(z.origin, z.file)
 	z1.TransferFrom = z.TransferFrom
+	z1.TransferKey = z.TransferKey
+	z1.BackingFile = z.BackingFile
 	z1.Expired = z.Expired
 
 	return z1
@@ -89,8 +110,6 @@ func (z *Zone) Insert(r dns.RR) error {
 
 		z.Apex.SOA = r.(*dns.SOA)
 		return nil
-	case dns.TypeNSEC3, dns.TypeNSEC3PARAM:
-		return fmt.Errorf("NSEC3 zone is not supported, dropping RR: %s for zone: %s", r.Header().Name, z.origin)
 	case dns.TypeRRSIG:
 		x := r.(*dns.RRSIG)
 		switch x.TypeCovered {
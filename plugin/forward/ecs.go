@@ -0,0 +1,138 @@
+package forward
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/coredns/coredns/plugin/pkg/edns"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// ecs holds the EDNS0 Client Subnet (RFC 7871) policy used towards the upstreams.
+type ecs struct {
+	action string // ecsAdd, ecsTruncate or ecsStrip
+	v4     uint8
+	v6     uint8
+}
+
+const (
+	ecsAdd      = "add"
+	ecsTruncate = "truncate"
+	ecsStrip    = "strip"
+)
+
+// Default source prefix lengths, as recommended in RFC 7871, Section 11.1.
+const (
+	defaultECSv4 = 24
+	defaultECSv6 = 56
+)
+
+// request returns a copy of the request in state with the subnet option changed according to e. The
+// original request is not modified. It also returns the subnet option the client sent, if any.
+func (e *ecs) request(state request.Request) (request.Request, *dns.EDNS0_SUBNET) {
+	client := edns.Subnet(state.Req)
+	if client == nil && e.action != ecsAdd {
+		return state, nil
+	}
+
+	req := state.Req.Copy()
+	switch e.action {
+	case ecsStrip:
+		edns.RemoveSubnet(req)
+	case ecsTruncate:
+		edns.TruncateSubnet(edns.Subnet(req), e.v4, e.v6)
+	case ecsAdd:
+		if client != nil {
+			edns.TruncateSubnet(edns.Subnet(req), e.v4, e.v6)
+			break
+		}
+		ip := net.ParseIP(state.IP())
+		if ip == nil {
+			return state, nil
+		}
+		o := req.IsEdns0()
+		if o == nil {
+			req.SetEdns0(dns.MinMsgSize, false)
+			o = req.IsEdns0()
+		}
+		o.Option = append(o.Option, edns.NewSubnet(ip, e.v4, e.v6))
+	}
+
+	return request.Request{W: state.W, Req: req}, client
+}
+
+// reply makes ret, received from an upstream for the (original) request in state, fit the client. The
+// subnet option of the upstream is reported via edns.ReportSubnet so a cache can use its scope.
+func (e *ecs) reply(ctx context.Context, state request.Request, client *dns.EDNS0_SUBNET, ret *dns.Msg) {
+	up := edns.Subnet(ret)
+	if up != nil && e.action != ecsStrip {
+		report := *up
+		edns.ReportSubnet(ctx, &report)
+	}
+
+	if client == nil {
+		// The client didn't ask for it, so it must not be in the reply. If we added the OPT RR as well, remove that too.
+		if state.Req.IsEdns0() == nil {
+			extra := ret.Extra[:0]
+			for _, rr := range ret.Extra {
+				if rr.Header().Rrtype == dns.TypeOPT {
+					continue
+				}
+				extra = append(extra, rr)
+			}
+			ret.Extra = extra
+			return
+		}
+		edns.RemoveSubnet(ret)
+		return
+	}
+
+	// The family, source prefix length and address in the reply must match what the client sent, see
+	// RFC 7871, Section 7.2.1.
+	scope := uint8(0)
+	if up != nil && e.action != ecsStrip {
+		scope = up.SourceScope
+	}
+	edns.RemoveSubnet(ret)
+	if o := ret.IsEdns0(); o != nil {
+		o.Option = append(o.Option, &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: client.Family,
+			SourceNetmask: client.SourceNetmask, SourceScope: scope, Address: client.Address})
+	}
+}
+
+func parseECS(args []string) (*ecs, error) {
+	if len(args) == 0 || len(args) > 3 {
+		return nil, fmt.Errorf("ecs: wrong number of arguments")
+	}
+	e := &ecs{action: args[0], v4: defaultECSv4, v6: defaultECSv6}
+	switch e.action {
+	case ecsStrip:
+		if len(args) > 1 {
+			return nil, fmt.Errorf("ecs: %s takes no prefix lengths", ecsStrip)
+		}
+		return e, nil
+	case ecsAdd, ecsTruncate:
+	default:
+		return nil, fmt.Errorf("ecs: unknown action '%s'", e.action)
+	}
+
+	if len(args) > 1 {
+		n, err := strconv.ParseUint(args[1], 10, 8)
+		if err != nil || n > 32 {
+			return nil, fmt.Errorf("ecs: invalid IPv4 prefix length '%s'", args[1])
+		}
+		e.v4 = uint8(n)
+	}
+	if len(args) > 2 {
+		n, err := strconv.ParseUint(args[2], 10, 8)
+		if err != nil || n > 128 {
+			return nil, fmt.Errorf("ecs: invalid IPv6 prefix length '%s'", args[2])
+		}
+		e.v6 = uint8(n)
+	}
+	return e, nil
+}
@@ -0,0 +1,146 @@
+package forward
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/pkg/edns"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestSetupECS(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		expected  ecs
+	}{
+		{"forward . 127.0.0.1 {\necs add\n}\n", false, ecs{action: ecsAdd, v4: 24, v6: 56}},
+		{"forward . 127.0.0.1 {\necs add 16\n}\n", false, ecs{action: ecsAdd, v4: 16, v6: 56}},
+		{"forward . 127.0.0.1 {\necs truncate 20 48\n}\n", false, ecs{action: ecsTruncate, v4: 20, v6: 48}},
+		{"forward . 127.0.0.1 {\necs strip\n}\n", false, ecs{action: ecsStrip, v4: 24, v6: 56}},
+		// negative
+		{"forward . 127.0.0.1 {\necs\n}\n", true, ecs{}},
+		{"forward . 127.0.0.1 {\necs strip 24\n}\n", true, ecs{}},
+		{"forward . 127.0.0.1 {\necs add 33\n}\n", true, ecs{}},
+		{"forward . 127.0.0.1 {\necs add 24 129\n}\n", true, ecs{}},
+		{"forward . 127.0.0.1 {\necs replace\n}\n", true, ecs{}},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		f, err := parseForward(c)
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: expected error but found none for input %s", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d: expected no error but found one for input %s, got: %v", i, test.input, err)
+		}
+		if *f.ecs != test.expected {
+			t.Errorf("Test %d: expected %v, got %v", i, test.expected, *f.ecs)
+		}
+	}
+}
+
+func TestECS(t *testing.T) {
+	var seen *dns.EDNS0_SUBNET
+	s := dnstest.NewServer(func(w dns.ResponseWriter, r *dns.Msg) {
+		seen = edns.Subnet(r)
+		ret := new(dns.Msg)
+		ret.SetReply(r)
+		ret.Answer = append(ret.Answer, test.A("example.org. IN A 127.0.0.1"))
+		if r.IsEdns0() != nil {
+			ret.SetEdns0(4096, false)
+		}
+		if seen != nil {
+			e := *seen
+			e.SourceScope = 16
+			ret.IsEdns0().Option = append(ret.IsEdns0().Option, &e)
+		}
+		w.WriteMsg(ret)
+	})
+	defer s.Close()
+
+	tests := []struct {
+		action    string
+		clientECS *dns.EDNS0_SUBNET
+		clientDo  bool // client sends an OPT RR
+
+		upstream string // address upstream should see, empty means no ECS
+		reply    string // address client should see in the reply, empty means no ECS
+		scope    uint8  // scope in the reply
+		reported bool
+	}{
+		{action: "add", upstream: "10.240.0.0", reported: true},
+		{action: "add", clientDo: true, upstream: "10.240.0.0", reported: true},
+		{action: "add", clientECS: edns.NewSubnet(net.ParseIP("192.0.2.1"), 32, 128), upstream: "192.0.2.0", reply: "192.0.2.1", scope: 16, reported: true},
+		{action: "truncate", upstream: ""},
+		{action: "truncate", clientECS: edns.NewSubnet(net.ParseIP("192.0.2.1"), 16, 128), upstream: "192.0.0.0", reply: "192.0.0.0", scope: 16, reported: true},
+		{action: "strip", upstream: ""},
+		{action: "strip", clientECS: edns.NewSubnet(net.ParseIP("192.0.2.1"), 32, 128), upstream: "", reply: "192.0.2.1", scope: 0},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", "forward . "+s.Addr+" {\necs "+tc.action+"\n}\n")
+		f, err := parseForward(c)
+		if err != nil {
+			t.Fatalf("Test %d: failed to create forwarder: %s", i, err)
+		}
+		f.OnStartup()
+
+		m := new(dns.Msg)
+		m.SetQuestion("example.org.", dns.TypeA)
+		if tc.clientDo || tc.clientECS != nil {
+			m.SetEdns0(4096, false)
+		}
+		if tc.clientECS != nil {
+			m.IsEdns0().Option = append(m.IsEdns0().Option, tc.clientECS)
+		}
+		orig := m.Copy()
+
+		seen = nil
+		ctx, report := edns.WithSubnetReport(context.TODO())
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := f.ServeDNS(ctx, rec, m); err != nil {
+			t.Fatalf("Test %d: expected to receive reply, but didn't: %s", i, err)
+		}
+		f.OnShutdown()
+
+		if m.String() != orig.String() {
+			t.Errorf("Test %d: expected request not to be modified", i)
+		}
+
+		switch {
+		case tc.upstream == "" && seen != nil:
+			t.Errorf("Test %d: expected no ECS upstream, got %s", i, seen)
+		case tc.upstream != "" && seen == nil:
+			t.Errorf("Test %d: expected ECS upstream, got none", i)
+		case tc.upstream != "" && seen.Address.String() != tc.upstream:
+			t.Errorf("Test %d: expected upstream ECS address %s, got %s", i, tc.upstream, seen.Address)
+		}
+
+		if !tc.clientDo && tc.clientECS == nil && rec.Msg.IsEdns0() != nil {
+			t.Errorf("Test %d: expected no OPT RR in reply", i)
+		}
+		got := edns.Subnet(rec.Msg)
+		switch {
+		case tc.reply == "" && got != nil:
+			t.Errorf("Test %d: expected no ECS in reply, got %s", i, got)
+		case tc.reply != "" && got == nil:
+			t.Errorf("Test %d: expected ECS in reply, got none", i)
+		case tc.reply != "" && (got.Address.String() != tc.reply || got.SourceScope != tc.scope):
+			t.Errorf("Test %d: expected reply ECS %s/%d, got %s/%d", i, tc.reply, tc.scope, got.Address, got.SourceScope)
+		}
+
+		if r := report.Subnet(); tc.reported != (r != nil) {
+			t.Errorf("Test %d: expected reported to be %t, got %v", i, tc.reported, r)
+		}
+	}
+}
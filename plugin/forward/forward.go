@@ -42,6 +42,7 @@ type Forward struct {
 	maxfails      uint32
 	expire        time.Duration
 	maxConcurrent int64
+	ecs           *ecs
 
 	opts options // also here for testing
 
@@ -89,6 +90,12 @@ func (f *Forward) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg
 		}
 	}
 
+	orig := state
+	var clientECS *dns.EDNS0_SUBNET
+	if f.ecs != nil {
+		state, clientECS = f.ecs.request(state)
+	}
+
 	fails := 0
 	var span, child ot.Span
 	var upstreamErr error
@@ -174,11 +181,15 @@ func (f *Forward) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg
 			debug.Hexdumpf(ret, "Wrong reply for id: %d, %s %d", ret.Id, state.QName(), state.QType())
 
 			formerr := new(dns.Msg)
-			formerr.SetRcode(state.Req, dns.RcodeFormatError)
+			formerr.SetRcode(orig.Req, dns.RcodeFormatError)
 			w.WriteMsg(formerr)
 			return 0, nil
 		}
 
+		if f.ecs != nil {
+			f.ecs.reply(ctx, orig, clientECS, ret)
+		}
+
 		w.WriteMsg(ret)
 		return 0, nil
 	}
@@ -258,6 +258,12 @@ func parseBlock(c *caddy.Controller, f *Forward) error {
 		}
 		f.ErrLimitExceeded = errors.New("concurrent queries exceeded maximum " + c.Val())
 		f.maxConcurrent = int64(n)
+	case "ecs":
+		e, err := parseECS(c.RemainingArgs())
+		if err != nil {
+			return err
+		}
+		f.ecs = e
 
 	default:
 		return c.Errf("unknown property '%s'", c.Val())
@@ -44,12 +44,8 @@ func (h Hosts) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (
 			return plugin.NextOrFailure(h.Name(), h.Next, ctx, w, r)
 		}
 		answers = h.ptr(qname, h.options.ttl, names)
-	case dns.TypeA:
-		ips := h.LookupStaticHostV4(qname)
-		answers = a(qname, h.options.ttl, ips)
-	case dns.TypeAAAA:
-		ips := h.LookupStaticHostV6(qname)
-		answers = aaaa(qname, h.options.ttl, ips)
+	default:
+		answers = h.lookup(qname, state.QType())
 	}
 
 	// Only on NXDOMAIN we will fallthrough.
@@ -79,9 +75,42 @@ func (h Hosts) otherRecordsExist(qname string) bool {
 	if len(h.LookupStaticHostV6(qname)) > 0 {
 		return true
 	}
+	for _, qtype := range recordTypes {
+		if len(h.LookupStaticRecords(qname, qtype)) > 0 {
+			return true
+		}
+	}
 	return false
 }
 
+// maxCNAMEs is the maximum number of CNAMEs followed in the hosts file.
+const maxCNAMEs = 8
+
+// lookup returns the records of type qtype for qname. If qname has a CNAME, it is returned instead, followed by
+// the records of its target if these are in the hosts file too.
+func (h Hosts) lookup(qname string, qtype uint16) []dns.RR {
+	answers := []dns.RR{}
+	for i := 0; i < maxCNAMEs; i++ {
+		if qtype != dns.TypeCNAME {
+			if cname := h.LookupStaticRecords(qname, dns.TypeCNAME); len(cname) > 0 {
+				answers = append(answers, cname[0])
+				qname = cname[0].(*dns.CNAME).Target
+				continue
+			}
+		}
+
+		switch qtype {
+		case dns.TypeA:
+			return append(answers, a(qname, h.options.ttl, h.LookupStaticHostV4(qname))...)
+		case dns.TypeAAAA:
+			return append(answers, aaaa(qname, h.options.ttl, h.LookupStaticHostV6(qname))...)
+		default:
+			return append(answers, h.LookupStaticRecords(qname, qtype)...)
+		}
+	}
+	return answers
+}
+
 // Name implements the plugin.Handle interface.
 func (h Hosts) Name() string { return "hosts" }
 
@@ -107,6 +107,42 @@ var hostsTestCases = []test.Case{
 		Qname: "fallthrough-example.org.", Qtype: dns.TypeAAAA,
 		Answer: []dns.RR{}, Rcode: dns.RcodeSuccess,
 	},
+	{
+		Qname: "www.example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.A("example.org. 3600	IN	A 10.0.0.1"),
+			test.CNAME("www.example.org. 3600 IN CNAME example.org."),
+		},
+	},
+	{
+		Qname: "www.example.org.", Qtype: dns.TypeCNAME,
+		Answer: []dns.RR{
+			test.CNAME("www.example.org. 3600 IN CNAME example.org."),
+		},
+	},
+	{
+		// The target isn't in the hosts file.
+		Qname: "alias.example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.CNAME("alias.example.org. 3600 IN CNAME elsewhere.example.net."),
+		},
+	},
+	{
+		Qname: "example.org.", Qtype: dns.TypeTXT,
+		Answer: []dns.RR{
+			test.TXT(`example.org. 3600 IN TXT "v=spf1 -all # not a comment"`),
+		},
+	},
+	{
+		Qname: "_http._tcp.example.org.", Qtype: dns.TypeSRV,
+		Answer: []dns.RR{
+			test.SRV("_http._tcp.example.org. 3600 IN SRV 10 5 80 example.org."),
+		},
+	},
+	{
+		Qname: "_http._tcp.example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{},
+	},
 }
 
 const hostsExample = `
@@ -115,6 +151,11 @@ const hostsExample = `
 10.0.0.1 example.org
 ::FFFF:10.0.0.2 example.com
 10.0.0.3 fallthrough-example.org
+CNAME www.example.org example.org
+cname alias.example.org elsewhere.example.net # a comment
+TXT example.org "v=spf1 -all # not a comment"
+SRV _http._tcp.example.org 10 5 80 example.org
+SRV _broken._tcp.example.org 10 5 example.org
 reload 5s
 timeout 3600
 `
@@ -9,14 +9,19 @@ package hosts
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 	"net"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
+
+	"github.com/miekg/dns"
 )
 
 // parseIP calls discards any v6 zone info, before calling net.ParseIP.
@@ -59,13 +64,17 @@ type Map struct {
 	// including IPv6 address without zone identifier.
 	// We don't support old-classful IP address notation.
 	addr map[string][]string
+
+	// Key for the CNAME, TXT and SRV records must be a FQDN lowercased host name.
+	records map[string][]dns.RR
 }
 
 func newMap() *Map {
 	return &Map{
-		name4: make(map[string][]net.IP),
-		name6: make(map[string][]net.IP),
-		addr:  make(map[string][]string),
+		name4:   make(map[string][]net.IP),
+		name6:   make(map[string][]net.IP),
+		addr:    make(map[string][]string),
+		records: make(map[string][]dns.RR),
 	}
 }
 
@@ -81,9 +90,53 @@ func (h *Map) Len() int {
 	for _, a := range h.addr {
 		l += len(a)
 	}
+	for _, rrs := range h.records {
+		l += len(rrs)
+	}
 	return l
 }
 
+// merge merges m into h. The addresses and records of a name in m replace those of the same type in h.
+// The reverse mapping isn't merged, it's rebuilt with reverse.
+func (h *Map) merge(m *Map) {
+	for name, ips := range m.name4 {
+		h.name4[name] = ips
+	}
+	for name, ips := range m.name6 {
+		h.name6[name] = ips
+	}
+	for name, rrs := range m.records {
+		types := map[uint16]bool{}
+		for _, rr := range rrs {
+			types[rr.Header().Rrtype] = true
+		}
+		var kept []dns.RR
+		for _, rr := range h.records[name] {
+			if !types[rr.Header().Rrtype] {
+				kept = append(kept, rr)
+			}
+		}
+		h.records[name] = append(kept, rrs...)
+	}
+}
+
+// reverse rebuilds the reverse mapping from the addresses of the names.
+func (h *Map) reverse() {
+	h.addr = make(map[string][]string)
+	for _, m := range []map[string][]net.IP{h.name4, h.name6} {
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, ip := range m[name] {
+				h.addr[ip.String()] = append(h.addr[ip.String()], name)
+			}
+		}
+	}
+}
+
 // Hostsfile contains known host entries.
 type Hostsfile struct {
 	sync.RWMutex
@@ -97,52 +150,124 @@ type Hostsfile struct {
 	// inline saves the hosts file that is inlined in a Corefile.
 	inline *Map
 
-	// path to the hosts file
+	// path to the hosts file, or to a directory of hosts files
 	path string
 
-	// mtime and size are only read and modified by a single goroutine
-	mtime time.Time
-	size  int64
+	// stats are only read and modified by a single goroutine
+	stats []fileStat
 
 	options *options
 }
 
-// readHosts determines if the cached data needs to be updated based on the size and modification time of the hostsfile.
+// fileStat is the size and modification time of a hosts file, to tell if it changed.
+type fileStat struct {
+	path  string
+	mtime time.Time
+	size  int64
+}
+
+// readHosts determines if the cached data needs to be updated based on the size and modification time of the hostsfile,
+// or of the files in it if it is a directory.
 func (h *Hostsfile) readHosts() {
-	file, err := os.Open(h.path)
+	stats, err := h.fileStats()
 	if err != nil {
 		// We already log a warning if the file doesn't exist or can't be opened on setup. No need to return the error here.
 		return
 	}
-	defer file.Close()
-
-	stat, err := file.Stat()
-	if err != nil {
+	if equalStats(h.stats, stats) {
 		return
 	}
-	h.RLock()
-	size := h.size
-	h.RUnlock()
 
-	if h.mtime.Equal(stat.ModTime()) && size == stat.Size() {
-		return
+	var hmap *Map
+	if len(stats) == 1 && stats[0].path == h.path {
+		hmap, err = h.parseFile(h.path)
+		if err != nil {
+			return
+		}
+	} else {
+		// The files in the directory are merged in lexical order, so a later file takes precedence.
+		hmap = newMap()
+		for _, s := range stats {
+			m, err := h.parseFile(s.path)
+			if err != nil {
+				return
+			}
+			hmap.merge(m)
+		}
+		if h.options.autoReverse {
+			hmap.reverse()
+		}
 	}
+	log.Debugf("Parsed hosts file into %d entries", hmap.Len())
 
-	newMap := h.parse(file)
-	log.Debugf("Parsed hosts file into %d entries", newMap.Len())
+	var mtime time.Time
+	for _, s := range stats {
+		if s.mtime.After(mtime) {
+			mtime = s.mtime
+		}
+	}
 
 	h.Lock()
 
-	h.hmap = newMap
+	h.hmap = hmap
 	// Update the data cache.
-	h.mtime = stat.ModTime()
-	h.size = stat.Size()
+	h.stats = stats
 
 	hostsEntries.WithLabelValues().Set(float64(h.inline.Len() + h.hmap.Len()))
-	hostsReloadTime.Set(float64(stat.ModTime().UnixNano()) / 1e9)
+	hostsReloadTime.Set(float64(mtime.UnixNano()) / 1e9)
 	h.Unlock()
 }
 
+// fileStats returns the stats of the hosts file, or of the files in it if it is a directory. Files in the
+// directory starting with a dot are skipped, as are directories.
+func (h *Hostsfile) fileStats() ([]fileStat, error) {
+	info, err := os.Stat(h.path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []fileStat{{path: h.path, mtime: info.ModTime(), size: info.Size()}}, nil
+	}
+
+	entries, err := os.ReadDir(h.path)
+	if err != nil {
+		return nil, err
+	}
+	stats := []fileStat{}
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, fileStat{path: filepath.Join(h.path, e.Name()), mtime: info.ModTime(), size: info.Size()})
+	}
+	return stats, nil
+}
+
+func equalStats(a, b []fileStat) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].path != b[i].path || !a[i].mtime.Equal(b[i].mtime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *Hostsfile) parseFile(path string) (*Map, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return h.parse(file), nil
+}
+
 func (h *Hostsfile) initInline(inline []string) {
 	if len(inline) == 0 {
 		return
@@ -157,15 +282,24 @@ func (h *Hostsfile) parse(r io.Reader) *Map {
 
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		line := scanner.Bytes()
-		if i := bytes.Index(line, []byte{'#'}); i >= 0 {
-			// Discard comments.
-			line = line[0:i]
-		}
+		line := stripComment(scanner.Bytes())
 		f := bytes.Fields(line)
 		if len(f) < 2 {
 			continue
 		}
+		if typ, ok := recordTypes[strings.ToUpper(string(f[0]))]; ok {
+			rr, err := h.parseRecord(typ, line)
+			if err != nil {
+				log.Warningf("Failed to parse %q: %s", line, err)
+				continue
+			}
+			if rr == nil {
+				continue
+			}
+			name := rr.Header().Name
+			hmap.records[name] = append(hmap.records[name], rr)
+			continue
+		}
 		addr := parseIP(string(f[0]))
 		if addr == nil {
 			continue
@@ -202,6 +336,58 @@ func (h *Hostsfile) parse(r io.Reader) *Map {
 	return hmap
 }
 
+// recordTypes are the types of records that can be defined with a line of the form: TYPE NAME DATA.
+var recordTypes = map[string]uint16{
+	"CNAME": dns.TypeCNAME,
+	"TXT":   dns.TypeTXT,
+	"SRV":   dns.TypeSRV,
+}
+
+// parseRecord parses a line of the form TYPE NAME DATA, where DATA is the data of the record in the
+// zone file format. It returns nil if the name isn't in Origins.
+func (h *Hostsfile) parseRecord(typ uint16, line []byte) (dns.RR, error) {
+	f := strings.Fields(string(line))
+	if len(f) < 3 {
+		return nil, fmt.Errorf("no data for %s", f[0])
+	}
+	name := plugin.Name(f[1]).Normalize()
+	if plugin.Zones(h.Origins).Matches(name) == "" {
+		return nil, nil
+	}
+
+	// The data is what follows the name, parsed by the dns package.
+	data := strings.TrimSpace(string(line))
+	data = strings.TrimSpace(data[len(f[0]):])
+	data = strings.TrimSpace(data[len(f[1]):])
+
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", name, h.options.ttl, dns.TypeToString[typ], data))
+	if err != nil {
+		return nil, err
+	}
+	if cname, ok := rr.(*dns.CNAME); ok {
+		cname.Target = strings.ToLower(cname.Target)
+	}
+	return rr, nil
+}
+
+// stripComment discards the comment in line, a # that isn't in a quoted string starts it.
+func stripComment(line []byte) []byte {
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = !quoted
+		case '#':
+			if !quoted {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
 // lookupStaticHost looks up the IP addresses for the given host from the hosts file.
 func (h *Hostsfile) lookupStaticHost(m map[string][]net.IP, host string) []net.IP {
 	h.RLock()
@@ -236,6 +422,25 @@ func (h *Hostsfile) LookupStaticHostV6(host string) []net.IP {
 	return append(ip1, ip2...)
 }
 
+// LookupStaticRecords looks up the records of type qtype for the given host from the hosts file. These are
+// copies, the caller may modify them.
+func (h *Hostsfile) LookupStaticRecords(host string, qtype uint16) []dns.RR {
+	host = strings.ToLower(host)
+
+	h.RLock()
+	defer h.RUnlock()
+
+	var rrs []dns.RR
+	for _, m := range []*Map{h.hmap, h.inline} {
+		for _, rr := range m.records[host] {
+			if rr.Header().Rrtype == qtype {
+				rrs = append(rrs, dns.Copy(rr))
+			}
+		}
+	}
+	return rrs
+}
+
 // LookupStaticAddr looks up the hosts for the given address from the hosts file.
 func (h *Hostsfile) LookupStaticAddr(addr string) []string {
 	addr = parseIP(addr).String()
@@ -6,11 +6,15 @@ package hosts
 
 import (
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/coredns/coredns/plugin"
+
+	"github.com/miekg/dns"
 )
 
 func testHostsfile(file string) *Hostsfile {
@@ -239,3 +243,49 @@ func TestHostCacheModification(t *testing.T) {
 	}
 	testStaticAddr(t, entip, h)
 }
+
+func TestReadHostsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("10-base", "10.0.0.1 example.org www.example.org\nTXT example.org base\nSRV _http._tcp.example.org 10 5 80 example.org\n")
+	write("20-local", "10.0.0.2 www.example.org\nTXT example.org local\n")
+	write(".hidden", "10.0.0.3 example.org\n")
+
+	h := testHostsfile("")
+	h.path = dir
+	h.readHosts()
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"example.org.", []string{"10.0.0.1"}},
+		// The later file takes precedence.
+		{"www.example.org.", []string{"10.0.0.2"}},
+	}
+	for _, tc := range tests {
+		testStaticHost(t, staticHostEntry{tc.name, tc.expected, nil}, h)
+	}
+	if txt := h.LookupStaticRecords("example.org.", dns.TypeTXT); len(txt) != 1 || txt[0].(*dns.TXT).Txt[0] != "local" {
+		t.Errorf("Expected the TXT record of the later file, got %v", txt)
+	}
+	if srv := h.LookupStaticRecords("example.org.", dns.TypeSRV); len(srv) != 0 {
+		t.Errorf("Expected no SRV record for example.org., got %v", srv)
+	}
+	if srv := h.LookupStaticRecords("_http._tcp.example.org.", dns.TypeSRV); len(srv) != 1 {
+		t.Errorf("Expected the SRV record of the earlier file, got %v", srv)
+	}
+	testStaticAddr(t, staticIPEntry{"10.0.0.1", []string{"example.org."}}, h)
+	testStaticAddr(t, staticIPEntry{"10.0.0.2", []string{"www.example.org."}}, h)
+
+	// A file is removed.
+	if err := os.Remove(filepath.Join(dir, "20-local")); err != nil {
+		t.Fatal(err)
+	}
+	h.readHosts()
+	testStaticHost(t, staticHostEntry{"www.example.org.", []string{"10.0.0.1"}, nil}, h)
+}
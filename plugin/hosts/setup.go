@@ -102,7 +102,7 @@ func hostsParse(c *caddy.Controller) (Hosts, error) {
 				}
 			}
 			if s != nil && s.IsDir() {
-				log.Warningf("Hosts file %q is a directory", h.path)
+				log.Infof("Reading the hosts files in directory %q", h.path)
 			}
 		}
 
@@ -142,7 +142,7 @@ func hostsParse(c *caddy.Controller) (Hosts, error) {
 				h.options.reload = reload
 			default:
 				if len(h.Fall.Zones) == 0 {
-					line := strings.Join(append([]string{c.Val()}, c.RemainingArgs()...), " ")
+					line := inlineLine(append([]string{c.Val()}, c.RemainingArgs()...))
 					inline = append(inline, line)
 					continue
 				}
@@ -155,3 +155,14 @@ func hostsParse(c *caddy.Controller) (Hosts, error) {
 
 	return h, nil
 }
+
+// inlineLine joins the tokens of an inlined line. The quotes around a token are removed by the Corefile parser,
+// they are put back when it has spaces, as in the text of a TXT record.
+func inlineLine(tokens []string) string {
+	for i, t := range tokens {
+		if strings.ContainsAny(t, " \t") {
+			tokens[i] = `"` + t + `"`
+		}
+	}
+	return strings.Join(tokens, " ")
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/plugin/pkg/fall"
+
+	"github.com/miekg/dns"
 )
 
 func TestHostsParse(t *testing.T) {
@@ -167,3 +169,21 @@ func TestHostsInlineParse(t *testing.T) {
 		}
 	}
 }
+
+func TestHostsInlineRecords(t *testing.T) {
+	c := caddy.NewTestController("dns", `hosts highly_unlikely_to_exist_hosts_file example.org {
+		TXT example.org "v=spf1 -all"
+		CNAME www.example.org example.org
+	}`)
+	h, err := hostsParse(c)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got '%v'", err)
+	}
+	txt := h.LookupStaticRecords("example.org.", dns.TypeTXT)
+	if len(txt) != 1 || len(txt[0].(*dns.TXT).Txt) != 1 || txt[0].(*dns.TXT).Txt[0] != "v=spf1 -all" {
+		t.Errorf("Expected one TXT record with one string, got %v", txt)
+	}
+	if cname := h.LookupStaticRecords("www.example.org.", dns.TypeCNAME); len(cname) != 1 {
+		t.Errorf("Expected a CNAME record, got %v", cname)
+	}
+}
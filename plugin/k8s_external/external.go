@@ -3,7 +3,8 @@ Package external implements external names for kubernetes clusters.
 
 This plugin only handles three qtypes (except the apex queries, because those are handled
 differently). We support A, AAAA and SRV request, for all other types we return NODATA or
-NXDOMAIN depending on the state of the cluster.
+NXDOMAIN depending on the state of the cluster. The hostnames of Ingress and Gateway API
+resources, if enabled, only have A and AAAA records.
 
 A plugin willing to provide these services must implement the Externaler interface, although it
 likely only makes sense for the *kubernetes* plugin.
@@ -31,6 +32,17 @@ type Externaler interface {
 	ExternalAddress(state request.Request) []dns.RR
 }
 
+// ExternalHoster defines the interface that a plugin should implement in order to publish the hostnames
+// of Ingress and Gateway API resources through External.
+type ExternalHoster interface {
+	// WatchExternalHosts is called before the plugin is started, with the kinds of resources whose
+	// hostnames should be published: "ingress" or "gateway".
+	WatchExternalHosts(kinds []string)
+	// ExternalHosts returns a slice of msg.Services with the addresses of the resources that declare
+	// the name of the request as a hostname.
+	ExternalHosts(request.Request) ([]msg.Service, int)
+}
+
 // External resolves Ingress and Loadbalance IPs from kubernetes clusters.
 type External struct {
 	Next  plugin.Handler
@@ -39,11 +51,14 @@ type External struct {
 	hostmaster string
 	apex       string
 	ttl        uint32
+	hostKinds  []string // kinds of resources whose hostnames are published
 
 	upstream *upstream.Upstream
 
 	externalFunc     func(request.Request) ([]msg.Service, int)
 	externalAddrFunc func(request.Request) []dns.RR
+
+	externalHostsFunc func(request.Request) ([]msg.Service, int)
 }
 
 // New returns a new and initialized *External.
@@ -79,7 +94,18 @@ func (e *External) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 		}
 	}
 
-	svc, rcode := e.externalFunc(state)
+	host := false
+	var (
+		svc   []msg.Service
+		rcode int
+	)
+	if e.externalHostsFunc != nil {
+		svc, rcode = e.externalHostsFunc(state)
+		host = len(svc) > 0
+	}
+	if !host {
+		svc, rcode = e.externalFunc(state)
+	}
 
 	m := new(dns.Msg)
 	m.SetReply(state.Req)
@@ -97,7 +123,9 @@ func (e *External) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 	case dns.TypeAAAA:
 		m.Answer = e.aaaa(ctx, svc, state)
 	case dns.TypeSRV:
-		m.Answer, m.Extra = e.srv(svc, state)
+		if !host {
+			m.Answer, m.Extra = e.srv(svc, state)
+		}
 	default:
 		m.Ns = []dns.RR{e.soa(state)}
 	}
@@ -24,6 +24,7 @@ func TestExternal(t *testing.T) {
 	e.Next = test.NextHandler(dns.RcodeSuccess, nil)
 	e.externalFunc = k.External
 	e.externalAddrFunc = externalAddress // internal test function
+	e.externalHostsFunc = k.ExternalHosts
 
 	ctx := context.TODO()
 	for i, tc := range tests {
@@ -159,6 +160,41 @@ var tests = []test.Case{
 			test.CNAME("svc12.testns.example.com.	5	IN	CNAME	dummy.hostname"),
 		},
 	},
+	// Ingress hostname
+	{
+		Qname: "app.example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("app.example.com.	5	IN	A	1.2.3.5"),
+		},
+	},
+	// No SRV records for hostnames
+	{
+		Qname: "app.example.com.", Qtype: dns.TypeSRV, Rcode: dns.RcodeSuccess,
+		Ns: []dns.RR{
+			test.SOA("example.com.	5	IN	SOA	ns1.dns.example.com. hostmaster.example.com. 1499347823 7200 1800 86400 5"),
+		},
+	},
+	// Wildcard Gateway listener hostname
+	{
+		Qname: "shop.web.example.com.", Qtype: dns.TypeAAAA, Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.AAAA("shop.web.example.com.	5	IN	AAAA	1:2::6"),
+		},
+	},
+	// Load balancer with a hostname
+	{
+		Qname: "lb.example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.CNAME("lb.example.com.	5	IN	CNAME	dummy.hostname"),
+		},
+	},
+	// Hostname in a namespace that isn't exposed
+	{
+		Qname: "hidden.example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("example.com.	5	IN	SOA	ns1.dns.example.com. hostmaster.example.com. 1499347823 7200 1800 86400 5"),
+		},
+	},
 }
 
 type external struct{}
@@ -167,6 +203,10 @@ func (external) HasSynced() bool
 func (external) Run()                                                              {}
 func (external) Stop() error                                                       { return nil }
 func (external) EpIndexReverse(string) []*object.Endpoints                         { return nil }
+func (external) NodeZone(string) string                                            { return "" }
+func (external) MCSvcIndex(string) []*object.Service                               { return nil }
+func (external) MCEpIndex(string) []*object.Endpoints                              { return nil }
+func (external) HostIndex(s string) []*object.Host                                 { return hostIndexExternal[s] }
 func (external) SvcIndexReverse(string) []*object.Service                          { return nil }
 func (external) Modified() int64                                                   { return 0 }
 func (external) EpIndex(s string) []*object.Endpoints                              { return nil }
@@ -222,6 +262,41 @@ var svcIndexExternal = map[string][]*object.Service{
 	},
 }
 
+var hostIndexExternal = map[string][]*object.Host{
+	"app.example.com.": {
+		{
+			Name:      "app",
+			Namespace: "testns",
+			Hostnames: []string{"app.example.com."},
+			Addresses: []string{"1.2.3.5"},
+		},
+	},
+	"*.web.example.com.": {
+		{
+			Name:      "web",
+			Namespace: "testns",
+			Hostnames: []string{"*.web.example.com."},
+			Addresses: []string{"1:2::6"},
+		},
+	},
+	"lb.example.com.": {
+		{
+			Name:      "lb",
+			Namespace: "testns",
+			Hostnames: []string{"lb.example.com."},
+			Addresses: []string{"dummy.hostname"},
+		},
+	},
+	"hidden.example.com.": {
+		{
+			Name:      "hidden",
+			Namespace: "other",
+			Hostnames: []string{"hidden.example.com."},
+			Addresses: []string{"1.2.3.6"},
+		},
+	},
+}
+
 func (external) ServiceList() []*object.Service {
 	var svcs []*object.Service
 	for _, svc := range svcIndexExternal {
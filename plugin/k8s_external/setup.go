@@ -1,6 +1,7 @@
 package external
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/coredns/caddy"
@@ -27,6 +28,16 @@ func setup(c *caddy.Controller) error {
 			e.externalFunc = x.External
 			e.externalAddrFunc = x.ExternalAddress
 		}
+		if len(e.hostKinds) == 0 {
+			return nil
+		}
+		x, ok := m.(ExternalHoster)
+		if !ok {
+			return plugin.Error("k8s_external", fmt.Errorf("%s can't publish hostnames", m.Name()))
+		}
+		// This runs before kubernetes is started, as k8s_external comes first in plugin.cfg.
+		x.WatchExternalHosts(e.hostKinds)
+		e.externalHostsFunc = x.ExternalHosts
 		return nil
 	})
 
@@ -66,6 +77,17 @@ func parse(c *caddy.Controller) (*External, error) {
 					return nil, c.ArgErr()
 				}
 				e.apex = args[0]
+			case "hostnames":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				for _, a := range args {
+					if a != "ingress" && a != "gateway" {
+						return nil, c.Errf("unknown resource '%s'", a)
+					}
+					e.hostKinds = append(e.hostKinds, a)
+				}
 			default:
 				return nil, c.Errf("unknown property '%s'", c.Val())
 			}
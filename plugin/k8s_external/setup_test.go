@@ -18,6 +18,15 @@ func TestSetup(t *testing.T) {
 		{`k8s_external example.org {
 			apex testdns
 }`, false, "example.org.", "testdns"},
+		{`k8s_external example.org {
+			hostnames ingress gateway
+}`, false, "example.org.", "dns"},
+		{`k8s_external example.org {
+			hostnames
+}`, true, "", ""},
+		{`k8s_external example.org {
+			hostnames service
+}`, true, "", ""},
 	}
 
 	for i, test := range tests {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,10 +14,15 @@ import (
 	api "k8s.io/api/core/v1"
 	discovery "k8s.io/api/discovery/v1"
 	discoveryV1beta1 "k8s.io/api/discovery/v1beta1"
+	networking "k8s.io/api/networking/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
@@ -27,6 +33,7 @@ const (
 	svcIPIndex            = "ServiceIP"
 	epNameNamespaceIndex  = "EndpointNameNamespace"
 	epIPIndex             = "EndpointsIP"
+	hostnameIndex         = "Hostname"
 )
 
 type dnsController interface {
@@ -37,8 +44,12 @@ type dnsController interface {
 	PodIndex(string) []*object.Pod
 	EpIndex(string) []*object.Endpoints
 	EpIndexReverse(string) []*object.Endpoints
+	MCSvcIndex(string) []*object.Service
+	MCEpIndex(string) []*object.Endpoints
+	HostIndex(string) []*object.Host
 
 	GetNodeByName(context.Context, string) (*api.Node, error)
+	NodeZone(string) string
 	GetNamespaceByName(string) (*object.Namespace, error)
 
 	Run()
@@ -74,6 +85,25 @@ type dnsControl struct {
 	epLister  cache.Indexer
 	nsLister  cache.Store
 
+	// Nodes, if the zones of the nodes are needed.
+	nodeController cache.Controller
+	nodeLister     cache.Store
+
+	// ServiceImports and the EndpointSlices imported from other clusters, if multi-cluster services are
+	// enabled.
+	mcSvcController cache.Controller
+	mcEpController  cache.Controller
+	mcSvcLister     cache.Indexer
+	mcEpLister      cache.Indexer
+
+	// Ingresses, Gateways and HTTPRoutes, if their hostnames are published.
+	ingressController cache.Controller
+	gatewayController cache.Controller
+	routeController   cache.Controller
+	ingressLister     cache.Indexer
+	gatewayLister     cache.Indexer
+	routeLister       cache.Indexer
+
 	// stopLock is used to enforce only a single call to Stop is active.
 	// Needed because we allow stopping through an http endpoint and
 	// allowing concurrent stoppers leads to stack traces.
@@ -88,6 +118,7 @@ type dnsControl struct {
 type dnsControlOpts struct {
 	initPodCache       bool
 	initEndpointsCache bool
+	initNodeCache      bool
 	ignoreEmptyService bool
 
 	// Label handling.
@@ -161,6 +192,19 @@ func newdnsController(ctx context.Context, kubeClient kubernetes.Interface, opts
 		object.DefaultProcessor(object.ToNamespace, nil),
 	)
 
+	if opts.initNodeCache {
+		dns.nodeLister, dns.nodeController = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  nodeListFunc(ctx, dns.client),
+				WatchFunc: nodeWatchFunc(ctx, dns.client),
+			},
+			&api.Node{},
+			cache.ResourceEventHandlerFuncs{},
+			cache.Indexers{},
+			object.DefaultProcessor(object.ToNode, nil),
+		)
+	}
+
 	return &dns
 }
 
@@ -200,6 +244,79 @@ func (dns *dnsControl) WatchEndpointSliceV1beta1(ctx context.Context) {
 	dns.epLock.Unlock()
 }
 
+// WatchMultiCluster sets up the watches on the ServiceImports and on the EndpointSlices imported from other
+// clusters, of the Multi-Cluster Services API.
+func (dns *dnsControl) WatchMultiCluster(ctx context.Context, client dynamic.Interface) {
+	dns.mcSvcLister, dns.mcSvcController = object.NewIndexerInformer(
+		&cache.ListWatch{
+			ListFunc:  dynamicListFunc(ctx, client, object.ServiceImportResource, api.NamespaceAll, dns.selector),
+			WatchFunc: dynamicWatchFunc(ctx, client, object.ServiceImportResource, api.NamespaceAll, dns.selector),
+		},
+		&unstructured.Unstructured{},
+		cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+		cache.Indexers{svcNameNamespaceIndex: svcNameNamespaceIndexFunc},
+		object.DefaultProcessor(object.ToServiceImport, nil),
+	)
+
+	// Only the EndpointSlices that belong to a ServiceImport.
+	selector := labels.NewSelector()
+	if dns.selector != nil {
+		reqs, _ := dns.selector.Requirements()
+		selector = selector.Add(reqs...)
+	}
+	req, _ := labels.NewRequirement(object.LabelMultiClusterServiceName, selection.Exists, nil)
+	selector = selector.Add(*req)
+
+	dns.mcEpLister, dns.mcEpController = object.NewIndexerInformer(
+		&cache.ListWatch{
+			ListFunc:  endpointSliceListFunc(ctx, dns.client, api.NamespaceAll, selector),
+			WatchFunc: endpointSliceWatchFunc(ctx, dns.client, api.NamespaceAll, selector),
+		},
+		&discovery.EndpointSlice{},
+		cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+		cache.Indexers{epNameNamespaceIndex: epNameNamespaceIndexFunc},
+		object.DefaultProcessor(object.MultiClusterEndpointSliceToEndpoints, nil),
+	)
+}
+
+// WatchIngresses sets up the watch on the Ingresses.
+func (dns *dnsControl) WatchIngresses(ctx context.Context) {
+	dns.ingressLister, dns.ingressController = object.NewIndexerInformer(
+		&cache.ListWatch{
+			ListFunc:  ingressListFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+			WatchFunc: ingressWatchFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+		},
+		&networking.Ingress{},
+		cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+		cache.Indexers{hostnameIndex: hostnameIndexFunc},
+		object.DefaultProcessor(object.ToIngress, nil),
+	)
+}
+
+// WatchGateways sets up the watches on the Gateways and HTTPRoutes of the Gateway API.
+func (dns *dnsControl) WatchGateways(ctx context.Context, client dynamic.Interface) {
+	dns.gatewayLister, dns.gatewayController = object.NewIndexerInformer(
+		&cache.ListWatch{
+			ListFunc:  dynamicListFunc(ctx, client, object.GatewayResource, api.NamespaceAll, dns.selector),
+			WatchFunc: dynamicWatchFunc(ctx, client, object.GatewayResource, api.NamespaceAll, dns.selector),
+		},
+		&unstructured.Unstructured{},
+		cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+		cache.Indexers{hostnameIndex: hostnameIndexFunc},
+		object.DefaultProcessor(object.ToGateway, nil),
+	)
+	dns.routeLister, dns.routeController = object.NewIndexerInformer(
+		&cache.ListWatch{
+			ListFunc:  dynamicListFunc(ctx, client, object.HTTPRouteResource, api.NamespaceAll, dns.selector),
+			WatchFunc: dynamicWatchFunc(ctx, client, object.HTTPRouteResource, api.NamespaceAll, dns.selector),
+		},
+		&unstructured.Unstructured{},
+		cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+		cache.Indexers{hostnameIndex: hostnameIndexFunc},
+		object.DefaultProcessor(object.ToHTTPRoute, nil),
+	)
+}
+
 func (dns *dnsControl) EndpointsLatencyRecorder() *object.EndpointLatencyRecorder {
 	return &object.EndpointLatencyRecorder{
 		ServiceFunc: func(o meta.Object) []*object.Service {
@@ -261,6 +378,14 @@ func epIPIndexFunc(obj interface{}) ([]string, error) {
 	return ep.IndexIP, nil
 }
 
+func hostnameIndexFunc(obj interface{}) ([]string, error) {
+	h, ok := obj.(*object.Host)
+	if !ok {
+		return nil, errObj
+	}
+	return h.Hostnames, nil
+}
+
 func serviceListFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
 	return func(opts meta.ListOptions) (runtime.Object, error) {
 		if s != nil {
@@ -309,6 +434,30 @@ func endpointsListFunc(ctx context.Context, c kubernetes.Interface, ns string, s
 	}
 }
 
+func ingressListFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		if s != nil {
+			opts.LabelSelector = s.String()
+		}
+		return c.NetworkingV1().Ingresses(ns).List(ctx, opts)
+	}
+}
+
+func dynamicListFunc(ctx context.Context, c dynamic.Interface, r schema.GroupVersionResource, ns string, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		if s != nil {
+			opts.LabelSelector = s.String()
+		}
+		return c.Resource(r).Namespace(ns).List(ctx, opts)
+	}
+}
+
+func nodeListFunc(ctx context.Context, c kubernetes.Interface) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		return c.CoreV1().Nodes().List(ctx, opts)
+	}
+}
+
 func namespaceListFunc(ctx context.Context, c kubernetes.Interface, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
 	return func(opts meta.ListOptions) (runtime.Object, error) {
 		if s != nil {
@@ -367,6 +516,24 @@ func endpointsWatchFunc(ctx context.Context, c kubernetes.Interface, ns string,
 	}
 }
 
+func ingressWatchFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		if s != nil {
+			options.LabelSelector = s.String()
+		}
+		return c.NetworkingV1().Ingresses(ns).Watch(ctx, options)
+	}
+}
+
+func dynamicWatchFunc(ctx context.Context, c dynamic.Interface, r schema.GroupVersionResource, ns string, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		if s != nil {
+			options.LabelSelector = s.String()
+		}
+		return c.Resource(r).Namespace(ns).Watch(ctx, options)
+	}
+}
+
 func namespaceWatchFunc(ctx context.Context, c kubernetes.Interface, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
 	return func(options meta.ListOptions) (watch.Interface, error) {
 		if s != nil {
@@ -376,6 +543,12 @@ func namespaceWatchFunc(ctx context.Context, c kubernetes.Interface, s labels.Se
 	}
 }
 
+func nodeWatchFunc(ctx context.Context, c kubernetes.Interface) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		return c.CoreV1().Nodes().Watch(ctx, options)
+	}
+}
+
 // Stop stops the  controller.
 func (dns *dnsControl) Stop() error {
 	dns.stopLock.Lock()
@@ -405,6 +578,20 @@ func (dns *dnsControl) Run() {
 	if dns.podController != nil {
 		go dns.podController.Run(dns.stopCh)
 	}
+	if dns.mcSvcController != nil {
+		go dns.mcSvcController.Run(dns.stopCh)
+		go dns.mcEpController.Run(dns.stopCh)
+	}
+	if dns.ingressController != nil {
+		go dns.ingressController.Run(dns.stopCh)
+	}
+	if dns.gatewayController != nil {
+		go dns.gatewayController.Run(dns.stopCh)
+		go dns.routeController.Run(dns.stopCh)
+	}
+	if dns.nodeController != nil {
+		go dns.nodeController.Run(dns.stopCh)
+	}
 	go dns.nsController.Run(dns.stopCh)
 	<-dns.stopCh
 }
@@ -423,7 +610,23 @@ func (dns *dnsControl) HasSynced() bool {
 		c = dns.podController.HasSynced()
 	}
 	d := dns.nsController.HasSynced()
-	return a && b && c && d
+	e := true
+	if dns.mcSvcController != nil {
+		e = dns.mcSvcController.HasSynced() && dns.mcEpController.HasSynced()
+	}
+	f := true
+	if dns.ingressController != nil {
+		f = dns.ingressController.HasSynced()
+	}
+	g := true
+	if dns.gatewayController != nil {
+		g = dns.gatewayController.HasSynced() && dns.routeController.HasSynced()
+	}
+	h := true
+	if dns.nodeController != nil {
+		h = dns.nodeController.HasSynced()
+	}
+	return a && b && c && d && e && f && g && h
 }
 
 func (dns *dnsControl) ServiceList() (svcs []*object.Service) {
@@ -532,6 +735,91 @@ func (dns *dnsControl) EpIndexReverse(ip string) (ep []*object.Endpoints) {
 	return ep
 }
 
+// MCSvcIndex returns the ServiceImports with the name and namespace in idx.
+func (dns *dnsControl) MCSvcIndex(idx string) (svcs []*object.Service) {
+	if dns.mcSvcLister == nil {
+		return nil
+	}
+	os, err := dns.mcSvcLister.ByIndex(svcNameNamespaceIndex, idx)
+	if err != nil {
+		return nil
+	}
+	for _, o := range os {
+		s, ok := o.(*object.Service)
+		if !ok {
+			continue
+		}
+		svcs = append(svcs, s)
+	}
+	return svcs
+}
+
+// MCEpIndex returns the endpoints imported from other clusters for the ServiceImport with the name and
+// namespace in idx.
+func (dns *dnsControl) MCEpIndex(idx string) (ep []*object.Endpoints) {
+	if dns.mcEpLister == nil {
+		return nil
+	}
+	os, err := dns.mcEpLister.ByIndex(epNameNamespaceIndex, idx)
+	if err != nil {
+		return nil
+	}
+	for _, o := range os {
+		e, ok := o.(*object.Endpoints)
+		if !ok {
+			continue
+		}
+		ep = append(ep, e)
+	}
+	return ep
+}
+
+// HostIndex returns the Ingresses, Gateways and HTTPRoutes that declare hostname. The HTTPRoutes get the
+// addresses of the Gateways they are attached to.
+func (dns *dnsControl) HostIndex(hostname string) (hosts []*object.Host) {
+	for _, l := range []cache.Indexer{dns.ingressLister, dns.gatewayLister} {
+		if l == nil {
+			continue
+		}
+		os, err := l.ByIndex(hostnameIndex, hostname)
+		if err != nil {
+			continue
+		}
+		for _, o := range os {
+			h, ok := o.(*object.Host)
+			if !ok {
+				continue
+			}
+			hosts = append(hosts, h)
+		}
+	}
+	if dns.routeLister == nil {
+		return hosts
+	}
+	os, err := dns.routeLister.ByIndex(hostnameIndex, hostname)
+	if err != nil {
+		return hosts
+	}
+	for _, o := range os {
+		r, ok := o.(*object.Host)
+		if !ok {
+			continue
+		}
+		h := &object.Host{Name: r.Name, Namespace: r.Namespace, Hostnames: r.Hostnames}
+		for _, key := range r.Parents {
+			o, exists, err := dns.gatewayLister.GetByKey(key)
+			if err != nil || !exists {
+				continue
+			}
+			if gw, ok := o.(*object.Host); ok {
+				h.Addresses = append(h.Addresses, gw.Addresses...)
+			}
+		}
+		hosts = append(hosts, h)
+	}
+	return hosts
+}
+
 // GetNodeByName return the node by name. If nothing is found an error is
 // returned. This query causes a roundtrip to the k8s API server, so use
 // sparingly. Currently this is only used for Federation.
@@ -556,6 +844,22 @@ func (dns *dnsControl) GetNamespaceByName(name string) (*object.Namespace, error
 	return ns, nil
 }
 
+// NodeZone returns the zone of node name, or the empty string if it isn't known.
+func (dns *dnsControl) NodeZone(name string) string {
+	if dns.nodeLister == nil {
+		return ""
+	}
+	o, exists, err := dns.nodeLister.GetByKey(name)
+	if err != nil || !exists {
+		return ""
+	}
+	n, ok := o.(*object.Node)
+	if !ok {
+		return ""
+	}
+	return n.Zone
+}
+
 func (dns *dnsControl) Add(obj interface{})               { dns.updateModified() }
 func (dns *dnsControl) Delete(obj interface{})            { dns.updateModified() }
 func (dns *dnsControl) Update(oldObj, newObj interface{}) { dns.detectChanges(oldObj, newObj) }
@@ -575,6 +879,8 @@ func (dns *dnsControl) detectChanges(oldObj, newObj interface{}) {
 		dns.updateModified()
 	case *object.Pod:
 		dns.updateModified()
+	case *object.Host:
+		dns.updateModified()
 	case *object.Endpoints:
 		if !endpointsEquivalent(oldObj.(*object.Endpoints), newObj.(*object.Endpoints)) {
 			dns.updateModified()
@@ -588,9 +894,6 @@ func (dns *dnsControl) detectChanges(oldObj, newObj interface{}) {
 // I.e. that they have the same ready addresses, host names, ports (including protocol
 // and service names for SRV)
 func subsetsEquivalent(sa, sb object.EndpointSubset) bool {
-	if len(sa.Addresses) != len(sb.Addresses) {
-		return false
-	}
 	if len(sa.Ports) != len(sb.Ports) {
 		return false
 	}
@@ -598,14 +901,8 @@ func subsetsEquivalent(sa, sb object.EndpointSubset) bool {
 	// in Addresses and Ports, we should be able to rely on
 	// these being sorted and able to be compared
 	// they are supposed to be in a canonical format
-	for addr, aaddr := range sa.Addresses {
-		baddr := sb.Addresses[addr]
-		if aaddr.IP != baddr.IP {
-			return false
-		}
-		if aaddr.Hostname != baddr.Hostname {
-			return false
-		}
+	if !addressesEquivalent(sa.Addresses, sb.Addresses) || !addressesEquivalent(sa.NotReadyAddresses, sb.NotReadyAddresses) {
+		return false
 	}
 
 	for port, aport := range sa.Ports {
@@ -623,6 +920,29 @@ func subsetsEquivalent(sa, sb object.EndpointSubset) bool {
 	return true
 }
 
+// addressesEquivalent checks if two lists of endpoint addresses are significantly equivalent.
+func addressesEquivalent(a, b []object.EndpointAddress) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, aaddr := range a {
+		baddr := b[i]
+		if aaddr.IP != baddr.IP {
+			return false
+		}
+		if aaddr.Hostname != baddr.Hostname {
+			return false
+		}
+		if aaddr.NodeName != baddr.NodeName || aaddr.Zone != baddr.Zone || aaddr.Terminating != baddr.Terminating {
+			return false
+		}
+		if strings.Join(aaddr.ForZones, ",") != strings.Join(baddr.ForZones, ",") {
+			return false
+		}
+	}
+	return true
+}
+
 // endpointsEquivalent checks if the update to an endpoint is something
 // that matters to us or if they are effectively equivalent.
 func endpointsEquivalent(a, b *object.Endpoints) bool {
@@ -83,6 +83,53 @@ func (k *Kubernetes) External(state request.Request) ([]msg.Service, int) {
 	return services, rcode
 }
 
+// WatchExternalHosts implements the WatchExternalHostsFunc call from the external plugin. It makes the
+// plugin watch the resources of kinds, "ingress" or "gateway", whose hostnames are published. It must be
+// called before the plugin is started.
+func (k *Kubernetes) WatchExternalHosts(kinds []string) {
+	for _, kind := range kinds {
+		switch kind {
+		case "ingress":
+			k.ingressHosts = true
+		case "gateway":
+			k.gatewayHosts = true
+		}
+	}
+}
+
+// ExternalHosts implements the ExternalHostsFunc call from the external plugin. It returns the addresses
+// of the Ingresses, Gateways and HTTPRoutes that declare the name of the request as one of their
+// hostnames. If none does, the wildcard hostname one label up is tried.
+func (k *Kubernetes) ExternalHosts(state request.Request) ([]msg.Service, int) {
+	name := strings.ToLower(state.Name())
+	hosts := k.APIConn.HostIndex(name)
+	if len(hosts) == 0 {
+		i, end := dns.NextLabel(name, 0)
+		if end {
+			return nil, dns.RcodeNameError
+		}
+		hosts = k.APIConn.HostIndex("*." + name[i:])
+	}
+
+	services := []msg.Service{}
+	zonePath := msg.Path(state.Zone, coredns)
+	rcode := dns.RcodeNameError
+
+	for _, h := range hosts {
+		if !k.namespaceExposed(h.Namespace) {
+			continue
+		}
+		for _, addr := range h.Addresses {
+			rcode = dns.RcodeSuccess
+			s := msg.Service{Host: addr, TTL: k.ttl}
+			s.Key = strings.Join([]string{zonePath, h.Namespace, h.Name}, "/")
+
+			services = append(services, s)
+		}
+	}
+	return services, rcode
+}
+
 // ExternalAddress returns the external service address(es) for the CoreDNS service.
 func (k *Kubernetes) ExternalAddress(state request.Request) []dns.RR {
 	// If CoreDNS is running inside the Kubernetes cluster: k.nsAddrs() will return the external IPs of the services
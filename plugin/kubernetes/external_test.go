@@ -2,6 +2,7 @@ package kubernetes
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/coredns/coredns/plugin/etcd/msg"
@@ -11,6 +12,10 @@ import (
 
 	"github.com/miekg/dns"
 	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
 )
 
 var extCases = []struct {
@@ -82,6 +87,10 @@ func (external) HasSynced() bool
 func (external) Run()                                                              {}
 func (external) Stop() error                                                       { return nil }
 func (external) EpIndexReverse(string) []*object.Endpoints                         { return nil }
+func (external) NodeZone(string) string                                            { return "" }
+func (external) MCSvcIndex(string) []*object.Service                               { return nil }
+func (external) MCEpIndex(string) []*object.Endpoints                              { return nil }
+func (external) HostIndex(string) []*object.Host                                   { return nil }
 func (external) SvcIndexReverse(string) []*object.Service                          { return nil }
 func (external) Modified() int64                                                   { return 0 }
 func (external) EpIndex(s string) []*object.Endpoints                              { return nil }
@@ -131,3 +140,62 @@ func testRequest(name string) request.Request {
 	m := new(dns.Msg).SetQuestion(name, dns.TypeA)
 	return request.Request{W: &test.ResponseWriter{}, Req: m, Zone: "example.org."}
 }
+
+func TestHostIndex(t *testing.T) {
+	controller := &dnsControl{
+		ingressLister: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{hostnameIndex: hostnameIndexFunc}),
+		gatewayLister: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{hostnameIndex: hostnameIndexFunc}),
+		routeLister:   cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{hostnameIndex: hostnameIndexFunc}),
+	}
+
+	ing, _ := object.ToIngress(&networking.Ingress{
+		ObjectMeta: meta.ObjectMeta{Name: "app", Namespace: "testns"},
+		Spec:       networking.IngressSpec{Rules: []networking.IngressRule{{Host: "App.example.org"}}},
+		Status: networking.IngressStatus{LoadBalancer: api.LoadBalancerStatus{
+			Ingress: []api.LoadBalancerIngress{{IP: "1.2.3.4"}, {Hostname: "lb.example.net"}},
+		}},
+	})
+	controller.ingressLister.Add(ing)
+
+	gw, _ := object.ToGateway(&unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "gw", "namespace": "infra"},
+		"spec": map[string]interface{}{
+			"listeners": []interface{}{map[string]interface{}{"hostname": "*.web.example.org"}},
+		},
+		"status": map[string]interface{}{
+			"addresses": []interface{}{map[string]interface{}{"type": "IPAddress", "value": "1.2.3.5"}},
+		},
+	}})
+	controller.gatewayLister.Add(gw)
+
+	route, _ := object.ToHTTPRoute(&unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "shop", "namespace": "testns"},
+		"spec": map[string]interface{}{
+			"hostnames": []interface{}{"shop.example.org"},
+			"parentRefs": []interface{}{
+				map[string]interface{}{"name": "gw", "namespace": "infra"},
+				map[string]interface{}{"name": "svc", "kind": "Service"},
+			},
+		},
+	}})
+	controller.routeLister.Add(route)
+
+	tests := []struct {
+		hostname  string
+		addresses []string
+	}{
+		{"app.example.org.", []string{"1.2.3.4", "lb.example.net"}},
+		{"*.web.example.org.", []string{"1.2.3.5"}},
+		{"shop.example.org.", []string{"1.2.3.5"}},
+		{"none.example.org.", nil},
+	}
+	for i, tc := range tests {
+		var addresses []string
+		for _, h := range controller.HostIndex(tc.hostname) {
+			addresses = append(addresses, h.Addresses...)
+		}
+		if !reflect.DeepEqual(addresses, tc.addresses) {
+			t.Errorf("Test %d, expected addresses %v for %s, got %v", i, tc.addresses, tc.hostname, addresses)
+		}
+	}
+}
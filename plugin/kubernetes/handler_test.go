@@ -554,6 +554,10 @@ func (a APIConnServeTest) HasSynced() bool                         { return !a.n
 func (APIConnServeTest) Run()                                      {}
 func (APIConnServeTest) Stop() error                               { return nil }
 func (APIConnServeTest) EpIndexReverse(string) []*object.Endpoints { return nil }
+func (APIConnServeTest) NodeZone(string) string                    { return "" }
+func (APIConnServeTest) MCSvcIndex(string) []*object.Service       { return nil }
+func (APIConnServeTest) MCEpIndex(string) []*object.Endpoints      { return nil }
+func (APIConnServeTest) HostIndex(string) []*object.Host           { return nil }
 func (APIConnServeTest) SvcIndexReverse(string) []*object.Service  { return nil }
 func (APIConnServeTest) Modified() int64                           { return int64(3) }
 
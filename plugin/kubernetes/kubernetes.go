@@ -25,6 +25,7 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -33,24 +34,29 @@ import (
 
 // Kubernetes implements a plugin that connects to a Kubernetes cluster.
 type Kubernetes struct {
-	Next             plugin.Handler
-	Zones            []string
-	Upstream         *upstream.Upstream
-	APIServerList    []string
-	APICertAuth      string
-	APIClientCert    string
-	APIClientKey     string
-	ClientConfig     clientcmd.ClientConfig
-	APIConn          dnsController
-	Namespaces       map[string]struct{}
-	podMode          string
-	endpointNameMode bool
-	Fall             fall.F
-	ttl              uint32
-	opts             dnsControlOpts
-	primaryZoneIndex int
-	localIPs         []net.IP
-	autoPathSearch   []string // Local search path from /etc/resolv.conf. Needed for autopath.
+	Next              plugin.Handler
+	Zones             []string
+	Upstream          *upstream.Upstream
+	APIServerList     []string
+	APICertAuth       string
+	APIClientCert     string
+	APIClientKey      string
+	ClientConfig      clientcmd.ClientConfig
+	APIConn           dnsController
+	Namespaces        map[string]struct{}
+	podMode           string
+	endpointNameMode  bool
+	topology          bool     // prefer endpoints in the zone of the client
+	terminating       bool     // publish endpoints that are terminating, but still serving
+	multiclusterZones []string // zones with the services of the cluster set
+	ingressHosts      bool     // watch Ingresses, to publish their hostnames
+	gatewayHosts      bool     // watch Gateways and HTTPRoutes, to publish their hostnames
+	Fall              fall.F
+	ttl               uint32
+	opts              dnsControlOpts
+	primaryZoneIndex  int
+	localIPs          []net.IP
+	autoPathSearch    []string // Local search path from /etc/resolv.conf. Needed for autopath.
 }
 
 // New returns a initialized Kubernetes. It default interfaceAddrFunc to return 127.0.0.1. All other
@@ -245,16 +251,38 @@ func (k *Kubernetes) InitKubeCache(ctx context.Context) (onStart func() error, o
 		k.opts.namespaceSelector = selector
 	}
 
-	k.opts.initPodCache = k.podMode == podModeVerified
+	// The zone of a client is found through its pod.
+	k.opts.initPodCache = k.podMode == podModeVerified || k.topology
+	k.opts.initNodeCache = k.topology
 
 	k.opts.zones = k.Zones
 	k.opts.endpointNameMode = k.endpointNameMode
 
 	k.APIConn = newdnsController(ctx, kubeClient, k.opts)
 
+	if len(k.multiclusterZones) > 0 {
+		dynamicClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create kubernetes multi-cluster notification controller: %q", err)
+		}
+		k.APIConn.(*dnsControl).WatchMultiCluster(ctx, dynamicClient)
+	}
+
 	initEndpointWatch := k.opts.initEndpointsCache
 
 	onStart = func() error {
+		// The watches for external hosts are known only now, as these are requested by k8s_external.
+		if k.ingressHosts {
+			k.APIConn.(*dnsControl).WatchIngresses(ctx)
+		}
+		if k.gatewayHosts {
+			dynamicClient, err := dynamic.NewForConfig(config)
+			if err != nil {
+				return fmt.Errorf("failed to create kubernetes gateway notification controller: %q", err)
+			}
+			k.APIConn.(*dnsControl).WatchGateways(ctx, dynamicClient)
+		}
+
 		go func() {
 			if initEndpointWatch {
 				// Revert to watching Endpoints for incompatible K8s.
@@ -347,7 +375,12 @@ func (k *Kubernetes) endpointSliceSupported(kubeClient *kubernetes.Clientset) (b
 
 // Records looks up services in kubernetes.
 func (k *Kubernetes) Records(ctx context.Context, state request.Request, exact bool) ([]msg.Service, error) {
-	r, e := parseRequest(state.Name(), state.Zone)
+	multicluster := k.isMultiClusterZone(state.Zone)
+	parse := parseRequest
+	if multicluster {
+		parse = parseMultiClusterRequest
+	}
+	r, e := parse(state.Name(), state.Zone)
 	if e != nil {
 		return nil, e
 	}
@@ -368,7 +401,15 @@ func (k *Kubernetes) Records(ctx context.Context, state request.Request, exact b
 		return pods, err
 	}
 
-	services, err := k.findServices(r, state.Zone)
+	if multicluster {
+		return k.findMultiClusterServices(r, state.Zone)
+	}
+
+	clientZone := ""
+	if k.topology {
+		clientZone = k.clientZone(state.IP())
+	}
+	services, err := k.findServices(r, state.Zone, clientZone)
 	return services, err
 }
 
@@ -457,8 +498,9 @@ func (k *Kubernetes) findPods(r recordRequest, zone string) (pods []msg.Service,
 	return pods, err
 }
 
-// findServices returns the services matching r from the cache.
-func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.Service, err error) {
+// findServices returns the services matching r from the cache. If clientZone isn't empty, the endpoints of
+// headless services meant for that zone are preferred.
+func (k *Kubernetes) findServices(r recordRequest, zone, clientZone string) (services []msg.Service, err error) {
 	if !wildcard(r.namespace) && !k.namespaceExposed(r.namespace) {
 		return nil, errNoItems
 	}
@@ -514,7 +556,7 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 			podsCount := 0
 			for _, ep := range endpointsListFunc() {
 				for _, eps := range ep.Subsets {
-					podsCount = podsCount + len(eps.Addresses)
+					podsCount = podsCount + len(k.endpointAddresses(eps, svc.PublishNotReady, r.notReady))
 				}
 			}
 
@@ -541,13 +583,17 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 				endpointsList = endpointsListFunc()
 			}
 
+			// Whether the zone of the client has endpoints is decided on the ready ones, the addresses that
+			// aren't ready are then limited to the zone as well.
+			local := r.endpoint == "" && clientZone != "" && hasEndpointsInZone(endpointsList, svc, clientZone)
+
 			for _, ep := range endpointsList {
 				if object.EndpointsKey(svc.Name, svc.Namespace) != ep.Index {
 					continue
 				}
 
 				for _, eps := range ep.Subsets {
-					for _, addr := range eps.Addresses {
+					for _, addr := range k.endpointAddresses(eps, svc.PublishNotReady, r.notReady) {
 
 						// See comments in parse.go parseRequest about the endpoint handling.
 						if r.endpoint != "" {
@@ -555,6 +601,9 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 								continue
 							}
 						}
+						if local && !forZone(addr, clientZone) {
+							continue
+						}
 
 						for _, p := range eps.Ports {
 							if !(match(r.port, p.Name) && match(r.protocol, string(p.Protocol))) {
@@ -591,6 +640,23 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 	return services, err
 }
 
+// endpointAddresses returns the addresses of eps that are published for a service: the ready ones, and
+// those that aren't ready if the service publishes them or the query asks for them. Addresses that are
+// terminating but serving are published with the terminating_endpoints option.
+func (k *Kubernetes) endpointAddresses(eps object.EndpointSubset, publishNotReady, notReady bool) []object.EndpointAddress {
+	if len(eps.NotReadyAddresses) == 0 || !(publishNotReady || notReady || k.terminating) {
+		return eps.Addresses
+	}
+	addrs := make([]object.EndpointAddress, len(eps.Addresses), len(eps.Addresses)+len(eps.NotReadyAddresses))
+	copy(addrs, eps.Addresses)
+	for _, a := range eps.NotReadyAddresses {
+		if publishNotReady || notReady || a.Terminating {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
 // Serial return the SOA serial.
 func (k *Kubernetes) Serial(state request.Request) uint32 { return uint32(k.APIConn.Modified()) }
 
@@ -66,6 +66,10 @@ func (APIConnServiceTest) Stop() error                               { return ni
 func (APIConnServiceTest) PodIndex(string) []*object.Pod             { return nil }
 func (APIConnServiceTest) SvcIndexReverse(string) []*object.Service  { return nil }
 func (APIConnServiceTest) EpIndexReverse(string) []*object.Endpoints { return nil }
+func (APIConnServiceTest) NodeZone(string) string                    { return "" }
+func (APIConnServiceTest) MCSvcIndex(string) []*object.Service       { return nil }
+func (APIConnServiceTest) MCEpIndex(string) []*object.Endpoints      { return nil }
+func (APIConnServiceTest) HostIndex(string) []*object.Host           { return nil }
 func (APIConnServiceTest) Modified() int64                           { return 0 }
 
 func (APIConnServiceTest) SvcIndex(string) []*object.Service {
@@ -0,0 +1,113 @@
+package kubernetes
+
+import (
+	"strings"
+
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+
+	"github.com/miekg/dns"
+)
+
+// isMultiClusterZone returns true if zone holds the services of the cluster set, the ServiceImports of the
+// Multi-Cluster Services API.
+func (k *Kubernetes) isMultiClusterZone(zone string) bool {
+	for _, z := range k.multiclusterZones {
+		if z == zone {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMultiClusterRequest parses the qname of a request in a multi-cluster zone. These zones have no pods,
+// and the names of the endpoints of a headless service hold the cluster they are in:
+// 1. _port._protocol.service.namespace.svc.zone
+// 2. (endpoint): endpoint.cluster.service.namespace.svc.zone
+// 3. (cluster): cluster.service.namespace.svc.zone
+// 4. (service): service.namespace.svc.zone
+func parseMultiClusterRequest(name, zone string) (r recordRequest, err error) {
+	base, _ := dnsutil.TrimZone(name, zone)
+	segs := dns.SplitDomainName(base)
+
+	if len(segs) == 5 && !strings.HasPrefix(segs[0], "_") {
+		r, err = parseRequest(dnsutil.Join(append(segs[1:], zone)...), zone)
+		r.cluster, r.endpoint = r.endpoint, segs[0]
+	} else {
+		r, err = parseRequest(name, zone)
+		r.cluster, r.endpoint = r.endpoint, ""
+	}
+	if r.podOrSvc == Pod {
+		return r, errInvalidRequest
+	}
+	return r, err
+}
+
+// findMultiClusterServices returns the services of the cluster set matching r from the cache.
+func (k *Kubernetes) findMultiClusterServices(r recordRequest, zone string) (services []msg.Service, err error) {
+	if r.service == "" {
+		// NODATA
+		return nil, nil
+	}
+	// Listing all services of the cluster set isn't supported.
+	if wildcard(r.service) || wildcard(r.namespace) || !k.namespaceExposed(r.namespace) {
+		return nil, errNoItems
+	}
+
+	err = errNoItems
+	idx := object.ServiceKey(r.service, r.namespace)
+	zonePath := msg.Path(zone, coredns)
+	for _, svc := range k.APIConn.MCSvcIndex(idx) {
+		// ClusterSetIP service, there are no names for clusters or endpoints.
+		if !svc.Headless() {
+			if r.cluster != "" {
+				continue
+			}
+			for _, p := range svc.Ports {
+				if !(match(r.port, p.Name) && match(r.protocol, string(p.Protocol))) {
+					continue
+				}
+
+				err = nil
+
+				for _, ip := range svc.ClusterIPs {
+					if ip == "" {
+						continue
+					}
+					s := msg.Service{Host: ip, Port: int(p.Port), TTL: k.ttl}
+					s.Key = strings.Join([]string{zonePath, Svc, svc.Namespace, svc.Name}, "/")
+					services = append(services, s)
+				}
+			}
+			continue
+		}
+
+		// Headless service, the endpoints of all clusters or of the requested one.
+		for _, ep := range k.APIConn.MCEpIndex(idx) {
+			if r.cluster != "" && !strings.EqualFold(r.cluster, ep.Cluster) {
+				continue
+			}
+			for _, eps := range ep.Subsets {
+				for _, addr := range k.endpointAddresses(eps, false, r.notReady) {
+					hostname := endpointHostname(addr, k.endpointNameMode)
+					if r.endpoint != "" && !strings.EqualFold(r.endpoint, hostname) {
+						continue
+					}
+					for _, p := range eps.Ports {
+						if !(match(r.port, p.Name) && match(r.protocol, p.Protocol)) {
+							continue
+						}
+						s := msg.Service{Host: addr.IP, Port: int(p.Port), TTL: k.ttl}
+						s.Key = strings.Join([]string{zonePath, Svc, svc.Namespace, svc.Name, ep.Cluster, hostname}, "/")
+
+						err = nil
+
+						services = append(services, s)
+					}
+				}
+			}
+		}
+	}
+	return services, err
+}
@@ -0,0 +1,202 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseMultiClusterRequest(t *testing.T) {
+	tests := []struct {
+		query    string
+		cluster  string
+		expected string // output from r.String()
+	}{
+		{"_http._tcp.webs.mynamespace.svc.inter.webs.tests.", "", "http.tcp..webs.mynamespace.svc"},
+		{"webs.mynamespace.svc.inter.webs.tests.", "", "*.*..webs.mynamespace.svc"},
+		{"east.webs.mynamespace.svc.inter.webs.tests.", "east", "*.*..webs.mynamespace.svc"},
+		{"pod-1.east.webs.mynamespace.svc.inter.webs.tests.", "east", "*.*.pod-1.webs.mynamespace.svc"},
+	}
+	for i, tc := range tests {
+		r, e := parseMultiClusterRequest(tc.query, zone)
+		if e != nil {
+			t.Errorf("Test %d, expected no error, got '%v'.", i, e)
+		}
+		if rs := r.String(); rs != tc.expected {
+			t.Errorf("Test %d, expected (stringified) recordRequest: %s, got %s", i, tc.expected, rs)
+		}
+		if r.cluster != tc.cluster {
+			t.Errorf("Test %d, expected cluster %q, got %q", i, tc.cluster, r.cluster)
+		}
+	}
+
+	if _, e := parseMultiClusterRequest("1-2-3-4.mynamespace.pod.inter.webs.tests.", zone); e == nil {
+		t.Errorf("Expected error for a pod request, got none")
+	}
+}
+
+type APIConnMultiClusterTest struct {
+	APIConnServeTest
+}
+
+func (APIConnMultiClusterTest) MCSvcIndex(idx string) []*object.Service {
+	switch idx {
+	case "svc1.testns":
+		return []*object.Service{{
+			Name: "svc1", Namespace: "testns", Type: api.ServiceTypeClusterIP, ClusterIPs: []string{"10.1.0.1"},
+			Ports: []api.ServicePort{{Name: "http", Protocol: "TCP", Port: 80}},
+		}}
+	case "hdls1.testns":
+		return []*object.Service{{
+			Name: "hdls1", Namespace: "testns", Type: api.ServiceTypeClusterIP, ClusterIPs: []string{api.ClusterIPNone},
+			Ports: []api.ServicePort{{Name: "http", Protocol: "TCP", Port: 80}},
+		}}
+	}
+	return nil
+}
+
+func (APIConnMultiClusterTest) MCEpIndex(idx string) []*object.Endpoints {
+	if idx != "hdls1.testns" {
+		return nil
+	}
+	ports := []object.EndpointPort{{Port: 80, Name: "http", Protocol: "TCP"}}
+	return []*object.Endpoints{
+		{
+			Name: "hdls1-east", Namespace: "testns", Index: idx, Cluster: "east",
+			Subsets: []object.EndpointSubset{{Addresses: []object.EndpointAddress{{IP: "172.0.0.1", Hostname: "web-0"}}, Ports: ports}},
+		},
+		{
+			Name: "hdls1-west", Namespace: "testns", Index: idx, Cluster: "west",
+			Subsets: []object.EndpointSubset{{Addresses: []object.EndpointAddress{{IP: "172.1.0.1", Hostname: "web-0"}}, Ports: ports}},
+		},
+	}
+}
+
+var multiClusterCases = []test.Case{
+	{
+		Qname: "svc1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("svc1.testns.svc.clusterset.local.	5	IN	A	10.1.0.1"),
+		},
+	},
+	{
+		Qname: "hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.1"),
+			test.A("hdls1.testns.svc.clusterset.local.	5	IN	A	172.1.0.1"),
+		},
+	},
+	{
+		Qname: "west.hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("west.hdls1.testns.svc.clusterset.local.	5	IN	A	172.1.0.1"),
+		},
+	},
+	{
+		Qname: "web-0.east.hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("web-0.east.hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.1"),
+		},
+	},
+	{
+		Qname: "_http._tcp.hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeSRV,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.SRV("_http._tcp.hdls1.testns.svc.clusterset.local.	5	IN	SRV	0 50 80 web-0.east.hdls1.testns.svc.clusterset.local."),
+			test.SRV("_http._tcp.hdls1.testns.svc.clusterset.local.	5	IN	SRV	0 50 80 web-0.west.hdls1.testns.svc.clusterset.local."),
+		},
+		Extra: []dns.RR{
+			test.A("web-0.east.hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.1"),
+			test.A("web-0.west.hdls1.testns.svc.clusterset.local.	5	IN	A	172.1.0.1"),
+		},
+	},
+	// No cluster names for a ClusterSetIP service.
+	{
+		Qname: "east.svc1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("clusterset.local.	5	IN	SOA	ns.dns.clusterset.local. hostmaster.clusterset.local. 1499347823 7200 1800 86400 5"),
+		},
+	},
+	{
+		Qname: "north.hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("clusterset.local.	5	IN	SOA	ns.dns.clusterset.local. hostmaster.clusterset.local. 1499347823 7200 1800 86400 5"),
+		},
+	},
+	// The services of the cluster are not in the cluster set zone.
+	{
+		Qname: "svc1.testns.svc.cluster.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("svc1.testns.svc.cluster.local.	5	IN	A	10.0.0.1"),
+		},
+	},
+}
+
+func TestServeDNSMultiCluster(t *testing.T) {
+	k := New([]string{"cluster.local.", "clusterset.local."})
+	k.APIConn = &APIConnMultiClusterTest{}
+	k.Next = test.NextHandler(dns.RcodeSuccess, nil)
+	k.Namespaces = map[string]struct{}{"testns": {}}
+	k.multiclusterZones = []string{"clusterset.local."}
+	ctx := context.TODO()
+
+	for i, tc := range multiClusterCases {
+		r := tc.Msg()
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+
+		if _, err := k.ServeDNS(ctx, w, r); err != nil {
+			t.Errorf("Test %d expected no error, got %v", i, err)
+			continue
+		}
+		if err := test.SortAndCheck(w.Msg, tc); err != nil {
+			t.Errorf("Test %d: %v", i, err)
+		}
+	}
+}
+
+func TestToServiceImport(t *testing.T) {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "multicluster.x-k8s.io/v1alpha1",
+		"kind":       "ServiceImport",
+		"metadata":   map[string]interface{}{"name": "svc1", "namespace": "testns"},
+		"spec": map[string]interface{}{
+			"type":  "ClusterSetIP",
+			"ips":   []interface{}{"10.1.0.1"},
+			"ports": []interface{}{map[string]interface{}{"name": "http", "port": int64(80)}},
+		},
+	}}
+	o, err := object.ToServiceImport(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := o.(*object.Service)
+	if svc.Index != "svc1.testns" || svc.Headless() || svc.ClusterIPs[0] != "10.1.0.1" {
+		t.Errorf("Unexpected service %+v", svc)
+	}
+	if len(svc.Ports) != 1 || svc.Ports[0].Port != 80 || svc.Ports[0].Protocol != api.ProtocolTCP {
+		t.Errorf("Unexpected ports %+v", svc.Ports)
+	}
+
+	u = &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "hdls1", "namespace": "testns"},
+		"spec":     map[string]interface{}{"type": "Headless"},
+	}}
+	o, _ = object.ToServiceImport(u)
+	if svc := o.(*object.Service); !svc.Headless() {
+		t.Errorf("Expected headless service, got %+v", svc)
+	}
+}
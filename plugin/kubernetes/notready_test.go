@@ -0,0 +1,122 @@
+package kubernetes
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	api "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type APIConnNotReadyTest struct {
+	APIConnServeTest
+	svc *object.Service
+}
+
+func (a APIConnNotReadyTest) SvcIndex(string) []*object.Service { return []*object.Service{a.svc} }
+func (a APIConnNotReadyTest) ServiceList() []*object.Service    { return []*object.Service{a.svc} }
+func (APIConnNotReadyTest) EpIndex(string) []*object.Endpoints  { return notReadyEndpoints }
+func (APIConnNotReadyTest) EndpointsList() []*object.Endpoints  { return notReadyEndpoints }
+
+var notReadyEndpoints = []*object.Endpoints{{
+	Name:      "hdls-1",
+	Namespace: "testns",
+	Index:     object.EndpointsKey("hdls", "testns"),
+	Subsets: []object.EndpointSubset{{
+		Addresses: []object.EndpointAddress{{IP: "172.0.0.1", Hostname: "ready"}},
+		NotReadyAddresses: []object.EndpointAddress{
+			{IP: "172.0.0.2", Hostname: "starting"},
+			{IP: "172.0.0.3", Hostname: "stopping", Terminating: true},
+		},
+		Ports: []object.EndpointPort{{Port: 80, Name: "http", Protocol: "TCP"}},
+	}},
+}}
+
+func TestNotReady(t *testing.T) {
+	tests := []struct {
+		qname           string
+		publishNotReady bool
+		terminating     bool
+		expected        []string
+	}{
+		{"hdls.testns.svc.cluster.local.", false, false, []string{"172.0.0.1"}},
+		{"hdls.testns.svc.cluster.local.", true, false, []string{"172.0.0.1", "172.0.0.2", "172.0.0.3"}},
+		{"hdls.testns.svc.cluster.local.", false, true, []string{"172.0.0.1", "172.0.0.3"}},
+		{"_notready.hdls.testns.svc.cluster.local.", false, false, []string{"172.0.0.1", "172.0.0.2", "172.0.0.3"}},
+		{"_notready.starting.hdls.testns.svc.cluster.local.", false, false, []string{"172.0.0.2"}},
+		{"starting.hdls.testns.svc.cluster.local.", false, false, nil},
+	}
+
+	for i, tc := range tests {
+		k := New([]string{"cluster.local."})
+		k.APIConn = &APIConnNotReadyTest{svc: &object.Service{
+			Name:            "hdls",
+			Namespace:       "testns",
+			Type:            api.ServiceTypeClusterIP,
+			ClusterIPs:      []string{api.ClusterIPNone},
+			PublishNotReady: tc.publishNotReady,
+		}}
+		k.Namespaces = map[string]struct{}{"testns": {}}
+		k.terminating = tc.terminating
+
+		m := new(dns.Msg)
+		m.SetQuestion(tc.qname, dns.TypeA)
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := k.ServeDNS(context.TODO(), w, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %s", i, err)
+		}
+
+		var got []string
+		for _, rr := range w.Msg.Answer {
+			got = append(got, rr.(*dns.A).A.String())
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("Test %d: expected %v for %s, got %v", i, tc.expected, tc.qname, got)
+		}
+	}
+}
+
+func TestEndpointSliceConditions(t *testing.T) {
+	yes, no := true, false
+	slice := &discovery.EndpointSlice{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "hdls-1",
+			Namespace: "testns",
+			Labels:    map[string]string{discovery.LabelServiceName: "hdls"},
+		},
+		Endpoints: []discovery.Endpoint{
+			{Addresses: []string{"172.0.0.1"}},
+			{Addresses: []string{"172.0.0.2"}, Conditions: discovery.EndpointConditions{Ready: &no}},
+			{Addresses: []string{"172.0.0.3"}, Conditions: discovery.EndpointConditions{Ready: &no, Serving: &yes, Terminating: &yes}},
+			{Addresses: []string{"172.0.0.4"}, Conditions: discovery.EndpointConditions{Ready: &no, Serving: &no, Terminating: &yes}},
+		},
+	}
+
+	o, err := object.EndpointSliceToEndpoints(slice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := o.(*object.Endpoints)
+
+	expected := object.EndpointSubset{
+		Addresses: []object.EndpointAddress{{IP: "172.0.0.1"}},
+		NotReadyAddresses: []object.EndpointAddress{
+			{IP: "172.0.0.2"},
+			{IP: "172.0.0.3", Terminating: true},
+		},
+		Ports: []object.EndpointPort{{Port: -1}},
+	}
+	if !reflect.DeepEqual(e.Subsets[0], expected) {
+		t.Errorf("Expected subset %v, got %v", expected, e.Subsets[0])
+	}
+	if !reflect.DeepEqual(e.IndexIP, []string{"172.0.0.1"}) {
+		t.Errorf("Expected only the ready address to be indexed, got %v", e.IndexIP)
+	}
+}
@@ -20,6 +20,10 @@ func (APIConnTest) Stop() error                              { return nil }
 func (APIConnTest) PodIndex(string) []*object.Pod            { return nil }
 func (APIConnTest) SvcIndexReverse(string) []*object.Service { return nil }
 func (APIConnTest) EpIndex(string) []*object.Endpoints       { return nil }
+func (APIConnTest) NodeZone(string) string                   { return "" }
+func (APIConnTest) MCSvcIndex(string) []*object.Service      { return nil }
+func (APIConnTest) MCEpIndex(string) []*object.Endpoints     { return nil }
+func (APIConnTest) HostIndex(string) []*object.Host          { return nil }
 func (APIConnTest) EndpointsList() []*object.Endpoints       { return nil }
 func (APIConnTest) Modified() int64                          { return 0 }
 
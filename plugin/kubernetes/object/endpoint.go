@@ -19,6 +19,7 @@ type Endpoints struct {
 	Index     string
 	IndexIP   []string
 	Subsets   []EndpointSubset
+	Cluster   string // the cluster a multi-cluster EndpointSlice is imported from
 
 	*Empty
 }
@@ -27,7 +28,10 @@ type Endpoints struct {
 // expanded set of endpoints is the Cartesian product of Addresses x Ports.
 type EndpointSubset struct {
 	Addresses []EndpointAddress
-	Ports     []EndpointPort
+	// NotReadyAddresses are the addresses that are not ready, including the ones that are
+	// terminating but still serving. Terminating addresses that aren't serving are not kept.
+	NotReadyAddresses []EndpointAddress
+	Ports             []EndpointPort
 }
 
 // EndpointAddress is a tuple that describes single IP address.
@@ -36,6 +40,9 @@ type EndpointAddress struct {
 	Hostname      string
 	NodeName      string
 	TargetRefName string
+	Zone          string
+	ForZones      []string // topology hints: the zones whose clients should use this address
+	Terminating   bool     // the endpoint is terminating, but still serving
 }
 
 // EndpointPort is a tuple that describes a single port.
@@ -65,6 +72,9 @@ func ToEndpoints(obj meta.Object) (meta.Object, error) {
 		sub := EndpointSubset{
 			Addresses: make([]EndpointAddress, len(eps.Addresses)),
 		}
+		if len(eps.NotReadyAddresses) > 0 {
+			sub.NotReadyAddresses = make([]EndpointAddress, len(eps.NotReadyAddresses))
+		}
 		if len(eps.Ports) == 0 {
 			// Add sentinel if there are no ports.
 			sub.Ports = []EndpointPort{{Port: -1}}
@@ -73,14 +83,10 @@ func ToEndpoints(obj meta.Object) (meta.Object, error) {
 		}
 
 		for j, a := range eps.Addresses {
-			ea := EndpointAddress{IP: a.IP, Hostname: a.Hostname}
-			if a.NodeName != nil {
-				ea.NodeName = *a.NodeName
-			}
-			if a.TargetRef != nil {
-				ea.TargetRefName = a.TargetRef.Name
-			}
-			sub.Addresses[j] = ea
+			sub.Addresses[j] = toEndpointAddress(a)
+		}
+		for j, a := range eps.NotReadyAddresses {
+			sub.NotReadyAddresses[j] = toEndpointAddress(a)
 		}
 
 		for k, p := range eps.Ports {
@@ -102,6 +108,17 @@ func ToEndpoints(obj meta.Object) (meta.Object, error) {
 	return e, nil
 }
 
+func toEndpointAddress(a api.EndpointAddress) EndpointAddress {
+	ea := EndpointAddress{IP: a.IP, Hostname: a.Hostname}
+	if a.NodeName != nil {
+		ea.NodeName = *a.NodeName
+	}
+	if a.TargetRef != nil {
+		ea.TargetRefName = a.TargetRef.Name
+	}
+	return ea
+}
+
 // EndpointSliceToEndpoints converts a *discovery.EndpointSlice to a *Endpoints.
 func EndpointSliceToEndpoints(obj meta.Object) (meta.Object, error) {
 	ends, ok := obj.(*discovery.EndpointSlice)
@@ -128,7 +145,8 @@ func EndpointSliceToEndpoints(obj meta.Object) (meta.Object, error) {
 	}
 
 	for _, end := range ends.Endpoints {
-		if !endpointsliceReady(end.Conditions.Ready) {
+		keep, ready, terminating := endpointsliceConditions(end.Conditions.Ready, end.Conditions.Serving, end.Conditions.Terminating)
+		if !keep {
 			continue
 		}
 		for _, a := range end.Addresses {
@@ -142,6 +160,19 @@ func EndpointSliceToEndpoints(obj meta.Object) (meta.Object, error) {
 			if end.NodeName != nil {
 				ea.NodeName = *end.NodeName
 			}
+			if end.Zone != nil {
+				ea.Zone = *end.Zone
+			}
+			if end.Hints != nil {
+				for _, z := range end.Hints.ForZones {
+					ea.ForZones = append(ea.ForZones, z.Name)
+				}
+			}
+			if !ready {
+				ea.Terminating = terminating
+				e.Subsets[0].NotReadyAddresses = append(e.Subsets[0].NotReadyAddresses, ea)
+				continue
+			}
 			e.Subsets[0].Addresses = append(e.Subsets[0].Addresses, ea)
 			e.IndexIP = append(e.IndexIP, a)
 		}
@@ -152,6 +183,26 @@ func EndpointSliceToEndpoints(obj meta.Object) (meta.Object, error) {
 	return e, nil
 }
 
+// MultiClusterEndpointSliceToEndpoints converts a *discovery.EndpointSlice imported from another cluster to a
+// *Endpoints. These belong to a ServiceImport.
+func MultiClusterEndpointSliceToEndpoints(obj meta.Object) (meta.Object, error) {
+	ends, ok := obj.(*discovery.EndpointSlice)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	index := EndpointsKey(ends.Labels[LabelMultiClusterServiceName], ends.GetNamespace())
+	cluster := ends.Labels[LabelSourceCluster]
+
+	o, err := EndpointSliceToEndpoints(ends)
+	if err != nil {
+		return nil, err
+	}
+	e := o.(*Endpoints)
+	e.Index = index
+	e.Cluster = cluster
+	return e, nil
+}
+
 // EndpointSliceV1beta1ToEndpoints converts a v1beta1 *discovery.EndpointSlice to a *Endpoints.
 func EndpointSliceV1beta1ToEndpoints(obj meta.Object) (meta.Object, error) {
 	ends, ok := obj.(*discoveryV1beta1.EndpointSlice)
@@ -178,7 +229,8 @@ func EndpointSliceV1beta1ToEndpoints(obj meta.Object) (meta.Object, error) {
 	}
 
 	for _, end := range ends.Endpoints {
-		if !endpointsliceReady(end.Conditions.Ready) {
+		keep, ready, terminating := endpointsliceConditions(end.Conditions.Ready, end.Conditions.Serving, end.Conditions.Terminating)
+		if !keep {
 			continue
 		}
 		for _, a := range end.Addresses {
@@ -189,7 +241,20 @@ func EndpointSliceV1beta1ToEndpoints(obj meta.Object) (meta.Object, error) {
 			if end.TargetRef != nil {
 				ea.TargetRefName = end.TargetRef.Name
 			}
-			// EndpointSlice does not contain NodeName, leave blank
+			if end.NodeName != nil {
+				ea.NodeName = *end.NodeName
+			}
+			ea.Zone = end.Topology[api.LabelTopologyZone]
+			if end.Hints != nil {
+				for _, z := range end.Hints.ForZones {
+					ea.ForZones = append(ea.ForZones, z.Name)
+				}
+			}
+			if !ready {
+				ea.Terminating = terminating
+				e.Subsets[0].NotReadyAddresses = append(e.Subsets[0].NotReadyAddresses, ea)
+				continue
+			}
 			e.Subsets[0].Addresses = append(e.Subsets[0].Addresses, ea)
 			e.IndexIP = append(e.IndexIP, a)
 		}
@@ -200,13 +265,19 @@ func EndpointSliceV1beta1ToEndpoints(obj meta.Object) (meta.Object, error) {
 	return e, nil
 }
 
-func endpointsliceReady(ready *bool) bool {
+// endpointsliceConditions returns whether an endpoint with these conditions is kept, and whether it is
+// ready or terminating. An endpoint that is terminating and no longer serving isn't kept.
+func endpointsliceConditions(ready, serving, terminating *bool) (keep, isReady, isTerminating bool) {
 	// Per API docs: a nil value indicates an unknown state. In most cases consumers
 	// should interpret this unknown state as ready.
-	if ready == nil {
-		return true
+	if ready == nil || *ready {
+		return true, true, false
+	}
+	if terminating == nil || !*terminating {
+		return true, false, false
 	}
-	return *ready
+	// Serving is, like ready, assumed when unknown.
+	return serving == nil || *serving, false, true
 }
 
 // CopyWithoutSubsets copies e, without the subsets.
@@ -217,6 +288,7 @@ func (e *Endpoints) CopyWithoutSubsets() *Endpoints {
 		Namespace: e.Namespace,
 		Index:     e.Index,
 		IndexIP:   make([]string, len(e.IndexIP)),
+		Cluster:   e.Cluster,
 	}
 	copy(e1.IndexIP, e.IndexIP)
 	return e1
@@ -233,6 +305,7 @@ func (e *Endpoints) DeepCopyObject() runtime.Object {
 		Index:     e.Index,
 		IndexIP:   make([]string, len(e.IndexIP)),
 		Subsets:   make([]EndpointSubset, len(e.Subsets)),
+		Cluster:   e.Cluster,
 	}
 	copy(e1.IndexIP, e.IndexIP)
 
@@ -242,8 +315,13 @@ func (e *Endpoints) DeepCopyObject() runtime.Object {
 			Ports:     make([]EndpointPort, len(eps.Ports)),
 		}
 		for j, a := range eps.Addresses {
-			ea := EndpointAddress{IP: a.IP, Hostname: a.Hostname, NodeName: a.NodeName, TargetRefName: a.TargetRefName}
-			sub.Addresses[j] = ea
+			sub.Addresses[j] = a.deepCopy()
+		}
+		if eps.NotReadyAddresses != nil {
+			sub.NotReadyAddresses = make([]EndpointAddress, len(eps.NotReadyAddresses))
+			for j, a := range eps.NotReadyAddresses {
+				sub.NotReadyAddresses[j] = a.deepCopy()
+			}
 		}
 		for k, p := range eps.Ports {
 			ep := EndpointPort{Port: p.Port, Name: p.Name, Protocol: p.Protocol}
@@ -255,6 +333,15 @@ func (e *Endpoints) DeepCopyObject() runtime.Object {
 	return e1
 }
 
+func (a EndpointAddress) deepCopy() EndpointAddress {
+	a1 := a
+	if a.ForZones != nil {
+		a1.ForZones = make([]string, len(a.ForZones))
+		copy(a1.ForZones, a.ForZones)
+	}
+	return a1
+}
+
 // GetNamespace implements the metav1.Object interface.
 func (e *Endpoints) GetNamespace() string { return e.Namespace }
 
@@ -0,0 +1,196 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Resources of the Gateway API, see https://gateway-api.sigs.k8s.io.
+var (
+	GatewayResource   = schema.GroupVersionResource{Group: GatewayGroup, Version: "v1alpha2", Resource: "gateways"}
+	HTTPRouteResource = schema.GroupVersionResource{Group: GatewayGroup, Version: "v1alpha2", Resource: "httproutes"}
+)
+
+// GatewayGroup is the API group of the Gateway API.
+const GatewayGroup = "gateway.networking.k8s.io"
+
+// Host is a stripped down Ingress, Gateway or HTTPRoute, with the hostnames it declares and the addresses
+// these resolve to.
+type Host struct {
+	Version   string
+	Name      string
+	Namespace string
+	// Hostnames are fully qualified and in lower case. A wildcard hostname starts with "*.".
+	Hostnames []string
+	// Addresses are the IP addresses or hostnames of the load balancers, from the status.
+	Addresses []string
+	// Parents are the keys of the Gateways an HTTPRoute is attached to, it gets its addresses from them.
+	Parents []string
+
+	*Empty
+}
+
+// ToIngress converts a networking.Ingress to a *Host.
+func ToIngress(obj meta.Object) (meta.Object, error) {
+	ing, ok := obj.(*networking.Ingress)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	h := &Host{
+		Version:   ing.GetResourceVersion(),
+		Name:      ing.GetName(),
+		Namespace: ing.GetNamespace(),
+	}
+	for _, r := range ing.Spec.Rules {
+		h.Hostnames = appendHostname(h.Hostnames, r.Host)
+	}
+	for _, lb := range ing.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			h.Addresses = append(h.Addresses, lb.IP)
+			continue
+		}
+		if lb.Hostname != "" {
+			h.Addresses = append(h.Addresses, lb.Hostname)
+		}
+	}
+
+	*ing = networking.Ingress{}
+
+	return h, nil
+}
+
+// ToGateway converts an unstructured Gateway to a *Host. The hostnames are those of its listeners.
+func ToGateway(obj meta.Object) (meta.Object, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	h := &Host{
+		Version:   u.GetResourceVersion(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+	}
+
+	listeners, _, _ := unstructured.NestedSlice(u.Object, "spec", "listeners")
+	for _, l := range listeners {
+		m, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hostname, _, _ := unstructured.NestedString(m, "hostname")
+		h.Hostnames = appendHostname(h.Hostnames, hostname)
+	}
+
+	addresses, _, _ := unstructured.NestedSlice(u.Object, "status", "addresses")
+	for _, a := range addresses {
+		m, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, _, _ := unstructured.NestedString(m, "value"); value != "" {
+			h.Addresses = append(h.Addresses, value)
+		}
+	}
+
+	u.Object = nil
+
+	return h, nil
+}
+
+// ToHTTPRoute converts an unstructured HTTPRoute to a *Host. It has no addresses of its own, these are
+// those of the Gateways in Parents.
+func ToHTTPRoute(obj meta.Object) (meta.Object, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	h := &Host{
+		Version:   u.GetResourceVersion(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+	}
+
+	hostnames, _, _ := unstructured.NestedStringSlice(u.Object, "spec", "hostnames")
+	for _, hostname := range hostnames {
+		h.Hostnames = appendHostname(h.Hostnames, hostname)
+	}
+
+	parents, _, _ := unstructured.NestedSlice(u.Object, "spec", "parentRefs")
+	for _, p := range parents {
+		m, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// Only Gateways have addresses, a parent without group and kind is a Gateway.
+		if group, ok, _ := unstructured.NestedString(m, "group"); ok && group != GatewayGroup {
+			continue
+		}
+		if kind, ok, _ := unstructured.NestedString(m, "kind"); ok && kind != "Gateway" {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(m, "name")
+		namespace, _, _ := unstructured.NestedString(m, "namespace")
+		if namespace == "" {
+			namespace = h.Namespace
+		}
+		h.Parents = append(h.Parents, namespace+"/"+name)
+	}
+
+	u.Object = nil
+
+	return h, nil
+}
+
+// appendHostname appends hostname to hostnames as a fully qualified name in lower case.
+func appendHostname(hostnames []string, hostname string) []string {
+	if hostname == "" {
+		return hostnames
+	}
+	hostname = strings.ToLower(hostname)
+	if !strings.HasSuffix(hostname, ".") {
+		hostname += "."
+	}
+	return append(hostnames, hostname)
+}
+
+var _ runtime.Object = &Host{}
+
+// DeepCopyObject implements the ObjectKind interface.
+func (h *Host) DeepCopyObject() runtime.Object {
+	h1 := &Host{
+		Version:   h.Version,
+		Name:      h.Name,
+		Namespace: h.Namespace,
+		Hostnames: make([]string, len(h.Hostnames)),
+		Addresses: make([]string, len(h.Addresses)),
+		Parents:   make([]string, len(h.Parents)),
+	}
+	copy(h1.Hostnames, h.Hostnames)
+	copy(h1.Addresses, h.Addresses)
+	copy(h1.Parents, h.Parents)
+	return h1
+}
+
+// GetNamespace implements the metav1.Object interface.
+func (h *Host) GetNamespace() string { return h.Namespace }
+
+// SetNamespace implements the metav1.Object interface.
+func (h *Host) SetNamespace(namespace string) {}
+
+// GetName implements the metav1.Object interface.
+func (h *Host) GetName() string { return h.Name }
+
+// SetName implements the metav1.Object interface.
+func (h *Host) SetName(name string) {}
+
+// GetResourceVersion implements the metav1.Object interface.
+func (h *Host) GetResourceVersion() string { return h.Version }
+
+// SetResourceVersion implements the metav1.Object interface.
+func (h *Host) SetResourceVersion(version string) {}
@@ -0,0 +1,64 @@
+package object
+
+import (
+	"fmt"
+
+	api "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Node is a stripped down api.Node with only the items we need for CoreDNS.
+type Node struct {
+	// Don't add new fields to this struct without talking to the CoreDNS maintainers.
+	Version string
+	Name    string
+	Zone    string // value of the topology.kubernetes.io/zone label
+
+	*Empty
+}
+
+// ToNode converts an api.Node to a *Node.
+func ToNode(obj meta.Object) (meta.Object, error) {
+	node, ok := obj.(*api.Node)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	n := &Node{
+		Version: node.GetResourceVersion(),
+		Name:    node.GetName(),
+		Zone:    node.GetLabels()[api.LabelTopologyZone],
+	}
+	*node = api.Node{}
+	return n, nil
+}
+
+var _ runtime.Object = &Node{}
+
+// DeepCopyObject implements the ObjectKind interface.
+func (n *Node) DeepCopyObject() runtime.Object {
+	n1 := &Node{
+		Version: n.Version,
+		Name:    n.Name,
+		Zone:    n.Zone,
+	}
+	return n1
+}
+
+// GetNamespace implements the metav1.Object interface.
+func (n *Node) GetNamespace() string { return "" }
+
+// SetNamespace implements the metav1.Object interface.
+func (n *Node) SetNamespace(namespace string) {}
+
+// GetName implements the metav1.Object interface.
+func (n *Node) GetName() string { return n.Name }
+
+// SetName implements the metav1.Object interface.
+func (n *Node) SetName(name string) {}
+
+// GetResourceVersion implements the metav1.Object interface.
+func (n *Node) GetResourceVersion() string { return n.Version }
+
+// SetResourceVersion implements the metav1.Object interface.
+func (n *Node) SetResourceVersion(version string) {}
@@ -23,6 +23,9 @@ type Service struct {
 	// ExternalIPs we may want to export.
 	ExternalIPs []string
 
+	// PublishNotReady is set if the addresses of endpoints that aren't ready are published too.
+	PublishNotReady bool
+
 	*Empty
 }
 
@@ -43,6 +46,8 @@ func ToService(obj meta.Object) (meta.Object, error) {
 		Type:         svc.Spec.Type,
 		ExternalName: svc.Spec.ExternalName,
 
+		PublishNotReady: svc.Spec.PublishNotReadyAddresses,
+
 		ExternalIPs: make([]string, len(svc.Status.LoadBalancer.Ingress)+len(svc.Spec.ExternalIPs)),
 	}
 
@@ -95,6 +100,8 @@ func (s *Service) DeepCopyObject() runtime.Object {
 		ClusterIPs:   make([]string, len(s.ClusterIPs)),
 		Ports:        make([]api.ServicePort, len(s.Ports)),
 		ExternalIPs:  make([]string, len(s.ExternalIPs)),
+
+		PublishNotReady: s.PublishNotReady,
 	}
 	copy(s1.ClusterIPs, s.ClusterIPs)
 	copy(s1.Ports, s.Ports)
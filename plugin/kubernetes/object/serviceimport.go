@@ -0,0 +1,74 @@
+package object
+
+import (
+	"fmt"
+
+	api "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ServiceImportResource is the ServiceImport resource of the Multi-Cluster Services API, see
+// https://github.com/kubernetes/enhancements/tree/master/keps/sig-multicluster/1645-multi-cluster-services-api.
+var ServiceImportResource = schema.GroupVersionResource{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Resource: "serviceimports"}
+
+const (
+	// LabelMultiClusterServiceName is the label of an EndpointSlice with the name of the ServiceImport it
+	// belongs to.
+	LabelMultiClusterServiceName = "multicluster.kubernetes.io/service-name"
+	// LabelSourceCluster is the label of an EndpointSlice with the name of the cluster it is imported from.
+	LabelSourceCluster = "multicluster.kubernetes.io/source-cluster"
+)
+
+// ToServiceImport converts an unstructured ServiceImport to a *Service. A ServiceImport of type ClusterSetIP
+// becomes a service with the cluster set IPs as its cluster IPs, one of type Headless a headless service.
+func ToServiceImport(obj meta.Object) (meta.Object, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %v", obj)
+	}
+	s := &Service{
+		Version:   u.GetResourceVersion(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+		Index:     ServiceKey(u.GetName(), u.GetNamespace()),
+		Type:      api.ServiceTypeClusterIP,
+	}
+
+	typ, _, _ := unstructured.NestedString(u.Object, "spec", "type")
+	if typ == "Headless" {
+		s.ClusterIPs = []string{api.ClusterIPNone}
+	} else {
+		s.ClusterIPs, _, _ = unstructured.NestedStringSlice(u.Object, "spec", "ips")
+		if len(s.ClusterIPs) == 0 {
+			s.ClusterIPs = []string{""}
+		}
+	}
+
+	ports, _, _ := unstructured.NestedSlice(u.Object, "spec", "ports")
+	for _, p := range ports {
+		m, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sp := api.ServicePort{}
+		sp.Name, _, _ = unstructured.NestedString(m, "name")
+		protocol, _, _ := unstructured.NestedString(m, "protocol")
+		sp.Protocol = api.Protocol(protocol)
+		if sp.Protocol == "" {
+			sp.Protocol = api.ProtocolTCP
+		}
+		port, _, _ := unstructured.NestedInt64(m, "port")
+		sp.Port = int32(port)
+		s.Ports = append(s.Ports, sp)
+	}
+	if len(s.Ports) == 0 {
+		// Add sentinel if there are no ports.
+		s.Ports = []api.ServicePort{{Port: -1}}
+	}
+
+	u.Object = nil
+
+	return s, nil
+}
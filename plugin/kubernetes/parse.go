@@ -1,6 +1,8 @@
 package kubernetes
 
 import (
+	"strings"
+
 	"github.com/coredns/coredns/plugin/pkg/dnsutil"
 
 	"github.com/miekg/dns"
@@ -14,14 +16,22 @@ type recordRequest struct {
 	// SRV record.
 	protocol string
 	endpoint string
+	// The cluster of the endpoints, only used in multi-cluster zones.
+	cluster string
 	// The servicename used in Kubernetes.
 	service string
 	// The namespace used in Kubernetes.
 	namespace string
 	// A each name can be for a pod or a service, here we track what we've seen, either "pod" or "service".
 	podOrSvc string
+	// notReady is set if the name starts with the notReadyLabel, the endpoints that aren't ready are
+	// returned too.
+	notReady bool
 }
 
+// notReadyLabel is the label a name starts with to also get the endpoints that aren't ready.
+const notReadyLabel = "_notready"
+
 // parseRequest parses the qname to find all the elements we need for querying k8s. Anything
 // that is not parsed will have the wildcard "*" value (except r.endpoint).
 // Potential underscores are stripped from _port and _protocol.
@@ -30,8 +40,13 @@ func parseRequest(name, zone string) (r recordRequest, err error) {
 	// 1. _port._protocol.service.namespace.pod|svc.zone
 	// 2. (endpoint): endpoint.service.namespace.pod|svc.zone
 	// 3. (service): service.namespace.pod|svc.zone
+	// Each may be prefixed with the notReadyLabel.
 
 	base, _ := dnsutil.TrimZone(name, zone)
+	if b := strings.TrimPrefix(base, notReadyLabel+"."); b != base {
+		r.notReady = true
+		base = b
+	}
 	// return NODATA for apex queries
 	if base == "" || base == Svc || base == Pod {
 		return r, nil
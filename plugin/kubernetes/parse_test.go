@@ -19,6 +19,9 @@ func TestParseRequest(t *testing.T) {
 		{"*.any.*.any.svc.inter.webs.tests.", "*.any..*.any.svc"},
 		// A request of endpoint
 		{"1-2-3-4.webs.mynamespace.svc.inter.webs.tests.", "*.*.1-2-3-4.webs.mynamespace.svc"},
+		// requests for the endpoints that aren't ready
+		{"_notready.webs.mynamespace.svc.inter.webs.tests.", "*.*..webs.mynamespace.svc"},
+		{"_notready._http._tcp.webs.mynamespace.svc.inter.webs.tests.", "http.tcp..webs.mynamespace.svc"},
 		// bare zone
 		{"inter.webs.tests.", "....."},
 		// bare svc type
@@ -202,6 +202,32 @@ func ParseStanza(c *caddy.Controller) (*Kubernetes, error) {
 				return nil, c.Errf("ttl must be in range [0, 3600]: %d", t)
 			}
 			k8s.ttl = uint32(t)
+		case "topology":
+			if len(c.RemainingArgs()) != 0 {
+				return nil, c.ArgErr()
+			}
+			k8s.topology = true
+		case "terminating_endpoints":
+			if len(c.RemainingArgs()) != 0 {
+				return nil, c.ArgErr()
+			}
+			k8s.terminating = true
+		case "multicluster":
+			args := c.RemainingArgs()
+			if len(args) == 0 {
+				return nil, c.ArgErr()
+			}
+			for _, a := range args {
+				z := plugin.Name(a).Normalize()
+				found := false
+				for _, zone := range k8s.Zones {
+					found = found || zone == z
+				}
+				if !found {
+					return nil, c.Errf("multicluster zone %s is not one of the zones of the plugin", a)
+				}
+				k8s.multiclusterZones = append(k8s.multiclusterZones, z)
+			}
 		case "noendpoints":
 			if len(c.RemainingArgs()) != 0 {
 				return nil, c.ArgErr()
@@ -610,3 +610,173 @@ func TestKubernetesParseIgnoreEmptyService(t *testing.T) {
 		}
 	}
 }
+
+func TestKubernetesParseTopology(t *testing.T) {
+	tests := []struct {
+		input            string // Corefile data as string
+		shouldErr        bool   // true if test case is expected to produce an error.
+		expectedTopology bool
+	}{
+		// valid
+		{
+			`kubernetes coredns.local {
+	topology
+}`,
+			false,
+			true,
+		},
+		// invalid
+		{
+			`kubernetes coredns.local {
+	topology zone
+}`,
+			true,
+			false,
+		},
+		// not set
+		{
+			`kubernetes coredns.local {
+}`,
+			false,
+			false,
+		},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		k8sController, err := kubernetesParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error, but did not find error for input '%s'. Error was: '%v'", i, test.input, err)
+		}
+
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+
+		if k8sController.topology != test.expectedTopology {
+			t.Errorf("Test %d: Expected topology to be '%v', found '%v' for input '%s'", i, test.expectedTopology, k8sController.topology, test.input)
+		}
+	}
+}
+
+func TestKubernetesParseTerminatingEndpoints(t *testing.T) {
+	tests := []struct {
+		input               string // Corefile data as string
+		shouldErr           bool   // true if test case is expected to produce an error.
+		expectedTerminating bool
+	}{
+		// valid
+		{
+			`kubernetes coredns.local {
+	terminating_endpoints
+}`,
+			false,
+			true,
+		},
+		// invalid
+		{
+			`kubernetes coredns.local {
+	terminating_endpoints yes
+}`,
+			true,
+			false,
+		},
+		// not set
+		{
+			`kubernetes coredns.local {
+}`,
+			false,
+			false,
+		},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		k8sController, err := kubernetesParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error, but did not find error for input '%s'. Error was: '%v'", i, test.input, err)
+		}
+
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+
+		if k8sController.terminating != test.expectedTerminating {
+			t.Errorf("Test %d: Expected terminating to be '%v', found '%v' for input '%s'", i, test.expectedTerminating, k8sController.terminating, test.input)
+		}
+	}
+}
+
+func TestKubernetesParseMultiCluster(t *testing.T) {
+	tests := []struct {
+		input         string // Corefile data as string
+		shouldErr     bool   // true if test case is expected to produce an error.
+		expectedZones []string
+	}{
+		// valid
+		{
+			`kubernetes cluster.local clusterset.local {
+	multicluster clusterset.local
+}`,
+			false,
+			[]string{"clusterset.local."},
+		},
+		// not a zone of the plugin
+		{
+			`kubernetes cluster.local {
+	multicluster clusterset.local
+}`,
+			true,
+			nil,
+		},
+		// no zones
+		{
+			`kubernetes cluster.local clusterset.local {
+	multicluster
+}`,
+			true,
+			nil,
+		},
+		// not set
+		{
+			`kubernetes cluster.local {
+}`,
+			false,
+			nil,
+		},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		k8sController, err := kubernetesParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error, but did not find error for input '%s'. Error was: '%v'", i, test.input, err)
+		}
+
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+
+		if len(k8sController.multiclusterZones) != len(test.expectedZones) {
+			t.Errorf("Test %d: Expected multicluster zones %v, found %v for input '%s'", i, test.expectedZones, k8sController.multiclusterZones, test.input)
+			continue
+		}
+		for j, z := range test.expectedZones {
+			if k8sController.multiclusterZones[j] != z {
+				t.Errorf("Test %d: Expected multicluster zones %v, found %v for input '%s'", i, test.expectedZones, k8sController.multiclusterZones, test.input)
+			}
+		}
+	}
+}
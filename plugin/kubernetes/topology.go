@@ -0,0 +1,51 @@
+package kubernetes
+
+import (
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+)
+
+// clientZone returns the zone of the client with ip, or the empty string if it isn't known. The client must
+// be a pod, its zone is the zone of the node it runs on.
+func (k *Kubernetes) clientZone(ip string) string {
+	for _, p := range k.APIConn.PodIndex(ip) {
+		if p.NodeName == "" {
+			continue
+		}
+		if zone := k.APIConn.NodeZone(p.NodeName); zone != "" {
+			return zone
+		}
+	}
+	return ""
+}
+
+// forZone returns true if addr is meant for clients in zone. With topology hints it is if the hints
+// include zone, otherwise if addr is in zone.
+func forZone(addr object.EndpointAddress, zone string) bool {
+	if len(addr.ForZones) == 0 {
+		return addr.Zone == zone
+	}
+	for _, z := range addr.ForZones {
+		if z == zone {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEndpointsInZone returns true if svc has ready endpoints meant for clients in zone. Addresses that
+// aren't ready don't count, a zone with only those falls back to all endpoints like a zone without any.
+func hasEndpointsInZone(endpointsList []*object.Endpoints, svc *object.Service, zone string) bool {
+	for _, ep := range endpointsList {
+		if object.EndpointsKey(svc.Name, svc.Namespace) != ep.Index {
+			continue
+		}
+		for _, eps := range ep.Subsets {
+			for _, addr := range eps.Addresses {
+				if forZone(addr, zone) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
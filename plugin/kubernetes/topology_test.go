@@ -0,0 +1,207 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	api "k8s.io/api/core/v1"
+)
+
+type APIConnTopologyTest struct {
+	APIConnServeTest
+	endpoints []*object.Endpoints
+	svc       *object.Service // topologySvc if nil
+}
+
+var topologySvc = &object.Service{
+	Name:       "hdls",
+	Namespace:  "testns",
+	Type:       api.ServiceTypeClusterIP,
+	ClusterIPs: []string{api.ClusterIPNone},
+}
+
+func (APIConnTopologyTest) PodIndex(ip string) []*object.Pod {
+	switch ip {
+	case "10.240.0.1":
+		return []*object.Pod{{Namespace: "testns", Name: "client-a", PodIP: ip, NodeName: "node-a"}}
+	case "10.240.0.2":
+		return []*object.Pod{{Namespace: "testns", Name: "client-c", PodIP: ip, NodeName: "node-c"}}
+	case "10.240.0.3":
+		return []*object.Pod{{Namespace: "testns", Name: "client-d", PodIP: ip, NodeName: "node-d"}}
+	}
+	return nil
+}
+
+func (a APIConnTopologyTest) SvcIndex(string) []*object.Service { return a.ServiceList() }
+func (a APIConnTopologyTest) ServiceList() []*object.Service {
+	if a.svc != nil {
+		return []*object.Service{a.svc}
+	}
+	return []*object.Service{topologySvc}
+}
+func (a APIConnTopologyTest) EpIndex(string) []*object.Endpoints {
+	return a.endpoints
+}
+func (a APIConnTopologyTest) EndpointsList() []*object.Endpoints { return a.endpoints }
+
+func (APIConnTopologyTest) NodeZone(node string) string {
+	switch node {
+	case "node-a":
+		return "a"
+	case "node-b", "node-d":
+		return "b"
+	}
+	return ""
+}
+
+func topologyEndpoints(addrs ...object.EndpointAddress) []*object.Endpoints {
+	return []*object.Endpoints{{
+		Name:      "hdls-1",
+		Namespace: "testns",
+		Index:     object.EndpointsKey("hdls", "testns"),
+		Subsets: []object.EndpointSubset{{
+			Addresses: addrs,
+			Ports:     []object.EndpointPort{{Port: 80, Name: "http", Protocol: "TCP"}},
+		}},
+	}}
+}
+
+func TestTopology(t *testing.T) {
+	tests := []struct {
+		endpoints []*object.Endpoints
+		remote    string
+		expected  []string
+	}{
+		// Zone of the client is a.
+		{
+			topologyEndpoints(
+				object.EndpointAddress{IP: "172.0.0.1", NodeName: "node-a", Zone: "a"},
+				object.EndpointAddress{IP: "172.0.0.2", NodeName: "node-b", Zone: "b"},
+			),
+			"10.240.0.1", []string{"172.0.0.1"},
+		},
+		// Hints take precedence over the zone of the endpoint.
+		{
+			topologyEndpoints(
+				object.EndpointAddress{IP: "172.0.0.1", NodeName: "node-a", Zone: "a", ForZones: []string{"a"}},
+				object.EndpointAddress{IP: "172.0.0.2", NodeName: "node-b", Zone: "b", ForZones: []string{"a", "b"}},
+			),
+			"10.240.0.1", []string{"172.0.0.1", "172.0.0.2"},
+		},
+		// No endpoints in zone a, fall back to all of them.
+		{
+			topologyEndpoints(
+				object.EndpointAddress{IP: "172.0.0.1", NodeName: "node-a", Zone: "a", ForZones: []string{"b"}},
+				object.EndpointAddress{IP: "172.0.0.2", NodeName: "node-b", Zone: "b", ForZones: []string{"b"}},
+			),
+			"10.240.0.1", []string{"172.0.0.1", "172.0.0.2"},
+		},
+		// No endpoints run on node-d, it is in zone b.
+		{
+			topologyEndpoints(
+				object.EndpointAddress{IP: "172.0.0.1", NodeName: "node-a", Zone: "a"},
+				object.EndpointAddress{IP: "172.0.0.2", NodeName: "node-b", Zone: "b"},
+			),
+			"10.240.0.3", []string{"172.0.0.2"},
+		},
+		// The zone of node-c isn't known.
+		{
+			topologyEndpoints(
+				object.EndpointAddress{IP: "172.0.0.1", NodeName: "node-a", Zone: "a"},
+				object.EndpointAddress{IP: "172.0.0.2", NodeName: "node-b", Zone: "b"},
+			),
+			"10.240.0.2", []string{"172.0.0.1", "172.0.0.2"},
+		},
+		// Not a pod.
+		{
+			topologyEndpoints(
+				object.EndpointAddress{IP: "172.0.0.1", NodeName: "node-a", Zone: "a"},
+				object.EndpointAddress{IP: "172.0.0.2", NodeName: "node-b", Zone: "b"},
+			),
+			"10.0.0.1", []string{"172.0.0.1", "172.0.0.2"},
+		},
+	}
+
+	for i, tc := range tests {
+		k := New([]string{"cluster.local."})
+		k.APIConn = &APIConnTopologyTest{endpoints: tc.endpoints}
+		k.Namespaces = map[string]struct{}{"testns": {}}
+		k.topology = true
+
+		m := new(dns.Msg)
+		m.SetQuestion("hdls.testns.svc.cluster.local.", dns.TypeA)
+		w := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: tc.remote})
+		if _, err := k.ServeDNS(context.TODO(), w, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %s", i, err)
+		}
+
+		var got []string
+		for _, rr := range w.Msg.Answer {
+			got = append(got, rr.(*dns.A).A.String())
+		}
+		if len(got) != len(tc.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+			continue
+		}
+		for j := range got {
+			if got[j] != tc.expected[j] {
+				t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+			}
+		}
+	}
+}
+
+func TestTopologyNotReady(t *testing.T) {
+	svc := *topologySvc
+	svc.PublishNotReady = true
+
+	tests := []struct {
+		ready    []object.EndpointAddress
+		notReady []object.EndpointAddress
+		expected []string
+	}{
+		// Zone a only has an endpoint that isn't ready, fall back to all of them.
+		{
+			[]object.EndpointAddress{{IP: "172.0.0.2", NodeName: "node-b", Zone: "b"}},
+			[]object.EndpointAddress{{IP: "172.0.0.1", NodeName: "node-a", Zone: "a"}},
+			[]string{"172.0.0.2", "172.0.0.1"},
+		},
+		// Zone a has a ready endpoint, the endpoints that aren't ready are limited to zone a too.
+		{
+			[]object.EndpointAddress{{IP: "172.0.0.1", NodeName: "node-a", Zone: "a"}, {IP: "172.0.0.2", NodeName: "node-b", Zone: "b"}},
+			[]object.EndpointAddress{{IP: "172.0.0.3", NodeName: "node-a", Zone: "a"}, {IP: "172.0.0.4", NodeName: "node-b", Zone: "b"}},
+			[]string{"172.0.0.1", "172.0.0.3"},
+		},
+	}
+
+	for i, tc := range tests {
+		eps := topologyEndpoints(tc.ready...)
+		eps[0].Subsets[0].NotReadyAddresses = tc.notReady
+
+		k := New([]string{"cluster.local."})
+		k.APIConn = &APIConnTopologyTest{endpoints: eps, svc: &svc}
+		k.Namespaces = map[string]struct{}{"testns": {}}
+		k.topology = true
+
+		m := new(dns.Msg)
+		m.SetQuestion("hdls.testns.svc.cluster.local.", dns.TypeA)
+		w := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: "10.240.0.1"})
+		if _, err := k.ServeDNS(context.TODO(), w, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %s", i, err)
+		}
+
+		var got []string
+		for _, rr := range w.Msg.Answer {
+			got = append(got, rr.(*dns.A).A.String())
+		}
+		if strings.Join(got, " ") != strings.Join(tc.expected, " ") {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+		}
+	}
+}
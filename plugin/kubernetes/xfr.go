@@ -19,7 +19,7 @@ import (
 // Transfer implements the transfer.Transfer interface.
 func (k *Kubernetes) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
 	match := plugin.Zones(k.Zones).Matches(zone)
-	if match == "" {
+	if match == "" || k.isMultiClusterZone(match) {
 		return nil, transfer.ErrNotAuthoritative
 	}
 	// state is not used here, hence the empty request.Request{]
@@ -103,8 +103,9 @@ func (k *Kubernetes) Transfer(zone string, serial uint32) (<-chan []dns.RR, erro
 
 				for _, ep := range endpointsList {
 					for _, eps := range ep.Subsets {
-						srvWeight := calcSRVWeight(len(eps.Addresses))
-						for _, addr := range eps.Addresses {
+						addrs := k.endpointAddresses(eps, svc.PublishNotReady, false)
+						srvWeight := calcSRVWeight(len(addrs))
+						for _, addr := range addrs {
 							s := msg.Service{Host: addr.IP, TTL: k.ttl}
 							s.Key = strings.Join(svcBase, "/")
 							// We don't need to change the msg.Service host from IP to Name yet
@@ -25,20 +25,33 @@ func ContextWithMetadata(ctx context.Context) context.Context {
 	return context.WithValue(ctx, key{}, md{})
 }
 
+// collectedKey is the context key of the request the metadata in the context was collected for.
+type collectedKey struct{}
+
 // ServeDNS implements the plugin.Handler interface.
 func (m *Metadata) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 
+	// The server collects the metadata before the plugin chain when the filter funcs of a view need it.
+	if ctx.Value(collectedKey{}) != r {
+		ctx = m.Collect(ctx, request.Request{W: w, Req: r})
+	}
+
+	rcode, err := plugin.NextOrFailure(m.Name(), m.Next, ctx, w, r)
+
+	return rcode, err
+}
+
+// Collect collects the metadata from all providers and returns the context holding it. The server uses
+// this to collect the metadata before a request is sent down the plugin chain, see dnsserver.MetadataCollector.
+func (m *Metadata) Collect(ctx context.Context, state request.Request) context.Context {
 	ctx = ContextWithMetadata(ctx)
+	ctx = context.WithValue(ctx, collectedKey{}, state.Req)
 
-	state := request.Request{W: w, Req: r}
 	if plugin.Zones(m.Zones).Matches(state.Name()) != "" {
 		// Go through all Providers and collect metadata.
 		for _, p := range m.Providers {
 			ctx = p.Metadata(ctx, state)
 		}
 	}
-
-	rcode, err := plugin.NextOrFailure(m.Name(), m.Next, ctx, w, r)
-
-	return rcode, err
+	return ctx
 }
@@ -88,3 +88,38 @@ func TestLabelFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestMetadataCollected(t *testing.T) {
+	calls := 0
+	p := testProvider{"test/calls": func() string { return "" }}
+	next := &testHandler{}
+	m := Metadata{
+		Zones:     []string{"."},
+		Providers: []Provider{countingProvider{p, &calls}},
+		Next:      next,
+	}
+
+	r := new(dns.Msg)
+	ctx := m.Collect(context.TODO(), request.Request{W: &test.ResponseWriter{}, Req: r})
+	m.ServeDNS(ctx, &test.ResponseWriter{}, r)
+	if calls != 1 {
+		t.Errorf("Expected metadata to be collected once for the same request, got %d", calls)
+	}
+
+	// Another request, e.g. one sent by a plugin while handling the first, has its own metadata.
+	m.ServeDNS(ctx, &test.ResponseWriter{}, new(dns.Msg))
+	if calls != 2 {
+		t.Errorf("Expected metadata to be collected for another request, got %d collections", calls)
+	}
+}
+
+// countingProvider counts how many times metadata is collected.
+type countingProvider struct {
+	testProvider
+	calls *int
+}
+
+func (cp countingProvider) Metadata(ctx context.Context, state request.Request) context.Context {
+	*cp.calls++
+	return cp.testProvider.Metadata(ctx, state)
+}
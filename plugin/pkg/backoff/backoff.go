@@ -0,0 +1,30 @@
+// Package backoff is used by the plugins that periodically update their zones from an API, to wait longer
+// between the updates when the API throttles them.
+package backoff
+
+import (
+	"errors"
+	"time"
+)
+
+// Max is the longest time to wait between updates when they are throttled, unless refresh is longer.
+const Max = 10 * time.Minute
+
+// ErrThrottled is wrapped in the error of an update when the API throttled a request.
+var ErrThrottled = errors.New("throttled")
+
+// Next returns the time to wait before the next update. This is refresh, unless the last update was
+// throttled, then it is twice the last wait, up to Max.
+func Next(wait, refresh time.Duration, throttled bool) time.Duration {
+	if !throttled {
+		return refresh
+	}
+	wait *= 2
+	if max := Max; wait > max {
+		if refresh > max {
+			max = refresh
+		}
+		wait = max
+	}
+	return wait
+}
@@ -0,0 +1,26 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		wait, refresh time.Duration
+		throttled     bool
+		expected      time.Duration
+	}{
+		{time.Minute, time.Minute, false, time.Minute},
+		{8 * time.Minute, time.Minute, false, time.Minute},
+		{time.Minute, time.Minute, true, 2 * time.Minute},
+		{8 * time.Minute, time.Minute, true, Max},
+		{Max, time.Minute, true, Max},
+		{time.Hour, time.Hour, true, time.Hour},
+	}
+	for i, tc := range tests {
+		if got := Next(tc.wait, tc.refresh, tc.throttled); got != tc.expected {
+			t.Errorf("Test %d: expected %s, got %s", i, tc.expected, got)
+		}
+	}
+}
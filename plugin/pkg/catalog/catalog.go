@@ -0,0 +1,139 @@
+// Package catalog contains functions to create and read catalog zones (RFC 9432). A catalog zone lists the
+// member zones a primary serves, so that secondaries can add and remove these zones without configuration.
+package catalog
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// Version is the version of the catalog zone schema, see RFC 9432, Section 4.2.1.
+const Version = "2"
+
+// Member is a member zone of a catalog zone.
+type Member struct {
+	Zone  string // fully qualified and lower cased
+	ID    string // unique label under the zones label of the catalog zone
+	Group string // group property, optional
+}
+
+// ID returns the unique ID of member zone name: the hex encoded SHA-1 of the name in wire format. This
+// stays the same as long as the zone is a member.
+func ID(name string) string {
+	buf := make([]byte, 255)
+	off, err := dns.PackDomainName(dns.Fqdn(strings.ToLower(name)), buf, 0, nil, false)
+	if err != nil {
+		buf, off = []byte(name), len(name)
+	}
+	h := sha1.Sum(buf[:off])
+	return hex.EncodeToString(h[:])
+}
+
+// Records returns the records of catalog zone with members, with SOA serial serial. The first record is the
+// SOA record.
+func Records(zone string, serial uint32, members []Member) []dns.RR {
+	zone = dns.Fqdn(strings.ToLower(zone))
+	hdr := func(name string, t uint16) dns.RR_Header {
+		// Catalog zones are not meant to be queried, all TTLs are 0 (Section 4).
+		return dns.RR_Header{Name: name, Rrtype: t, Class: dns.ClassINET, Ttl: 0}
+	}
+
+	rrs := []dns.RR{
+		&dns.SOA{Hdr: hdr(zone, dns.TypeSOA), Ns: "invalid.", Mbox: "invalid.", Serial: serial,
+			Refresh: 3600, Retry: 600, Expire: 2147483646, Minttl: 0},
+		&dns.NS{Hdr: hdr(zone, dns.TypeNS), Ns: "invalid."},
+		&dns.TXT{Hdr: hdr("version."+zone, dns.TypeTXT), Txt: []string{Version}},
+	}
+	for _, m := range members {
+		id := m.ID
+		if id == "" {
+			id = ID(m.Zone)
+		}
+		owner := id + ".zones." + zone
+		rrs = append(rrs, &dns.PTR{Hdr: hdr(owner, dns.TypePTR), Ptr: dns.Fqdn(m.Zone)})
+		if m.Group != "" {
+			rrs = append(rrs, &dns.TXT{Hdr: hdr("group."+owner, dns.TypeTXT), Txt: []string{m.Group}})
+		}
+	}
+	return rrs
+}
+
+// Parse returns the member zones in rrs, the records of catalog zone, sorted by name. Members that are
+// invalid are skipped. Returns error if the catalog zone doesn't have a supported version.
+func Parse(zone string, rrs []dns.RR) ([]Member, error) {
+	zone = dns.Fqdn(strings.ToLower(zone))
+	zones := "zones." + zone
+	version := "version." + zone
+
+	var versions []string
+	ptrs := map[string][]string{} // ID -> member zones
+	groups := map[string]string{} // ID -> group
+	for _, rr := range rrs {
+		name := strings.ToLower(rr.Header().Name)
+		switch x := rr.(type) {
+		case *dns.TXT:
+			if name == version {
+				versions = append(versions, strings.Join(x.Txt, ""))
+				continue
+			}
+			// group.<ID>.zones.<zone>
+			if !strings.HasPrefix(name, "group.") {
+				continue
+			}
+			if id, ok := memberID(strings.TrimPrefix(name, "group."), zones); ok {
+				groups[id] = strings.Join(x.Txt, "")
+			}
+		case *dns.PTR:
+			if id, ok := memberID(name, zones); ok {
+				ptrs[id] = append(ptrs[id], strings.ToLower(dns.Fqdn(x.Ptr)))
+			}
+		}
+	}
+	if len(versions) != 1 || versions[0] != Version {
+		return nil, fmt.Errorf("catalog zone %s: unsupported version %q", zone, versions)
+	}
+
+	ids := make([]string, 0, len(ptrs))
+	for id := range ptrs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	seen := map[string]bool{}
+	members := make([]Member, 0, len(ids))
+	for _, id := range ids {
+		// A member must have exactly one PTR record (Section 4.1), and a zone can only be a member once.
+		if len(ptrs[id]) != 1 {
+			continue
+		}
+		m := ptrs[id][0]
+		if seen[m] {
+			continue
+		}
+		if _, ok := dns.IsDomainName(m); !ok {
+			continue
+		}
+		seen[m] = true
+		members = append(members, Member{Zone: m, ID: id, Group: groups[id]})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Zone < members[j].Zone })
+	return members, nil
+}
+
+// memberID returns the ID of a member in name, which is ID.zones. If name is not of that form, false is
+// returned.
+func memberID(name, zones string) (string, bool) {
+	if !strings.HasSuffix(name, "."+zones) {
+		return "", false
+	}
+	id := strings.TrimSuffix(name, "."+zones)
+	if id == "" || strings.Contains(id, ".") {
+		return "", false
+	}
+	return id, true
+}
@@ -0,0 +1,110 @@
+package catalog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestRecords(t *testing.T) {
+	members := []Member{{Zone: "example.org."}, {Zone: "example.net.", ID: "a", Group: "internal"}}
+	rrs := Records("Catalog.invalid", 10, members)
+
+	expected := []string{
+		"catalog.invalid.\t0\tIN\tSOA\tinvalid. invalid. 10 3600 600 2147483646 0",
+		"catalog.invalid.\t0\tIN\tNS\tinvalid.",
+		"version.catalog.invalid.\t0\tIN\tTXT\t\"2\"",
+		ID("example.org.") + ".zones.catalog.invalid.\t0\tIN\tPTR\texample.org.",
+		"a.zones.catalog.invalid.\t0\tIN\tPTR\texample.net.",
+		"group.a.zones.catalog.invalid.\t0\tIN\tTXT\t\"internal\"",
+	}
+	if len(rrs) != len(expected) {
+		t.Fatalf("Expected %d records, got %d: %v", len(expected), len(rrs), rrs)
+	}
+	for i := range rrs {
+		if rrs[i].String() != expected[i] {
+			t.Errorf("Expected record %d to be %q, got %q", i, expected[i], rrs[i])
+		}
+	}
+
+	parsed, err := Parse("catalog.invalid.", rrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	members[0].ID = ID("example.org.")
+	if !reflect.DeepEqual(parsed, []Member{members[1], members[0]}) {
+		t.Errorf("Expected the members to be parsed back, got %v", parsed)
+	}
+}
+
+func TestID(t *testing.T) {
+	if ID("Example.org") != ID("example.org.") {
+		t.Errorf("Expected the ID to not depend on case and the final dot")
+	}
+	if ID("example.org.") == ID("example.net.") {
+		t.Errorf("Expected different IDs for different zones")
+	}
+	if len(ID("example.org.")) != 40 {
+		t.Errorf("Expected a hex encoded SHA-1, got %q", ID("example.org."))
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rrs       []dns.RR
+		shouldErr bool
+		expected  []string
+	}{
+		{
+			[]dns.RR{
+				test.TXT(`version.catalog.invalid. 0 IN TXT "2"`),
+				test.PTR("b.zones.catalog.invalid. 0 IN PTR example.org."),
+				test.PTR("a.zones.catalog.invalid. 0 IN PTR Example.NET."),
+				// The same zone twice, only the first is used.
+				test.PTR("c.zones.catalog.invalid. 0 IN PTR example.org."),
+				// Two PTR records for a member, skipped.
+				test.PTR("d.zones.catalog.invalid. 0 IN PTR example.com."),
+				test.PTR("d.zones.catalog.invalid. 0 IN PTR example.info."),
+				// Not a member.
+				test.PTR("x.y.zones.catalog.invalid. 0 IN PTR example.edu."),
+				test.PTR("zones.catalog.invalid. 0 IN PTR example.gov."),
+			},
+			false, []string{"example.net.", "example.org."},
+		},
+		{
+			[]dns.RR{test.PTR("a.zones.catalog.invalid. 0 IN PTR example.org.")},
+			true, nil,
+		},
+		{
+			[]dns.RR{
+				test.TXT(`version.catalog.invalid. 0 IN TXT "1"`),
+				test.PTR("a.zones.catalog.invalid. 0 IN PTR example.org."),
+			},
+			true, nil,
+		},
+	}
+
+	for i, tc := range tests {
+		members, err := Parse("catalog.invalid.", tc.rrs)
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: expected error but got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %v", i, err)
+			continue
+		}
+		var zones []string
+		for _, m := range members {
+			zones = append(zones, m.Zone)
+		}
+		if !reflect.DeepEqual(zones, tc.expected) {
+			t.Errorf("Test %d: expected members %v, got %v", i, tc.expected, zones)
+		}
+	}
+}
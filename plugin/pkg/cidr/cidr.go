@@ -1,7 +1,8 @@
-// Package cidr contains functions that deal with classless reverse zones in the DNS.
+// Package cidr contains functions that deal with networks in CIDR notation and classless reverse zones in the DNS.
 package cidr
 
 import (
+	"fmt"
 	"math"
 	"net"
 	"strings"
@@ -81,3 +82,34 @@ func Reverse(nets []string) []string {
 	}
 	return rev
 }
+
+// ParseNetwork parses s as a network in CIDR notation. A single address is a network of only that address, a /32 for
+// IPv4 and a /128 for IPv6.
+func ParseNetwork(s string) (*net.IPNet, error) {
+	cidr := s
+	if !strings.Contains(s, "/") {
+		if strings.Contains(s, ":") {
+			cidr += "/128"
+		} else {
+			cidr += "/32"
+		}
+	}
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("illegal CIDR notation %q", s)
+	}
+	return n, nil
+}
+
+// ParseNetworks parses each of ss with ParseNetwork.
+func ParseNetworks(ss []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(ss))
+	for _, s := range ss {
+		n, err := ParseNetwork(s)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
@@ -45,3 +45,40 @@ func TestReverse(t *testing.T) {
 		}
 	}
 }
+
+func TestParseNetwork(t *testing.T) {
+	tests := []struct {
+		in        string
+		expected  string
+		shouldErr bool
+	}{
+		{"10.218.10.8/24", "10.218.10.0/24", false},
+		{"10.218.10.8", "10.218.10.8/32", false},
+		{"2001:0db8:85a3:0000:0000:8a2e:0370:7334", "2001:db8:85a3::8a2e:370:7334/128", false},
+		{"2001:db8::/32", "2001:db8::/32", false},
+		{"10.218.10.8/33", "", true},
+		{"10.218.10", "", true},
+		{"example.org", "", true},
+		{"*", "", true},
+	}
+	for i, tc := range tests {
+		n, err := ParseNetwork(tc.in)
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d, expected error for %q", i, tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d, expected no error for %q, got %s", i, tc.in, err)
+			continue
+		}
+		if n.String() != tc.expected {
+			t.Errorf("Test %d, expected %s, got %s", i, tc.expected, n)
+		}
+	}
+
+	if _, err := ParseNetworks([]string{"10.0.0.0/8", "10.0.0.256"}); err == nil {
+		t.Errorf("Expected error for an invalid network")
+	}
+}
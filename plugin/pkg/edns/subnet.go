@@ -0,0 +1,108 @@
+package edns
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// Subnet returns the EDNS0 Client Subnet option (RFC 7871) from m, or nil if there isn't one.
+func Subnet(m *dns.Msg) *dns.EDNS0_SUBNET {
+	o := m.IsEdns0()
+	if o == nil {
+		return nil
+	}
+	for _, s := range o.Option {
+		if e, ok := s.(*dns.EDNS0_SUBNET); ok {
+			return e
+		}
+	}
+	return nil
+}
+
+// RemoveSubnet removes all EDNS0 Client Subnet options from m.
+func RemoveSubnet(m *dns.Msg) {
+	o := m.IsEdns0()
+	if o == nil {
+		return
+	}
+	opts := o.Option[:0]
+	for _, s := range o.Option {
+		if _, ok := s.(*dns.EDNS0_SUBNET); ok {
+			continue
+		}
+		opts = append(opts, s)
+	}
+	o.Option = opts
+}
+
+// NewSubnet returns a Client Subnet option for ip. The source prefix length is set to v4 or v6 bits
+// depending on the address family of ip, and the address is masked accordingly.
+func NewSubnet(ip net.IP, v4, v6 uint8) *dns.EDNS0_SUBNET {
+	e := &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET}
+	if ip4 := ip.To4(); ip4 != nil {
+		e.Family = 1
+		e.SourceNetmask = v4
+		e.Address = ip4.Mask(net.CIDRMask(int(v4), 32))
+		return e
+	}
+	e.Family = 2
+	e.SourceNetmask = v6
+	e.Address = ip.To16().Mask(net.CIDRMask(int(v6), 128))
+	return e
+}
+
+// TruncateSubnet shortens the source prefix length of e to at most v4 or v6 bits (depending on the
+// family) and masks the address accordingly. Options with an unknown family are left alone.
+func TruncateSubnet(e *dns.EDNS0_SUBNET, v4, v6 uint8) {
+	switch e.Family {
+	case 1:
+		if e.SourceNetmask > v4 {
+			e.SourceNetmask = v4
+		}
+		e.Address = e.Address.To4().Mask(net.CIDRMask(int(e.SourceNetmask), 32))
+	case 2:
+		if e.SourceNetmask > v6 {
+			e.SourceNetmask = v6
+		}
+		e.Address = e.Address.To16().Mask(net.CIDRMask(int(e.SourceNetmask), 128))
+	}
+}
+
+// SubnetReport carries the Client Subnet option an upstream returned back up the plugin chain. This
+// is needed when the plugin talking to the upstream removes the option from the reply, because the
+// client never sent one.
+type SubnetReport struct {
+	sync.RWMutex
+	e *dns.EDNS0_SUBNET
+}
+
+// Subnet returns the reported Client Subnet option, or nil if nothing was reported.
+func (s *SubnetReport) Subnet() *dns.EDNS0_SUBNET {
+	s.RLock()
+	defer s.RUnlock()
+	return s.e
+}
+
+type subnetReportKey struct{}
+
+// WithSubnetReport returns a context in which plugins further down the chain can report the Client
+// Subnet option of an upstream reply, via ReportSubnet.
+func WithSubnetReport(ctx context.Context) (context.Context, *SubnetReport) {
+	s := &SubnetReport{}
+	return context.WithValue(ctx, subnetReportKey{}, s), s
+}
+
+// ReportSubnet records e as the Client Subnet option of the reply. It is a noop if ctx wasn't
+// created by WithSubnetReport.
+func ReportSubnet(ctx context.Context, e *dns.EDNS0_SUBNET) {
+	s, ok := ctx.Value(subnetReportKey{}).(*SubnetReport)
+	if !ok {
+		return
+	}
+	s.Lock()
+	s.e = e
+	s.Unlock()
+}
@@ -0,0 +1,70 @@
+package edns
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestNewSubnet(t *testing.T) {
+	tests := []struct {
+		ip       string
+		family   uint16
+		netmask  uint8
+		expected string
+	}{
+		{"192.0.2.17", 1, 24, "192.0.2.0"},
+		{"2001:db8:1:2:3::1", 2, 56, "2001:db8:1::"},
+	}
+	for i, tc := range tests {
+		e := NewSubnet(net.ParseIP(tc.ip), 24, 56)
+		if e.Family != tc.family || e.SourceNetmask != tc.netmask || e.Address.String() != tc.expected {
+			t.Errorf("Test %d: expected %d %s/%d, got %d %s/%d", i, tc.family, tc.expected, tc.netmask, e.Family, e.Address, e.SourceNetmask)
+		}
+	}
+}
+
+func TestTruncateSubnet(t *testing.T) {
+	e := NewSubnet(net.ParseIP("192.0.2.17"), 32, 128)
+	TruncateSubnet(e, 16, 48)
+	if e.SourceNetmask != 16 || e.Address.String() != "192.0.0.0" {
+		t.Errorf("Expected 192.0.0.0/16, got %s/%d", e.Address, e.SourceNetmask)
+	}
+	// never lengthen the prefix
+	TruncateSubnet(e, 24, 48)
+	if e.SourceNetmask != 16 {
+		t.Errorf("Expected source prefix length 16, got %d", e.SourceNetmask)
+	}
+}
+
+func TestRemoveSubnet(t *testing.T) {
+	m := ednsMsg()
+	o := m.IsEdns0()
+	o.Option = append(o.Option, &dns.EDNS0_NSID{Code: dns.EDNS0NSID}, NewSubnet(net.ParseIP("192.0.2.1"), 24, 56))
+	if Subnet(m) == nil {
+		t.Fatal("Expected subnet option, got none")
+	}
+	RemoveSubnet(m)
+	if Subnet(m) != nil {
+		t.Errorf("Expected no subnet option, got one")
+	}
+	if len(o.Option) != 1 {
+		t.Errorf("Expected 1 remaining option, got %d", len(o.Option))
+	}
+}
+
+func TestReportSubnet(t *testing.T) {
+	e := NewSubnet(net.ParseIP("192.0.2.1"), 24, 56)
+	ReportSubnet(context.TODO(), e) // must not panic
+
+	ctx, report := WithSubnetReport(context.TODO())
+	if report.Subnet() != nil {
+		t.Fatal("Expected no reported subnet")
+	}
+	ReportSubnet(ctx, e)
+	if report.Subnet() != e {
+		t.Errorf("Expected reported subnet %s, got %v", e, report.Subnet())
+	}
+}
@@ -0,0 +1,147 @@
+// Package proxyproto implements the PROXY protocol, version 1 and 2, for listeners behind a load balancer.
+// See https://www.haproxy.org/download/2.8/doc/proxy-protocol.txt.
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+const (
+	v1MaxLength = 107 // including the CRLF
+	v2HeaderLen = 16  // signature, version and command, family and protocol, and length
+
+	v2CmdLocal = 0x0
+	v2CmdProxy = 0x1
+
+	v2FamInet  = 0x1
+	v2FamInet6 = 0x2
+	v2ProtoTCP = 0x1
+	v2ProtoUDP = 0x2
+)
+
+// errNoHeader is returned when the data doesn't start with a PROXY protocol header.
+var errNoHeader = errors.New("no PROXY protocol header")
+
+// readHeader reads the PROXY protocol header from r, and returns the source address from it. The
+// address is nil when the header doesn't hold one, for a health check from the proxy itself. If r doesn't
+// start with a header, errNoHeader is returned and nothing is read.
+func readHeader(r *bufio.Reader) (net.Addr, error) {
+	b, err := r.Peek(len(v1Prefix))
+	if err != nil {
+		return nil, errNoHeader
+	}
+	if bytes.Equal(b, v1Prefix) {
+		return readV1(r)
+	}
+	b, err = r.Peek(v2HeaderLen)
+	if err != nil || !bytes.Equal(b[:len(v2Signature)], v2Signature) {
+		return nil, errNoHeader
+	}
+	length := int(binary.BigEndian.Uint16(b[14:16]))
+	b, err = r.Peek(v2HeaderLen + length)
+	if err != nil {
+		return nil, fmt.Errorf("truncated PROXY protocol v2 header: %s", err)
+	}
+	addr, err := parseV2(b)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.Discard(len(b))
+	return addr, err
+}
+
+// readV1 reads a version 1 header, e.g. "PROXY TCP4 192.0.2.1 198.51.100.1 56324 53\r\n".
+func readV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < v1MaxLength {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("truncated PROXY protocol v1 header: %s", err)
+		}
+		line = append(line, c)
+		if c == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.New("PROXY protocol v1 header too long")
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("invalid PROXY protocol v1 header: %q", line)
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil {
+		return nil, fmt.Errorf("invalid source address in PROXY protocol v1 header: %q", line)
+	}
+	switch fields[1] {
+	case "TCP4":
+		if ip.To4() == nil {
+			return nil, fmt.Errorf("invalid source address in PROXY protocol v1 header: %q", line)
+		}
+	case "TCP6":
+	default:
+		return nil, fmt.Errorf("unsupported protocol in PROXY protocol v1 header: %q", fields[1])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// parseV2 parses a complete version 2 header in b.
+func parseV2(b []byte) (net.Addr, error) {
+	if b[12]>>4 != 2 {
+		return nil, fmt.Errorf("unsupported PROXY protocol version %d", b[12]>>4)
+	}
+	switch b[12] & 0xF {
+	case v2CmdLocal:
+		return nil, nil
+	case v2CmdProxy:
+	default:
+		return nil, fmt.Errorf("unsupported PROXY protocol v2 command %d", b[12]&0xF)
+	}
+
+	fam, proto := b[13]>>4, b[13]&0xF
+	addrs := b[v2HeaderLen:]
+	var ip net.IP
+	var port uint16
+	switch fam {
+	case v2FamInet:
+		if len(addrs) < 12 {
+			return nil, errors.New("truncated PROXY protocol v2 addresses")
+		}
+		ip, port = net.IP(addrs[0:4]), binary.BigEndian.Uint16(addrs[8:10])
+	case v2FamInet6:
+		if len(addrs) < 36 {
+			return nil, errors.New("truncated PROXY protocol v2 addresses")
+		}
+		ip, port = net.IP(addrs[0:16]), binary.BigEndian.Uint16(addrs[32:34])
+	default:
+		// Unspecified or unix sockets, there is no address to use.
+		return nil, nil
+	}
+	ip = append(net.IP(nil), ip...)
+
+	switch proto {
+	case v2ProtoTCP:
+		return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+	case v2ProtoUDP:
+		return &net.UDPAddr{IP: ip, Port: int(port)}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,108 @@
+package proxyproto
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"time"
+
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+)
+
+var log = clog.NewWithPlugin("proxyproto")
+
+// Listener wraps a net.Listener. Connections from the networks in its Config may start with a PROXY
+// protocol header, the source address in the header is then returned as the connection's remote address.
+type Listener struct {
+	net.Listener
+	config *Config
+}
+
+// NewListener returns a Listener that wraps l.
+func NewListener(l net.Listener, config *Config) *Listener {
+	return &Listener{Listener: l, config: config}
+}
+
+// Accept implements the net.Listener interface. The header isn't read here, so a slow proxy doesn't block
+// other connections; it is read on the first call to Read or RemoteAddr.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.config.trusted(c.RemoteAddr()) {
+		return c, nil
+	}
+	return &Conn{Conn: c, r: bufio.NewReader(c), timeout: l.config.timeout()}, nil
+}
+
+// Conn is a connection from a proxy.
+type Conn struct {
+	net.Conn
+	r       *bufio.Reader
+	timeout time.Duration
+
+	once   sync.Once
+	remote net.Addr
+	err    error
+
+	mu       sync.Mutex
+	deadline time.Time // read deadline set by the user of the connection
+}
+
+func (c *Conn) readHeader() {
+	c.once.Do(func() {
+		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+		defer func() {
+			c.mu.Lock()
+			c.Conn.SetReadDeadline(c.deadline)
+			c.mu.Unlock()
+		}()
+
+		addr, err := readHeader(c.r)
+		switch {
+		case err == errNoHeader:
+		case err != nil:
+			log.Warningf("Closing connection from %s: %s", c.Conn.RemoteAddr(), err)
+			c.err = err
+			c.Conn.Close()
+		case addr != nil:
+			c.remote = tcpAddr(addr)
+		}
+	})
+}
+
+// SetDeadline implements the net.Conn interface.
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline = t
+	c.mu.Unlock()
+	return c.Conn.SetDeadline(t)
+}
+
+// SetReadDeadline implements the net.Conn interface.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline = t
+	c.mu.Unlock()
+	return c.Conn.SetReadDeadline(t)
+}
+
+// Read implements the net.Conn interface.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.r.Read(b)
+}
+
+// RemoteAddr implements the net.Conn interface. It returns the source address from the PROXY protocol
+// header, or the address of the proxy when there is none.
+func (c *Conn) RemoteAddr() net.Addr {
+	c.readHeader()
+	if c.remote != nil {
+		return c.remote
+	}
+	return c.Conn.RemoteAddr()
+}
@@ -0,0 +1,86 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+
+	"github.com/miekg/dns"
+)
+
+// PacketConn wraps a net.PacketConn. Datagrams from the networks in its Config may start with a PROXY
+// protocol header. For these ReadFrom returns an *Addr, that holds both the client and the proxy address.
+type PacketConn struct {
+	net.PacketConn
+	config *Config
+}
+
+// NewPacketConn returns a PacketConn that wraps p.
+func NewPacketConn(p net.PacketConn, config *Config) *PacketConn {
+	return &PacketConn{PacketConn: p, config: config}
+}
+
+// Addr is the address of a client behind a proxy.
+type Addr struct {
+	Client net.Addr
+	Proxy  net.Addr
+}
+
+// Network implements the net.Addr interface.
+func (a *Addr) Network() string { return a.Client.Network() }
+
+// String implements the net.Addr interface.
+func (a *Addr) String() string { return a.Client.String() }
+
+// ReadFrom implements the net.PacketConn interface.
+func (p *PacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	for {
+		n, addr, err := p.PacketConn.ReadFrom(b)
+		if err != nil || !p.config.trusted(addr) {
+			return n, addr, err
+		}
+
+		r := bufio.NewReaderSize(bytes.NewReader(b[:n]), n)
+		client, err := readHeader(r)
+		switch {
+		case err == errNoHeader:
+			return n, addr, nil
+		case err != nil:
+			log.Warningf("Dropping datagram from %s: %s", addr, err)
+			continue
+		}
+		if client == nil {
+			client = addr
+		} else {
+			client = udpAddr(client)
+		}
+		// What is left in the reader is the payload, move it to the start of b.
+		m := r.Buffered()
+		payload, _ := r.Peek(m)
+		copy(b, payload)
+		return m, &Addr{Client: client, Proxy: addr}, nil
+	}
+}
+
+// WriteTo implements the net.PacketConn interface. Replies to a client behind a proxy are sent to the
+// proxy.
+func (p *PacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	if a, ok := addr.(*Addr); ok {
+		addr = a.Proxy
+	}
+	return p.PacketConn.WriteTo(b, addr)
+}
+
+// ResponseWriter wraps a dns.ResponseWriter whose remote address is an *Addr, and returns the client's
+// address as the remote address.
+type ResponseWriter struct {
+	dns.ResponseWriter
+}
+
+// RemoteAddr implements the dns.ResponseWriter interface.
+func (w ResponseWriter) RemoteAddr() net.Addr {
+	if a, ok := w.ResponseWriter.RemoteAddr().(*Addr); ok {
+		return a.Client
+	}
+	return w.ResponseWriter.RemoteAddr()
+}
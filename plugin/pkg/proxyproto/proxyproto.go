@@ -0,0 +1,76 @@
+package proxyproto
+
+import (
+	"net"
+	"time"
+)
+
+// Config holds the networks that may send PROXY protocol headers.
+type Config struct {
+	// Allow are the networks of the proxies. A PROXY protocol header from any other address is not
+	// parsed, the data is used as is.
+	Allow []*net.IPNet
+	// Timeout is the time a proxy has to send the header on a new connection.
+	Timeout time.Duration
+}
+
+// DefaultTimeout is the default time a proxy has to send the header.
+const DefaultTimeout = 5 * time.Second
+
+// Equal returns true if c and o have the same settings. Both may be nil, when PROXY protocol isn't used.
+func (c *Config) Equal(o *Config) bool {
+	if c == nil || o == nil {
+		return c == o
+	}
+	if c.Timeout != o.Timeout || len(c.Allow) != len(o.Allow) {
+		return false
+	}
+	for i := range c.Allow {
+		if c.Allow[i].String() != o.Allow[i].String() {
+			return false
+		}
+	}
+	return true
+}
+
+// trusted returns true if addr is in one of the networks of c.
+func (c *Config) trusted(addr net.Addr) bool {
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.UDPAddr:
+		ip = a.IP
+	default:
+		return false
+	}
+	for _, n := range c.Allow {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) timeout() time.Duration {
+	if c.Timeout == 0 {
+		return DefaultTimeout
+	}
+	return c.Timeout
+}
+
+// tcpAddr returns addr as a *net.TCPAddr, the transport in the header may differ from the one used.
+func tcpAddr(addr net.Addr) net.Addr {
+	if a, ok := addr.(*net.UDPAddr); ok {
+		return &net.TCPAddr{IP: a.IP, Port: a.Port, Zone: a.Zone}
+	}
+	return addr
+}
+
+// udpAddr returns addr as a *net.UDPAddr.
+func udpAddr(addr net.Addr) net.Addr {
+	if a, ok := addr.(*net.TCPAddr); ok {
+		return &net.UDPAddr{IP: a.IP, Port: a.Port, Zone: a.Zone}
+	}
+	return addr
+}
@@ -0,0 +1,244 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// v2Header returns a version 2 PROXY header for src and dst.
+func v2Header(cmd, proto byte, src, dst *net.UDPAddr) []byte {
+	var addrs []byte
+	fam := byte(v2FamInet)
+	if src.IP.To4() == nil {
+		fam = v2FamInet6
+		addrs = append(addrs, src.IP.To16()...)
+		addrs = append(addrs, dst.IP.To16()...)
+	} else {
+		addrs = append(addrs, src.IP.To4()...)
+		addrs = append(addrs, dst.IP.To4()...)
+	}
+	addrs = appendUint16(addrs, uint16(src.Port))
+	addrs = appendUint16(addrs, uint16(dst.Port))
+
+	b := append([]byte{}, v2Signature...)
+	b = append(b, 0x20|cmd, fam<<4|proto)
+	b = appendUint16(b, uint16(len(addrs)))
+	return append(b, addrs...)
+}
+
+func appendUint16(b []byte, i uint16) []byte {
+	var x [2]byte
+	binary.BigEndian.PutUint16(x[:], i)
+	return append(b, x[:]...)
+}
+
+func TestReadHeader(t *testing.T) {
+	src := &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 56324}
+	src6 := &net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 56324}
+	dst := &net.UDPAddr{IP: net.ParseIP("198.51.100.1"), Port: 53}
+	dst6 := &net.UDPAddr{IP: net.ParseIP("2001:db8::53"), Port: 53}
+
+	tests := []struct {
+		header    []byte
+		expected  string // empty for no address
+		shouldErr bool
+		noHeader  bool
+	}{
+		{[]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 53\r\n"), "192.0.2.1:56324", false, false},
+		{[]byte("PROXY TCP6 2001:db8::1 2001:db8::53 56324 53\r\n"), "[2001:db8::1]:56324", false, false},
+		{[]byte("PROXY UNKNOWN\r\n"), "", false, false},
+		{v2Header(v2CmdProxy, v2ProtoUDP, src, dst), "192.0.2.1:56324", false, false},
+		{v2Header(v2CmdProxy, v2ProtoTCP, src6, dst6), "[2001:db8::1]:56324", false, false},
+		{v2Header(v2CmdLocal, v2ProtoTCP, src, dst), "", false, false},
+		// errors
+		{[]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324\r\n"), "", true, false},
+		{[]byte("PROXY TCP4 2001:db8::1 198.51.100.1 56324 53\r\n"), "", true, false},
+		{[]byte("PROXY UDP4 192.0.2.1 198.51.100.1 56324 53\r\n"), "", true, false},
+		{[]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 53"), "", true, false},
+		{v2Header(v2CmdProxy, v2ProtoUDP, src, dst)[:20], "", true, false},
+		// no header
+		{[]byte("\x00\x1dabcdef"), "", false, true},
+		{[]byte("\r\n\r\n\x00\r\nQUI"), "", false, true},
+	}
+
+	for i, tc := range tests {
+		payload := []byte("payload")
+		r := bufio.NewReader(bytes.NewReader(append(append([]byte{}, tc.header...), payload...)))
+		addr, err := readHeader(r)
+		if tc.noHeader {
+			if err != errNoHeader {
+				t.Errorf("Test %d: expected no header, got %v", i, err)
+			}
+			continue
+		}
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: expected error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %s", i, err)
+			continue
+		}
+		got := ""
+		if addr != nil {
+			got = addr.String()
+		}
+		if got != tc.expected {
+			t.Errorf("Test %d: expected address %q, got %q", i, tc.expected, got)
+		}
+		if rest, _ := io.ReadAll(r); !bytes.Equal(rest, payload) {
+			t.Errorf("Test %d: expected the payload after the header, got %q", i, rest)
+		}
+	}
+}
+
+func localConfig() *Config {
+	_, n, _ := net.ParseCIDR("127.0.0.0/8")
+	return &Config{Allow: []*net.IPNet{n}, Timeout: time.Second}
+}
+
+func TestListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pl := NewListener(l, localConfig())
+	defer pl.Close()
+
+	go func() {
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		c.Write([]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 53\r\nhello"))
+		io.Copy(io.Discard, c)
+	}()
+
+	c, err := pl.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if a, ok := c.RemoteAddr().(*net.TCPAddr); !ok || a.String() != "192.0.2.1:56324" {
+		t.Errorf("Expected remote address 192.0.2.1:56324, got %v", c.RemoteAddr())
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(c, buf); err != nil || string(buf) != "hello" {
+		t.Errorf("Expected to read %q, got %q: %v", "hello", buf, err)
+	}
+}
+
+func TestListenerUntrusted(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, n, _ := net.ParseCIDR("192.0.2.0/24")
+	pl := NewListener(l, &Config{Allow: []*net.IPNet{n}})
+	defer pl.Close()
+
+	header := "PROXY TCP4 192.0.2.1 198.51.100.1 56324 53\r\n"
+	go func() {
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		c.Write([]byte(header))
+		io.Copy(io.Discard, c)
+	}()
+
+	c, err := pl.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	// The header of an untrusted client is data.
+	if a := c.RemoteAddr().(*net.TCPAddr); !a.IP.IsLoopback() {
+		t.Errorf("Expected loopback remote address, got %v", a)
+	}
+	buf := make([]byte, len(header))
+	if _, err := io.ReadFull(c, buf); err != nil || string(buf) != header {
+		t.Errorf("Expected to read the header as data, got %q: %v", buf, err)
+	}
+}
+
+func TestPacketConn(t *testing.T) {
+	p, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc := NewPacketConn(p, localConfig())
+	defer pc.Close()
+
+	c, err := net.Dial("udp", p.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	src := &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 56324}
+	dst := &net.UDPAddr{IP: net.ParseIP("198.51.100.1"), Port: 53}
+	c.Write(append(v2Header(v2CmdProxy, v2ProtoUDP, src, dst), []byte("query")...))
+
+	buf := make([]byte, 512)
+	n, addr, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf[:n]) != "query" {
+		t.Errorf("Expected payload %q, got %q", "query", buf[:n])
+	}
+	a, ok := addr.(*Addr)
+	if !ok {
+		t.Fatalf("Expected an *Addr, got %T", addr)
+	}
+	if a.String() != "192.0.2.1:56324" {
+		t.Errorf("Expected client address 192.0.2.1:56324, got %s", a)
+	}
+	if _, ok := a.Client.(*net.UDPAddr); !ok {
+		t.Errorf("Expected a *net.UDPAddr client address, got %T", a.Client)
+	}
+
+	// The reply goes to the proxy.
+	if _, err := pc.WriteTo([]byte("reply"), addr); err != nil {
+		t.Fatal(err)
+	}
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	n, err = c.Read(buf)
+	if err != nil || string(buf[:n]) != "reply" {
+		t.Errorf("Expected reply %q, got %q: %v", "reply", buf[:n], err)
+	}
+}
+
+func TestConfigEqual(t *testing.T) {
+	_, n1, _ := net.ParseCIDR("10.0.0.0/24")
+	_, n2, _ := net.ParseCIDR("10.0.1.0/24")
+	c := &Config{Allow: []*net.IPNet{n1}, Timeout: DefaultTimeout}
+
+	tests := []struct {
+		a, b  *Config
+		equal bool
+	}{
+		{nil, nil, true},
+		{c, nil, false},
+		{nil, c, false},
+		{c, &Config{Allow: []*net.IPNet{n1}, Timeout: DefaultTimeout}, true},
+		{c, &Config{Allow: []*net.IPNet{n2}, Timeout: DefaultTimeout}, false},
+		{c, &Config{Allow: []*net.IPNet{n1, n2}, Timeout: DefaultTimeout}, false},
+		{c, &Config{Allow: []*net.IPNet{n1}, Timeout: time.Second}, false},
+	}
+	for i, tc := range tests {
+		if got := tc.a.Equal(tc.b); got != tc.equal {
+			t.Errorf("Test %d: expected %t, got %t", i, tc.equal, got)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package rollover
+
+import (
+	"bufio"
+	"crypto"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Key is a DNSSEC key pair with its timing metadata, see RFC 7583, Section 3.3. A zero time means the event
+// isn't scheduled.
+type Key struct {
+	Public  *dns.DNSKEY
+	Private crypto.Signer
+	Tag     uint16
+
+	Created     time.Time
+	Publish     time.Time // DNSKEY is added to the zone
+	Activate    time.Time // key starts signing
+	SyncPublish time.Time // CDS and CDNSKEY for the key are added to the zone, KSK only
+	Inactive    time.Time // key stops signing
+	Delete      time.Time // DNSKEY is removed from the zone
+
+	base string // file name without the .key or .private extension
+}
+
+// KSK returns true if k is a key signing key, i.e. it has the SEP bit set.
+func (k *Key) KSK() bool { return k.Public.Flags&dns.SEP == dns.SEP }
+
+// role returns KSK or ZSK.
+func (k *Key) role() string {
+	if k.KSK() {
+		return "KSK"
+	}
+	return "ZSK"
+}
+
+// published returns true if k is in the DNSKEY RRset at now.
+func (k *Key) published(now time.Time) bool {
+	return !k.Publish.IsZero() && !now.Before(k.Publish) && (k.Delete.IsZero() || now.Before(k.Delete))
+}
+
+// active returns true if k signs at now.
+func (k *Key) active(now time.Time) bool {
+	return !k.Activate.IsZero() && !now.Before(k.Activate) && (k.Inactive.IsZero() || now.Before(k.Inactive))
+}
+
+// status returns the state of k at now, as reported in the key status metric.
+func (k *Key) status(now time.Time) string {
+	switch {
+	case k.active(now):
+		return "active"
+	case k.published(now) && now.Before(k.Activate):
+		return "published"
+	case k.published(now):
+		return "retired"
+	case !k.Delete.IsZero() && !now.Before(k.Delete):
+		return "removed"
+	}
+	return "generated"
+}
+
+// timing holds the names of the timing metadata as written in the private key file, the same as BIND9 uses.
+var timing = []struct {
+	name string
+	t    func(k *Key) *time.Time
+}{
+	{"Created", func(k *Key) *time.Time { return &k.Created }},
+	{"Publish", func(k *Key) *time.Time { return &k.Publish }},
+	{"Activate", func(k *Key) *time.Time { return &k.Activate }},
+	{"SyncPublish", func(k *Key) *time.Time { return &k.SyncPublish }},
+	{"Inactive", func(k *Key) *time.Time { return &k.Inactive }},
+	{"Delete", func(k *Key) *time.Time { return &k.Delete }},
+}
+
+const timeFormat = "20060102150405"
+
+// generate generates a new key for zone with algorithm alg, the key is a KSK if ksk is true.
+func generate(zone string, alg uint8, ksk bool, now time.Time) (*Key, error) {
+	bits, ok := keySize[alg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm: %s", dns.AlgorithmToString[alg])
+	}
+	dk := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: zone, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     dns.ZONE,
+		Protocol:  3,
+		Algorithm: alg,
+	}
+	if ksk {
+		dk.Flags |= dns.SEP
+	}
+	priv, err := dk.Generate(bits)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key for algorithm: %s", dns.AlgorithmToString[alg])
+	}
+	return &Key{Public: dk, Private: signer, Tag: dk.KeyTag(), Created: now.UTC().Truncate(time.Second)}, nil
+}
+
+// keySize holds the key sizes used when generating keys.
+var keySize = map[uint8]int{
+	dns.RSASHA256:       2048,
+	dns.RSASHA512:       2048,
+	dns.ECDSAP256SHA256: 256,
+	dns.ECDSAP384SHA384: 384,
+	dns.ED25519:         256,
+}
+
+// readKey reads the key pair with file name base, without extension.
+func readKey(base string) (*Key, error) {
+	pub, err := os.Open(base + ".key")
+	if err != nil {
+		return nil, err
+	}
+	defer pub.Close()
+	rr, err := dns.ReadRR(pub, base+".key")
+	if err != nil {
+		return nil, err
+	}
+	dk, ok := rr.(*dns.DNSKEY)
+	if !ok {
+		return nil, fmt.Errorf("no public key found in %q", base+".key")
+	}
+
+	buf, err := ioutil.ReadFile(base + ".private")
+	if err != nil {
+		return nil, err
+	}
+	priv, err := dk.ReadPrivateKey(strings.NewReader(string(buf)), base+".private")
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("no private key found in %q", base+".private")
+	}
+
+	k := &Key{Public: dk, Private: signer, Tag: dk.KeyTag(), base: base}
+	scanner := bufio.NewScanner(strings.NewReader(string(buf)))
+	for scanner.Scan() {
+		kv := strings.SplitN(scanner.Text(), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		for _, t := range timing {
+			if strings.TrimSpace(kv[0]) != t.name {
+				continue
+			}
+			v, err := time.Parse(timeFormat, strings.TrimSpace(kv[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s time in %q: %s", t.name, base+".private", err)
+			}
+			*t.t(k) = v
+		}
+	}
+	// A key without timing metadata is published and active since it was created.
+	if k.Publish.IsZero() && k.Activate.IsZero() {
+		k.Publish, k.Activate = k.Created, k.Created
+		if k.Created.IsZero() {
+			fi, err := os.Stat(base + ".key")
+			if err != nil {
+				return nil, err
+			}
+			k.Publish, k.Activate = fi.ModTime().UTC(), fi.ModTime().UTC()
+		}
+	}
+	return k, nil
+}
+
+// write writes k to dir, using the BIND9 file names: K<zone>+<alg>+<tag>.key and .private.
+func (k *Key) write(dir string) error {
+	if k.base == "" {
+		k.base = filepath.Join(dir, fmt.Sprintf("K%s+%03d+%05d", k.Public.Header().Name, k.Public.Algorithm, k.Tag))
+	}
+
+	var b strings.Builder
+	b.WriteString(k.Public.PrivateKeyString(k.Private))
+	for _, t := range timing {
+		if v := *t.t(k); !v.IsZero() {
+			fmt.Fprintf(&b, "%s: %s\n", t.name, v.UTC().Format(timeFormat))
+		}
+	}
+	if err := writeFile(k.base+".private", []byte(b.String()), 0600); err != nil {
+		return err
+	}
+	return writeFile(k.base+".key", []byte(k.Public.String()+"\n"), 0644)
+}
+
+// writeFile writes data to name, via a temporary file that is renamed to name.
+func writeFile(name string, data []byte, perm os.FileMode) error {
+	tmp := name + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
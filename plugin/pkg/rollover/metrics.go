@@ -0,0 +1,16 @@
+package rollover
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// keyStatus is the status of each key: generated, published, active, retired.
+var keyStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: plugin.Namespace,
+	Subsystem: "dnssec",
+	Name:      "key_status",
+	Help:      "The status of a DNSSEC key, the value is always 1.",
+}, []string{"zone", "tag", "role", "status"})
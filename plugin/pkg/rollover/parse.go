@@ -0,0 +1,70 @@
+package rollover
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coredns/caddy"
+
+	"github.com/miekg/dns"
+)
+
+// Parse parses the policy property the controller c is at into p. It returns false if the property is not a
+// policy property. These are:
+//
+//	algorithm ALGORITHM
+//	zsk_lifetime DURATION
+//	ksk_lifetime DURATION
+//	propagation DURATION
+//	ds_delay DURATION
+func (p *Policy) Parse(c *caddy.Controller) (bool, error) {
+	var d *time.Duration
+	name := c.Val()
+	switch name {
+	case "algorithm":
+		if !c.NextArg() {
+			return true, c.ArgErr()
+		}
+		alg, ok := dns.StringToAlgorithm[strings.ToUpper(c.Val())]
+		if _, supported := keySize[alg]; !ok || !supported {
+			return true, c.Errf("unsupported algorithm: %s", c.Val())
+		}
+		p.Algorithm = alg
+		return true, nil
+	case "zsk_lifetime":
+		d = &p.ZSKLifetime
+	case "ksk_lifetime":
+		d = &p.KSKLifetime
+	case "propagation":
+		d = &p.Propagation
+	case "ds_delay":
+		d = &p.DSDelay
+	default:
+		return false, nil
+	}
+
+	if !c.NextArg() {
+		return true, c.ArgErr()
+	}
+	dur, err := time.ParseDuration(c.Val())
+	if err != nil {
+		return true, c.Err(err.Error())
+	}
+	if dur < 0 {
+		return true, c.Errf("%s can not be negative: %s", name, dur)
+	}
+	*d = dur
+	return true, nil
+}
+
+// Validate checks that the lifetimes in p are long enough for the rollovers to complete.
+func (p Policy) Validate() error {
+	if p.ZSKLifetime > 0 && p.ZSKLifetime <= 2*p.Propagation {
+		return fmt.Errorf("zsk_lifetime %s must be more than twice the propagation delay %s", p.ZSKLifetime, p.Propagation)
+	}
+	if p.KSKLifetime > 0 && p.KSKLifetime <= p.Propagation+p.DSDelay {
+		return fmt.Errorf("ksk_lifetime %s must be more than the propagation and DS delays %s", p.KSKLifetime, p.Propagation+p.DSDelay)
+	}
+	return nil
+}
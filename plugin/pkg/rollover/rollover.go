@@ -0,0 +1,286 @@
+// Package rollover implements automated DNSSEC key rollovers, as described in RFC 6781 and RFC 7583.
+//
+// A zone has a key signing key (KSK) that signs the DNSKEY RRset, and a zone signing key (ZSK) that signs
+// all other RRsets. The keys are stored in a directory as BIND9 key files, the timing metadata of each key
+// is kept in its private key file. When no keys exist they are generated. ZSKs are rolled with the
+// pre-publish method: the successor is published, and only starts signing after that has propagated. KSKs
+// are rolled with the double-signature method: the successor is published and signs the DNSKEY RRset along
+// with the old KSK. Once that has propagated, CDS and CDNSKEY records (RFC 7344) are published for the new
+// KSK, so the parent can update its DS record. The old KSK is removed once that is expected to be done.
+package rollover
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	"github.com/miekg/dns"
+)
+
+var log = clog.NewWithPlugin("rollover")
+
+// Policy holds the parameters for the key rollovers.
+type Policy struct {
+	Algorithm   uint8         // algorithm for newly generated keys
+	ZSKLifetime time.Duration // time a ZSK signs, 0 means it is never rolled
+	KSKLifetime time.Duration // time a KSK signs, 0 means it is never rolled
+	Propagation time.Duration // time for a change to reach all secondaries and expire from caches
+	DSDelay     time.Duration // time for the parent to update the DS record and for the old one to expire
+}
+
+// DefaultPolicy returns the default policy: ECDSAP256SHA256 keys, a ZSK lifetime of 30 days, a KSK
+// lifetime of a year, a propagation delay of 2 hours and a DS delay of 2 days.
+func DefaultPolicy() Policy {
+	return Policy{
+		Algorithm:   dns.ECDSAP256SHA256,
+		ZSKLifetime: 30 * 24 * time.Hour,
+		KSKLifetime: 365 * 24 * time.Hour,
+		Propagation: 2 * time.Hour,
+		DSDelay:     2 * 24 * time.Hour,
+	}
+}
+
+// Manager manages the keys of a zone.
+type Manager struct {
+	zone   string
+	dir    string
+	policy Policy
+
+	sync.RWMutex
+	keys   []*Key
+	status map[uint16]string // last reported status per key tag
+}
+
+// New returns a manager for the keys of zone in dir. Existing keys for zone are read from dir. Keys for
+// another zone are ignored.
+func New(zone, dir string, policy Policy) (*Manager, error) {
+	m := &Manager{zone: dns.Fqdn(strings.ToLower(zone)), dir: dir, policy: policy, status: make(map[uint16]string)}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := "K" + m.zone + "+"
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) || !strings.HasSuffix(name, ".key") {
+			continue
+		}
+		k, err := readKey(filepath.Join(dir, strings.TrimSuffix(name, ".key")))
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(k.Public.Header().Name, m.zone) {
+			continue
+		}
+		m.keys = append(m.keys, k)
+	}
+	return m, nil
+}
+
+// Zone returns the zone of m.
+func (m *Manager) Zone() string { return m.zone }
+
+// Roll generates keys and schedules the rollovers that are due at now, and saves the keys. It returns true
+// when the keys were changed.
+func (m *Manager) Roll(now time.Time) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	now = now.UTC().Truncate(time.Second)
+
+	var changed []*Key
+	for _, ksk := range []bool{true, false} {
+		c, err := m.roll(now, ksk)
+		if err != nil {
+			return false, err
+		}
+		changed = append(changed, c...)
+	}
+	for _, k := range changed {
+		if err := k.write(m.dir); err != nil {
+			return false, err
+		}
+	}
+	m.report(now)
+	return len(changed) > 0, nil
+}
+
+// roll handles the KSKs (if ksk is true) or ZSKs, it returns the keys that were changed.
+func (m *Manager) roll(now time.Time, ksk bool) ([]*Key, error) {
+	current := m.current(ksk)
+	if current == nil {
+		k, err := m.generate(ksk, m.policy.Algorithm, now)
+		if err != nil {
+			return nil, err
+		}
+		k.Publish, k.Activate = now, now
+		if ksk {
+			k.SyncPublish = now
+		}
+		log.Infof("Generated %s with key tag %d for zone %q", k.role(), k.Tag, m.zone)
+		return []*Key{k}, nil
+	}
+
+	lifetime := m.policy.ZSKLifetime
+	if ksk {
+		lifetime = m.policy.KSKLifetime
+	}
+	if lifetime == 0 {
+		return nil, nil
+	}
+	due := current.Activate.Add(lifetime)
+	if !ksk {
+		due = due.Add(-m.policy.Propagation) // pre-publish the successor
+	}
+	if now.Before(due) {
+		return nil, nil
+	}
+
+	// The successor uses the same algorithm, changing it requires an algorithm rollover.
+	k, err := m.generate(ksk, current.Public.Algorithm, now)
+	if err != nil {
+		return nil, err
+	}
+	if ksk {
+		// Double-signature: both KSKs sign until the parent has the new DS record.
+		k.Publish, k.Activate = now, now
+		k.SyncPublish = now.Add(m.policy.Propagation)
+		current.Inactive = k.SyncPublish.Add(m.policy.DSDelay)
+		current.Delete = current.Inactive
+	} else {
+		// Pre-publish: the new ZSK signs once its DNSKEY has propagated, the old one is removed once its
+		// signatures have expired from the caches.
+		k.Publish = now
+		k.Activate = now.Add(m.policy.Propagation)
+		if a := current.Activate.Add(lifetime); a.After(k.Activate) {
+			k.Activate = a
+		}
+		current.Inactive = k.Activate
+		current.Delete = k.Activate.Add(m.policy.Propagation)
+	}
+	log.Infof("Rolling %s with key tag %d for zone %q, successor has key tag %d", k.role(), current.Tag, m.zone, k.Tag)
+	return []*Key{k, current}, nil
+}
+
+// current returns the newest KSK (if ksk is true) or ZSK that has no successor, or nil if there is none.
+func (m *Manager) current(ksk bool) *Key {
+	var current *Key
+	for _, k := range m.keys {
+		if k.KSK() != ksk || !k.Inactive.IsZero() || k.Activate.IsZero() {
+			continue
+		}
+		if current == nil || k.Activate.After(current.Activate) {
+			current = k
+		}
+	}
+	return current
+}
+
+// generate generates a new key and adds it to m.keys. Its key tag is unique among the keys of the zone.
+func (m *Manager) generate(ksk bool, alg uint8, now time.Time) (*Key, error) {
+Generate:
+	k, err := generate(m.zone, alg, ksk, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, x := range m.keys {
+		if x.Tag == k.Tag {
+			goto Generate
+		}
+	}
+	m.keys = append(m.keys, k)
+	return k, nil
+}
+
+// Keys is the set of keys of a zone at a moment in time.
+type Keys struct {
+	DNSKEY []*Key // keys in the DNSKEY RRset
+	KSK    []*Key // keys that sign the DNSKEY RRset
+	ZSK    []*Key // keys that sign all other RRsets
+	Sync   *Key   // key the CDS and CDNSKEY records are published for, may be nil
+}
+
+// Keys returns the keys of the zone at now.
+func (m *Manager) Keys(now time.Time) Keys {
+	m.RLock()
+	defer m.RUnlock()
+
+	ks := Keys{}
+	for _, k := range m.keys {
+		if k.published(now) {
+			ks.DNSKEY = append(ks.DNSKEY, k)
+		}
+		if !k.active(now) {
+			continue
+		}
+		if !k.KSK() {
+			ks.ZSK = append(ks.ZSK, k)
+			continue
+		}
+		ks.KSK = append(ks.KSK, k)
+		if !k.SyncPublish.IsZero() && !now.Before(k.SyncPublish) && (ks.Sync == nil || k.Activate.After(ks.Sync.Activate)) {
+			ks.Sync = k
+		}
+	}
+	return ks
+}
+
+// CDS returns the CDS and CDNSKEY records for the key in ks.Sync, with owner name zone and TTL ttl.
+func (ks Keys) CDS(zone string, ttl uint32) []dns.RR {
+	if ks.Sync == nil {
+		return nil
+	}
+	dk := dns.Copy(ks.Sync.Public).(*dns.DNSKEY)
+	dk.Hdr.Name, dk.Hdr.Ttl = zone, ttl
+	return []dns.RR{dk.ToDS(dns.SHA256).ToCDS(), dk.ToCDNSKEY()}
+}
+
+// Next returns the time of the next scheduled event: a key that is published, activated, retired or
+// removed, or the next rollover.
+func (m *Manager) Next(now time.Time) time.Time {
+	m.RLock()
+	defer m.RUnlock()
+
+	var next time.Time
+	add := func(t time.Time) {
+		if t.After(now) && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	for _, k := range m.keys {
+		add(k.Publish)
+		add(k.Activate)
+		add(k.SyncPublish)
+		add(k.Inactive)
+		add(k.Delete)
+	}
+	if k := m.current(true); k != nil && m.policy.KSKLifetime > 0 {
+		add(k.Activate.Add(m.policy.KSKLifetime))
+	}
+	if k := m.current(false); k != nil && m.policy.ZSKLifetime > 0 {
+		add(k.Activate.Add(m.policy.ZSKLifetime - m.policy.Propagation))
+	}
+	return next
+}
+
+// report updates the key status metric, the caller must hold the lock on m.
+func (m *Manager) report(now time.Time) {
+	for _, k := range m.keys {
+		status := k.status(now)
+		tag := fmt.Sprintf("%d", k.Tag)
+		if old, ok := m.status[k.Tag]; ok && old != status {
+			keyStatus.DeleteLabelValues(m.zone, tag, k.role(), old)
+		}
+		m.status[k.Tag] = status
+		if status == "removed" {
+			keyStatus.DeleteLabelValues(m.zone, tag, k.role(), status)
+			continue
+		}
+		keyStatus.WithLabelValues(m.zone, tag, k.role(), status).Set(1)
+	}
+}
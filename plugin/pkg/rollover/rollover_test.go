@@ -0,0 +1,105 @@
+package rollover
+
+import (
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestRollover(t *testing.T) {
+	dir := t.TempDir()
+	p := DefaultPolicy()
+	p.KSKLifetime = 50 * 24 * time.Hour // before the second ZSK rollover
+	m, err := New("example.org.", dir, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		now     time.Time
+		changed bool
+		dnskey  int
+		ksk     int
+		zsk     int
+	}{
+		{t0, true, 2, 1, 1},                                          // initial keys
+		{t0.Add(day), false, 2, 1, 1},                                // nothing to do
+		{t0.Add(30*day - p.Propagation), true, 3, 1, 1},              // new ZSK is published
+		{t0.Add(30 * day), false, 3, 1, 1},                           // and signs
+		{t0.Add(30*day + p.Propagation), false, 2, 1, 1},             // old ZSK is removed
+		{t0.Add(50 * day), true, 3, 2, 1},                            // new KSK, double signature
+		{t0.Add(50*day + p.Propagation + p.DSDelay), false, 2, 1, 1}, // old KSK is removed
+	}
+
+	var zsk, ksk uint16
+	for i, tc := range tests {
+		changed, err := m.Roll(tc.now)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		if changed != tc.changed {
+			t.Errorf("Test %d: expected changed %t, got %t", i, tc.changed, changed)
+		}
+		ks := m.Keys(tc.now)
+		if len(ks.DNSKEY) != tc.dnskey || len(ks.KSK) != tc.ksk || len(ks.ZSK) != tc.zsk {
+			t.Errorf("Test %d: expected %d/%d/%d DNSKEY/KSK/ZSK, got %d/%d/%d", i, tc.dnskey, tc.ksk, tc.zsk, len(ks.DNSKEY), len(ks.KSK), len(ks.ZSK))
+		}
+		if ks.Sync == nil {
+			t.Fatalf("Test %d: expected a key to publish CDS records for", i)
+		}
+		switch i {
+		case 0:
+			zsk, ksk = ks.ZSK[0].Tag, ks.Sync.Tag
+		case 2:
+			if ks.ZSK[0].Tag != zsk {
+				t.Errorf("Test %d: expected the old ZSK to sign until the new one has propagated", i)
+			}
+		case 5:
+			if ks.Sync.Tag != ksk {
+				t.Errorf("Test %d: expected CDS records for the old KSK until the new one has propagated", i)
+			}
+		case 3:
+			if ks.ZSK[0].Tag == zsk {
+				t.Errorf("Test %d: expected the new ZSK to sign", i)
+			}
+		case 6:
+			if ks.Sync.Tag == ksk || ks.KSK[0].Tag == ksk {
+				t.Errorf("Test %d: expected CDS records for the new KSK", i)
+			}
+		}
+	}
+
+	// The keys and their timing are read back from dir.
+	m1, err := New("example.org.", dir, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m1.keys) != len(m.keys) {
+		t.Fatalf("Expected %d keys to be read, got %d", len(m.keys), len(m1.keys))
+	}
+	now := t0.Add(50*day + p.Propagation + p.DSDelay)
+	if ks, ks1 := m.Keys(now), m1.Keys(now); len(ks.DNSKEY) != len(ks1.DNSKEY) || ks.Sync.Tag != ks1.Sync.Tag {
+		t.Errorf("Expected the same keys after reading them back")
+	}
+	if cds := m1.Keys(now).CDS("example.org.", 3600); len(cds) != 2 || cds[0].Header().Rrtype != dns.TypeCDS {
+		t.Errorf("Expected CDS and CDNSKEY records, got %v", cds)
+	}
+}
+
+func TestNext(t *testing.T) {
+	p := DefaultPolicy()
+	m, err := New("example.org.", t.TempDir(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := m.Roll(t0); err != nil {
+		t.Fatal(err)
+	}
+	if next := m.Next(t0); !next.Equal(t0.Add(p.ZSKLifetime - p.Propagation)) {
+		t.Errorf("Expected next event at %s, got %s", t0.Add(p.ZSKLifetime-p.Propagation), next)
+	}
+}
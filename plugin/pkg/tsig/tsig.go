@@ -0,0 +1,87 @@
+// Package tsig contains functions to define and use TSIG (RFC 8945) keys in plugins.
+package tsig
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+
+	"github.com/miekg/dns"
+)
+
+// Key is a TSIG key.
+type Key struct {
+	Name      string // fully qualified and lower cased
+	Algorithm string // fully qualified algorithm name, i.e. hmac-sha256.
+	Secret    string // base64 encoded secret
+}
+
+// Fudge is the permitted error in the signing time, in seconds.
+const Fudge = 300
+
+var algorithms = map[string]string{
+	"hmac-md5":    dns.HmacMD5,
+	"hmac-sha1":   dns.HmacSHA1,
+	"hmac-sha224": dns.HmacSHA224,
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha384": dns.HmacSHA384,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+// Parse parses a key definition: NAME ALGORITHM SECRET.
+func Parse(args []string) (*Key, error) {
+	if len(args) != 3 {
+		return nil, fmt.Errorf("key needs a name, algorithm and secret")
+	}
+	alg, ok := algorithms[strings.TrimSuffix(strings.ToLower(args[1]), ".")]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TSIG algorithm: %q", args[1])
+	}
+	if _, err := base64.StdEncoding.DecodeString(args[2]); err != nil {
+		return nil, fmt.Errorf("secret of key %q is not base64 encoded: %s", args[0], err)
+	}
+	return &Key{Name: strings.ToLower(dns.Fqdn(args[0])), Algorithm: alg, Secret: args[2]}, nil
+}
+
+// Register adds k to the TSIG secrets of config, so the server verifies requests signed with it. It is an
+// error if config already has a different secret for a key with the same name.
+func (k *Key) Register(config *dnsserver.Config) error {
+	if config.TsigSecret == nil {
+		config.TsigSecret = make(map[string]string)
+	}
+	if s, ok := config.TsigSecret[k.Name]; ok && s != k.Secret {
+		return fmt.Errorf("key %q is defined with different secrets", k.Name)
+	}
+	config.TsigSecret[k.Name] = k.Secret
+	return nil
+}
+
+// Secrets returns the TSIG secrets to use in a dns.Client or dns.Transfer that sends messages signed with k.
+func (k *Key) Secrets() map[string]string { return map[string]string{k.Name: k.Secret} }
+
+// Sign adds a TSIG record for k to m. The message is signed when it is sent by a dns.Client or dns.Transfer
+// that uses Secrets.
+func (k *Key) Sign(m *dns.Msg) { m.SetTsig(k.Name, k.Algorithm, Fudge, time.Now().Unix()) }
+
+// Status returns the name of the key that signed r, and the outcome of verifying that signature. The name
+// is empty if r isn't signed.
+func Status(w dns.ResponseWriter, r *dns.Msg) (string, error) {
+	t := r.IsTsig()
+	if t == nil {
+		return "", nil
+	}
+	return strings.ToLower(t.Hdr.Name), w.TsigStatus()
+}
+
+// Reply adds a TSIG record to the reply m when the request r was signed, so the server signs m with the
+// same key. It must only be called when the signature on r was verified.
+func Reply(m, r *dns.Msg) {
+	t := r.IsTsig()
+	if t == nil {
+		return
+	}
+	m.SetTsig(t.Hdr.Name, t.Algorithm, t.Fudge, time.Now().Unix())
+}
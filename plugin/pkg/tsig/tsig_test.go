@@ -0,0 +1,59 @@
+package tsig
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/core/dnsserver"
+
+	"github.com/miekg/dns"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		args      []string
+		shouldErr bool
+		expected  Key
+	}{
+		{[]string{"xfr.example.org", "hmac-sha256", "c2VjcmV0"}, false, Key{"xfr.example.org.", dns.HmacSHA256, "c2VjcmV0"}},
+		{[]string{"XFR.example.org.", "HMAC-SHA512.", "c2VjcmV0"}, false, Key{"xfr.example.org.", dns.HmacSHA512, "c2VjcmV0"}},
+		// errors
+		{[]string{"xfr.example.org", "hmac-sha256"}, true, Key{}},
+		{[]string{"xfr.example.org", "hmac-foo", "c2VjcmV0"}, true, Key{}},
+		{[]string{"xfr.example.org", "hmac-sha256", "not base64!"}, true, Key{}},
+	}
+
+	for i, tc := range tests {
+		k, err := Parse(tc.args)
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: expected error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d: expected no error, got %s", i, err)
+		}
+		if *k != tc.expected {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, *k)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	config := &dnsserver.Config{}
+	k := &Key{Name: "xfr.example.org.", Algorithm: dns.HmacSHA256, Secret: "c2VjcmV0"}
+	if err := k.Register(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Register(config); err != nil {
+		t.Errorf("Expected no error registering the same key twice, got %s", err)
+	}
+	if x := config.TsigSecret[k.Name]; x != k.Secret {
+		t.Errorf("Expected secret %q, got %q", k.Secret, x)
+	}
+
+	other := &Key{Name: "xfr.example.org.", Algorithm: dns.HmacSHA256, Secret: "b3RoZXI="}
+	if err := other.Register(config); err == nil {
+		t.Errorf("Expected error registering a key with a different secret")
+	}
+}
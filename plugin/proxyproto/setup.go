@@ -0,0 +1,69 @@
+// Package proxyproto implements a plugin that enables the PROXY protocol on the listeners of a server.
+package proxyproto
+
+import (
+	"time"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cidr"
+	"github.com/coredns/coredns/plugin/pkg/proxyproto"
+)
+
+func init() { plugin.Register("proxyproto", setup) }
+
+func setup(c *caddy.Controller) error {
+	pc, err := parse(c)
+	if err != nil {
+		return plugin.Error("proxyproto", err)
+	}
+	dnsserver.GetConfig(c).ProxyProtocol = pc
+	return nil
+}
+
+func parse(c *caddy.Controller) (*proxyproto.Config, error) {
+	pc := &proxyproto.Config{Timeout: proxyproto.DefaultTimeout}
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, plugin.ErrOnce
+		}
+		i++
+
+		if len(c.RemainingArgs()) != 0 {
+			return nil, c.ArgErr()
+		}
+
+		for c.NextBlock() {
+			switch x := c.Val(); x {
+			case "allow":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				nets, err := cidr.ParseNetworks(args)
+				if err != nil {
+					return nil, c.Err(err.Error())
+				}
+				pc.Allow = append(pc.Allow, nets...)
+			case "timeout":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				d, err := time.ParseDuration(c.Val())
+				if err != nil || d <= 0 {
+					return nil, c.Errf("invalid timeout %q", c.Val())
+				}
+				pc.Timeout = d
+			default:
+				return nil, c.Errf("unknown property '%s'", x)
+			}
+		}
+	}
+	if len(pc.Allow) == 0 {
+		return nil, c.Err("at least one network of proxies must be allowed")
+	}
+	return pc, nil
+}
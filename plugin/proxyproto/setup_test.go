@@ -0,0 +1,73 @@
+package proxyproto
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedAllow      []string
+		expectedTimeout    time.Duration
+		expectedErrContent string
+	}{
+		{`proxyproto {
+			allow 10.0.0.0/8 192.168.0.1 fd00::1
+		}`, false, []string{"10.0.0.0/8", "192.168.0.1/32", "fd00::1/128"}, 5 * time.Second, ""},
+		{`proxyproto {
+			allow 10.0.0.0/8
+			allow 172.16.0.0/12
+			timeout 1s
+		}`, false, []string{"10.0.0.0/8", "172.16.0.0/12"}, time.Second, ""},
+		// fails
+		{`proxyproto`, true, nil, 0, "at least one network"},
+		{`proxyproto 10.0.0.0/8`, true, nil, 0, "Wrong argument count"},
+		{`proxyproto {
+			allow
+		}`, true, nil, 0, "Wrong argument count"},
+		{`proxyproto {
+			allow 10.0.0.0/33
+		}`, true, nil, 0, "illegal CIDR"},
+		{`proxyproto {
+			allow 10.0.0.0/8
+			timeout -1s
+		}`, true, nil, 0, "invalid timeout"},
+		{`proxyproto {
+			blah
+		}`, true, nil, 0, "unknown property"},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		pc, err := parse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			if !strings.Contains(err.Error(), test.expectedErrContent) {
+				t.Errorf("Test %d: Expected error to contain: %v, found error: %v, input: %s", i, test.expectedErrContent, err, test.input)
+			}
+			continue
+		}
+		if len(pc.Allow) != len(test.expectedAllow) {
+			t.Fatalf("Test %d: Expected %d networks, got %d", i, len(test.expectedAllow), len(pc.Allow))
+		}
+		for j, n := range test.expectedAllow {
+			if pc.Allow[j].String() != n {
+				t.Errorf("Test %d: Expected network %s, got %s", i, n, pc.Allow[j])
+			}
+		}
+		if pc.Timeout != test.expectedTimeout {
+			t.Errorf("Test %d: Expected timeout %s, got %s", i, test.expectedTimeout, pc.Timeout)
+		}
+	}
+}
@@ -13,11 +13,13 @@ import (
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/backoff"
 	"github.com/coredns/coredns/plugin/pkg/fall"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 	"github.com/coredns/coredns/request"
 
 	"github.com/aws/aws-sdk-go/aws"
+	awsrequest "github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
 	"github.com/miekg/dns"
@@ -84,15 +86,18 @@ func (h *Route53) Run(ctx context.Context) error {
 		return err
 	}
 	go func() {
+		wait := h.refresh
 		for {
 			select {
 			case <-ctx.Done():
 				log.Debugf("Breaking out of Route53 update loop for %v: %v", h.zoneNames, ctx.Err())
 				return
-			case <-time.After(h.refresh):
-				if err := h.updateZones(ctx); err != nil && ctx.Err() == nil /* Don't log error if ctx expired. */ {
+			case <-time.After(wait):
+				err := h.updateZones(ctx)
+				if err != nil && ctx.Err() == nil /* Don't log error if ctx expired. */ {
 					log.Errorf("Failed to update zones %v: %v", h.zoneNames, err)
 				}
+				wait = backoff.Next(wait, h.refresh, errors.Is(err, backoff.ErrThrottled))
 			}
 		}
 	}()
@@ -246,6 +251,7 @@ func (h *Route53) updateZones(ctx context.Context) error {
 			}()
 
 			for i, hostedZone := range z {
+				start := time.Now()
 				newZ := file.NewZone(zName, "")
 				newZ.Upstream = h.upstream
 				in := &route53.ListResourceRecordSetsInput{
@@ -263,12 +269,19 @@ func (h *Route53) updateZones(ctx context.Context) error {
 						return true
 					})
 				if err != nil {
+					if awsrequest.IsErrorThrottle(err) {
+						err = fmt.Errorf("failed to list resource records for %v:%v from route53: %w: %v", zName, hostedZone.id, backoff.ErrThrottled, err)
+						return
+					}
 					err = fmt.Errorf("failed to list resource records for %v:%v from route53: %v", zName, hostedZone.id, err)
 					return
 				}
 				h.zMu.Lock()
 				(*z[i]).z = newZ
 				h.zMu.Unlock()
+
+				syncDuration.WithLabelValues(hostedZone.id).Observe(time.Since(start).Seconds())
+				lastSync.WithLabelValues(hostedZone.id).Set(float64(time.Now().Unix()))
 			}
 
 		}(zName, z)
@@ -276,12 +289,17 @@ func (h *Route53) updateZones(ctx context.Context) error {
 	// Collect errors (if any). This will also sync on all zones updates
 	// completion.
 	var errs []string
+	throttled := false
 	for i := 0; i < len(h.zones); i++ {
 		err := <-errc
 		if err != nil {
 			errs = append(errs, err.Error())
+			throttled = throttled || errors.Is(err, backoff.ErrThrottled)
 		}
 	}
+	if throttled {
+		return fmt.Errorf("errors updating zones: %v: %w", errs, backoff.ErrThrottled)
+	}
 	if len(errs) != 0 {
 		return fmt.Errorf("errors updating zones: %v", errs)
 	}
@@ -7,12 +7,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/coredns/coredns/plugin/pkg/backoff"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	"github.com/coredns/coredns/plugin/pkg/fall"
 	"github.com/coredns/coredns/plugin/test"
 	crequest "github.com/coredns/coredns/request"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
@@ -31,6 +33,9 @@ func (fakeRoute53) ListResourceRecordSetsPagesWithContext(_ aws.Context, in *rou
 	if aws.StringValue(in.HostedZoneId) == "0987654321" {
 		return errors.New("bad. zone is bad")
 	}
+	if aws.StringValue(in.HostedZoneId) == "1122334455" {
+		return awserr.New("Throttling", "Rate exceeded", nil)
+	}
 	rrsResponse := map[string][]*route53.ResourceRecordSet{}
 	for _, r := range []struct {
 		rType, name, value, hostedZoneID string
@@ -296,3 +301,23 @@ func TestMaybeUnescape(t *testing.T) {
 		}
 	}
 }
+
+func TestRoute53Throttled(t *testing.T) {
+	ctx := context.Background()
+
+	r, err := New(ctx, fakeRoute53{}, map[string][]string{"org.": {"1234567890"}, "throttled.": {"1122334455"}}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create route53: %v", err)
+	}
+	if err := r.updateZones(ctx); !errors.Is(err, backoff.ErrThrottled) {
+		t.Errorf("Expected the update to be throttled, got %v", err)
+	}
+
+	r, err = New(ctx, fakeRoute53{}, map[string][]string{"bad.": {"0987654321"}}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create route53: %v", err)
+	}
+	if err := r.updateZones(ctx); err == nil || errors.Is(err, backoff.ErrThrottled) {
+		t.Errorf("Expected the update to fail without being throttled, got %v", err)
+	}
+}
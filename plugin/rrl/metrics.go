@@ -0,0 +1,23 @@
+package rrl
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	dropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "rrl",
+		Name:      "responses_dropped_total",
+		Help:      "Counter of responses dropped because of the rate limit.",
+	}, []string{"server", "category"})
+	slipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "rrl",
+		Name:      "responses_slipped_total",
+		Help:      "Counter of responses replaced by a truncated one because of the rate limit.",
+	}, []string{"server", "category"})
+)
@@ -0,0 +1,45 @@
+package rrl
+
+import (
+	"context"
+
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// ResponseWriter limits the responses written to it.
+type ResponseWriter struct {
+	dns.ResponseWriter
+	ctx   context.Context
+	state request.Request
+	rrl   *RRL
+}
+
+// WriteMsg implements the dns.ResponseWriter interface. A limited response is dropped, or replaced by a
+// truncated one, so a legitimate client retries over TCP.
+func (w *ResponseWriter) WriteMsg(m *dns.Msg) error {
+	switch w.rrl.account(w.ctx, w.state, m) {
+	case drop:
+		return nil
+	case slip:
+		tc := new(dns.Msg)
+		tc.SetReply(w.state.Req)
+		tc.Rcode = m.Rcode
+		tc.Truncated = true
+		return w.ResponseWriter.WriteMsg(tc)
+	}
+	return w.ResponseWriter.WriteMsg(m)
+}
+
+// Write implements the dns.ResponseWriter interface.
+func (w *ResponseWriter) Write(buf []byte) (int, error) {
+	m := new(dns.Msg)
+	if err := m.Unpack(buf); err != nil {
+		return w.ResponseWriter.Write(buf)
+	}
+	if err := w.WriteMsg(m); err != nil {
+		return 0, err
+	}
+	return len(buf), nil
+}
@@ -0,0 +1,233 @@
+// Package rrl implements a plugin that limits the rate of responses sent to clients, to make the server less
+// useful in reflection attacks.
+package rrl
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metrics"
+	"github.com/coredns/coredns/plugin/pkg/cache"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/pkg/response"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+var log = clog.NewWithPlugin("rrl")
+
+// RRL limits the rate of the responses of the next plugin. Responses are accounted per client network and
+// per response: the same answer, the same NXDOMAIN or referral for a zone, or any error.
+type RRL struct {
+	Next  plugin.Handler
+	Zones []string
+
+	limits  [numCategories]float64 // allowed responses per second, 0 is unlimited
+	window  time.Duration          // how long an excess of responses is remembered
+	ipv4    net.IPMask
+	ipv6    net.IPMask
+	slip    int // every slip'th limited response is truncated instead of dropped, 0 drops all
+	exempt  []*net.IPNet
+	logOnly bool
+
+	mu    sync.Mutex // protects creating buckets in table
+	table *cache.Cache
+}
+
+// New returns an RRL with the defaults and a table of size accounts.
+func New(zones []string, size int) *RRL {
+	return &RRL{
+		Zones:  zones,
+		window: defaultWindow,
+		ipv4:   net.CIDRMask(24, 32),
+		ipv6:   net.CIDRMask(56, 128),
+		slip:   2,
+		table:  cache.New(size),
+	}
+}
+
+const (
+	defaultWindow = 15 * time.Second
+	defaultSize   = 20000
+)
+
+// category is the kind of response that is limited.
+type category int
+
+const (
+	responses category = iota // positive answers
+	nodata                    // the name exists, the type doesn't
+	nxdomains                 // the name doesn't exist
+	referrals                 // delegations to another name server
+	failures                  // all other responses
+	numCategories
+)
+
+func (c category) String() string {
+	switch c {
+	case nodata:
+		return "nodata"
+	case nxdomains:
+		return "nxdomain"
+	case referrals:
+		return "referral"
+	case failures:
+		return "error"
+	}
+	return "response"
+}
+
+// Name implements the plugin.Handler interface.
+func (rl *RRL) Name() string { return "rrl" }
+
+// ServeDNS implements the plugin.Handler interface.
+func (rl *RRL) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+	// A reflection attack needs spoofed source addresses, which TCP doesn't allow.
+	if state.Proto() != "udp" || plugin.Zones(rl.Zones).Matches(state.Name()) == "" || rl.exempted(net.ParseIP(state.IP())) {
+		return plugin.NextOrFailure(rl.Name(), rl.Next, ctx, w, r)
+	}
+
+	rw := &ResponseWriter{ResponseWriter: w, ctx: ctx, state: state, rrl: rl}
+	rcode, err := plugin.NextOrFailure(rl.Name(), rl.Next, ctx, rw, r)
+	if plugin.ClientWrite(rcode) {
+		return rcode, err
+	}
+	// The server would write this error, do it here so it is limited as well.
+	m := new(dns.Msg)
+	m.SetRcode(r, rcode)
+	state.SizeAndDo(m)
+	rw.WriteMsg(m)
+	return dns.RcodeSuccess, err
+}
+
+// action is what is done with a response.
+type action int
+
+const (
+	send action = iota
+	slip
+	drop
+)
+
+// account debits the account of the client of state for the response m, and returns what should be done
+// with m.
+func (rl *RRL) account(ctx context.Context, state request.Request, m *dns.Msg) action {
+	cat, name := classify(m, state.Name())
+	rate := rl.limits[cat]
+	if rate == 0 {
+		return send
+	}
+
+	ip := net.ParseIP(state.IP())
+	prefix := ip.Mask(rl.ipv6)
+	if ip4 := ip.To4(); ip4 != nil {
+		prefix = ip4.Mask(rl.ipv4)
+	}
+	var qtype uint16
+	if cat == responses || cat == nodata {
+		qtype = state.QType()
+	}
+	key := cache.Hash([]byte(prefix.String() + "/" + cat.String() + "/" + strings.ToLower(name) + "/" + strconv.Itoa(int(qtype))))
+
+	a := rl.debit(key, rate)
+	if a == send {
+		return send
+	}
+	server := metrics.WithServer(ctx)
+	if a == slip {
+		slipped.WithLabelValues(server, cat.String()).Inc()
+	} else {
+		dropped.WithLabelValues(server, cat.String()).Inc()
+	}
+	if rl.logOnly {
+		log.Debugf("Would limit %s response for %s %s to %s", cat, state.Name(), state.Type(), prefix)
+		return send
+	}
+	return a
+}
+
+// debit debits the bucket with key, creating it if needed.
+func (rl *RRL) debit(key uint64, rate float64) action {
+	now := time.Now()
+	rl.mu.Lock()
+	b, ok := rl.table.Get(key)
+	if !ok {
+		b = &bucket{balance: rate, last: now}
+		rl.table.Add(key, b)
+	}
+	rl.mu.Unlock()
+
+	return b.(*bucket).debit(now, rate, rl.window, rl.slip)
+}
+
+// exempted returns true if ip is in one of the exempted networks.
+func (rl *RRL) exempted(ip net.IP) bool {
+	for _, n := range rl.exempt {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// classify returns the category of m, and the name it is accounted to: the qname, or for NXDOMAIN and
+// referrals the zone, so random names don't each get their own account.
+func classify(m *dns.Msg, qname string) (category, string) {
+	t, _ := response.Typify(m, time.Now().UTC())
+	switch t {
+	case response.NoError:
+		return responses, qname
+	case response.NoData:
+		return nodata, qname
+	case response.NameError:
+		if len(m.Ns) > 0 {
+			return nxdomains, m.Ns[0].Header().Name
+		}
+		return nxdomains, qname
+	case response.Delegation:
+		return referrals, m.Ns[0].Header().Name
+	}
+	return failures, ""
+}
+
+// bucket is the account of responses of a client, as in BIND 9. The balance is credited with the allowed rate
+// each second, up to the rate, and debited for each response. Responses are limited while it is negative.
+type bucket struct {
+	sync.Mutex
+	balance float64
+	last    time.Time
+	limited int // limited responses, to pick the ones to slip
+}
+
+func (b *bucket) debit(now time.Time, rate float64, window time.Duration, slipRate int) action {
+	b.Lock()
+	defer b.Unlock()
+
+	b.balance += now.Sub(b.last).Seconds() * rate
+	b.last = now
+	if b.balance > rate {
+		b.balance = rate
+	}
+	b.balance--
+	// The debt is limited, so a client is let through again at most window after an attack stops.
+	if min := -rate * window.Seconds(); b.balance < min {
+		b.balance = min
+	}
+	if b.balance >= 0 {
+		b.limited = 0
+		return send
+	}
+
+	b.limited++
+	if slipRate > 0 && b.limited%slipRate == 0 {
+		return slip
+	}
+	return drop
+}
@@ -0,0 +1,167 @@
+package rrl
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+// next answers www.example.org, and NXDOMAIN for any other name.
+var next = plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	if r.Question[0].Name != "www.example.org." {
+		m.Rcode = dns.RcodeNameError
+		m.Ns = []dns.RR{test.SOA("example.org. 300 IN SOA ns.example.org. admin.example.org. 1 3600 600 86400 300")}
+		w.WriteMsg(m)
+		return dns.RcodeNameError, nil
+	}
+	m.Answer = []dns.RR{test.A("www.example.org. 300 IN A 192.0.2.1")}
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+})
+
+func newRRL(resp, nx float64) *RRL {
+	rl := New([]string{"example.org."}, defaultSize)
+	rl.Next = next
+	rl.limits[responses] = resp
+	rl.limits[nxdomains] = nx
+	return rl
+}
+
+func TestServeDNS(t *testing.T) {
+	tests := []struct {
+		rrl       *RRL
+		qname     string
+		tcp       bool
+		sent      int // of 10 queries
+		truncated int
+	}{
+		{rrl: newRRL(5, 0), qname: "www.example.org.", sent: 7, truncated: 2},
+		{rrl: newRRL(0, 5), qname: "www.example.org.", sent: 10},
+		{rrl: newRRL(5, 0), qname: "www.example.org.", tcp: true, sent: 10},
+		{rrl: newRRL(5, 0), qname: "www.example.net.", sent: 10},
+		{rrl: newRRL(0, 3), qname: "a.example.org.", sent: 6, truncated: 3},
+	}
+
+	for i, tc := range tests {
+		sent, truncated := 0, 0
+		for j := 0; j < 10; j++ {
+			m := new(dns.Msg)
+			m.SetQuestion(tc.qname, dns.TypeA)
+			rec := dnstest.NewRecorder(&test.ResponseWriter{TCP: tc.tcp})
+			tc.rrl.ServeDNS(context.TODO(), rec, m)
+			if rec.Msg == nil {
+				continue
+			}
+			sent++
+			if rec.Msg.Truncated {
+				truncated++
+			}
+		}
+		if sent != tc.sent {
+			t.Errorf("Test %d: expected %d responses, got %d", i, tc.sent, sent)
+		}
+		if truncated != tc.truncated {
+			t.Errorf("Test %d: expected %d truncated responses, got %d", i, tc.truncated, truncated)
+		}
+	}
+}
+
+func TestServeDNSAccounts(t *testing.T) {
+	rl := newRRL(0, 1)
+	rl.slip = 0
+
+	query := func(qname, remote string) bool {
+		m := new(dns.Msg)
+		m.SetQuestion(qname, dns.TypeA)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: remote})
+		rl.ServeDNS(context.TODO(), rec, m)
+		return rec.Msg != nil
+	}
+
+	if !query("a.example.org.", "10.0.0.1") {
+		t.Fatal("Expected first NXDOMAIN to be sent")
+	}
+	// NXDOMAIN responses are accounted to the zone, not to the name.
+	if query("b.example.org.", "10.0.0.1") {
+		t.Error("Expected NXDOMAIN for another name in the zone to be dropped")
+	}
+	// Clients in the same /24 share an account.
+	if query("a.example.org.", "10.0.0.2") {
+		t.Error("Expected NXDOMAIN for a client in the same network to be dropped")
+	}
+	if !query("a.example.org.", "10.0.1.1") {
+		t.Error("Expected NXDOMAIN for a client in another network to be sent")
+	}
+
+	rl.exempt = []*net.IPNet{{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(24, 32)}}
+	if !query("a.example.org.", "10.0.0.1") {
+		t.Error("Expected NXDOMAIN for an exempted client to be sent")
+	}
+}
+
+func TestServeDNSLogOnly(t *testing.T) {
+	rl := newRRL(1, 0)
+	rl.logOnly = true
+	for i := 0; i < 5; i++ {
+		m := new(dns.Msg)
+		m.SetQuestion("www.example.org.", dns.TypeA)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		rl.ServeDNS(context.TODO(), rec, m)
+		if rec.Msg == nil || rec.Msg.Truncated {
+			t.Fatalf("Expected response %d to be sent in log only mode", i)
+		}
+	}
+}
+
+func TestBucket(t *testing.T) {
+	now := time.Now()
+	b := &bucket{balance: 2, last: now}
+	window := 2 * time.Second
+
+	for i, want := range []action{send, send, drop, slip, drop} {
+		if got := b.debit(now, 2, window, 2); got != want {
+			t.Errorf("Response %d: expected %d, got %d", i, want, got)
+		}
+	}
+	// The debt is at most window times the rate: after 2 more seconds there are 2 credits.
+	for i := 0; i < 10; i++ {
+		b.debit(now, 2, window, 2)
+	}
+	now = now.Add(2*time.Second + time.Second/2)
+	if got := b.debit(now, 2, window, 2); got != send {
+		t.Errorf("Expected response to be sent after the window, got %d", got)
+	}
+}
+
+func TestServeDNSErrors(t *testing.T) {
+	rl := New([]string{"."}, defaultSize)
+	rl.limits[failures] = 1
+	rl.Next = plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		return dns.RcodeServerFailure, nil
+	})
+
+	for i, want := range []bool{true, false, true} {
+		m := new(dns.Msg)
+		m.SetQuestion("example.org.", dns.TypeA)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		rcode, _ := rl.ServeDNS(context.TODO(), rec, m)
+		if rcode != dns.RcodeSuccess {
+			t.Errorf("Query %d: expected the error to be written by the plugin, got rcode %d", i, rcode)
+		}
+		if sent := rec.Msg != nil; sent != want {
+			t.Errorf("Query %d: expected sent to be %t, got %t", i, want, sent)
+		}
+		if rec.Msg != nil && rec.Msg.Rcode != dns.RcodeServerFailure {
+			t.Errorf("Query %d: expected SERVFAIL, got %s", i, dns.RcodeToString[rec.Msg.Rcode])
+		}
+	}
+}
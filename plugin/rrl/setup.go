@@ -0,0 +1,149 @@
+package rrl
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cache"
+	"github.com/coredns/coredns/plugin/pkg/cidr"
+)
+
+func init() { plugin.Register("rrl", setup) }
+
+func setup(c *caddy.Controller) error {
+	rl, err := parse(c)
+	if err != nil {
+		return plugin.Error("rrl", err)
+	}
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		rl.Next = next
+		return rl
+	})
+
+	return nil
+}
+
+// properties maps the properties that set a limit to their category.
+var properties = map[string]category{
+	"responses_per_second": responses,
+	"nodata_per_second":    nodata,
+	"nxdomains_per_second": nxdomains,
+	"referrals_per_second": referrals,
+	"errors_per_second":    failures,
+}
+
+func parse(c *caddy.Controller) (*RRL, error) {
+	rl := New(nil, defaultSize)
+	// The limits that are not set default to the one of responses.
+	var limits [numCategories]float64
+	for i := range limits {
+		limits[i] = -1
+	}
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, plugin.ErrOnce
+		}
+		i++
+
+		rl.Zones = plugin.OriginsFromArgsOrServerBlock(c.RemainingArgs(), c.ServerBlockKeys)
+
+		for c.NextBlock() {
+			switch x := c.Val(); x {
+			case "window":
+				n, err := intArg(c, 1, 3600)
+				if err != nil {
+					return nil, err
+				}
+				rl.window = time.Duration(n) * time.Second
+			case "ipv4_prefix_length":
+				n, err := intArg(c, 0, 32)
+				if err != nil {
+					return nil, err
+				}
+				rl.ipv4 = net.CIDRMask(n, 32)
+			case "ipv6_prefix_length":
+				n, err := intArg(c, 0, 128)
+				if err != nil {
+					return nil, err
+				}
+				rl.ipv6 = net.CIDRMask(n, 128)
+			case "slip":
+				n, err := intArg(c, 0, 10)
+				if err != nil {
+					return nil, err
+				}
+				rl.slip = n
+			case "exempt":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				nets, err := cidr.ParseNetworks(args)
+				if err != nil {
+					return nil, c.Err(err.Error())
+				}
+				rl.exempt = append(rl.exempt, nets...)
+			case "max_table_size":
+				n, err := intArg(c, 1, 1<<24)
+				if err != nil {
+					return nil, err
+				}
+				rl.table = cache.New(n)
+			case "log_only":
+				if len(c.RemainingArgs()) != 0 {
+					return nil, c.ArgErr()
+				}
+				rl.logOnly = true
+			default:
+				cat, ok := properties[x]
+				if !ok {
+					return nil, c.Errf("unknown property '%s'", x)
+				}
+				n, err := intArg(c, 0, 1<<20)
+				if err != nil {
+					return nil, err
+				}
+				limits[cat] = float64(n)
+			}
+		}
+	}
+
+	if limits[responses] < 0 {
+		limits[responses] = 0
+	}
+	limited := false
+	for i, l := range limits {
+		if l < 0 {
+			l = limits[responses]
+		}
+		rl.limits[i] = l
+		limited = limited || l > 0
+	}
+	if !limited {
+		return nil, c.Err("no rate limit is set")
+	}
+	return rl, nil
+}
+
+// intArg parses the next argument as an integer between min and max.
+func intArg(c *caddy.Controller, min, max int) (int, error) {
+	prop := c.Val()
+	if !c.NextArg() {
+		return 0, c.ArgErr()
+	}
+	n, err := strconv.Atoi(c.Val())
+	if err != nil || n < min || n > max {
+		return 0, c.Errf("%s must be a number between %d and %d: %s", prop, min, max, c.Val())
+	}
+	if c.NextArg() {
+		return 0, c.ArgErr()
+	}
+	return n, nil
+}
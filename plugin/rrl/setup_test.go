@@ -0,0 +1,111 @@
+package rrl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coredns/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		input      string
+		shouldErr  bool
+		responses  float64
+		nxdomains  float64
+		window     time.Duration
+		slip       int
+		ipv4Prefix int
+		exempt     int
+		logOnly    bool
+	}{
+		{`rrl {
+			responses_per_second 10
+		}`, false, 10, 10, defaultWindow, 2, 24, 0, false},
+		{`rrl example.org {
+			responses_per_second 10
+			nxdomains_per_second 5
+			window 5
+			slip 0
+			ipv4_prefix_length 32
+			exempt 10.0.0.0/8 192.0.2.1
+			log_only
+		}`, false, 10, 5, 5 * time.Second, 0, 32, 2, true},
+		{`rrl {
+			nxdomains_per_second 5
+		}`, false, 0, 5, defaultWindow, 2, 24, 0, false},
+		// fails
+		{`rrl`, true, 0, 0, 0, 0, 0, 0, false},
+		{`rrl {
+			responses_per_second
+		}`, true, 0, 0, 0, 0, 0, 0, false},
+		{`rrl {
+			responses_per_second -1
+		}`, true, 0, 0, 0, 0, 0, 0, false},
+		{`rrl {
+			responses_per_second 10
+			slip 11
+		}`, true, 0, 0, 0, 0, 0, 0, false},
+		{`rrl {
+			responses_per_second 10
+			window 0
+		}`, true, 0, 0, 0, 0, 0, 0, false},
+		{`rrl {
+			responses_per_second 10
+			exempt 10.0.0.0/33
+		}`, true, 0, 0, 0, 0, 0, 0, false},
+		{`rrl {
+			responses_per_second 10
+			ipv6_prefix_length 129
+		}`, true, 0, 0, 0, 0, 0, 0, false},
+		{`rrl {
+			responses_per_second 10
+			log_only yes
+		}`, true, 0, 0, 0, 0, 0, 0, false},
+		{`rrl {
+			responses_per_second 10
+			unknown
+		}`, true, 0, 0, 0, 0, 0, 0, false},
+		{`rrl {
+			responses_per_second 10
+		}
+		rrl {
+			responses_per_second 10
+		}`, true, 0, 0, 0, 0, 0, 0, false},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		rl, err := parse(c)
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: expected no error but found one for input %s, got: %v", i, test.input, err)
+			}
+			continue
+		}
+		if rl.limits[responses] != test.responses {
+			t.Errorf("Test %d: expected %v responses per second, got %v", i, test.responses, rl.limits[responses])
+		}
+		if rl.limits[nxdomains] != test.nxdomains {
+			t.Errorf("Test %d: expected %v nxdomains per second, got %v", i, test.nxdomains, rl.limits[nxdomains])
+		}
+		if rl.window != test.window {
+			t.Errorf("Test %d: expected window %s, got %s", i, test.window, rl.window)
+		}
+		if rl.slip != test.slip {
+			t.Errorf("Test %d: expected slip %d, got %d", i, test.slip, rl.slip)
+		}
+		if ones, _ := rl.ipv4.Size(); ones != test.ipv4Prefix {
+			t.Errorf("Test %d: expected IPv4 prefix length %d, got %d", i, test.ipv4Prefix, ones)
+		}
+		if len(rl.exempt) != test.exempt {
+			t.Errorf("Test %d: expected %d exempted networks, got %d", i, test.exempt, len(rl.exempt))
+		}
+		if rl.logOnly != test.logOnly {
+			t.Errorf("Test %d: expected log only %t, got %t", i, test.logOnly, rl.logOnly)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package secondary
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/catalog"
+	"github.com/coredns/coredns/plugin/pkg/upstream"
+
+	"github.com/miekg/dns"
+)
+
+// catalogZone is a secondary zone that is a catalog zone (RFC 9432). The zones listed in it are
+// transferred from the same primaries, and are added and removed when the catalog zone changes.
+type catalogZone struct {
+	name    string
+	z       *file.Zone
+	dir     string // directory the member zones are saved to, if not empty
+	members *members
+}
+
+// sync adds and removes the member zones after the catalog zone is transferred.
+func (c *catalogZone) sync() {
+	ch, err := c.z.Transfer(0)
+	if err != nil {
+		log.Errorf("Failed to read catalog zone `%s': %s", c.name, err)
+		return
+	}
+	var rrs []dns.RR
+	for r := range ch {
+		rrs = append(rrs, r...)
+	}
+	ms, err := catalog.Parse(c.name, rrs)
+	if err != nil {
+		log.Errorf("Not updating the zones of catalog zone `%s': %s", c.name, err)
+		return
+	}
+	c.members.update(c, ms)
+}
+
+// newZone returns member zone name, which is loaded from its backing file if there is one.
+func (c *catalogZone) newZone(name string) *file.Zone {
+	z := file.NewZone(name, "stdin")
+	z.TransferFrom = c.z.TransferFrom
+	z.TransferKey = c.z.TransferKey
+	z.Upstream = upstream.New()
+	if c.dir != "" {
+		z.BackingFile = filepath.Join(c.dir, "db."+strings.TrimSuffix(name, "."))
+		if err := z.LoadBackingFile(); err != nil {
+			log.Warningf("Failed to load zone `%s' from %q: %s", name, z.BackingFile, err)
+		}
+	}
+	return z
+}
+
+// members holds the member zones of the catalog zones.
+type members struct {
+	sync.RWMutex
+	z       map[string]*file.Zone
+	names   []string
+	catalog map[string]string // catalog zone a member zone is listed in
+	static  []string          // configured zones, these are never added as member zones
+	closed  bool
+}
+
+func newMembers(static []string) *members {
+	return &members{z: make(map[string]*file.Zone), catalog: make(map[string]string), static: static}
+}
+
+// match returns the member zone that qname is in, if any.
+func (m *members) match(qname string) (string, *file.Zone) {
+	m.RLock()
+	defer m.RUnlock()
+	zone := plugin.Zones(m.names).Matches(qname)
+	return zone, m.z[zone]
+}
+
+// zone returns member zone name, or nil if there is no such member zone.
+func (m *members) zone(name string) *file.Zone {
+	m.RLock()
+	defer m.RUnlock()
+	return m.z[name]
+}
+
+// update sets the member zones of catalog zone c to ms. New zones are transferred, removed zones aren't
+// served anymore and their backing files are removed.
+func (m *members) update(c *catalogZone, ms []catalog.Member) {
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return
+	}
+
+	listed := make(map[string]bool, len(ms))
+	for _, member := range ms {
+		name := member.Zone
+		listed[name] = true
+		if _, ok := m.z[name]; ok {
+			// Already a member zone, possibly of another catalog zone.
+			continue
+		}
+		if plugin.Zones(m.static).Matches(name) == name {
+			log.Warningf("Not adding zone `%s' of catalog zone `%s', it is already configured", name, c.name)
+			continue
+		}
+		z := c.newZone(name)
+		m.z[name] = z
+		m.catalog[name] = c.name
+		zoneAges.add(name, z)
+		go update(name, z)
+		log.Infof("Added zone `%s' of catalog zone `%s'", name, c.name)
+	}
+
+	for name, cat := range m.catalog {
+		if cat != c.name || listed[name] {
+			continue
+		}
+		z := m.z[name]
+		m.remove(name)
+		if z.BackingFile != "" {
+			if err := os.Remove(z.BackingFile); err != nil && !os.IsNotExist(err) {
+				log.Warningf("Failed to remove %q: %s", z.BackingFile, err)
+			}
+		}
+		log.Infof("Removed zone `%s' of catalog zone `%s'", name, c.name)
+	}
+
+	m.names = m.names[:0]
+	for name := range m.z {
+		m.names = append(m.names, name)
+	}
+	sort.Strings(m.names)
+}
+
+// remove stops updating member zone name and removes it. The lock must be held.
+func (m *members) remove(name string) {
+	z := m.z[name]
+	z.StopUpdate()
+	zoneAges.remove(name, z)
+	delete(m.z, name)
+	delete(m.catalog, name)
+}
+
+// close removes all member zones, and makes sure no new ones are added.
+func (m *members) close() {
+	m.Lock()
+	defer m.Unlock()
+	for name := range m.z {
+		m.remove(name)
+	}
+	m.names = nil
+	m.closed = true
+}
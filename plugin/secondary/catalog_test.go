@@ -0,0 +1,60 @@
+package secondary
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/catalog"
+)
+
+func TestMembersUpdate(t *testing.T) {
+	dir := t.TempDir()
+	m := newMembers([]string{"catalog.invalid.", "example.net."})
+	z := file.NewZone("catalog.invalid.", "stdin")
+	// No primaries, so the member zones aren't transferred.
+	c := &catalogZone{name: "catalog.invalid.", z: z, dir: dir, members: m}
+
+	m.update(c, []catalog.Member{{Zone: "example.org."}, {Zone: "example.com."}, {Zone: "example.net."}})
+	if zone, mz := m.match("www.example.org."); zone != "example.org." || mz == nil {
+		t.Errorf("Expected www.example.org. to match member zone example.org., got %q", zone)
+	}
+	if m.zone("example.com.") == nil {
+		t.Errorf("Expected example.com. to be a member zone")
+	}
+	// A configured zone isn't added.
+	if m.zone("example.net.") != nil {
+		t.Errorf("Expected example.net. not to be a member zone")
+	}
+	if mz := m.zone("example.org."); mz.BackingFile != filepath.Join(dir, "db.example.org") {
+		t.Errorf("Expected the member zone to be saved in %s, got %q", dir, mz.BackingFile)
+	}
+
+	backing := filepath.Join(dir, "db.example.com")
+	if err := os.WriteFile(backing, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	mz := m.zone("example.com.")
+	m.update(c, []catalog.Member{{Zone: "example.org."}})
+	if m.zone("example.com.") != nil {
+		t.Errorf("Expected example.com. to be removed")
+	}
+	select {
+	case <-mz.UpdateStopped():
+	default:
+		t.Errorf("Expected the updates of example.com. to be stopped")
+	}
+	if _, err := os.Stat(backing); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", backing, err)
+	}
+
+	m.close()
+	if zone, _ := m.match("www.example.org."); zone != "" {
+		t.Errorf("Expected no member zones after close, got %q", zone)
+	}
+	m.update(c, []catalog.Member{{Zone: "example.org."}})
+	if m.zone("example.org.") != nil {
+		t.Errorf("Expected no member zones to be added after close")
+	}
+}
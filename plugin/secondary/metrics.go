@@ -0,0 +1,69 @@
+package secondary
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/file"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// zoneAge is the time since the zone was last transferred.
+	zoneAge = prometheus.NewDesc(
+		prometheus.BuildFQName(plugin.Namespace, "secondary", "zone_age_seconds"),
+		"The time since the zone was last transferred from a primary.",
+		[]string{"zone"}, nil)
+	// zoneRefresh is the timestamp of the last successful refresh of the zone.
+	zoneRefresh = prometheus.NewDesc(
+		prometheus.BuildFQName(plugin.Namespace, "secondary", "refresh_timestamp_seconds"),
+		"The timestamp of the last successful refresh of the zone.",
+		[]string{"zone"}, nil)
+)
+
+// zoneAges collects the age and last refresh of the secondary zones when the metrics are scraped.
+var zoneAges = &ages{zones: make(map[string]*file.Zone)}
+
+func init() { prometheus.MustRegister(zoneAges) }
+
+type ages struct {
+	sync.RWMutex
+	zones map[string]*file.Zone
+}
+
+func (a *ages) add(name string, z *file.Zone) {
+	a.Lock()
+	a.zones[name] = z
+	a.Unlock()
+}
+
+// remove removes the zone name, unless it has been replaced by another zone z.
+func (a *ages) remove(name string, z *file.Zone) {
+	a.Lock()
+	if a.zones[name] == z {
+		delete(a.zones, name)
+	}
+	a.Unlock()
+}
+
+// Describe implements prometheus.Collector.
+func (a *ages) Describe(ch chan<- *prometheus.Desc) {
+	ch <- zoneAge
+	ch <- zoneRefresh
+}
+
+// Collect implements prometheus.Collector.
+func (a *ages) Collect(ch chan<- prometheus.Metric) {
+	a.RLock()
+	defer a.RUnlock()
+	for name, z := range a.zones {
+		transferred, refreshed := z.Refreshed()
+		if transferred.IsZero() {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(zoneAge, prometheus.GaugeValue, time.Since(transferred).Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(zoneRefresh, prometheus.GaugeValue, float64(refreshed.Unix()), name)
+	}
+}
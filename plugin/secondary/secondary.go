@@ -1,10 +1,45 @@
 // Package secondary implements a secondary plugin.
 package secondary
 
-import "github.com/coredns/coredns/plugin/file"
+import (
+	"context"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
 
 // Secondary implements a secondary plugin that allows CoreDNS to retrieve (via AXFR)
 // zone information from a primary server.
 type Secondary struct {
 	file.File
+	members *members // zones of the catalog zones, nil if there are no catalog zones
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (s Secondary) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	if s.members == nil {
+		return s.File.ServeDNS(ctx, w, r)
+	}
+	state := request.Request{W: w, Req: r}
+	qname := state.Name()
+
+	zone, z := s.members.match(qname)
+	if z == nil || len(zone) <= len(plugin.Zones(s.Zones.Names).Matches(qname)) {
+		return s.File.ServeDNS(ctx, w, r)
+	}
+	f := file.File{Next: s.Next, Zones: file.Zones{Z: map[string]*file.Zone{zone: z}, Names: []string{zone}}}
+	return f.ServeDNS(ctx, w, r)
+}
+
+// Transfer implements the transfer.Transferer interface.
+func (s Secondary) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
+	if s.members != nil {
+		if z := s.members.zone(zone); z != nil {
+			return z.Transfer(serial)
+		}
+	}
+	return s.File.Transfer(zone, serial)
 }
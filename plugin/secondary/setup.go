@@ -1,6 +1,7 @@
 package secondary
 
 import (
+	"path/filepath"
 	"time"
 
 	"github.com/coredns/caddy"
@@ -9,6 +10,7 @@ import (
 	"github.com/coredns/coredns/plugin/file"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
 	"github.com/coredns/coredns/plugin/pkg/parse"
+	"github.com/coredns/coredns/plugin/pkg/tsig"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 )
 
@@ -17,51 +19,92 @@ var log = clog.NewWithPlugin("secondary")
 func init() { plugin.Register("secondary", setup) }
 
 func setup(c *caddy.Controller) error {
-	zones, err := secondaryParse(c)
+	zones, catalogs, err := secondaryParse(c)
 	if err != nil {
 		return plugin.Error("secondary", err)
 	}
 
+	s := Secondary{File: file.File{Zones: zones}}
+	if len(catalogs) > 0 {
+		s.members = newMembers(zones.Names)
+		c.OnShutdown(func() error { s.members.close(); return nil })
+	}
+
 	// Add startup functions to retrieve the zone and keep it up to date.
 	for _, n := range zones.Names {
-		z := zones.Z[n]
+		n, z := n, zones.Z[n]
+		if err := z.LoadBackingFile(); err != nil {
+			return plugin.Error("secondary", err)
+		}
+		zoneAges.add(n, z)
+		c.OnShutdown(func() error { z.StopUpdate(); zoneAges.remove(n, z); return nil })
+
+		if dir, ok := catalogs[n]; ok {
+			cz := &catalogZone{name: n, z: z, dir: dir, members: s.members}
+			z.OnTransfer = cz.sync
+			c.OnStartup(func() error {
+				if z.Apex.SOA != nil {
+					// Loaded from the backing file.
+					cz.sync()
+				}
+				return nil
+			})
+		}
+
 		if len(z.TransferFrom) > 0 {
 			c.OnStartup(func() error {
-				z.StartupOnce.Do(func() {
-					go func() {
-						dur := time.Millisecond * 250
-						step := time.Duration(2)
-						max := time.Second * 10
-						for {
-							err := z.TransferIn()
-							if err == nil {
-								break
-							}
-							log.Warningf("All '%s' masters failed to transfer, retrying in %s: %s", n, dur.String(), err)
-							time.Sleep(dur)
-							dur = step * dur
-							if dur > max {
-								dur = max
-							}
-						}
-						z.Update()
-					}()
-				})
+				z.StartupOnce.Do(func() { go update(n, z) })
 				return nil
 			})
 		}
 	}
 
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
-		return Secondary{file.File{Next: next, Zones: zones}}
+		s.Next = next
+		return s
 	})
 
 	return nil
 }
 
-func secondaryParse(c *caddy.Controller) (file.Zones, error) {
+// update transfers zone z named n from its primaries and keeps it up to date, until z.StopUpdate is called.
+func update(n string, z *file.Zone) {
+	if z.Apex.SOA != nil {
+		// Loaded from the backing file, serve it while it's refreshed.
+		if err := z.Refresh(); err != nil {
+			log.Warningf("Failed to refresh '%s', serving it from %q: %s", n, z.BackingFile, err)
+		}
+		z.Update()
+		return
+	}
+	dur := time.Millisecond * 250
+	step := time.Duration(2)
+	max := time.Second * 10
+	for {
+		err := z.TransferIn()
+		if err == nil {
+			break
+		}
+		log.Warningf("All '%s' masters failed to transfer, retrying in %s: %s", n, dur.String(), err)
+		select {
+		case <-z.UpdateStopped():
+			return
+		case <-time.After(dur):
+		}
+		dur = step * dur
+		if dur > max {
+			dur = max
+		}
+	}
+	z.Update()
+}
+
+// secondaryParse parses the secondary zones. The catalog zones among them are returned with the directory
+// their member zones are saved to, which is empty if they aren't saved.
+func secondaryParse(c *caddy.Controller) (file.Zones, map[string]string, error) {
 	z := make(map[string]*file.Zone)
 	names := []string{}
+	catalogs := make(map[string]string)
 	for c.Next() {
 
 		if c.Val() == "secondary" {
@@ -81,10 +124,49 @@ func secondaryParse(c *caddy.Controller) (file.Zones, error) {
 					var err error
 					f, err = parse.TransferIn(c)
 					if err != nil {
-						return file.Zones{}, err
+						return file.Zones{}, nil, err
+					}
+				case "key":
+					k, err := tsig.Parse(c.RemainingArgs())
+					if err != nil {
+						return file.Zones{}, nil, c.Err(err.Error())
+					}
+					if err := k.Register(dnsserver.GetConfig(c)); err != nil {
+						return file.Zones{}, nil, c.Err(err.Error())
+					}
+					for _, origin := range origins {
+						z[origin].TransferKey = k
+					}
+				case "file":
+					if !c.NextArg() {
+						return file.Zones{}, nil, c.ArgErr()
+					}
+					if len(origins) != 1 {
+						return file.Zones{}, nil, c.Errf("file can only be used with a single zone, got %d", len(origins))
+					}
+					fileName := c.Val()
+					config := dnsserver.GetConfig(c)
+					if !filepath.IsAbs(fileName) && config.Root != "" {
+						fileName = filepath.Join(config.Root, fileName)
+					}
+					z[origins[0]].BackingFile = fileName
+				case "catalog":
+					dir := ""
+					if c.NextArg() {
+						dir = c.Val()
+						config := dnsserver.GetConfig(c)
+						if !filepath.IsAbs(dir) && config.Root != "" {
+							dir = filepath.Join(config.Root, dir)
+						}
+					}
+					if c.NextArg() {
+						return file.Zones{}, nil, c.ArgErr()
+					}
+					for _, origin := range origins {
+						catalogs[origin] = dir
 					}
 				default:
-					return file.Zones{}, c.Errf("unknown property '%s'", c.Val())
+					return file.Zones{}, nil, c.Errf("unknown property '%s'", c.Val())
 				}
 
 				for _, origin := range origins {
@@ -96,5 +178,5 @@ func secondaryParse(c *caddy.Controller) (file.Zones, error) {
 			}
 		}
 	}
-	return file.Zones{Z: z, Names: names}, nil
+	return file.Zones{Z: z, Names: names}, catalogs, nil
 }
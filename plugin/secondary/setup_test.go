@@ -1,6 +1,7 @@
 package secondary
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/coredns/caddy"
@@ -12,12 +13,14 @@ func TestSecondaryParse(t *testing.T) {
 		shouldErr      bool
 		transferFrom   string
 		zones          []string
+		key            string
 	}{
 		{
 			`secondary`,
 			false, // TODO(miek): should actually be true, because without transfer lines this does not make sense
 			"",
 			nil,
+			"",
 		},
 		{
 			`secondary {
@@ -26,26 +29,51 @@ func TestSecondaryParse(t *testing.T) {
 			false,
 			"127.0.0.1:53",
 			nil,
+			"",
+		},
+		{
+			`secondary example.org {
+				transfer from 127.0.0.1
+			}`,
+			false,
+			"127.0.0.1:53",
+			[]string{"example.org."},
+			"",
 		},
 		{
 			`secondary example.org {
 				transfer from 127.0.0.1
+				key xfr.example.org. hmac-sha256 c2VjcmV0
 			}`,
 			false,
 			"127.0.0.1:53",
 			[]string{"example.org."},
+			"xfr.example.org.",
+		},
+		{
+			`secondary example.org {
+				transfer from 127.0.0.1
+				key xfr.example.org. hmac-sha256
+			}`,
+			true,
+			"",
+			nil,
+			"",
 		},
 	}
 
 	for i, test := range tests {
 		c := caddy.NewTestController("dns", test.inputFileRules)
-		s, err := secondaryParse(c)
+		s, _, err := secondaryParse(c)
 
 		if err == nil && test.shouldErr {
 			t.Fatalf("Test %d expected errors, but got no error", i)
 		} else if err != nil && !test.shouldErr {
 			t.Fatalf("Test %d expected no errors, but got '%v'", i, err)
 		}
+		if test.shouldErr {
+			continue
+		}
 
 		for i, name := range test.zones {
 			if x := s.Names[i]; x != name {
@@ -58,6 +86,90 @@ func TestSecondaryParse(t *testing.T) {
 			if x := v.TransferFrom[0]; x != test.transferFrom {
 				t.Fatalf("Test %d transform from names don't match expected %q, but got %q", i, test.transferFrom, x)
 			}
+			if test.key == "" && v.TransferKey != nil {
+				t.Fatalf("Test %d expected no key, but got %q", i, v.TransferKey.Name)
+			}
+			if test.key != "" && (v.TransferKey == nil || v.TransferKey.Name != test.key) {
+				t.Fatalf("Test %d expected key %q, but got %v", i, test.key, v.TransferKey)
+			}
+		}
+	}
+}
+
+func TestSecondaryParseFile(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		file      string
+	}{
+		{`secondary example.org {
+			transfer from 127.0.0.1
+			file /var/lib/coredns/db.example.org
+		}`, false, "/var/lib/coredns/db.example.org"},
+		{`secondary example.org {
+			transfer from 127.0.0.1
+		}`, false, ""},
+		{`secondary example.org {
+			file
+		}`, true, ""},
+		{`secondary example.org example.net {
+			transfer from 127.0.0.1
+			file /var/lib/coredns/db.example.org
+		}`, true, ""},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		s, _, err := secondaryParse(c)
+		if err == nil && tc.shouldErr {
+			t.Fatalf("Test %d expected errors, but got no error", i)
+		} else if err != nil && !tc.shouldErr {
+			t.Fatalf("Test %d expected no errors, but got '%v'", i, err)
+		}
+		if tc.shouldErr {
+			continue
+		}
+		if x := s.Z["example.org."].BackingFile; x != tc.file {
+			t.Errorf("Test %d expected file %q, but got %q", i, tc.file, x)
+		}
+	}
+}
+
+func TestSecondaryParseCatalog(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		catalogs  map[string]string
+	}{
+		{`secondary catalog.invalid {
+			transfer from 127.0.0.1
+			catalog
+		}`, false, map[string]string{"catalog.invalid.": ""}},
+		{`secondary catalog.invalid {
+			transfer from 127.0.0.1
+			catalog /var/lib/coredns/zones
+		}`, false, map[string]string{"catalog.invalid.": "/var/lib/coredns/zones"}},
+		{`secondary example.org {
+			transfer from 127.0.0.1
+		}`, false, map[string]string{}},
+		{`secondary catalog.invalid {
+			catalog /var/lib/coredns/zones more
+		}`, true, nil},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		_, catalogs, err := secondaryParse(c)
+		if err == nil && tc.shouldErr {
+			t.Fatalf("Test %d expected errors, but got no error", i)
+		} else if err != nil && !tc.shouldErr {
+			t.Fatalf("Test %d expected no errors, but got '%v'", i, err)
+		}
+		if tc.shouldErr {
+			continue
+		}
+		if !reflect.DeepEqual(catalogs, tc.catalogs) {
+			t.Errorf("Test %d expected catalog zones %v, but got %v", i, tc.catalogs, catalogs)
 		}
 	}
 }
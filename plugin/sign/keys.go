@@ -13,6 +13,7 @@ import (
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin/pkg/rollover"
 
 	"github.com/miekg/dns"
 	"golang.org/x/crypto/ed25519"
@@ -25,10 +26,11 @@ type Pair struct {
 	Private crypto.Signer
 }
 
-// keyParse reads the public and private key from disk.
-func keyParse(c *caddy.Controller) ([]Pair, error) {
+// keyParse reads the public and private key from disk for key file, or returns the directory for key
+// directory.
+func keyParse(c *caddy.Controller) ([]Pair, string, error) {
 	if !c.NextArg() {
-		return nil, c.ArgErr()
+		return nil, "", c.ArgErr()
 	}
 	pairs := []Pair{}
 	config := dnsserver.GetConfig(c)
@@ -37,7 +39,7 @@ func keyParse(c *caddy.Controller) ([]Pair, error) {
 	case "file":
 		ks := c.RemainingArgs()
 		if len(ks) == 0 {
-			return nil, c.ArgErr()
+			return nil, "", c.ArgErr()
 		}
 		for _, k := range ks {
 			base := k
@@ -54,15 +56,33 @@ func keyParse(c *caddy.Controller) ([]Pair, error) {
 
 			pair, err := readKeyPair(base+".key", base+".private")
 			if err != nil {
-				return nil, err
+				return nil, "", err
 			}
 			pairs = append(pairs, pair)
 		}
 	case "directory":
-		return nil, fmt.Errorf("directory: not implemented")
+		if !c.NextArg() {
+			return nil, "", c.ArgErr()
+		}
+		dir := c.Val()
+		if !filepath.IsAbs(dir) && config.Root != "" {
+			dir = filepath.Join(config.Root, dir)
+		}
+		return nil, dir, nil
 	}
 
-	return pairs, nil
+	return pairs, "", nil
+}
+
+// managedPairs returns the keys in ks as pairs with owner name origin and TTL ttl.
+func managedPairs(keys []*rollover.Key, origin string, ttl uint32) []Pair {
+	pairs := make([]Pair, len(keys))
+	for i, k := range keys {
+		public := dns.Copy(k.Public).(*dns.DNSKEY)
+		public.Hdr.Name, public.Hdr.Ttl = origin, ttl
+		pairs[i] = Pair{Public: public, KeyTag: k.Tag, Private: k.Private}
+	}
+	return pairs
 }
 
 func readKeyPair(public, private string) (Pair, error) {
@@ -0,0 +1,170 @@
+package sign
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/file/tree"
+
+	"github.com/miekg/dns"
+)
+
+// nsec3 holds the parameters for signing a zone with NSEC3 (RFC 5155) instead of NSEC.
+type nsec3 struct {
+	iterations uint16
+	salt       string // hex encoded, empty for no salt
+	optOut     bool
+}
+
+// maxIterations is the maximum number of additional hash iterations that can be configured. Validators may
+// treat zones with more iterations as insecure, see RFC 9276.
+const maxIterations = 150
+
+// nsec3Parse parses the arguments of the nsec3 property: [ITERATIONS [SALT]] [optout].
+func nsec3Parse(c *caddy.Controller) (*nsec3, error) {
+	n := &nsec3{}
+	args := c.RemainingArgs()
+	if len(args) > 0 && args[len(args)-1] == "optout" {
+		n.optOut = true
+		args = args[:len(args)-1]
+	}
+	if len(args) > 2 {
+		return nil, c.ArgErr()
+	}
+	if len(args) > 0 {
+		i, err := strconv.ParseUint(args[0], 10, 16)
+		if err != nil || i > maxIterations {
+			return nil, c.Errf("iterations must be a number between 0 and %d: %s", maxIterations, args[0])
+		}
+		n.iterations = uint16(i)
+	}
+	if len(args) > 1 && args[1] != "-" {
+		salt, err := hex.DecodeString(args[1])
+		if err != nil || len(salt) > 255 {
+			return nil, c.Errf("salt must be at most 255 hex encoded bytes: %s", args[1])
+		}
+		n.salt = strings.ToUpper(args[1])
+	}
+	return n, nil
+}
+
+// param returns the NSEC3PARAM record for n.
+func (n *nsec3) param(origin string) *dns.NSEC3PARAM {
+	return &dns.NSEC3PARAM{
+		Hdr:        dns.RR_Header{Name: origin, Rrtype: dns.TypeNSEC3PARAM, Class: dns.ClassINET, Ttl: 0},
+		Hash:       dns.SHA1,
+		Iterations: n.iterations,
+		SaltLength: uint8(len(n.salt) / 2),
+		Salt:       n.salt,
+	}
+}
+
+// chain returns the NSEC3 records for z, see RFC 5155, Section 7.1. The DNSKEY and NSEC3PARAM records
+// must have been added to z. Empty non-terminals get an NSEC3 record with an empty type bitmap. With opt-out,
+// unsigned delegations don't get an NSEC3 record.
+func (n *nsec3) chain(z *file.Zone, origin string, ttl uint32) []*dns.NSEC3 {
+	bitmaps := map[string][]uint16{}
+	z.AuthWalk(func(e *tree.Elem, _ map[uint16][]dns.RR, auth bool) error {
+		if !auth {
+			return nil
+		}
+		types := e.Types()
+		switch {
+		case e.Name() == origin:
+			types = append(types, dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG)
+		case e.Type(dns.TypeNS) == nil:
+			types = append(types, dns.TypeRRSIG)
+		case e.Type(dns.TypeDS) != nil:
+			types = append(types, dns.TypeRRSIG)
+		case n.optOut:
+			return nil // unsigned delegation
+		}
+		bitmaps[e.Name()] = types
+		return nil
+	})
+
+	names := make([]string, 0, len(bitmaps))
+	for name := range bitmaps {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		for name != origin {
+			i, _ := dns.NextLabel(name, 0)
+			name = name[i:]
+			if _, ok := bitmaps[name]; ok {
+				continue
+			}
+			if _, found := z.Search(name); !found {
+				bitmaps[name] = []uint16{}
+			}
+		}
+	}
+
+	hashes := make([]string, 0, len(bitmaps))
+	nsec3s := make(map[string]*dns.NSEC3, len(bitmaps))
+	flags := uint8(0)
+	if n.optOut {
+		flags = 1
+	}
+	for name, bitmap := range bitmaps {
+		h := dns.HashName(name, dns.SHA1, n.iterations, n.salt)
+		sort.Slice(bitmap, func(i, j int) bool { return bitmap[i] < bitmap[j] })
+		hashes = append(hashes, h)
+		nsec3s[h] = &dns.NSEC3{
+			Hdr:        dns.RR_Header{Name: strings.ToLower(h) + "." + origin, Rrtype: dns.TypeNSEC3, Class: dns.ClassINET, Ttl: ttl},
+			Hash:       dns.SHA1,
+			Flags:      flags,
+			Iterations: n.iterations,
+			SaltLength: uint8(len(n.salt) / 2),
+			Salt:       n.salt,
+			HashLength: 20,
+			TypeBitMap: bitmap,
+		}
+	}
+	sort.Strings(hashes)
+
+	chain := make([]*dns.NSEC3, len(hashes))
+	for i, h := range hashes {
+		chain[i] = nsec3s[h]
+		chain[i].NextDomain = hashes[(i+1)%len(hashes)]
+	}
+	return chain
+}
+
+// nsec3Changed returns an error when the signed zone in rd isn't signed with the NSEC3 parameters in n, or
+// when n is nil, but the zone is signed with NSEC3. Like resign only the first 100 records are checked.
+func nsec3Changed(rd io.Reader, origin string, n *nsec3) error {
+	zp := dns.NewZoneParser(rd, ".", "resign")
+	zp.SetIncludeAllowed(true)
+	i := 0
+
+	var param *dns.NSEC3PARAM
+	for rr, ok := zp.Next(); ok && i < 100; rr, ok = zp.Next() {
+		if err := zp.Err(); err != nil {
+			return err
+		}
+		if p, ok := rr.(*dns.NSEC3PARAM); ok && strings.EqualFold(p.Hdr.Name, origin) {
+			param = p
+			break
+		}
+		i++
+	}
+
+	switch {
+	case n == nil && param != nil:
+		return fmt.Errorf("zone is signed with NSEC3, but NSEC is configured")
+	case n == nil:
+		return nil
+	case param == nil:
+		return fmt.Errorf("zone isn't signed with NSEC3")
+	case param.Iterations != n.iterations || !strings.EqualFold(param.Salt, n.salt):
+		return fmt.Errorf("zone is signed with NSEC3 iterations %d and salt %q, configured are %d and %q", param.Iterations, param.Salt, n.iterations, n.salt)
+	}
+	return nil
+}
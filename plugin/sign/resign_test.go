@@ -38,3 +38,28 @@ func TestResignExpire(t *testing.T) {
 		t.Errorf("Expected RRSIG to be invalid for %s, got valid", then.Format(timeFmt))
 	}
 }
+
+func TestResignNSEC3(t *testing.T) {
+	param := `miek.nl.	0	IN	NSEC3PARAM	1 0 5 AABBCCDD`
+	tests := []struct {
+		zone      string
+		nsec3     *nsec3
+		shouldErr bool
+	}{
+		{param, &nsec3{iterations: 5, salt: "AABBCCDD"}, false},
+		{param, &nsec3{iterations: 0, salt: "AABBCCDD"}, true},
+		{param, &nsec3{iterations: 5}, true},
+		{param, nil, true},
+		{`miek.nl.	1800	IN	NSEC	a.miek.nl. NS SOA RRSIG NSEC DNSKEY`, &nsec3{}, true},
+		{`miek.nl.	1800	IN	NSEC	a.miek.nl. NS SOA RRSIG NSEC DNSKEY`, nil, false},
+	}
+	for i, tc := range tests {
+		err := nsec3Changed(strings.NewReader(tc.zone), "miek.nl.", tc.nsec3)
+		if err == nil && tc.shouldErr {
+			t.Errorf("Test %d, expected zone to be resigned, got nothing", i)
+		}
+		if err != nil && !tc.shouldErr {
+			t.Errorf("Test %d, expected zone not to be resigned, got %s", i, err)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/rollover"
 )
 
 func init() { plugin.Register("sign", setup) }
@@ -64,13 +65,18 @@ func parse(c *caddy.Controller) (*Sign, error) {
 			}
 		}
 
+		keydir := ""
+		policy := rollover.DefaultPolicy()
 		for c.NextBlock() {
 			switch c.Val() {
 			case "key":
-				pairs, err := keyParse(c)
+				pairs, dir, err := keyParse(c)
 				if err != nil {
 					return sign, err
 				}
+				if dir != "" {
+					keydir = dir
+				}
 				for i := range signers {
 					for _, p := range pairs {
 						p.Public.Header().Name = signers[i].origin
@@ -89,8 +95,37 @@ func parse(c *caddy.Controller) (*Sign, error) {
 					signers[i].directory = dir[0]
 					signers[i].signedfile = fmt.Sprintf("db.%ssigned", signers[i].origin)
 				}
+			case "nsec3":
+				n, err := nsec3Parse(c)
+				if err != nil {
+					return nil, err
+				}
+				for i := range signers {
+					signers[i].nsec3 = n
+				}
 			default:
-				return nil, c.Errf("unknown property '%s'", c.Val())
+				ok, err := policy.Parse(c)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					return nil, c.Errf("unknown property '%s'", c.Val())
+				}
+			}
+		}
+		if keydir != "" {
+			if len(signers) > 0 && len(signers[0].keys) > 0 {
+				return nil, fmt.Errorf("key file and key directory can not be used together")
+			}
+			if err := policy.Validate(); err != nil {
+				return nil, err
+			}
+			for i := range signers {
+				m, err := rollover.New(signers[i].origin, keydir, policy)
+				if err != nil {
+					return nil, err
+				}
+				signers[i].manager = m
 			}
 		}
 		sign.signers = append(sign.signers, signers...)
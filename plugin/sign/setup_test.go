@@ -37,7 +37,69 @@ func TestParse(t *testing.T) {
 				signedfile: "db.example.org.signed",
 			},
 		},
+		{`sign testdata/db.miek.nl example.org {
+			key directory testdata
+			zsk_lifetime 720h
+			algorithm ED25519
+		 }`,
+			false,
+			&Signer{
+				origin:     "example.org.",
+				dbfile:     "testdata/db.miek.nl",
+				directory:  "/var/lib/coredns",
+				signedfile: "db.example.org.signed",
+			},
+		},
+		{`sign testdata/db.miek.nl miek.nl {
+			key file testdata/Kmiek.nl.+013+59725
+			nsec3 10 - optout
+		 }`,
+			false,
+			&Signer{
+				origin:     "miek.nl.",
+				dbfile:     "testdata/db.miek.nl",
+				directory:  "/var/lib/coredns",
+				signedfile: "db.miek.nl.signed",
+				nsec3:      &nsec3{iterations: 10, optOut: true},
+			},
+		},
 		// errors
+		{`sign testdata/db.miek.nl miek.nl {
+			key file testdata/Kmiek.nl.+013+59725
+			key directory testdata
+		 }`,
+			true,
+			nil,
+		},
+		{`sign testdata/db.miek.nl example.org {
+			key directory testdata
+			propagation 24h
+			zsk_lifetime 36h
+		 }`,
+			true,
+			nil,
+		},
+		{`sign testdata/db.miek.nl example.org {
+			key directory testdata
+			algorithm DSA
+		 }`,
+			true,
+			nil,
+		},
+		{`sign testdata/db.miek.nl miek.nl {
+			key file testdata/Kmiek.nl.+013+59725
+			nsec3 200
+		 }`,
+			true,
+			nil,
+		},
+		{`sign testdata/db.miek.nl miek.nl {
+			key file testdata/Kmiek.nl.+013+59725
+			nsec3 0 XYZ
+		 }`,
+			true,
+			nil,
+		},
 		{`sign db.example.org {
 			key file /etc/coredns/keys/Kexample.org
 		 }`,
@@ -68,6 +130,9 @@ func TestParse(t *testing.T) {
 		if x := signer.directory; x != tc.exp.directory {
 			t.Errorf("Test %d expected %s as directory, got %s", i, tc.exp.directory, x)
 		}
+		if x := signer.nsec3; (x == nil) != (tc.exp.nsec3 == nil) || (x != nil && *x != *tc.exp.nsec3) {
+			t.Errorf("Test %d expected %v as nsec3, got %v", i, tc.exp.nsec3, x)
+		}
 		if x := signer.signedfile; x != tc.exp.signedfile {
 			t.Errorf("Test %d expected %s as signedfile, got %s", i, tc.exp.signedfile, x)
 		}
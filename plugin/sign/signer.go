@@ -10,6 +10,7 @@ import (
 	"github.com/coredns/coredns/plugin/file"
 	"github.com/coredns/coredns/plugin/file/tree"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/pkg/rollover"
 
 	"github.com/miekg/dns"
 )
@@ -19,6 +20,8 @@ var log = clog.NewWithPlugin("sign")
 // Signer holds the data needed to sign a zone file.
 type Signer struct {
 	keys        []Pair
+	manager     *rollover.Manager // set when the keys are managed, keys is then empty
+	nsec3       *nsec3            // set when the zone is signed with NSEC3 instead of NSEC
 	origin      string
 	dbfile      string
 	directory   string
@@ -46,6 +49,21 @@ func (s *Signer) Sign(now time.Time) (*file.Zone, error) {
 	inception, expiration := lifetime(now, s.jitterIncep, s.jitterExpir)
 	z.Apex.SOA.Serial = uint32(now.Unix())
 
+	// Without managed keys all keys sign everything.
+	ksk, zsk := s.keys, s.keys
+	if s.manager != nil {
+		if _, err := s.manager.Roll(now); err != nil {
+			return nil, err
+		}
+		ks := s.manager.Keys(now)
+		for _, pair := range managedPairs(ks.DNSKEY, s.origin, ttl) {
+			z.Insert(pair.Public)
+		}
+		for _, rr := range ks.CDS(s.origin, ttl) {
+			z.Insert(rr)
+		}
+		ksk, zsk = managedPairs(ks.KSK, s.origin, ttl), managedPairs(ks.ZSK, s.origin, ttl)
+	}
 	for _, pair := range s.keys {
 		pair.Public.Header().Ttl = ttl // set TTL on key so it matches the RRSIG.
 		z.Insert(pair.Public)
@@ -54,10 +72,16 @@ func (s *Signer) Sign(now time.Time) (*file.Zone, error) {
 		z.Insert(pair.Public.ToCDNSKEY())
 	}
 
+	var nsec3s []*dns.NSEC3
+	if s.nsec3 != nil {
+		z.Insert(s.nsec3.param(s.origin))
+		nsec3s = s.nsec3.chain(z, s.origin, mttl)
+	}
+
 	names := names(s.origin, z)
 	ln := len(names)
 
-	for _, pair := range s.keys {
+	for _, pair := range zsk {
 		rrsig, err := pair.signRRs([]dns.RR{z.Apex.SOA}, s.origin, ttl, inception, expiration)
 		if err != nil {
 			return nil, err
@@ -80,10 +104,13 @@ func (s *Signer) Sign(now time.Time) (*file.Zone, error) {
 			return nil
 		}
 
-		if e.Name() == s.origin {
+		switch {
+		case s.nsec3 != nil:
+			// NSEC3 records are added after the walk, they have their own owner names.
+		case e.Name() == s.origin:
 			nsec := NSEC(e.Name(), names[(ln+i)%ln], mttl, append(e.Types(), dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeNSEC))
 			z.Insert(nsec)
-		} else {
+		default:
 			nsec := NSEC(e.Name(), names[(ln+i)%ln], mttl, append(e.Types(), dns.TypeRRSIG, dns.TypeNSEC))
 			z.Insert(nsec)
 		}
@@ -94,7 +121,11 @@ func (s *Signer) Sign(now time.Time) (*file.Zone, error) {
 			if t == dns.TypeRRSIG || t == dns.TypeNS {
 				continue
 			}
-			for _, pair := range s.keys {
+			pairs := zsk
+			if t == dns.TypeDNSKEY {
+				pairs = ksk
+			}
+			for _, pair := range pairs {
 				rrsig, err := pair.signRRs(rrs, s.origin, rrs[0].Header().Ttl, inception, expiration)
 				if err != nil {
 					return err
@@ -105,7 +136,21 @@ func (s *Signer) Sign(now time.Time) (*file.Zone, error) {
 		i++
 		return nil
 	})
-	return z, err
+	if err != nil {
+		return z, err
+	}
+
+	for _, nsec3 := range nsec3s {
+		z.Insert(nsec3)
+		for _, pair := range zsk {
+			rrsig, err := pair.signRRs([]dns.RR{nsec3}, s.origin, mttl, inception, expiration)
+			if err != nil {
+				return z, err
+			}
+			z.Insert(rrsig)
+		}
+	}
+	return z, nil
 }
 
 // resign checks if the signed zone exists, or needs resigning.
@@ -117,6 +162,22 @@ func (s *Signer) resign() error {
 	}
 
 	now := time.Now().UTC()
+	if s.manager != nil {
+		// Resign when a key was published, activated, retired or removed after the zone was signed.
+		fi, err := rd.Stat()
+		if err != nil {
+			return err
+		}
+		if next := s.manager.Next(fi.ModTime()); !next.IsZero() && !now.Before(next) {
+			return fmt.Errorf("key rollover event at %s", next.Format(timeFmt))
+		}
+	}
+	if err := nsec3Changed(rd, s.origin, s.nsec3); err != nil {
+		return err
+	}
+	if _, err := rd.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
 	return resign(rd, now)
 }
 
@@ -174,7 +235,7 @@ func signAndLog(s *Signer, why error) {
 	z, err := s.Sign(now)
 	log.Infof("Signing %q because %s", s.origin, why)
 	if err != nil {
-		log.Warningf("Error signing %q with key tags %q in %s: %s, next: %s", s.origin, keyTag(s.keys), time.Since(now), err, now.Add(durationRefreshHours).Format(timeFmt))
+		log.Warningf("Error signing %q with key tags %q in %s: %s, next: %s", s.origin, s.keyTags(now), time.Since(now), err, now.Add(durationRefreshHours).Format(timeFmt))
 		return
 	}
 
@@ -182,18 +243,33 @@ func signAndLog(s *Signer, why error) {
 		log.Warningf("Error signing %q: failed to move zone file into place: %s", s.origin, err)
 		return
 	}
-	log.Infof("Successfully signed zone %q in %q with key tags %q and %d SOA serial, elapsed %f, next: %s", s.origin, filepath.Join(s.directory, s.signedfile), keyTag(s.keys), z.Apex.SOA.Serial, time.Since(now).Seconds(), now.Add(durationRefreshHours).Format(timeFmt))
+	log.Infof("Successfully signed zone %q in %q with key tags %q and %d SOA serial, elapsed %f, next: %s", s.origin, filepath.Join(s.directory, s.signedfile), s.keyTags(now), z.Apex.SOA.Serial, time.Since(now).Seconds(), now.Add(durationRefreshHours).Format(timeFmt))
 }
 
-// refresh checks every val if some zones need to be resigned.
+// keyTags returns the key tags of the keys in the zone at now as a formatted string.
+func (s *Signer) keyTags(now time.Time) string {
+	if s.manager == nil {
+		return keyTag(s.keys)
+	}
+	return keyTag(managedPairs(s.manager.Keys(now).DNSKEY, s.origin, 0))
+}
+
+// refresh checks every val if some zones need to be resigned. With managed keys it also checks when the
+// next key rollover event is due.
 func (s *Signer) refresh(val time.Duration) {
-	tick := time.NewTicker(val)
-	defer tick.Stop()
 	for {
+		wait := val
+		if s.manager != nil {
+			if next := s.manager.Next(time.Now().UTC()); !next.IsZero() && time.Until(next) < wait {
+				wait = time.Until(next)
+			}
+		}
+		timer := time.NewTimer(wait)
 		select {
 		case <-s.stop:
+			timer.Stop()
 			return
-		case <-tick.C:
+		case <-timer.C:
 			why := s.resign()
 			if why == nil {
 				continue
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/plugin/file/tree"
 
 	"github.com/miekg/dns"
 )
@@ -44,6 +45,121 @@ func TestSign(t *testing.T) {
 	}
 }
 
+func TestSignManaged(t *testing.T) {
+	dir := t.TempDir()
+	input := `sign testdata/db.miek.nl miek.nl {
+		key directory ` + dir + `
+		directory ` + dir + `
+	}`
+	c := caddy.NewTestController("dns", input)
+	sign, err := parse(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := sign.signers[0]
+	z, err := s.Sign(time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := s.manager.Keys(time.Now().UTC())
+	if len(ks.KSK) != 1 || len(ks.ZSK) != 1 {
+		t.Fatalf("Expected a KSK and a ZSK to be generated, got %d and %d", len(ks.KSK), len(ks.ZSK))
+	}
+
+	apex, _ := z.Search("miek.nl.")
+	if x := apex.Type(dns.TypeDNSKEY); len(x) != 2 {
+		t.Errorf("Expected %d DNSKEY records, got %d", 2, len(x))
+	}
+	if x := apex.Type(dns.TypeCDS); len(x) != 1 {
+		t.Errorf("Expected %d CDS record, got %d", 1, len(x))
+	}
+	if x := apex.Type(dns.TypeCDNSKEY); len(x) != 1 {
+		t.Errorf("Expected %d CDNSKEY record, got %d", 1, len(x))
+	}
+	for _, rr := range apex.Type(dns.TypeRRSIG) {
+		sig := rr.(*dns.RRSIG)
+		tag := ks.ZSK[0].Tag
+		if sig.TypeCovered == dns.TypeDNSKEY {
+			tag = ks.KSK[0].Tag
+		}
+		if sig.KeyTag != tag {
+			t.Errorf("Expected RRSIG for %s to have key tag %d, got %d", dns.TypeToString[sig.TypeCovered], tag, sig.KeyTag)
+		}
+	}
+}
+
+func TestSignNSEC3(t *testing.T) {
+	tests := []struct {
+		nsec3  string
+		names  []string // names that must have a matching NSEC3 record
+		optOut bool
+	}{
+		{"nsec3", []string{"miek.nl.", "a.miek.nl.", "www.miek.nl.", "bla.miek.nl.", "blaaat.miek.nl.", "ns3.blaaat.miek.nl."}, false},
+		{"nsec3 5 AABBCCDD optout", []string{"miek.nl.", "a.miek.nl.", "www.miek.nl.", "blaaat.miek.nl.", "ns3.blaaat.miek.nl."}, true},
+	}
+	for i, tc := range tests {
+		input := `sign testdata/db.miek.nl miek.nl {
+			key file testdata/Kmiek.nl.+013+59725
+			` + tc.nsec3 + `
+		}`
+		c := caddy.NewTestController("dns", input)
+		sign, err := parse(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		z, err := sign.signers[0].Sign(time.Now().UTC())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		apex, _ := z.Search("miek.nl.")
+		params := apex.Type(dns.TypeNSEC3PARAM)
+		if len(params) != 1 {
+			t.Fatalf("Test %d, expected %d NSEC3PARAM record, got %d", i, 1, len(params))
+		}
+		param := params[0].(*dns.NSEC3PARAM)
+
+		var nsec3s []*dns.NSEC3
+		z.Walk(func(e *tree.Elem, _ map[uint16][]dns.RR) error {
+			if len(e.Type(dns.TypeNSEC)) > 0 {
+				t.Errorf("Test %d, expected no NSEC records, got one for %s", i, e.Name())
+			}
+			for _, rr := range e.Type(dns.TypeNSEC3) {
+				nsec3s = append(nsec3s, rr.(*dns.NSEC3))
+				if len(e.Type(dns.TypeRRSIG)) != 1 {
+					t.Errorf("Test %d, expected NSEC3 record %s to be signed", i, e.Name())
+				}
+			}
+			return nil
+		})
+		if len(nsec3s) != len(tc.names) {
+			t.Fatalf("Test %d, expected %d NSEC3 records, got %d", i, len(tc.names), len(nsec3s))
+		}
+
+		for _, name := range tc.names {
+			found := false
+			for _, n := range nsec3s {
+				if n.Iterations != param.Iterations || n.Salt != param.Salt {
+					t.Errorf("Test %d, expected NSEC3 parameters to match NSEC3PARAM, got %s", i, n)
+				}
+				if (n.Flags == 1) != tc.optOut {
+					t.Errorf("Test %d, expected opt-out flag to be %t, got %s", i, tc.optOut, n)
+				}
+				if !n.Match(name) {
+					continue
+				}
+				found = true
+				if name == "blaaat.miek.nl." && len(n.TypeBitMap) != 0 {
+					t.Errorf("Test %d, expected empty type bitmap for empty non-terminal, got %v", i, n.TypeBitMap)
+				}
+			}
+			if !found {
+				t.Errorf("Test %d, expected NSEC3 record for %s", i, name)
+			}
+		}
+	}
+}
+
 func TestSignApexZone(t *testing.T) {
 	apex := `$TTL    30M
 $ORIGIN example.org.
@@ -0,0 +1,105 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/transfer"
+
+	// The database drivers that can be used.
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+var log = clog.NewWithPlugin("sql")
+
+func init() { plugin.Register("sql", setup) }
+
+func setup(c *caddy.Controller) error {
+	driver, dsn, zones, reload, fall, err := parse(c)
+	if err != nil {
+		return plugin.Error("sql", err)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return plugin.Error("sql", err)
+	}
+	s := New(db, driver, zones, reload)
+	s.Fall = fall
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := s.Run(ctx); err != nil {
+		cancel()
+		db.Close()
+		return plugin.Error("sql", c.Errf("failed to load zones: %v", err))
+	}
+
+	// get the transfer plugin, so we can send notifies when a zone changes.
+	c.OnStartup(func() error {
+		t := dnsserver.GetConfig(c).Handler("transfer")
+		if t == nil {
+			return nil
+		}
+		s.zMu.Lock()
+		s.transfer = t.(*transfer.Transfer) // if found this must be OK.
+		s.zMu.Unlock()
+		return nil
+	})
+	c.OnShutdown(func() error { cancel(); return db.Close() })
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		s.Next = next
+		return s
+	})
+	return nil
+}
+
+func parse(c *caddy.Controller) (driver, dsn string, zones []string, reload time.Duration, fall fall.F, err error) {
+	reload = 1 * time.Minute
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return driver, dsn, zones, reload, fall, plugin.ErrOnce
+		}
+		i++
+
+		args := c.RemainingArgs()
+		if len(args) < 2 {
+			return driver, dsn, zones, reload, fall, c.ArgErr()
+		}
+		driver, dsn = args[0], args[1]
+		if driver != "sqlite" && driver != "postgres" {
+			return driver, dsn, zones, reload, fall, c.Errf("unknown driver '%s'", driver)
+		}
+		zones = plugin.OriginsFromArgsOrServerBlock(args[2:], c.ServerBlockKeys)
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "reload":
+				if !c.NextArg() {
+					return driver, dsn, zones, reload, fall, c.ArgErr()
+				}
+				reload, err = time.ParseDuration(c.Val())
+				if err != nil {
+					return driver, dsn, zones, reload, fall, c.Errf("invalid duration for reload '%s'", c.Val())
+				}
+				if reload < 0 {
+					return driver, dsn, zones, reload, fall, c.Errf("invalid negative duration for reload '%s'", c.Val())
+				}
+			case "fallthrough":
+				fall.SetZonesFromArgs(c.RemainingArgs())
+			default:
+				return driver, dsn, zones, reload, fall, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+	return driver, dsn, zones, reload, fall, nil
+}
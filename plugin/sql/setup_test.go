@@ -0,0 +1,71 @@
+package sql
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/caddy"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input          string
+		shouldErr      bool
+		expectedDriver string
+		expectedDSN    string
+		expectedZones  []string
+		expectedReload time.Duration
+		expectedErr    string
+	}{
+		{`sql sqlite /var/lib/dns.db`, false, "sqlite", "/var/lib/dns.db", []string{"example.org."}, time.Minute, ""},
+		{`sql postgres "host=localhost dbname=dns" example.org example.net {
+			reload 10s
+			fallthrough
+		}`, false, "postgres", "host=localhost dbname=dns", []string{"example.org.", "example.net."}, 10 * time.Second, ""},
+		{`sql sqlite /var/lib/dns.db {
+			reload 0s
+		}`, false, "sqlite", "/var/lib/dns.db", []string{"example.org."}, 0, ""},
+		// negative
+		{`sql sqlite`, true, "", "", nil, 0, "Wrong argument count"},
+		{`sql mysql user@/dns`, true, "", "", nil, 0, "unknown driver"},
+		{`sql sqlite /var/lib/dns.db {
+			reload -1s
+		}`, true, "", "", nil, 0, "invalid negative duration"},
+		{`sql sqlite /var/lib/dns.db {
+			reload often
+		}`, true, "", "", nil, 0, "invalid duration"},
+		{`sql sqlite /var/lib/dns.db {
+			table records
+		}`, true, "", "", nil, 0, "unknown property"},
+		{`sql sqlite /var/lib/dns.db
+		sql sqlite /var/lib/other.db`, true, "", "", nil, 0, "this plugin"},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		c.ServerBlockKeys = []string{"example.org."}
+		driver, dsn, zones, reload, _, err := parse(c)
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: expected error but got none for input %s", i, tc.input)
+			} else if !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("Test %d: expected error to contain %q, got %q", i, tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %v", i, err)
+			continue
+		}
+		if driver != tc.expectedDriver || dsn != tc.expectedDSN {
+			t.Errorf("Test %d: expected driver %q and DSN %q, got %q and %q", i, tc.expectedDriver, tc.expectedDSN, driver, dsn)
+		}
+		if strings.Join(zones, " ") != strings.Join(tc.expectedZones, " ") {
+			t.Errorf("Test %d: expected zones %v, got %v", i, tc.expectedZones, zones)
+		}
+		if reload != tc.expectedReload {
+			t.Errorf("Test %d: expected reload %s, got %s", i, tc.expectedReload, reload)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package sql implements a plugin that serves zones from the records in a SQL database, in a schema like
+// the one of the generic SQL backends of PowerDNS.
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/pkg/upstream"
+	"github.com/coredns/coredns/plugin/transfer"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// SQL is a plugin that serves zones from a SQL database.
+type SQL struct {
+	Next plugin.Handler
+	Fall fall.F
+
+	db       *sql.DB
+	driver   string
+	reload   time.Duration
+	upstream *upstream.Upstream
+	transfer *transfer.Transfer
+
+	zoneNames []string
+	zMu       sync.RWMutex
+	zones     map[string]*file.Zone
+}
+
+// New returns a new *SQL serving zones from db, which is opened with driver.
+func New(db *sql.DB, driver string, zones []string, reload time.Duration) *SQL {
+	return &SQL{
+		db:        db,
+		driver:    driver,
+		reload:    reload,
+		upstream:  upstream.New(),
+		zoneNames: zones,
+		zones:     make(map[string]*file.Zone, len(zones)),
+	}
+}
+
+// Run loads the zones, and then checks for changes to them every reload in the background. Returns error if
+// the zones can't be loaded.
+func (s *SQL) Run(ctx context.Context) error {
+	if err := s.updateZones(ctx); err != nil {
+		return err
+	}
+	if s.reload == 0 {
+		return nil
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				log.Debugf("Breaking out of SQL update loop for %v: %v", s.zoneNames, ctx.Err())
+				return
+			case <-time.After(s.reload):
+				if err := s.updateZones(ctx); err != nil && ctx.Err() == nil /* Don't log error if ctx expired. */ {
+					log.Errorf("Failed to update zones %v: %v", s.zoneNames, err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (s *SQL) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+	qname := state.Name()
+
+	zone := plugin.Zones(s.zoneNames).Matches(qname)
+	if zone == "" {
+		return plugin.NextOrFailure(s.Name(), s.Next, ctx, w, r)
+	}
+	z := s.zone(zone)
+	if z == nil {
+		return dns.RcodeServerFailure, nil
+	}
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	var result file.Result
+	m.Answer, m.Ns, m.Extra, result = z.Lookup(ctx, state, qname)
+
+	if len(m.Answer) == 0 && result != file.NoData && s.Fall.Through(qname) {
+		return plugin.NextOrFailure(s.Name(), s.Next, ctx, w, r)
+	}
+
+	switch result {
+	case file.Success:
+	case file.NoData:
+	case file.NameError:
+		m.Rcode = dns.RcodeNameError
+	case file.Delegation:
+		m.Authoritative = false
+	case file.ServerFailure:
+		return dns.RcodeServerFailure, nil
+	}
+
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
+
+// Name implements the plugin.Handler interface.
+func (s *SQL) Name() string { return "sql" }
+
+func (s *SQL) zone(name string) *file.Zone {
+	s.zMu.RLock()
+	defer s.zMu.RUnlock()
+	return s.zones[name]
+}
+
+// updateZones reloads the zones of which the serial in the database differs from the serial of the loaded
+// zone. Returns error if any zone error'ed out, but updates the other zones.
+func (s *SQL) updateZones(ctx context.Context) error {
+	var errs []string
+	for _, name := range s.zoneNames {
+		if err := s.updateZone(ctx, name); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("errors updating zones: %v", errs)
+	}
+	return nil
+}
+
+func (s *SQL) updateZone(ctx context.Context, name string) error {
+	serial, err := s.serial(ctx, name)
+	if err != nil {
+		return err
+	}
+	old := s.zone(name)
+	if old != nil {
+		if apex, err := old.ApexIfDefined(); err == nil && apex[0].(*dns.SOA).Serial == serial {
+			return nil
+		}
+	}
+
+	z, err := s.load(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	s.zMu.Lock()
+	s.zones[name] = z
+	t := s.transfer
+	s.zMu.Unlock()
+
+	if old == nil {
+		log.Infof("Loaded zone %s with serial %d", name, serial)
+		return nil
+	}
+	log.Infof("Reloaded zone %s with serial %d", name, serial)
+	if err := t.Notify(name); err != nil {
+		log.Warningf("Failed sending notifies: %s", err)
+	}
+	return nil
+}
+
+var errNoSOA = errors.New("no SOA record")
+
+// serial returns the serial in the SOA record of zone name in the database.
+func (s *SQL) serial(ctx context.Context, name string) (uint32, error) {
+	var content string
+	err := s.db.QueryRowContext(ctx, s.query(serialQuery), domain(name)).Scan(&content)
+	if err == sql.ErrNoRows {
+		return 0, errNoSOA
+	}
+	if err != nil {
+		return 0, err
+	}
+	// The content of a SOA record is: primary hostmaster serial refresh retry expire minimum.
+	f := strings.Fields(content)
+	if len(f) < 3 {
+		return 0, fmt.Errorf("invalid SOA record %q", content)
+	}
+	serial, err := strconv.ParseUint(f[2], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid serial in SOA record %q", content)
+	}
+	return uint32(serial), nil
+}
+
+// load loads zone name from the database.
+func (s *SQL) load(ctx context.Context, name string) (*file.Zone, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(recordsQuery), domain(name))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	z := file.NewZone(name, "")
+	z.Upstream = s.upstream
+	for rows.Next() {
+		var (
+			owner, typ, content string
+			ttl                 uint32
+		)
+		if err := rows.Scan(&owner, &typ, &content, &ttl); err != nil {
+			return nil, err
+		}
+		// Names are stored without the final dot.
+		rr, err := dns.NewRR(fmt.Sprintf("%s. %d IN %s %s", owner, ttl, typ, content))
+		if err != nil {
+			// Maybe unsupported record type. Log and carry on.
+			log.Warningf("Failed to parse %s record of %s: %v", typ, owner, err)
+			continue
+		}
+		if !dns.IsSubDomain(name, rr.Header().Name) {
+			log.Warningf("Record %s is not in zone %s", rr.Header().Name, name)
+			continue
+		}
+		z.Insert(rr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := z.ApexIfDefined(); err != nil {
+		return nil, errNoSOA
+	}
+	return z, nil
+}
+
+const (
+	serialQuery = `SELECT r.content FROM records r JOIN domains d ON r.domain_id = d.id
+		WHERE d.name = ? AND r.name = d.name AND r.type = 'SOA' AND (r.disabled IS NULL OR NOT r.disabled)`
+	// Records without type are used by PowerDNS for DNSSEC ordering, these are skipped.
+	recordsQuery = `SELECT r.name, r.type, r.content, COALESCE(r.ttl, 3600) FROM records r JOIN domains d ON r.domain_id = d.id
+		WHERE d.name = ? AND r.type IS NOT NULL AND r.type <> '' AND (r.disabled IS NULL OR NOT r.disabled)`
+)
+
+// query returns q with the placeholders of the driver.
+func (s *SQL) query(q string) string {
+	if s.driver != "postgres" {
+		return q
+	}
+	n := 0
+	var b strings.Builder
+	for _, c := range q {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// domain returns the name of zone as stored in the database: in lower case without the final dot.
+func domain(zone string) string { return strings.TrimSuffix(strings.ToLower(zone), ".") }
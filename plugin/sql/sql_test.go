@@ -0,0 +1,234 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+const schema = `
+CREATE TABLE domains (
+	id   INTEGER PRIMARY KEY,
+	name VARCHAR(255) NOT NULL UNIQUE
+);
+CREATE TABLE records (
+	id        INTEGER PRIMARY KEY,
+	domain_id INTEGER REFERENCES domains(id),
+	name      VARCHAR(255),
+	type      VARCHAR(10),
+	content   VARCHAR(65535),
+	ttl       INTEGER,
+	prio      INTEGER,
+	disabled  BOOLEAN DEFAULT 0
+);
+INSERT INTO domains (id, name) VALUES (1, 'example.org');
+INSERT INTO records (domain_id, name, type, content, ttl, disabled) VALUES
+	(1, 'example.org', 'SOA', 'ns1.example.org hostmaster.example.org 2021100101 7200 3600 1209600 3600', 3600, 0),
+	(1, 'example.org', 'NS', 'ns1.example.org', 3600, 0),
+	(1, 'example.org', 'MX', '10 mail.example.org', 3600, 0),
+	(1, 'ns1.example.org', 'A', '127.0.0.1', 3600, 0),
+	(1, 'www.example.org', 'A', '127.0.0.2', 300, 0),
+	(1, 'www.example.org', 'A', '127.0.0.3', 300, 1),
+	(1, 'web.example.org', 'CNAME', 'www.example.org', 300, 0),
+	(1, 'example.org', 'TXT', '"v=spf1 -all"', NULL, 0),
+	(1, 'example.org', NULL, NULL, NULL, 0);
+`
+
+func newTestSQL(t *testing.T) (*SQL, *sql.DB) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "dns.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	s := New(db, "sqlite", []string{"example.org."}, time.Minute)
+	if err := s.updateZones(context.Background()); err != nil {
+		t.Fatalf("Failed to load zones: %v", err)
+	}
+	return s, db
+}
+
+var sqlTestCases = []test.Case{
+	{
+		Qname: "www.example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.A("www.example.org. 300 IN A 127.0.0.2"),
+		},
+		Ns: []dns.RR{
+			test.NS("example.org. 3600 IN NS ns1.example.org."),
+		},
+	},
+	{
+		Qname: "web.example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.CNAME("web.example.org. 300 IN CNAME www.example.org."),
+			test.A("www.example.org. 300 IN A 127.0.0.2"),
+		},
+		Ns: []dns.RR{
+			test.NS("example.org. 3600 IN NS ns1.example.org."),
+		},
+	},
+	{
+		Qname: "example.org.", Qtype: dns.TypeMX,
+		Answer: []dns.RR{
+			test.MX("example.org. 3600 IN MX 10 mail.example.org."),
+		},
+		Ns: []dns.RR{
+			test.NS("example.org. 3600 IN NS ns1.example.org."),
+		},
+	},
+	{
+		Qname: "example.org.", Qtype: dns.TypeTXT,
+		Answer: []dns.RR{
+			test.TXT(`example.org. 3600 IN TXT "v=spf1 -all"`),
+		},
+		Ns: []dns.RR{
+			test.NS("example.org. 3600 IN NS ns1.example.org."),
+		},
+	},
+	{
+		Qname: "www.example.org.", Qtype: dns.TypeAAAA,
+		Ns: []dns.RR{
+			test.SOA("example.org. 3600 IN SOA ns1.example.org. hostmaster.example.org. 2021100101 7200 3600 1209600 3600"),
+		},
+	},
+	{
+		Qname: "nope.example.org.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("example.org. 3600 IN SOA ns1.example.org. hostmaster.example.org. 2021100101 7200 3600 1209600 3600"),
+		},
+	},
+}
+
+func TestSQL(t *testing.T) {
+	s, _ := newTestSQL(t)
+	s.Next = test.NextHandler(dns.RcodeRefused, nil)
+
+	for _, tc := range sqlTestCases {
+		m := tc.Msg()
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := s.ServeDNS(context.Background(), rec, m); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+			continue
+		}
+		if err := test.SortAndCheck(rec.Msg, tc); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Not our zone.
+	m := new(dns.Msg)
+	m.SetQuestion("example.net.", dns.TypeA)
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	if code, _ := s.ServeDNS(context.Background(), rec, m); code != dns.RcodeRefused {
+		t.Errorf("Expected the query to be passed to the next plugin, got rcode %d", code)
+	}
+}
+
+func TestSQLReload(t *testing.T) {
+	s, db := newTestSQL(t)
+	ctx := context.Background()
+
+	// A change without a new serial isn't seen.
+	if _, err := db.Exec(`UPDATE records SET content = '127.0.0.4' WHERE name = 'www.example.org' AND content = '127.0.0.2'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.updateZones(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if rrs := lookup(t, s, "www.example.org.", dns.TypeA); len(rrs) != 1 || rrs[0].(*dns.A).A.String() != "127.0.0.2" {
+		t.Errorf("Expected the zone not to be reloaded, got %v", rrs)
+	}
+
+	if _, err := db.Exec(`UPDATE records SET content = 'ns1.example.org hostmaster.example.org 2021100102 7200 3600 1209600 3600' WHERE type = 'SOA'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.updateZones(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if rrs := lookup(t, s, "www.example.org.", dns.TypeA); len(rrs) != 1 || rrs[0].(*dns.A).A.String() != "127.0.0.4" {
+		t.Errorf("Expected the zone to be reloaded, got %v", rrs)
+	}
+
+	// The SOA record is removed, the zone that is loaded is kept.
+	if _, err := db.Exec(`DELETE FROM records WHERE type = 'SOA'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.updateZones(ctx); err == nil {
+		t.Errorf("Expected an error for a zone without SOA record")
+	}
+	if rrs := lookup(t, s, "www.example.org.", dns.TypeA); len(rrs) != 1 {
+		t.Errorf("Expected the zone to be kept, got %v", rrs)
+	}
+}
+
+func TestSQLTransfer(t *testing.T) {
+	s, _ := newTestSQL(t)
+
+	if _, err := s.Transfer("example.net.", 0); err == nil {
+		t.Errorf("Expected an error for a zone that isn't ours")
+	}
+
+	ch, err := s.Transfer("example.org.", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rrs []dns.RR
+	for r := range ch {
+		rrs = append(rrs, r...)
+	}
+	// SOA, NS, MX, TXT, 2 A and a CNAME record, with the SOA record at the end too.
+	if len(rrs) != 8 {
+		t.Errorf("Expected 8 records, got %d: %v", len(rrs), rrs)
+	}
+	if _, ok := rrs[0].(*dns.SOA); !ok {
+		t.Errorf("Expected the first record to be the SOA record, got %s", rrs[0])
+	}
+	if _, ok := rrs[len(rrs)-1].(*dns.SOA); !ok {
+		t.Errorf("Expected the last record to be the SOA record, got %s", rrs[len(rrs)-1])
+	}
+
+	// The serial is current, only the SOA record is sent.
+	ch, err = s.Transfer("example.org.", 2021100101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rrs = nil
+	for r := range ch {
+		rrs = append(rrs, r...)
+	}
+	if len(rrs) != 1 {
+		t.Errorf("Expected only the SOA record, got %v", rrs)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	s := &SQL{driver: "postgres"}
+	if q := s.query("SELECT a FROM b WHERE c = ? AND d = ?"); q != "SELECT a FROM b WHERE c = $1 AND d = $2" {
+		t.Errorf("Expected postgres placeholders, got %q", q)
+	}
+	s.driver = "sqlite"
+	if q := s.query("SELECT a FROM b WHERE c = ?"); q != "SELECT a FROM b WHERE c = ?" {
+		t.Errorf("Expected the query to be unchanged, got %q", q)
+	}
+}
+
+func lookup(t *testing.T, s *SQL, qname string, qtype uint16) []dns.RR {
+	m := new(dns.Msg)
+	m.SetQuestion(qname, qtype)
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	if _, err := s.ServeDNS(context.Background(), rec, m); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Msg.Answer
+}
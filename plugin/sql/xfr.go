@@ -0,0 +1,16 @@
+package sql
+
+import (
+	"github.com/coredns/coredns/plugin/transfer"
+
+	"github.com/miekg/dns"
+)
+
+// Transfer implements the transfer.Transferer interface.
+func (s *SQL) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
+	z := s.zone(zone)
+	if z == nil {
+		return nil, transfer.ErrNotAuthoritative
+	}
+	return z.Transfer(serial)
+}
@@ -56,6 +56,15 @@ func (t *ResponseWriter) TsigTimersOnly(bool) {}
 // Hijack implements dns.ResponseWriter interface.
 func (t *ResponseWriter) Hijack() {}
 
+// TsigResponseWriter is a ResponseWriter that reports Status as the outcome of the TSIG verification.
+type TsigResponseWriter struct {
+	*ResponseWriter
+	Status error
+}
+
+// TsigStatus implements dns.ResponseWriter interface.
+func (t *TsigResponseWriter) TsigStatus() error { return t.Status }
+
 // ResponseWriter6 returns fixed client and remote address in IPv6.  The remote
 // address is always fe80::42:ff:feca:4c65 and port 40212. The local address is always ::1 and port 53.
 type ResponseWriter6 struct {
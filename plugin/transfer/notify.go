@@ -29,6 +29,12 @@ func (t *Transfer) Notify(zone string) error {
 		if t == "*" {
 			continue
 		}
+		c, m := c, m
+		if k, ok := x.keys[t]; ok {
+			c = &dns.Client{TsigSecret: k.Secrets()}
+			m = m.Copy()
+			k.Sign(m)
+		}
 		if err := sendNotify(c, m, t); err != nil {
 			err1 = err
 		}
@@ -1,11 +1,16 @@
 package transfer
 
 import (
+	"strings"
+
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/parse"
 	"github.com/coredns/coredns/plugin/pkg/transport"
+	"github.com/coredns/coredns/plugin/pkg/tsig"
+
+	"github.com/miekg/dns"
 )
 
 func init() {
@@ -22,6 +27,14 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("transfer", err)
 	}
 
+	for _, x := range t.xfrs {
+		for _, k := range x.keys {
+			if err := k.Register(dnsserver.GetConfig(c)); err != nil {
+				return plugin.Error("transfer", err)
+			}
+		}
+	}
+
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
 		t.Next = next
 		return t
@@ -48,24 +61,39 @@ func parseTransfer(c *caddy.Controller) (*Transfer, error) {
 	for c.Next() {
 		x := &xfr{}
 		x.Zones = plugin.OriginsFromArgsOrServerBlock(c.RemainingArgs(), c.ServerBlockKeys)
+		keys := map[string]*tsig.Key{} // defined keys
+		names := map[string]string{}   // host -> key name
 		for c.NextBlock() {
 			switch c.Val() {
 			case "to":
 				args := c.RemainingArgs()
+				name := ""
+				if len(args) > 2 && args[len(args)-2] == "key" {
+					name = strings.ToLower(dns.Fqdn(args[len(args)-1]))
+					args = args[:len(args)-2]
+				}
 				if len(args) == 0 {
 					return nil, c.ArgErr()
 				}
 				for _, host := range args {
-					if host == "*" {
-						x.to = append(x.to, host)
-						continue
+					if host != "*" {
+						normalized, err := parse.HostPort(host, transport.Port)
+						if err != nil {
+							return nil, err
+						}
+						host = normalized
 					}
-					normalized, err := parse.HostPort(host, transport.Port)
-					if err != nil {
-						return nil, err
+					x.to = append(x.to, host)
+					if name != "" {
+						names[host] = name
 					}
-					x.to = append(x.to, normalized)
 				}
+			case "key":
+				k, err := tsig.Parse(c.RemainingArgs())
+				if err != nil {
+					return nil, c.Err(err.Error())
+				}
+				keys[k.Name] = k
 			default:
 				return nil, plugin.Error("transfer", c.Errf("unknown property %q", c.Val()))
 			}
@@ -73,6 +101,16 @@ func parseTransfer(c *caddy.Controller) (*Transfer, error) {
 		if len(x.to) == 0 {
 			return nil, plugin.Error("transfer", c.Err("'to' is required"))
 		}
+		for host, name := range names {
+			k, ok := keys[name]
+			if !ok {
+				return nil, plugin.Error("transfer", c.Errf("key %q is not defined", name))
+			}
+			if x.keys == nil {
+				x.keys = make(map[string]*tsig.Key)
+			}
+			x.keys[host] = k
+		}
 		t.xfrs = append(t.xfrs, x)
 	}
 	return t, nil
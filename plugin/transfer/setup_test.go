@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/plugin/pkg/tsig"
+
+	"github.com/miekg/dns"
 )
 
 func TestParse(t *testing.T) {
@@ -31,6 +34,20 @@ func TestParse(t *testing.T) {
 				}},
 			},
 		},
+		{`transfer example.net {
+			to 1.2.3.4 key xfr.example.net.
+			key xfr.example.net. hmac-sha256 c2VjcmV0
+		 }`,
+			nil,
+			false,
+			&Transfer{
+				xfrs: []*xfr{{
+					Zones: []string{"example.net."},
+					to:    []string{"1.2.3.4:53"},
+					keys:  map[string]*tsig.Key{"1.2.3.4:53": {Name: "xfr.example.net.", Algorithm: dns.HmacSHA256, Secret: "c2VjcmV0"}},
+				}},
+			},
+		},
 		// errors
 		{`transfer example.net example.org {
 		 }`,
@@ -45,6 +62,21 @@ func TestParse(t *testing.T) {
 			true,
 			nil,
 		},
+		{`transfer example.net {
+			to 1.2.3.4 key xfr.example.net.
+		 }`,
+			nil,
+			true,
+			nil,
+		},
+		{`transfer example.net {
+			to 1.2.3.4
+			key xfr.example.net. hmac-foo c2VjcmV0
+		 }`,
+			nil,
+			true,
+			nil,
+		},
 		{
 			`
          transfer example.com example.edu {
@@ -111,6 +143,15 @@ func TestParse(t *testing.T) {
 
 				}
 			}
+			// Check keys
+			if len(tc.exp.xfrs[j].keys) != len(x.keys) {
+				t.Fatalf("Test %d expected %d keys, got %d", i, len(tc.exp.xfrs[j].keys), len(x.keys))
+			}
+			for to, k := range x.keys {
+				if *tc.exp.xfrs[j].keys[to] != *k {
+					t.Errorf("Test %d expected key %v for %s, got %v", i, tc.exp.xfrs[j].keys[to], to, k)
+				}
+			}
 		}
 	}
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/coredns/coredns/plugin"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/pkg/tsig"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
@@ -24,6 +25,7 @@ type Transfer struct {
 type xfr struct {
 	Zones []string
 	to    []string
+	keys  map[string]*tsig.Key // TSIG key required for a host in to, and used to sign notifies to it
 }
 
 // Transferer may be implemented by plugins to enable zone transfers
@@ -41,8 +43,10 @@ type Transferer interface {
 	//
 	// If serial is not 0, it will be handled as an IXFR request. If the serial is equal to or greater (newer) than
 	// the current serial for the zone, send a single SOA record to the channel and then close it.
-	// If the serial is less (older) than the current serial for the zone, perform an AXFR fallback
-	// by proceeding as if an AXFR was requested (as above).
+	// If the serial is less (older) than the current serial for the zone, the changes since serial may be sent
+	// as specified in RFC 1995: the current SOA, for each change the old SOA, the deleted records, the new
+	// SOA and the added records, and the current SOA again. Or perform an AXFR fallback by proceeding as if
+	// an AXFR was requested (as above).
 	Transfer(zone string, serial uint32) (<-chan []dns.RR, error)
 }
 
@@ -67,10 +71,20 @@ func (t *Transfer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 		return plugin.NextOrFailure(t.Name(), t.Next, ctx, w, r)
 	}
 
-	if !x.allowed(state) {
+	signer, err := tsig.Status(w, r)
+	if err != nil {
+		log.Warningf("Refusing transfer of zone %q to %s: TSIG of key %q: %s", state.QName(), state.IP(), signer, err)
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeNotAuth)
+		w.WriteMsg(m)
+		return 0, nil
+	}
+
+	if !x.allowed(state, signer) {
 		// write msg here, so logging will pick it up
 		m := new(dns.Msg)
 		m.SetRcode(r, dns.RcodeRefused)
+		tsig.Reply(m, r)
 		w.WriteMsg(m)
 		return 0, nil
 	}
@@ -90,7 +104,6 @@ func (t *Transfer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 
 	// Get a receiving channel from the first Transferer plugin that returns one.
 	var pchan <-chan []dns.RR
-	var err error
 	for _, p := range t.Transferers {
 		pchan, err = p.Transfer(state.QName(), serial)
 		if err == ErrNotAuthoritative {
@@ -150,6 +163,7 @@ func (t *Transfer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 		m := new(dns.Msg)
 		m.SetReply(r)
 		m.Answer = []dns.RR{soa}
+		tsig.Reply(m, r)
 		w.WriteMsg(m)
 
 		log.Infof("Outgoing noop, incremental transfer for up to date zone %q to %s for %d SOA serial", state.QName(), state.IP(), soa.Serial)
@@ -180,8 +194,13 @@ func (t *Transfer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Ms
 	return 0, nil
 }
 
-func (x xfr) allowed(state request.Request) bool {
+// allowed returns true if the client in state may transfer the zone. Signer is the name of the TSIG key
+// the request was signed with, if any.
+func (x xfr) allowed(state request.Request, signer string) bool {
 	for _, h := range x.to {
+		if k, ok := x.keys[h]; ok && k.Name != signer {
+			continue
+		}
 		if h == "*" {
 			return true
 		}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/pkg/tsig"
 	"github.com/coredns/coredns/plugin/test"
 
 	"github.com/miekg/dns"
@@ -276,3 +277,53 @@ func TestTransferNotAllowed(t *testing.T) {
 		t.Errorf("Expected REFUSED response code, got %s", dns.RcodeToString[w.Msg.Rcode])
 	}
 }
+
+func TestTransferTsig(t *testing.T) {
+	nextPlugin := transfererPlugin{Zone: "example.org.", Serial: 12345}
+	key := &tsig.Key{Name: "xfr.example.org.", Algorithm: dns.HmacSHA256, Secret: "c2VjcmV0"}
+
+	transfer := Transfer{
+		Transferers: []Transferer{&nextPlugin},
+		xfrs: []*xfr{
+			{
+				Zones: []string{"example.org."},
+				to:    []string{"*"},
+				keys:  map[string]*tsig.Key{"*": key},
+			},
+		},
+		Next: &nextPlugin,
+	}
+
+	tests := []struct {
+		key    string
+		status error
+		rcode  int
+	}{
+		{"", nil, dns.RcodeRefused},
+		{"xfr.example.org.", nil, dns.RcodeSuccess},
+		{"other.example.org.", nil, dns.RcodeRefused},
+		{"xfr.example.org.", dns.ErrSig, dns.RcodeNotAuth},
+	}
+
+	for i, tc := range tests {
+		w := dnstest.NewMultiRecorder(&test.TsigResponseWriter{ResponseWriter: &test.ResponseWriter{TCP: true}, Status: tc.status})
+		m := &dns.Msg{}
+		m.SetAxfr("example.org.")
+		if tc.key != "" {
+			m.SetTsig(tc.key, dns.HmacSHA256, 300, 0)
+		}
+
+		if _, err := transfer.ServeDNS(context.TODO(), w, m); err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+		if len(w.Msgs) == 0 {
+			t.Fatalf("Test %d: got no reply", i)
+		}
+		if x := w.Msgs[0].Rcode; x != tc.rcode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.rcode], dns.RcodeToString[x])
+		}
+		if tc.rcode == dns.RcodeSuccess && w.Msgs[0].IsTsig() == nil {
+			t.Errorf("Test %d: expected a signed reply", i)
+		}
+	}
+}
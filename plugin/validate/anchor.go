@@ -0,0 +1,43 @@
+package validate
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// rootAnchors are the DS records of the root zone's key signing keys, KSK-2017 and KSK-2024, see
+// https://data.iana.org/root-anchors/root-anchors.xml.
+var rootAnchors = []string{
+	". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+	". IN DS 38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+}
+
+// readAnchors reads the DS and DNSKEY records in the zone file file, and returns them per owner name. Other
+// records are ignored.
+func readAnchors(file string) (map[string][]dns.RR, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	anchors := make(map[string][]dns.RR)
+	zp := dns.NewZoneParser(f, ".", file)
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		switch rr.(type) {
+		case *dns.DS, *dns.DNSKEY:
+			name := strings.ToLower(rr.Header().Name)
+			anchors[name] = append(anchors[name], rr)
+		}
+	}
+	if err := zp.Err(); err != nil {
+		return nil, err
+	}
+	if len(anchors) == 0 {
+		return nil, fmt.Errorf("no DS or DNSKEY records in %s", file)
+	}
+	return anchors, nil
+}
@@ -0,0 +1,344 @@
+package validate
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin/metrics"
+	"github.com/coredns/coredns/plugin/pkg/cache"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// The chain of trust is built top down, from the closest trust anchor to the zone that signed an RRset, see
+// RFC 4035, Section 5. The validated keys of each zone, and what is known about a delegation, are cached.
+
+const (
+	maxTTL   = time.Hour
+	bogusTTL = time.Minute // how long bogus results and failed lookups are cached
+)
+
+// zoneKeys is the validated DNSKEY RRset of a zone.
+type zoneKeys struct {
+	result
+	keys   []*dns.DNSKEY
+	expire time.Time
+}
+
+// cut is what is known about a name in a secure zone.
+type cut int
+
+const (
+	noCut       cut = iota // not a delegation
+	secureCut              // a delegation with DS records
+	insecureCut            // a delegation without DS records
+	nxName                 // the name doesn't exist
+)
+
+// zoneCut is the validated answer for the DS records of a name.
+type zoneCut struct {
+	result
+	cut    cut
+	ds     []dns.RR
+	expire time.Time
+}
+
+// keys returns the validated keys of zone.
+func (v *Validate) keys(ctx context.Context, state request.Request, zone string) zoneKeys {
+	zone = strings.ToLower(dns.Fqdn(zone))
+	key := cache.Hash([]byte("k" + zone))
+	now := time.Now()
+	if i, ok := v.cache.Get(key); ok {
+		if zk := i.(zoneKeys); now.Before(zk.expire) {
+			cacheHits.WithLabelValues(metrics.WithServer(ctx)).Inc()
+			return zk
+		}
+	}
+	cacheMisses.WithLabelValues(metrics.WithServer(ctx)).Inc()
+
+	var zk zoneKeys
+	switch a := v.anchor(zone); {
+	case v.negativeAnchor(zone), a == "":
+		zk = zoneKeys{result: result{status: insecure}, expire: now.Add(maxTTL)}
+	case a == zone:
+		zk = v.dnskeys(ctx, state, zone, v.anchors[zone])
+	default:
+		zc := v.delegation(ctx, state, zone)
+		switch {
+		case zc.status != secure:
+			zk = zoneKeys{result: zc.result, expire: zc.expire}
+		case zc.cut != secureCut:
+			zk = zoneKeys{result: bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "no DS records for signer %s", zone), expire: now.Add(bogusTTL)}
+		default:
+			zk = v.dnskeys(ctx, state, zone, zc.ds)
+		}
+	}
+	v.cache.Add(key, zk)
+	return zk
+}
+
+// dnskeys looks up the DNSKEY RRset of zone, and validates it with the trust anchors, DS or DNSKEY records,
+// in anchors.
+func (v *Validate) dnskeys(ctx context.Context, state request.Request, zone string, anchors []dns.RR) zoneKeys {
+	now := time.Now()
+	fail := func(r result) zoneKeys { return zoneKeys{result: r, expire: now.Add(bogusTTL)} }
+
+	supported := 0
+	for _, a := range anchors {
+		if supportedAnchor(a) {
+			supported++
+		}
+	}
+	if supported == 0 {
+		// A zone with only unsupported algorithms or digest types is insecure, see RFC 4035, Section 5.2.
+		return zoneKeys{result: result{status: insecure}, expire: now.Add(maxTTL)}
+	}
+
+	resp, err := v.lookup(ctx, state, zone, dns.TypeDNSKEY)
+	if err != nil {
+		return fail(bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "lookup of DNSKEY for %s failed: %s", zone, err))
+	}
+	set := rrset(resp.Answer, zone, dns.TypeDNSKEY)
+	if len(set) == 0 {
+		return fail(bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "no DNSKEY records for %s", zone))
+	}
+
+	var trusted []*dns.DNSKEY
+	for _, rr := range set {
+		k := rr.(*dns.DNSKEY)
+		for _, a := range anchors {
+			if supportedAnchor(a) && matches(k, a) {
+				trusted = append(trusted, k)
+				break
+			}
+		}
+	}
+	if len(trusted) == 0 {
+		return fail(bogusf(dns.ExtendedErrorCodeDNSKEYMissing, "no DNSKEY for %s matches the trust anchor", zone))
+	}
+	for _, k := range trusted {
+		if k.Flags&dns.ZONE == 0 {
+			return fail(bogusf(dns.ExtendedErrorCodeNoZoneKeyBitSet, "DNSKEY %d for %s doesn't have the zone key bit set", k.KeyTag(), zone))
+		}
+	}
+
+	res := bogusf(dns.ExtendedErrorCodeRRSIGsMissing, "no signatures for DNSKEY %s", zone)
+	for _, sig := range signatures(resp.Answer) {
+		if sig.TypeCovered != dns.TypeDNSKEY || !strings.EqualFold(sig.SignerName, zone) {
+			continue
+		}
+		if res = verify(sig, trusted, set, now.UTC()); res.status == secure {
+			break
+		}
+	}
+	if res.status != secure {
+		return fail(res)
+	}
+
+	zk := zoneKeys{result: res, expire: now.Add(ttl(set))}
+	for _, rr := range set {
+		k := rr.(*dns.DNSKEY)
+		if k.Flags&dns.ZONE != 0 && k.Flags&dns.REVOKE == 0 {
+			zk.keys = append(zk.keys, k)
+		}
+	}
+	return zk
+}
+
+// delegation returns what is known about name, that must be below a trust anchor. It looks up the DS records
+// for name and validates them, or the proof they don't exist.
+func (v *Validate) delegation(ctx context.Context, state request.Request, name string) zoneCut {
+	name = strings.ToLower(name)
+	key := cache.Hash([]byte("d" + name))
+	now := time.Now()
+	if i, ok := v.cache.Get(key); ok {
+		if zc := i.(zoneCut); now.Before(zc.expire) {
+			cacheHits.WithLabelValues(metrics.WithServer(ctx)).Inc()
+			return zc
+		}
+	}
+	cacheMisses.WithLabelValues(metrics.WithServer(ctx)).Inc()
+
+	zc := v.ds(ctx, state, name)
+	if zc.expire.IsZero() {
+		zc.expire = now.Add(bogusTTL)
+	}
+	v.cache.Add(key, zc)
+	return zc
+}
+
+func (v *Validate) ds(ctx context.Context, state request.Request, name string) zoneCut {
+	now := time.Now()
+	resp, err := v.lookup(ctx, state, name, dns.TypeDS)
+	if err != nil {
+		return zoneCut{result: bogusf(dns.ExtendedErrorCodeNetworkError, "lookup of DS for %s failed: %s", name, err)}
+	}
+
+	// The DS records, and the proof they don't exist, are signed by a zone above name.
+	parent := parentOf(name)
+	section := resp.Ns
+	set := rrset(resp.Answer, name, dns.TypeDS)
+	if len(set) > 0 {
+		section = resp.Answer
+	}
+	var sigs []*dns.RRSIG
+	for _, s := range signatures(section) {
+		if s.SignerName != "" && !strings.EqualFold(s.SignerName, name) && dns.IsSubDomain(s.SignerName, name) {
+			sigs = append(sigs, s)
+		}
+	}
+	if len(sigs) == 0 {
+		r := v.unsigned(ctx, state, parent)
+		if r.status == insecure {
+			return zoneCut{result: r, cut: insecureCut, expire: now.Add(maxTTL)}
+		}
+		return zoneCut{result: r}
+	}
+
+	if len(set) > 0 {
+		if !signed(set, sigs) {
+			return zoneCut{result: bogusf(dns.ExtendedErrorCodeRRSIGsMissing, "no signatures for %s DS", name)}
+		}
+		r, _ := v.verifyRRset(ctx, state, set, sigs, now.UTC())
+		if r.status != secure {
+			return zoneCut{result: r}
+		}
+		return zoneCut{result: r, cut: secureCut, ds: set, expire: now.Add(ttl(set))}
+	}
+
+	var denial []dns.RR
+	res := result{status: secure}
+	for _, set := range rrsets(resp.Ns) {
+		switch set[0].Header().Rrtype {
+		case dns.TypeSOA, dns.TypeNSEC, dns.TypeNSEC3:
+		default:
+			continue
+		}
+		if !signed(set, sigs) {
+			return zoneCut{result: bogusf(dns.ExtendedErrorCodeRRSIGsMissing, "no signatures for %s %s", set[0].Header().Name, dns.TypeToString[set[0].Header().Rrtype])}
+		}
+		r, _ := v.verifyRRset(ctx, state, set, sigs, now.UTC())
+		if r.status == bogus {
+			return zoneCut{result: r}
+		}
+		res = res.and(r)
+		denial = append(denial, set...)
+	}
+	if res.status != secure {
+		return zoneCut{result: res, cut: insecureCut, expire: now.Add(maxTTL)}
+	}
+
+	c, ok := cutFromDenial(name, denial)
+	if !ok {
+		return zoneCut{result: bogusf(dns.ExtendedErrorCodeNSECMissing, "no proof of non-existence for %s DS", name)}
+	}
+	if c == secureCut {
+		return zoneCut{result: bogusf(dns.ExtendedErrorCodeDNSBogus, "denial of existence for %s DS shows DS records", name)}
+	}
+	return zoneCut{result: res, cut: c, expire: now.Add(ttl(denial))}
+}
+
+// unsigned returns insecure if the unsigned data with owner name is in an insecure zone. This is the case if
+// there is a delegation without DS records between the closest trust anchor and name, see RFC 4035, Section
+// 5.2. Otherwise the data should have been signed, and bogus is returned.
+func (v *Validate) unsigned(ctx context.Context, state request.Request, name string) result {
+	name = strings.ToLower(dns.Fqdn(name))
+	if v.negativeAnchor(name) {
+		return result{status: insecure}
+	}
+	a := v.anchor(name)
+	if a == "" {
+		return result{status: insecure}
+	}
+
+	labels := dns.CountLabel(a)
+	total := dns.CountLabel(name)
+Walk:
+	for l := labels + 1; l <= total; l++ {
+		i, _ := dns.PrevLabel(name, l)
+		zc := v.delegation(ctx, state, name[i:])
+		if zc.status != secure {
+			return zc.result
+		}
+		switch zc.cut {
+		case insecureCut:
+			return result{status: insecure}
+		case nxName:
+			break Walk
+		}
+	}
+	return bogusf(dns.ExtendedErrorCodeRRSIGsMissing, "no signatures for %s in a secure zone", name)
+}
+
+// signed returns true if there is a signature in sigs for rrset.
+func signed(rrset []dns.RR, sigs []*dns.RRSIG) bool {
+	hdr := rrset[0].Header()
+	for _, s := range sigs {
+		if s.TypeCovered == hdr.Rrtype && strings.EqualFold(s.Hdr.Name, hdr.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// supportedAnchor returns true if the algorithm, and for DS records the digest type, of the trust anchor a
+// are supported.
+func supportedAnchor(a dns.RR) bool {
+	switch a := a.(type) {
+	case *dns.DS:
+		switch a.DigestType {
+		case dns.SHA1, dns.SHA256, dns.SHA384:
+		default:
+			return false
+		}
+		return supportedAlgorithm(a.Algorithm)
+	case *dns.DNSKEY:
+		return supportedAlgorithm(a.Algorithm)
+	}
+	return false
+}
+
+func supportedAlgorithm(alg uint8) bool {
+	switch alg {
+	case dns.RSASHA1, dns.RSASHA1NSEC3SHA1, dns.RSASHA256, dns.RSASHA512, dns.ECDSAP256SHA256, dns.ECDSAP384SHA384, dns.ED25519:
+		return true
+	}
+	return false
+}
+
+// matches returns true if k matches the trust anchor a.
+func matches(k *dns.DNSKEY, a dns.RR) bool {
+	switch a := a.(type) {
+	case *dns.DS:
+		if k.Algorithm != a.Algorithm || k.KeyTag() != a.KeyTag {
+			return false
+		}
+		ds := k.ToDS(a.DigestType)
+		return ds != nil && strings.EqualFold(ds.Digest, a.Digest)
+	case *dns.DNSKEY:
+		return k.Algorithm == a.Algorithm && k.Protocol == a.Protocol && k.PublicKey == a.PublicKey
+	}
+	return false
+}
+
+// ttl returns the lowest TTL of rrs, capped at maxTTL.
+func ttl(rrs []dns.RR) time.Duration {
+	t := maxTTL
+	for _, rr := range rrs {
+		if d := time.Duration(rr.Header().Ttl) * time.Second; d < t {
+			t = d
+		}
+	}
+	return t
+}
+
+// parentOf returns the parent of name, or the root for the root.
+func parentOf(name string) string {
+	i, end := dns.NextLabel(name, 0)
+	if end {
+		return "."
+	}
+	return name[i:]
+}
@@ -0,0 +1,276 @@
+package validate
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// denies returns true if the NSEC or NSEC3 records in denial prove that name doesn't exist when nx is true,
+// or that it doesn't have records of type qtype, see RFC 4035, Section 5.4 and RFC 5155, Section 8.
+func denies(name string, qtype uint16, nx bool, denial []dns.RR) bool {
+	var nsec []*dns.NSEC
+	var nsec3 []*dns.NSEC3
+	for _, rr := range denial {
+		switch x := rr.(type) {
+		case *dns.NSEC:
+			nsec = append(nsec, x)
+		case *dns.NSEC3:
+			nsec3 = append(nsec3, x)
+		}
+	}
+	if len(nsec) > 0 {
+		return nsecDenies(name, qtype, nx, nsec)
+	}
+	if len(nsec3) > 0 {
+		return nsec3Denies(name, qtype, nx, nsec3)
+	}
+	return false
+}
+
+func nsecDenies(name string, qtype uint16, nx bool, nsec []*dns.NSEC) bool {
+	if !nx {
+		for _, n := range nsec {
+			if equal(n.Hdr.Name, name) {
+				return !hasType(n.TypeBitMap, qtype) && !hasType(n.TypeBitMap, dns.TypeCNAME)
+			}
+		}
+	}
+
+	cover := nsecCovering(nsec, name)
+	if cover == nil {
+		return false
+	}
+	// An empty non-terminal is covered by the record before it, and the next name is below it.
+	if !nx && dns.IsSubDomain(name, cover.NextDomain) && !equal(name, cover.NextDomain) {
+		return true
+	}
+
+	// The name doesn't exist; there must be no wildcard at the closest encloser, or, for no data, the
+	// wildcard must not have the type.
+	ce := closestEncloser(name, cover)
+	wildcard := "*." + ce
+	if ce == "." {
+		wildcard = "*."
+	}
+	if nx {
+		return nsecCovering(nsec, wildcard) != nil
+	}
+	for _, n := range nsec {
+		if equal(n.Hdr.Name, wildcard) {
+			return !hasType(n.TypeBitMap, qtype) && !hasType(n.TypeBitMap, dns.TypeCNAME)
+		}
+	}
+	return false
+}
+
+func nsec3Denies(name string, qtype uint16, nx bool, nsec3 []*dns.NSEC3) bool {
+	if !nx {
+		if n := nsec3Matching(nsec3, name); n != nil {
+			return !hasType(n.TypeBitMap, qtype) && !hasType(n.TypeBitMap, dns.TypeCNAME)
+		}
+	}
+
+	ce, nc := nsec3ClosestEncloser(nsec3, name)
+	if nc == "" {
+		return false
+	}
+	cover := nsec3Covering(nsec3, nc)
+	if cover == nil {
+		return false
+	}
+	// An opt-out span may hold an unsigned delegation, see RFC 5155, Section 8.6.
+	if !nx && qtype == dns.TypeDS && cover.Flags&1 == 1 {
+		return true
+	}
+
+	wildcard := "*." + ce
+	if ce == "." {
+		wildcard = "*."
+	}
+	if nx {
+		return nsec3Covering(nsec3, wildcard) != nil
+	}
+	if n := nsec3Matching(nsec3, wildcard); n != nil {
+		return !hasType(n.TypeBitMap, qtype) && !hasType(n.TypeBitMap, dns.TypeCNAME)
+	}
+	return false
+}
+
+// expanded returns true if the NSEC or NSEC3 records in denial prove that there is no closer match for name,
+// which was expanded from a wildcard with labels labels, see RFC 4035, Section 5.3.4 and RFC 5155, Section
+// 8.8.
+func expanded(name string, labels int, denial []dns.RR) bool {
+	i, _ := dns.PrevLabel(name, labels+1)
+	nc := name[i:]
+	for _, rr := range denial {
+		switch x := rr.(type) {
+		case *dns.NSEC:
+			if nsecCovers(x, name) {
+				return true
+			}
+		case *dns.NSEC3:
+			if x.Cover(nc) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cutFromDenial returns what the NSEC or NSEC3 records in denial, from the answer for the DS records of name,
+// prove about name. It returns false if there is no proof.
+func cutFromDenial(name string, denial []dns.RR) (cut, bool) {
+	var nsec []*dns.NSEC
+	var nsec3 []*dns.NSEC3
+	for _, rr := range denial {
+		switch x := rr.(type) {
+		case *dns.NSEC:
+			nsec = append(nsec, x)
+		case *dns.NSEC3:
+			nsec3 = append(nsec3, x)
+		}
+	}
+
+	for _, n := range nsec {
+		if equal(n.Hdr.Name, name) {
+			return cutFromBitmap(n.TypeBitMap)
+		}
+	}
+	if cover := nsecCovering(nsec, name); cover != nil {
+		if dns.IsSubDomain(name, cover.NextDomain) {
+			return noCut, true // empty non-terminal
+		}
+		return nxName, true
+	}
+
+	if n := nsec3Matching(nsec3, name); n != nil {
+		return cutFromBitmap(n.TypeBitMap)
+	}
+	if _, nc := nsec3ClosestEncloser(nsec3, name); nc != "" {
+		if cover := nsec3Covering(nsec3, nc); cover != nil {
+			if cover.Flags&1 == 1 {
+				return insecureCut, true // opt-out, there may be an unsigned delegation
+			}
+			return nxName, true
+		}
+	}
+	return noCut, false
+}
+
+// cutFromBitmap returns the cut for a name with the types in bitmap, from a zone that doesn't have DS
+// records for it. A bitmap with the SOA type is from the zone below the cut, which is no proof.
+func cutFromBitmap(bitmap []uint16) (cut, bool) {
+	switch {
+	case hasType(bitmap, dns.TypeDS):
+		return secureCut, true
+	case hasType(bitmap, dns.TypeSOA):
+		return noCut, false
+	case hasType(bitmap, dns.TypeNS):
+		return insecureCut, true
+	}
+	return noCut, true
+}
+
+func nsecCovering(nsec []*dns.NSEC, name string) *dns.NSEC {
+	for _, n := range nsec {
+		if nsecCovers(n, name) {
+			return n
+		}
+	}
+	return nil
+}
+
+// nsecCovers returns true if name sorts between the owner name and the next name of n.
+func nsecCovers(n *dns.NSEC, name string) bool {
+	owner, next := n.Hdr.Name, n.NextDomain
+	if compare(owner, next) < 0 {
+		return compare(owner, name) < 0 && compare(name, next) < 0
+	}
+	// The last record in the zone wraps around to the apex.
+	return compare(owner, name) < 0 || compare(name, next) < 0
+}
+
+// closestEncloser returns the closest encloser of name, given the NSEC record that covers it.
+func closestEncloser(name string, cover *dns.NSEC) string {
+	a := dns.CompareDomainName(name, cover.Hdr.Name)
+	b := dns.CompareDomainName(name, cover.NextDomain)
+	if b > a {
+		a = b
+	}
+	if a == 0 {
+		return "."
+	}
+	i, _ := dns.PrevLabel(name, a)
+	return name[i:]
+}
+
+func nsec3Matching(nsec3 []*dns.NSEC3, name string) *dns.NSEC3 {
+	for _, n := range nsec3 {
+		if n.Match(name) {
+			return n
+		}
+	}
+	return nil
+}
+
+func nsec3Covering(nsec3 []*dns.NSEC3, name string) *dns.NSEC3 {
+	for _, n := range nsec3 {
+		if n.Cover(name) {
+			return n
+		}
+	}
+	return nil
+}
+
+// nsec3ClosestEncloser returns the closest provable encloser of name and the next closer name, see RFC 5155,
+// Section 8.3.
+func nsec3ClosestEncloser(nsec3 []*dns.NSEC3, name string) (ce, nc string) {
+	for n := name; ; {
+		if nsec3Matching(nsec3, n) != nil {
+			return n, nc
+		}
+		i, end := dns.NextLabel(n, 0)
+		if end {
+			return "", ""
+		}
+		nc, n = n, n[i:]
+	}
+}
+
+func hasType(bitmap []uint16, t uint16) bool {
+	for _, b := range bitmap {
+		if b == t {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a, b string) bool { return strings.EqualFold(a, b) }
+
+// compare compares a and b in canonical order, see RFC 4034, Section 6.1.
+func compare(a, b string) int {
+	la, lb := labels(a), labels(b)
+	for i, j := len(la)-1, len(lb)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if c := bytes.Compare(la[i], lb[j]); c != 0 {
+			return c
+		}
+	}
+	return len(la) - len(lb)
+}
+
+// labels returns the labels of name in wire format and lower case.
+func labels(name string) [][]byte {
+	buf := make([]byte, 256)
+	n, err := dns.PackDomainName(dns.Fqdn(name), buf, 0, nil, false)
+	if err != nil {
+		return nil
+	}
+	var ls [][]byte
+	for i := 0; i < n && buf[i] != 0; i += int(buf[i]) + 1 {
+		ls = append(ls, bytes.ToLower(buf[i+1:i+1+int(buf[i])]))
+	}
+	return ls
+}
@@ -0,0 +1,32 @@
+package validate
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// results is the count of validated answers per result.
+	results = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "validate",
+		Name:      "results_total",
+		Help:      "The count of validated answers per result: secure, insecure or bogus.",
+	}, []string{"server", "result"})
+	// cacheHits is the count of cache hits.
+	cacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "validate",
+		Name:      "cache_hits_total",
+		Help:      "The count of cache hits.",
+	}, []string{"server"})
+	// cacheMisses is the count of cache misses.
+	cacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "validate",
+		Name:      "cache_misses_total",
+		Help:      "The count of cache misses.",
+	}, []string{"server"})
+)
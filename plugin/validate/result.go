@@ -0,0 +1,150 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// status is the security status of an answer, see RFC 4035, Section 4.3.
+type status int
+
+const (
+	insecure status = iota // no chain of trust
+	secure                 // chain of trust from a trust anchor
+	bogus                  // the chain of trust is broken
+)
+
+func (s status) String() string {
+	switch s {
+	case secure:
+		return "secure"
+	case bogus:
+		return "bogus"
+	}
+	return "insecure"
+}
+
+// result is the outcome of a validation.
+type result struct {
+	status status
+	ede    uint16 // extended DNS error code (RFC 8914) when bogus
+	reason string
+}
+
+func bogusf(ede uint16, format string, a ...interface{}) result {
+	return result{status: bogus, ede: ede, reason: fmt.Sprintf(format, a...)}
+}
+
+// and combines r and o: bogus if one of them is, otherwise insecure if one of them is.
+func (r result) and(o result) result {
+	if r.status == bogus || o.status == secure {
+		return r
+	}
+	if o.status == bogus || r.status == secure {
+		return o
+	}
+	return r
+}
+
+// rrsets returns the RRsets in rrs, without the signatures.
+func rrsets(rrs []dns.RR) [][]dns.RR {
+	var sets [][]dns.RR
+Next:
+	for _, rr := range rrs {
+		hdr := rr.Header()
+		if hdr.Rrtype == dns.TypeRRSIG || hdr.Rrtype == dns.TypeOPT {
+			continue
+		}
+		for i, set := range sets {
+			h := set[0].Header()
+			if h.Rrtype == hdr.Rrtype && h.Class == hdr.Class && strings.EqualFold(h.Name, hdr.Name) {
+				sets[i] = append(set, rr)
+				continue Next
+			}
+		}
+		sets = append(sets, []dns.RR{rr})
+	}
+	return sets
+}
+
+// signatures returns the RRSIG records in rrs.
+func signatures(rrs []dns.RR) []*dns.RRSIG {
+	var sigs []*dns.RRSIG
+	for _, rr := range rrs {
+		if s, ok := rr.(*dns.RRSIG); ok {
+			sigs = append(sigs, s)
+		}
+	}
+	return sigs
+}
+
+// rrset returns the records with name and qtype from rrs.
+func rrset(rrs []dns.RR, name string, qtype uint16) []dns.RR {
+	var set []dns.RR
+	for _, rr := range rrs {
+		if rr.Header().Rrtype == qtype && strings.EqualFold(rr.Header().Name, name) {
+			set = append(set, rr)
+		}
+	}
+	return set
+}
+
+// target follows the CNAMEs in the answer of resp, starting at qname. It returns the name the answer ends
+// at, and true when there is no record of type qtype for that name.
+func target(qname string, qtype uint16, resp *dns.Msg) (string, bool) {
+	name := qname
+	for i := 0; i < 8 && qtype != dns.TypeCNAME; i++ {
+		cname := rrset(resp.Answer, name, dns.TypeCNAME)
+		if len(cname) == 0 {
+			break
+		}
+		name = cname[0].(*dns.CNAME).Target
+	}
+	return name, len(rrset(resp.Answer, name, qtype)) == 0
+}
+
+// wildcard returns true if sig shows the signed RRset with owner name is expanded from a wildcard, see RFC
+// 4035, Section 5.3.4.
+func wildcard(sig *dns.RRSIG, name string) bool {
+	labels := dns.CountLabel(name)
+	if strings.HasPrefix(name, "*.") {
+		labels--
+	}
+	return int(sig.Labels) < labels
+}
+
+// filter removes the DNSSEC records from rrs, except those of type qtype. This is done for clients that
+// didn't set the DO bit.
+func filter(rrs []dns.RR, qtype uint16) []dns.RR {
+	filtered := rrs[:0]
+	for _, rr := range rrs {
+		switch t := rr.Header().Rrtype; t {
+		case dns.TypeRRSIG, dns.TypeNSEC, dns.TypeNSEC3:
+			if t != qtype {
+				continue
+			}
+		}
+		filtered = append(filtered, rr)
+	}
+	return filtered
+}
+
+// setOPT makes the OPT record of resp match the one of the request r: it's removed if r doesn't have one,
+// and the DO bit is copied.
+func setOPT(resp, r *dns.Msg) {
+	ro := r.IsEdns0()
+	for i, rr := range resp.Extra {
+		o, ok := rr.(*dns.OPT)
+		if !ok {
+			continue
+		}
+		if ro == nil {
+			resp.Extra = append(resp.Extra[:i], resp.Extra[i+1:]...)
+			return
+		}
+		o.SetDo(ro.Do())
+		return
+	}
+}
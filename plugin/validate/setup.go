@@ -0,0 +1,106 @@
+package validate
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	"github.com/miekg/dns"
+)
+
+var log = clog.NewWithPlugin("validate")
+
+func init() { plugin.Register("validate", setup) }
+
+func setup(c *caddy.Controller) error {
+	v, err := validateParse(c)
+	if err != nil {
+		return plugin.Error("validate", err)
+	}
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		v.Next = next
+		return v
+	})
+
+	return nil
+}
+
+func validateParse(c *caddy.Controller) (*Validate, error) {
+	capacity := defaultCap
+	var (
+		zones    []string
+		files    []string
+		negative []string
+	)
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, plugin.ErrOnce
+		}
+		i++
+
+		zones = plugin.OriginsFromArgsOrServerBlock(c.RemainingArgs(), c.ServerBlockKeys)
+
+		for c.NextBlock() {
+			switch x := c.Val(); x {
+			case "trust_anchor":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				files = append(files, args...)
+			case "negative_trust_anchor":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				for _, a := range args {
+					if _, ok := dns.IsDomainName(a); !ok {
+						return nil, c.Errf("invalid domain name: %s", a)
+					}
+					negative = append(negative, plugin.Name(a).Normalize())
+				}
+			case "cache_capacity":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				cacheCap, err := strconv.Atoi(c.Val())
+				if err != nil || cacheCap <= 0 {
+					return nil, c.Errf("cache_capacity must be a positive number: %s", c.Val())
+				}
+				capacity = cacheCap
+			default:
+				return nil, c.Errf("unknown property '%s'", x)
+			}
+		}
+	}
+
+	v := New(zones, capacity)
+	v.negative = negative
+	if len(files) > 0 {
+		// Configured trust anchors replace the root's.
+		v.anchors = make(map[string][]dns.RR)
+		config := dnsserver.GetConfig(c)
+		for _, f := range files {
+			if !filepath.IsAbs(f) && config.Root != "" {
+				f = filepath.Join(config.Root, f)
+			}
+			anchors, err := readAnchors(f)
+			if err != nil {
+				return nil, err
+			}
+			for name, rrs := range anchors {
+				v.anchors[name] = append(v.anchors[name], rrs...)
+			}
+		}
+	}
+	return v, nil
+}
+
+const defaultCap = 10000
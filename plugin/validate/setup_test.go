@@ -0,0 +1,105 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coredns/caddy"
+)
+
+func TestSetupValidate(t *testing.T) {
+	dir := t.TempDir()
+	anchor := filepath.Join(dir, "anchor")
+	if err := os.WriteFile(anchor, []byte(`example.org. IN DS 19036 8 2 49AAC11D7B6F6446702E54A1607371607A1A41855200FD2CE1CDDE32F24E8FB5
+example.org. IN NS ns.example.org.
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("example.org. IN NS ns.example.org.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedZones      []string
+		expectedAnchors    []string
+		expectedNegative   []string
+		expectedCapacity   int
+		expectedErrContent string
+	}{
+		{`validate`, false, nil, []string{"."}, nil, defaultCap, ""},
+		{`validate example.org`, false, []string{"example.org."}, []string{"."}, nil, defaultCap, ""},
+		{
+			`validate {
+				trust_anchor ` + anchor + `
+				negative_trust_anchor test. Example.NET
+				cache_capacity 100
+			}`, false, nil, []string{"example.org."}, []string{"test.", "example.net."}, 100, "",
+		},
+		// fails
+		{`validate {
+			trust_anchor
+		}`, true, nil, nil, nil, 0, "Wrong argument count"},
+		{`validate {
+			trust_anchor ` + empty + `
+		}`, true, nil, nil, nil, 0, "no DS or DNSKEY records"},
+		{`validate {
+			trust_anchor /does/not/exist
+		}`, true, nil, nil, nil, 0, "no such file"},
+		{`validate {
+			negative_trust_anchor a..b
+		}`, true, nil, nil, nil, 0, "invalid domain name"},
+		{`validate {
+			cache_capacity 0
+		}`, true, nil, nil, nil, 0, "cache_capacity"},
+		{`validate {
+			blah
+		}`, true, nil, nil, nil, 0, "unknown property"},
+		{`validate
+		validate`, true, nil, nil, nil, 0, "plugin"},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		v, err := validateParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			if !strings.Contains(err.Error(), test.expectedErrContent) {
+				t.Errorf("Test %d: Expected error to contain: %v, found error: %v, input: %s", i, test.expectedErrContent, err, test.input)
+			}
+			continue
+		}
+
+		for j, z := range test.expectedZones {
+			if v.Zones[j] != z {
+				t.Errorf("Test %d: Expected zone %s, got %s", i, z, v.Zones[j])
+			}
+		}
+		if len(v.anchors) != len(test.expectedAnchors) {
+			t.Errorf("Test %d: Expected %d trust anchors, got %d", i, len(test.expectedAnchors), len(v.anchors))
+		}
+		for _, a := range test.expectedAnchors {
+			if _, ok := v.anchors[a]; !ok {
+				t.Errorf("Test %d: Expected trust anchor for %s", i, a)
+			}
+		}
+		if len(v.negative) != len(test.expectedNegative) {
+			t.Errorf("Test %d: Expected %d negative trust anchors, got %d", i, len(test.expectedNegative), len(v.negative))
+		}
+		for j, n := range test.expectedNegative {
+			if v.negative[j] != n {
+				t.Errorf("Test %d: Expected negative trust anchor %s, got %s", i, n, v.negative[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Package validate implements a plugin that validates DNSSEC signed answers.
+package validate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metrics"
+	"github.com/coredns/coredns/plugin/pkg/cache"
+	"github.com/coredns/coredns/plugin/pkg/nonwriter"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// Validate validates the answers of the next plugin, see RFC 4035, Section 5.
+type Validate struct {
+	Next  plugin.Handler
+	Zones []string
+
+	anchors  map[string][]dns.RR // trust anchors, DS or DNSKEY records, per zone
+	negative []string            // negative trust anchors, see RFC 7646
+	cache    *cache.Cache        // validated keys and delegations
+}
+
+// New returns a new Validate that uses the root zone's key signing keys as the trust anchor.
+func New(zones []string, capacity int) *Validate {
+	v := &Validate{Zones: zones, anchors: make(map[string][]dns.RR), cache: cache.New(capacity)}
+	for _, s := range rootAnchors {
+		rr, _ := dns.NewRR(s)
+		v.anchors["."] = append(v.anchors["."], rr)
+	}
+	return v
+}
+
+// Name implements the plugin.Handler interface.
+func (v *Validate) Name() string { return "validate" }
+
+// ServeDNS implements the plugin.Handler interface.
+func (v *Validate) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+	// With the CD bit the client does its own validation, see RFC 4035, Section 3.2.2.
+	if r.CheckingDisabled || plugin.Zones(v.Zones).Matches(state.Name()) == "" {
+		return plugin.NextOrFailure(v.Name(), v.Next, ctx, w, r)
+	}
+
+	// Ask for the signatures, and set the CD bit so an upstream validator leaves the validation to us.
+	req := r.Copy()
+	req.CheckingDisabled = true
+	if o := req.IsEdns0(); o != nil {
+		o.SetDo()
+	} else {
+		req.SetEdns0(4096, true)
+	}
+
+	nw := nonwriter.New(w)
+	rcode, err := plugin.NextOrFailure(v.Name(), v.Next, ctx, nw, req)
+	if nw.Msg == nil {
+		return rcode, err
+	}
+	resp := nw.Msg
+
+	res := v.validate(ctx, state, resp)
+	results.WithLabelValues(metrics.WithServer(ctx), res.status.String()).Inc()
+	if res.status == bogus {
+		log.Debugf("Answer for %s %s is bogus: %s", state.Name(), state.Type(), res.reason)
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeServerFailure)
+		if o := r.IsEdns0(); o != nil {
+			m.SetEdns0(o.UDPSize(), o.Do())
+			m.IsEdns0().Option = append(m.IsEdns0().Option, &dns.EDNS0_EDE{InfoCode: res.ede, ExtraText: res.reason})
+		}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	}
+
+	do := state.Do()
+	resp.CheckingDisabled = false
+	// Only set the AD bit for clients that show they understand it, see RFC 6840, Section 5.7.
+	resp.AuthenticatedData = res.status == secure && (do || r.AuthenticatedData)
+	if !do {
+		qtype := state.QType()
+		resp.Answer = filter(resp.Answer, qtype)
+		resp.Ns = filter(resp.Ns, qtype)
+		resp.Extra = filter(resp.Extra, qtype)
+	}
+	setOPT(resp, r)
+
+	w.WriteMsg(resp)
+	return rcode, err
+}
+
+// validate validates resp, the answer for the question in state.
+func (v *Validate) validate(ctx context.Context, state request.Request, resp *dns.Msg) result {
+	if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+		return result{status: insecure}
+	}
+	if v.negativeAnchor(state.Name()) {
+		return result{status: insecure}
+	}
+	now := time.Now().UTC()
+
+	res := result{status: secure}
+	var wildcards []*dns.RRSIG
+	sigs := signatures(resp.Answer)
+	for _, rrset := range rrsets(resp.Answer) {
+		r, sig := v.verifyRRset(ctx, state, rrset, sigs, now)
+		if r.status == bogus {
+			return r
+		}
+		res = res.and(r)
+		if sig != nil && wildcard(sig, rrset[0].Header().Name) {
+			wildcards = append(wildcards, sig)
+		}
+	}
+
+	name, negative := target(state.Name(), state.QType(), resp)
+	if !negative && len(wildcards) == 0 {
+		return res
+	}
+
+	// The authority section must hold the signed proof the name or type doesn't exist, or that no closer
+	// name exists for an answer synthesized from a wildcard.
+	sigs = signatures(resp.Ns)
+	var denial []dns.RR
+	for _, rrset := range rrsets(resp.Ns) {
+		switch rrset[0].Header().Rrtype {
+		case dns.TypeSOA, dns.TypeNSEC, dns.TypeNSEC3:
+		default:
+			continue
+		}
+		r, _ := v.verifyRRset(ctx, state, rrset, sigs, now)
+		if r.status == bogus {
+			return r
+		}
+		res = res.and(r)
+		denial = append(denial, rrset...)
+	}
+	if negative && len(sigs) == 0 {
+		// Without signatures the name must be in an insecure zone.
+		return res.and(v.unsigned(ctx, state, name))
+	}
+	if res.status != secure {
+		return res
+	}
+
+	if negative && !denies(name, state.QType(), resp.Rcode == dns.RcodeNameError, denial) {
+		return bogusf(dns.ExtendedErrorCodeNSECMissing, "no proof of non-existence for %s %s", name, state.Type())
+	}
+	for _, sig := range wildcards {
+		if !expanded(sig.Header().Name, int(sig.Labels), denial) {
+			return bogusf(dns.ExtendedErrorCodeNSECMissing, "no proof of non-existence for wildcard expansion %s", sig.Header().Name)
+		}
+	}
+	return res
+}
+
+// verifyRRset verifies rrset with the signatures in sigs. It returns the signature that verified the rrset,
+// if any.
+func (v *Validate) verifyRRset(ctx context.Context, state request.Request, rrset []dns.RR, sigs []*dns.RRSIG, now time.Time) (result, *dns.RRSIG) {
+	hdr := rrset[0].Header()
+	var covering []*dns.RRSIG
+	for _, s := range sigs {
+		if s.TypeCovered == hdr.Rrtype && strings.EqualFold(s.Hdr.Name, hdr.Name) {
+			covering = append(covering, s)
+		}
+	}
+	if len(covering) == 0 {
+		return v.unsigned(ctx, state, hdr.Name), nil
+	}
+
+	res := bogusf(dns.ExtendedErrorCodeDNSBogus, "no valid signature for %s %s", hdr.Name, dns.TypeToString[hdr.Rrtype])
+	for _, sig := range covering {
+		if !dns.IsSubDomain(sig.SignerName, hdr.Name) {
+			continue
+		}
+		zk := v.keys(ctx, state, sig.SignerName)
+		if zk.status != secure {
+			return zk.result, nil
+		}
+		if res = verify(sig, zk.keys, rrset, now); res.status == secure {
+			return res, sig
+		}
+	}
+	return res, nil
+}
+
+// verify verifies rrset with sig and one of keys.
+func verify(sig *dns.RRSIG, keys []*dns.DNSKEY, rrset []dns.RR, now time.Time) result {
+	if !sig.ValidityPeriod(now) {
+		if int64(sig.Inception) > now.Unix() {
+			return bogusf(dns.ExtendedErrorCodeSignatureNotYetValid, "signature for %s %s is not yet valid", sig.Hdr.Name, dns.TypeToString[sig.TypeCovered])
+		}
+		return bogusf(dns.ExtendedErrorCodeSignatureExpired, "signature for %s %s has expired", sig.Hdr.Name, dns.TypeToString[sig.TypeCovered])
+	}
+	for _, k := range keys {
+		if k.Algorithm != sig.Algorithm || k.KeyTag() != sig.KeyTag {
+			continue
+		}
+		if err := sig.Verify(k, rrset); err == nil {
+			return result{status: secure}
+		}
+	}
+	return bogusf(dns.ExtendedErrorCodeDNSBogus, "signature for %s %s with key tag %d failed to verify", sig.Hdr.Name, dns.TypeToString[sig.TypeCovered], sig.KeyTag)
+}
+
+// lookup looks up name and qtype with the next plugin, with the DO and CD bits set.
+func (v *Validate) lookup(ctx context.Context, state request.Request, name string, qtype uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	m.SetEdns0(4096, true)
+	m.CheckingDisabled = true
+
+	nw := nonwriter.New(state.W)
+	if _, err := plugin.NextOrFailure(v.Name(), v.Next, ctx, nw, m); err != nil {
+		return nil, err
+	}
+	if nw.Msg == nil {
+		return nil, fmt.Errorf("no answer for %s %s", name, dns.TypeToString[qtype])
+	}
+	if nw.Msg.Rcode != dns.RcodeSuccess && nw.Msg.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("answer for %s %s has rcode %s", name, dns.TypeToString[qtype], dns.RcodeToString[nw.Msg.Rcode])
+	}
+	return nw.Msg, nil
+}
+
+// negativeAnchor returns true if name is at or below a negative trust anchor.
+func (v *Validate) negativeAnchor(name string) bool {
+	for _, n := range v.negative {
+		if dns.IsSubDomain(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// anchor returns the closest trust anchor of name, or the empty string if there is none.
+func (v *Validate) anchor(name string) string {
+	anchor := ""
+	for a := range v.anchors {
+		if dns.IsSubDomain(a, name) && len(a) > len(anchor) {
+			anchor = a
+		}
+	}
+	return anchor
+}
@@ -0,0 +1,270 @@
+package validate
+
+import (
+	"context"
+	"crypto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+// signedZone is a zone with a single key that signs everything.
+type signedZone struct {
+	key  *dns.DNSKEY
+	priv crypto.Signer
+}
+
+func newSignedZone(t *testing.T, origin string) *signedZone {
+	k := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: origin, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     257,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	priv, err := k.Generate(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &signedZone{key: k, priv: priv.(crypto.Signer)}
+}
+
+// sign returns rrs with their signature, valid from inception to expiration relative to now.
+func (z *signedZone) sign(t *testing.T, inception, expiration time.Duration, rrs ...dns.RR) []dns.RR {
+	now := time.Now().UTC()
+	sig := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Name: rrs[0].Header().Name, Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: rrs[0].Header().Ttl},
+		Algorithm:  z.key.Algorithm,
+		KeyTag:     z.key.KeyTag(),
+		SignerName: z.key.Hdr.Name,
+		Inception:  uint32(now.Add(inception).Unix()),
+		Expiration: uint32(now.Add(expiration).Unix()),
+	}
+	if err := sig.Sign(z.priv, rrs); err != nil {
+		t.Fatal(err)
+	}
+	return append(rrs, sig)
+}
+
+func (z *signedZone) signed(t *testing.T, rrs ...dns.RR) []dns.RR {
+	return z.sign(t, -time.Hour, 24*time.Hour, rrs...)
+}
+
+type question struct {
+	name  string
+	qtype uint16
+}
+
+// upstream answers from a map of responses, like a forwarder that doesn't validate.
+type upstream map[question]*dns.Msg
+
+func (u upstream) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	m, ok := u[question{strings.ToLower(r.Question[0].Name), r.Question[0].Qtype}]
+	if !ok {
+		m = &dns.Msg{MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure}}
+	}
+	rcode := m.Rcode
+	m = m.Copy()
+	m.SetReply(r)
+	m.Rcode = rcode
+	if o := r.IsEdns0(); o != nil {
+		m.SetEdns0(o.UDPSize(), o.Do())
+	}
+	w.WriteMsg(m)
+	return m.Rcode, nil
+}
+
+func (u upstream) Name() string { return "upstream" }
+
+func rr(s string) dns.RR {
+	r, _ := dns.NewRR(s)
+	return r
+}
+
+func concat(rrs ...[]dns.RR) []dns.RR {
+	var all []dns.RR
+	for _, r := range rrs {
+		all = append(all, r...)
+	}
+	return all
+}
+
+// newTestValidate returns a Validate with a root, org. and example.org. zone, all signed, and insecure.org. that
+// isn't.
+func newTestValidate(t *testing.T) *Validate {
+	root := newSignedZone(t, ".")
+	org := newSignedZone(t, "org.")
+	example := newSignedZone(t, "example.org.")
+
+	soa := rr("example.org. 3600 IN SOA ns.example.org. hostmaster.example.org. 1 7200 3600 1209600 3600")
+	orgSOA := rr("org. 3600 IN SOA ns.org. hostmaster.org. 1 7200 3600 1209600 3600")
+
+	u := upstream{
+		{".", dns.TypeDNSKEY}:            {Answer: root.signed(t, root.key)},
+		{"org.", dns.TypeDS}:             {Answer: root.signed(t, org.key.ToDS(dns.SHA256))},
+		{"org.", dns.TypeDNSKEY}:         {Answer: org.signed(t, org.key)},
+		{"example.org.", dns.TypeDS}:     {Answer: org.signed(t, example.key.ToDS(dns.SHA256))},
+		{"example.org.", dns.TypeDNSKEY}: {Answer: example.signed(t, example.key)},
+		{"insecure.org.", dns.TypeDS}: {Ns: concat(
+			org.signed(t, orgSOA),
+			org.signed(t, rr("insecure.org. 3600 IN NSEC zzz.org. NS RRSIG NSEC")),
+		)},
+		{"plain.example.org.", dns.TypeDS}: {Ns: concat(
+			example.signed(t, soa),
+			example.signed(t, rr("plain.example.org. 3600 IN NSEC www.example.org. A RRSIG NSEC")),
+		)},
+
+		{"www.example.org.", dns.TypeA}: {Answer: example.signed(t, rr("www.example.org. 3600 IN A 127.0.0.1"))},
+		{"www.example.org.", dns.TypeAAAA}: {Ns: concat(
+			example.signed(t, soa),
+			example.signed(t, rr("www.example.org. 3600 IN NSEC example.org. A RRSIG NSEC")),
+		)},
+		{"nx.example.org.", dns.TypeA}: {MsgHdr: dns.MsgHdr{Rcode: dns.RcodeNameError}, Ns: concat(
+			example.signed(t, soa),
+			example.signed(t, rr("example.org. 3600 IN NSEC plain.example.org. SOA NS RRSIG NSEC DNSKEY")),
+		)},
+		{"gone.example.org.", dns.TypeA}: {MsgHdr: dns.MsgHdr{Rcode: dns.RcodeNameError}, Ns: example.signed(t, soa)},
+		{"bad.example.org.", dns.TypeA}: {Answer: func() []dns.RR {
+			rrs := example.signed(t, rr("bad.example.org. 3600 IN A 127.0.0.1"))
+			rrs[0].(*dns.A).A[3] = 2
+			return rrs
+		}()},
+		{"old.example.org.", dns.TypeA}:    {Answer: example.sign(t, -48*time.Hour, -24*time.Hour, rr("old.example.org. 3600 IN A 127.0.0.1"))},
+		{"plain.example.org.", dns.TypeA}:  {Answer: []dns.RR{rr("plain.example.org. 3600 IN A 127.0.0.1")}},
+		{"a.insecure.org.", dns.TypeA}:     {Answer: []dns.RR{rr("a.insecure.org. 3600 IN A 127.0.0.1")}},
+		{"nx.insecure.org.", dns.TypeA}:    {MsgHdr: dns.MsgHdr{Rcode: dns.RcodeNameError}, Ns: []dns.RR{rr("insecure.org. 3600 IN SOA ns.insecure.org. hostmaster.insecure.org. 1 7200 3600 1209600 3600")}},
+		{"broken.example.org.", dns.TypeA}: {MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure}},
+	}
+
+	v := New([]string{"."}, defaultCap)
+	v.anchors = map[string][]dns.RR{".": {root.key.ToDS(dns.SHA256)}}
+	v.Next = u
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		qname  string
+		qtype  uint16
+		do     bool
+		cd     bool
+		rcode  int
+		ad     bool
+		ede    int // -1 for no extended error
+		answer int
+		ns     int
+	}{
+		{qname: "www.example.org.", qtype: dns.TypeA, do: true, rcode: dns.RcodeSuccess, ad: true, ede: -1, answer: 2},
+		// Without DO the signatures are removed, and the AD bit isn't set.
+		{qname: "www.example.org.", qtype: dns.TypeA, rcode: dns.RcodeSuccess, ede: -1, answer: 1},
+		{qname: "www.example.org.", qtype: dns.TypeAAAA, do: true, rcode: dns.RcodeSuccess, ad: true, ede: -1, ns: 4},
+		{qname: "nx.example.org.", qtype: dns.TypeA, do: true, rcode: dns.RcodeNameError, ad: true, ede: -1, ns: 4},
+		{qname: "gone.example.org.", qtype: dns.TypeA, do: true, rcode: dns.RcodeServerFailure, ede: int(dns.ExtendedErrorCodeNSECMissing)},
+		{qname: "bad.example.org.", qtype: dns.TypeA, do: true, rcode: dns.RcodeServerFailure, ede: int(dns.ExtendedErrorCodeDNSBogus)},
+		{qname: "old.example.org.", qtype: dns.TypeA, do: true, rcode: dns.RcodeServerFailure, ede: int(dns.ExtendedErrorCodeSignatureExpired)},
+		{qname: "plain.example.org.", qtype: dns.TypeA, do: true, rcode: dns.RcodeServerFailure, ede: int(dns.ExtendedErrorCodeRRSIGsMissing)},
+		{qname: "a.insecure.org.", qtype: dns.TypeA, do: true, rcode: dns.RcodeSuccess, ede: -1, answer: 1},
+		{qname: "nx.insecure.org.", qtype: dns.TypeA, do: true, rcode: dns.RcodeNameError, ede: -1, ns: 1},
+		// With CD the client validates, the bogus answer is returned as is.
+		{qname: "bad.example.org.", qtype: dns.TypeA, do: true, cd: true, rcode: dns.RcodeSuccess, ede: -1, answer: 2},
+		// Errors are returned as is.
+		{qname: "broken.example.org.", qtype: dns.TypeA, do: true, rcode: dns.RcodeServerFailure, ede: -1},
+	}
+
+	v := newTestValidate(t)
+	ctx := context.TODO()
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion(tc.qname, tc.qtype)
+		m.SetEdns0(4096, tc.do)
+		m.CheckingDisabled = tc.cd
+
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := v.ServeDNS(ctx, rec, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %s", i, err)
+		}
+		resp := rec.Msg
+		if resp.Rcode != tc.rcode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.rcode], dns.RcodeToString[resp.Rcode])
+		}
+		if resp.AuthenticatedData != tc.ad {
+			t.Errorf("Test %d: expected AD bit %t, got %t", i, tc.ad, resp.AuthenticatedData)
+		}
+		if len(resp.Answer) != tc.answer {
+			t.Errorf("Test %d: expected %d records in the answer section, got %d", i, tc.answer, len(resp.Answer))
+		}
+		if len(resp.Ns) != tc.ns {
+			t.Errorf("Test %d: expected %d records in the authority section, got %d", i, tc.ns, len(resp.Ns))
+		}
+
+		ede := -1
+		if o := resp.IsEdns0(); o != nil {
+			if o.Do() != tc.do {
+				t.Errorf("Test %d: expected DO bit %t, got %t", i, tc.do, o.Do())
+			}
+			for _, opt := range o.Option {
+				if e, ok := opt.(*dns.EDNS0_EDE); ok {
+					ede = int(e.InfoCode)
+				}
+			}
+		}
+		if ede != tc.ede {
+			t.Errorf("Test %d: expected extended error %d, got %d", i, tc.ede, ede)
+		}
+	}
+}
+
+func TestValidateNegativeTrustAnchor(t *testing.T) {
+	v := newTestValidate(t)
+	v.negative = []string{"example.org."}
+
+	m := new(dns.Msg)
+	m.SetQuestion("bad.example.org.", dns.TypeA)
+	m.SetEdns0(4096, true)
+
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	v.ServeDNS(context.TODO(), rec, m)
+	if rec.Msg.Rcode != dns.RcodeSuccess || rec.Msg.AuthenticatedData {
+		t.Errorf("Expected insecure answer below the negative trust anchor, got rcode %s and AD bit %t", dns.RcodeToString[rec.Msg.Rcode], rec.Msg.AuthenticatedData)
+	}
+}
+
+func TestValidateNoOPT(t *testing.T) {
+	v := newTestValidate(t)
+
+	m := new(dns.Msg)
+	m.SetQuestion("bad.example.org.", dns.TypeA)
+
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	v.ServeDNS(context.TODO(), rec, m)
+	if rec.Msg.Rcode != dns.RcodeServerFailure {
+		t.Errorf("Expected rcode %s, got %s", dns.RcodeToString[dns.RcodeServerFailure], dns.RcodeToString[rec.Msg.Rcode])
+	}
+	if rec.Msg.IsEdns0() != nil {
+		t.Errorf("Expected no OPT record for a request without one")
+	}
+
+	m.SetQuestion("www.example.org.", dns.TypeA)
+	rec = dnstest.NewRecorder(&test.ResponseWriter{})
+	v.ServeDNS(context.TODO(), rec, m)
+	if rec.Msg.IsEdns0() != nil {
+		t.Errorf("Expected no OPT record for a request without one")
+	}
+	if len(rec.Msg.Answer) != 1 {
+		t.Errorf("Expected 1 record in the answer section, got %d", len(rec.Msg.Answer))
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Canonical order from RFC 4034, Section 6.1.
+	names := []string{"example.", "a.example.", "yljkjljk.a.example.", "Z.a.example.", "zABC.a.EXAMPLE.", "z.example.", "\\001.z.example.", "*.z.example.", "\\200.z.example."}
+	for i := 0; i < len(names)-1; i++ {
+		if compare(names[i], names[i+1]) >= 0 {
+			t.Errorf("Expected %s to sort before %s", names[i], names[i+1])
+		}
+	}
+}
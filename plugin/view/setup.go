@@ -0,0 +1,90 @@
+package view
+
+import (
+	"strings"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/plugin/pkg/cidr"
+
+	"github.com/miekg/dns"
+)
+
+func init() { plugin.Register("view", setup) }
+
+func setup(c *caddy.Controller) error {
+	v, err := parse(c)
+	if err != nil {
+		return plugin.Error("view", err)
+	}
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		v.Next = next
+		return v
+	})
+
+	return nil
+}
+
+func parse(c *caddy.Controller) (*View, error) {
+	v := &View{}
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, plugin.ErrOnce
+		}
+		i++
+
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return nil, c.ArgErr()
+		}
+		v.name = args[0]
+
+		for c.NextBlock() {
+			cond := condition{kind: c.Val()}
+			args := c.RemainingArgs()
+			switch cond.kind {
+			case "client", "ecs":
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				nets, err := cidr.ParseNetworks(args)
+				if err != nil {
+					return nil, c.Err(err.Error())
+				}
+				cond.nets = nets
+			case "type":
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				for _, a := range args {
+					qtype, ok := dns.StringToType[strings.ToUpper(a)]
+					if !ok {
+						return nil, c.Errf("unknown query type %q", a)
+					}
+					cond.qtypes = append(cond.qtypes, qtype)
+				}
+			case "metadata":
+				if len(args) < 2 {
+					return nil, c.ArgErr()
+				}
+				if !metadata.IsLabel(args[0]) {
+					return nil, c.Errf("invalid metadata label %q", args[0])
+				}
+				cond.label = args[0]
+				cond.values = args[1:]
+			default:
+				return nil, c.Errf("unknown property '%s'", cond.kind)
+			}
+			v.conditions = append(v.conditions, cond)
+		}
+	}
+	if len(v.conditions) == 0 {
+		return nil, c.Err("a view needs at least one condition")
+	}
+	return v, nil
+}
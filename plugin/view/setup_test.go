@@ -0,0 +1,81 @@
+package view
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coredns/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedName       string
+		expectedConditions int
+		expectedErrContent string
+	}{
+		{`view internal {
+			client 10.0.0.0/8 192.168.0.1 fd00::/8
+		}`, false, "internal", 1, ""},
+		{`view ecs {
+			ecs 192.0.2.0/24
+			type A AAAA
+			metadata geoip/country/code NL BE
+		}`, false, "ecs", 3, ""},
+		// fails
+		{`view`, true, "", 0, "Wrong argument count"},
+		{`view a b {
+			type A
+		}`, true, "", 0, "Wrong argument count"},
+		{`view internal`, true, "", 0, "at least one condition"},
+		{`view internal {
+			client
+		}`, true, "", 0, "Wrong argument count"},
+		{`view internal {
+			client 10.0.0.0/33
+		}`, true, "", 0, "illegal CIDR"},
+		{`view internal {
+			type BLAH
+		}`, true, "", 0, "unknown query type"},
+		{`view internal {
+			metadata geoip
+		}`, true, "", 0, "Wrong argument count"},
+		{`view internal {
+			metadata geoip NL
+		}`, true, "", 0, "invalid metadata label"},
+		{`view internal {
+			blah
+		}`, true, "", 0, "unknown property"},
+		{`view a {
+			type A
+		}
+		view b {
+			type A
+		}`, true, "", 0, "plugin"},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		v, err := parse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			if !strings.Contains(err.Error(), test.expectedErrContent) {
+				t.Errorf("Test %d: Expected error to contain: %v, found error: %v, input: %s", i, test.expectedErrContent, err, test.input)
+			}
+			continue
+		}
+		if v.ViewName() != test.expectedName {
+			t.Errorf("Test %d: Expected view name %s, got %s", i, test.expectedName, v.ViewName())
+		}
+		if len(v.conditions) != test.expectedConditions {
+			t.Errorf("Test %d: Expected %d conditions, got %d", i, test.expectedConditions, len(v.conditions))
+		}
+	}
+}
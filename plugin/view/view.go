@@ -0,0 +1,113 @@
+// Package view implements a plugin that selects the server block that handles a request, to serve
+// different views of a zone.
+package view
+
+import (
+	"context"
+	"net"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// View is a named set of conditions, a request is in the view when it meets all of them.
+type View struct {
+	Next plugin.Handler
+
+	name       string
+	conditions []condition
+}
+
+// condition is met when a request matches one of its values.
+type condition struct {
+	kind   string       // client, ecs, type or metadata
+	nets   []*net.IPNet // for client and ecs
+	qtypes []uint16     // for type
+	label  string       // for metadata
+	values []string     // for metadata
+}
+
+// Filter implements the dnsserver.Viewer interface.
+func (v *View) Filter(ctx context.Context, state *request.Request) bool {
+	for _, c := range v.conditions {
+		if !c.match(ctx, state) {
+			return false
+		}
+	}
+	return true
+}
+
+// ViewName implements the dnsserver.Viewer interface.
+func (v *View) ViewName() string { return v.name }
+
+// Name implements the plugin.Handler interface.
+func (v *View) Name() string { return "view" }
+
+// ServeDNS implements the plugin.Handler interface. The view has already been selected by the server, the
+// request is passed on.
+func (v *View) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	return plugin.NextOrFailure(v.Name(), v.Next, ctx, w, r)
+}
+
+// Metadata implements the metadata.Provider interface.
+func (v *View) Metadata(ctx context.Context, state request.Request) context.Context {
+	metadata.SetValueFunc(ctx, "view/name", func() string { return v.name })
+	return ctx
+}
+
+func (c condition) match(ctx context.Context, state *request.Request) bool {
+	switch c.kind {
+	case "client":
+		return contains(c.nets, net.ParseIP(state.IP()))
+	case "ecs":
+		return contains(c.nets, ecs(state.Req))
+	case "type":
+		qtype := state.QType()
+		for _, t := range c.qtypes {
+			if t == qtype {
+				return true
+			}
+		}
+	case "metadata":
+		f := metadata.ValueFunc(ctx, c.label)
+		if f == nil {
+			return false
+		}
+		value := f()
+		for _, v := range c.values {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ecs returns the address of the EDNS0 Client Subnet option (RFC 7871) in r, or nil if there is none.
+func ecs(r *dns.Msg) net.IP {
+	o := r.IsEdns0()
+	if o == nil {
+		return nil
+	}
+	for _, opt := range o.Option {
+		if e, ok := opt.(*dns.EDNS0_SUBNET); ok {
+			return e.Address
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package view
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/coredns/caddy"
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		input    string
+		qtype    uint16
+		ecs      net.IP
+		country  string
+		expected bool
+	}{
+		// test.ResponseWriter has client address 10.240.0.1.
+		{`view v {
+			client 10.0.0.0/8
+		}`, dns.TypeA, nil, "", true},
+		{`view v {
+			client 192.168.0.0/16 10.240.0.1
+		}`, dns.TypeA, nil, "", true},
+		{`view v {
+			client 192.168.0.0/16
+		}`, dns.TypeA, nil, "", false},
+		{`view v {
+			ecs 192.0.2.0/24
+		}`, dns.TypeA, net.ParseIP("192.0.2.1").To4(), "", true},
+		{`view v {
+			ecs 192.0.2.0/24
+		}`, dns.TypeA, net.ParseIP("198.51.100.1").To4(), "", false},
+		{`view v {
+			ecs 192.0.2.0/24
+		}`, dns.TypeA, nil, "", false},
+		{`view v {
+			type AAAA A
+		}`, dns.TypeA, nil, "", true},
+		{`view v {
+			type AAAA
+		}`, dns.TypeA, nil, "", false},
+		{`view v {
+			metadata test/country NL BE
+		}`, dns.TypeA, nil, "BE", true},
+		{`view v {
+			metadata test/country NL
+		}`, dns.TypeA, nil, "BE", false},
+		{`view v {
+			metadata test/country NL
+		}`, dns.TypeA, nil, "", false},
+		// All conditions must be met.
+		{`view v {
+			client 10.0.0.0/8
+			type AAAA
+		}`, dns.TypeA, nil, "", false},
+		{`view v {
+			client 10.0.0.0/8
+			type A
+		}`, dns.TypeA, nil, "", true},
+	}
+
+	for i, tc := range tests {
+		v, err := parse(caddy.NewTestController("dns", tc.input))
+		if err != nil {
+			t.Fatalf("Test %d: %s", i, err)
+		}
+
+		m := new(dns.Msg)
+		m.SetQuestion("example.org.", tc.qtype)
+		if tc.ecs != nil {
+			m.SetEdns0(4096, false)
+			m.IsEdns0().Option = append(m.IsEdns0().Option, &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: 24, Address: tc.ecs})
+		}
+		ctx := context.TODO()
+		if tc.country != "" {
+			ctx = metadata.ContextWithMetadata(ctx)
+			metadata.SetValueFunc(ctx, "test/country", func() string { return tc.country })
+		}
+
+		state := &request.Request{W: &test.ResponseWriter{}, Req: m}
+		if got := v.Filter(ctx, state); got != tc.expected {
+			t.Errorf("Test %d: Expected filter to return %t, got %t", i, tc.expected, got)
+		}
+	}
+}
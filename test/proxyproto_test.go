@@ -0,0 +1,82 @@
+package test
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestProxyProtocol(t *testing.T) {
+	corefile := `example.org:0 {
+		proxyproto {
+			allow 127.0.0.1 ::1
+		}
+		whoami
+	}`
+
+	i, udp, tcp, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	m := new(dns.Msg)
+	m.SetQuestion("example.org.", dns.TypeA)
+	query, _ := m.Pack()
+
+	// UDP with a version 2 header.
+	header := []byte("\r\n\r\n\x00\r\nQUIT\n\x21\x12\x00\x0c")
+	header = append(header, net.ParseIP("192.0.2.1").To4()...)
+	header = append(header, net.ParseIP("198.51.100.1").To4()...)
+	header = append(header, 0xdc, 0x04, 0x00, 0x35) // ports 56324 and 53
+
+	c, err := net.Dial("udp", udp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Write(append(header, query...))
+	buf := make([]byte, dns.MaxMsgSize)
+	n, err := c.Read(buf)
+	if err != nil {
+		t.Fatalf("Expected to receive reply, but didn't: %s", err)
+	}
+	resp := new(dns.Msg)
+	if err := resp.Unpack(buf[:n]); err != nil {
+		t.Fatal(err)
+	}
+	checkWhoami(t, resp, "192.0.2.1", "udp")
+
+	// TCP with a version 1 header.
+	tc, err := net.Dial("tcp", tcp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tc.Close()
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(query)))
+	tc.Write([]byte("PROXY TCP4 192.0.2.2 198.51.100.1 56324 53\r\n"))
+	tc.Write(append(length, query...))
+	conn := &dns.Conn{Conn: tc}
+	resp, err = conn.ReadMsg()
+	if err != nil {
+		t.Fatalf("Expected to receive reply, but didn't: %s", err)
+	}
+	checkWhoami(t, resp, "192.0.2.2", "tcp")
+}
+
+func checkWhoami(t *testing.T, resp *dns.Msg, ip, proto string) {
+	t.Helper()
+	if len(resp.Extra) != 2 {
+		t.Fatalf("Expected 2 RRs in the additional section, got %d", len(resp.Extra))
+	}
+	a, ok := resp.Extra[0].(*dns.A)
+	if !ok || a.A.String() != ip {
+		t.Errorf("Expected client address %s, got %s", ip, resp.Extra[0])
+	}
+	if srv, ok := resp.Extra[1].(*dns.SRV); !ok || srv.Hdr.Name != "_"+proto+".example.org." {
+		t.Errorf("Expected SRV record for %s, got %s", proto, resp.Extra[1])
+	}
+}
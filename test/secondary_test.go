@@ -1,6 +1,9 @@
 package test
 
 import (
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -216,3 +219,81 @@ www    IN AAAA ::1
 	}
 
 }
+
+func TestSecondaryCatalogZone(t *testing.T) {
+	tmpdir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpdir, "db.example.org"), []byte(zoneContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	corefile := `.:0 {
+		auto {
+			directory ` + tmpdir + ` db\.(.*) {1}
+			reload 0.01s
+			catalog catalog.invalid
+		}
+		transfer {
+			to *
+		}
+	}`
+
+	i, _, tcp, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	// Notifies are only accepted from the address the zone is transferred from.
+	_, port, _ := net.SplitHostPort(tcp)
+	corefile = `.:0 {
+		secondary catalog.invalid {
+			transfer from 127.0.0.1:` + port + `
+			catalog
+		}
+	}`
+
+	i1, udp, _, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i1.Stop()
+	_, port, _ = net.SplitHostPort(udp)
+	udp = net.JoinHostPort("127.0.0.1", port)
+
+	m := new(dns.Msg)
+	m.SetQuestion("www.example.org.", dns.TypeA)
+	// The catalog zone and then example.org are transferred in the background.
+	var r *dns.Msg
+	for i := 0; i < 50; i++ {
+		r, _ = dns.Exchange(m, udp)
+		if r != nil && len(r.Answer) != 0 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if r == nil || len(r.Answer) == 0 {
+		t.Fatalf("Expected example.org to be transferred, got %v", r)
+	}
+
+	// Remove example.org from the primary, and notify the secondary of the change of the catalog zone.
+	if err := os.Remove(filepath.Join(tmpdir, "db.example.org")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond) // wait for it to be removed from the catalog zone
+	notify := new(dns.Msg)
+	notify.SetNotify("catalog.invalid.")
+	if _, err := dns.Exchange(notify, udp); err != nil {
+		t.Fatalf("Failed to send notify: %s", err)
+	}
+
+	for i := 0; i < 50; i++ {
+		r, _ = dns.Exchange(m, udp)
+		if r != nil && r.Rcode != dns.RcodeSuccess {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if r == nil || r.Rcode == dns.RcodeSuccess {
+		t.Fatalf("Expected example.org to be removed, got %v", r)
+	}
+}
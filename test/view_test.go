@@ -0,0 +1,94 @@
+package test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestView(t *testing.T) {
+	corefile := `example.org:0 {
+		view ecs {
+			ecs 192.0.2.0/24
+		}
+		hosts {
+			10.0.0.2 example.org
+		}
+	}
+	example.org:0 {
+		view internal {
+			client 127.0.0.0/8 ::1
+		}
+		hosts {
+			10.0.0.1 example.org
+		}
+	}
+	example.org:0 {
+		hosts {
+			10.0.0.3 example.org
+		}
+	}`
+
+	i, udp, _, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	tests := []struct {
+		ecs      net.IP
+		expected string
+	}{
+		{nil, "10.0.0.1"},
+		{net.ParseIP("192.0.2.1").To4(), "10.0.0.2"},
+		{net.ParseIP("198.51.100.1").To4(), "10.0.0.1"},
+	}
+	for _, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion("example.org.", dns.TypeA)
+		if tc.ecs != nil {
+			m.SetEdns0(4096, false)
+			m.IsEdns0().Option = append(m.IsEdns0().Option, &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: 24, Address: tc.ecs})
+		}
+		resp, err := dns.Exchange(m, udp)
+		if err != nil {
+			t.Fatalf("Expected to receive reply, but didn't: %s", err)
+		}
+		if len(resp.Answer) != 1 {
+			t.Fatalf("Expected 1 RR in the answer section, got %d", len(resp.Answer))
+		}
+		if a := resp.Answer[0].(*dns.A).A.String(); a != tc.expected {
+			t.Errorf("Expected %s for client subnet %v, got %s", tc.expected, tc.ecs, a)
+		}
+	}
+}
+
+func TestViewOverlap(t *testing.T) {
+	// Without a view, two server blocks for the same zone and port are an error.
+	corefile := `example.org:0 {
+		whoami
+	}
+	example.org:0 {
+		whoami
+	}`
+
+	if _, err := CoreDNSServer(corefile); err == nil {
+		t.Fatal("Expected an error for overlapping server blocks without views")
+	}
+
+	// A view after a server block without one would never be used.
+	corefile = `example.org:0 {
+		whoami
+	}
+	example.org:0 {
+		view internal {
+			client 127.0.0.0/8
+		}
+		whoami
+	}`
+
+	if _, err := CoreDNSServer(corefile); err == nil {
+		t.Fatal("Expected an error for a view after a server block without a view")
+	}
+}